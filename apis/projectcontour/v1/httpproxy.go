@@ -61,9 +61,26 @@ type MatchCondition struct {
 	// +optional
 	Prefix string `json:"prefix,omitempty"`
 
+	// Exact defines an exact match for the request path. The path
+	// is appended to the prefix conditions of any parent includes.
+	// Exact conditions cannot be used on includes.
+	// +optional
+	Exact string `json:"exact,omitempty"`
+
+	// Regex defines a regular expression match for the request path.
+	// The regular expression must match the whole path, and is
+	// appended to the prefix conditions of any parent includes.
+	// Regex conditions cannot be used on includes.
+	// +optional
+	Regex string `json:"regex,omitempty"`
+
 	// Header specifies the header condition to match.
 	// +optional
 	Header *HeaderMatchCondition `json:"header,omitempty"`
+
+	// QueryParameter specifies the query parameter condition to match.
+	// +optional
+	QueryParameter *QueryParameterMatchCondition `json:"queryParameter,omitempty"`
 }
 
 // HeaderMatchCondition specifies how to conditionally match against HTTP
@@ -99,6 +116,56 @@ type HeaderMatchCondition struct {
 	// equal to. The condition is true if the header has any other value.
 	// +optional
 	NotExact string `json:"notexact,omitempty"`
+
+	// Regex specifies a regular expression that the whole header
+	// value must match.
+	// +optional
+	Regex string `json:"regex,omitempty"`
+
+	// NotRegex specifies a regular expression that the whole header
+	// value must not match. The condition is true if the header has
+	// any other value.
+	// +optional
+	NotRegex string `json:"notregex,omitempty"`
+
+	// In specifies a set of strings that the header value must be
+	// equal to one of.
+	// +optional
+	In []string `json:"in,omitempty"`
+
+	// IgnoreCase specifies that the Exact, NotExact and In conditions
+	// compare the header value case insensitively.
+	// +optional
+	IgnoreCase bool `json:"ignoreCase,omitempty"`
+}
+
+// QueryParameterMatchCondition specifies how to conditionally match against
+// HTTP query parameters. The Name field is required, but only one of the
+// remaining fields should be be provided.
+type QueryParameterMatchCondition struct {
+	// Name is the name of the query parameter to match against. Name
+	// is required. Query parameter names are case sensitive.
+	Name string `json:"name"`
+
+	// Present specifies that condition is true when the named query
+	// parameter is present, regardless of its value.
+	// +optional
+	Present bool `json:"present,omitempty"`
+
+	// Exact specifies a string that the query parameter value must be
+	// equal to.
+	// +optional
+	Exact string `json:"exact,omitempty"`
+
+	// Contains specifies a substring that must be present in the
+	// query parameter value.
+	// +optional
+	Contains string `json:"contains,omitempty"`
+
+	// Regex specifies a regular expression that the whole query
+	// parameter value must match.
+	// +optional
+	Regex string `json:"regex,omitempty"`
 }
 
 // ExtensionServiceReference names an ExtensionService resource.
@@ -210,8 +277,179 @@ type VirtualHost struct {
 	// Specifies the cross-origin policy to apply to the VirtualHost.
 	// +optional
 	CORSPolicy *CORSPolicy `json:"corsPolicy,omitempty"`
+	// The policy for global rate limiting of client requests
+	// to this virtual host.
+	// +optional
+	RateLimitPolicy *VirtualHostRateLimitPolicy `json:"rateLimitPolicy,omitempty"`
+	// The name of an additional listener, defined in the Contour
+	// configuration file, that this virtual host is attached to.
+	// A virtual host with TLS enabled must name an HTTPS listener,
+	// and one without TLS must name an HTTP listener. If not set,
+	// the virtual host is attached to the default listeners.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	Listener string `json:"listener,omitempty"`
+	// The policy for distributed tracing of client requests
+	// to this virtual host.
+	// +optional
+	TracingPolicy *TracingPolicy `json:"tracingPolicy,omitempty"`
+}
+
+// TracingPolicy overrides the distributed tracing configuration
+// of the Contour configuration file for a virtual host.
+type TracingPolicy struct {
+	// SamplingRate is the percentage of requests to this virtual
+	// host that are traced, from "0" to "100". Fractional values,
+	// such as "0.5", are allowed. If not specified, the sampling
+	// rate from the Contour configuration file is used.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(100(\.0*)?|\d{1,2}(\.\d*)?)$`
+	SamplingRate string `json:"samplingRate,omitempty"`
 }
 
+// VirtualHostRateLimitPolicy configures global rate limiting for a
+// virtual host. Client requests are described by a set of rate limit
+// descriptors that are sent to an extension service implementing the
+// [Envoy rate limit service](https://www.envoyproxy.io/docs/envoy/latest/api-v2/service/ratelimit/v2/rls.proto)
+// protocol, which decides whether the request should be rate limited.
+type VirtualHostRateLimitPolicy struct {
+	// ExtensionServiceRef specifies the extension resource that will
+	// make rate limit decisions for client requests.
+	//
+	// +required
+	ExtensionServiceRef ExtensionServiceReference `json:"extensionRef"`
+
+	// Domain is the rate limit domain that is sent to the rate
+	// limit service with each request. If not specified, the
+	// domain "contour" is used.
+	//
+	// +optional
+	Domain string `json:"domain,omitempty"`
+
+	// ResponseTimeout configures maximum time to wait for a response from the rate limit service.
+	// Timeout durations are expressed in the Go [Duration format](https://godoc.org/time#ParseDuration).
+	// Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h".
+	// The string "infinity" is also a valid input and specifies no timeout.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+|infinity|infinite)$`
+	ResponseTimeout string `json:"responseTimeout,omitempty"`
+
+	// If FailOpen is true, the client request is forwarded to the upstream
+	// service even if the rate limit service fails to respond. Otherwise,
+	// the client request fails with a 500 status.
+	//
+	// +optional
+	FailOpen bool `json:"failOpen,omitempty"`
+
+	// Descriptors defines the rate limit descriptors that are
+	// sent for every client request to the virtual host.
+	//
+	// +optional
+	Descriptors []RateLimitDescriptor `json:"descriptors,omitempty"`
+}
+
+// RateLimitPolicy configures the global rate limit descriptors
+// of a route.
+type RateLimitPolicy struct {
+	// Descriptors defines the rate limit descriptors that are
+	// sent for client requests that match the route, in addition
+	// to the descriptors of the virtual host.
+	//
+	// +optional
+	Descriptors []RateLimitDescriptor `json:"descriptors,omitempty"`
+}
+
+// RateLimitDescriptor defines a rate limit descriptor, which is
+// a list of entries that are built from the client request.
+// If any entry of a descriptor can't be built for a request,
+// the descriptor is not sent.
+type RateLimitDescriptor struct {
+	// Entries is the list of entries that make up the descriptor.
+	//
+	// +kubebuilder:validation:MinItems=1
+	Entries []RateLimitDescriptorEntry `json:"entries"`
+}
+
+// RateLimitDescriptorEntry is a rate limit descriptor entry.
+// Exactly one field must be set.
+type RateLimitDescriptorEntry struct {
+	// GenericKey defines a descriptor entry with a static value.
+	//
+	// +optional
+	GenericKey *GenericKeyDescriptor `json:"genericKey,omitempty"`
+
+	// RequestHeader defines a descriptor entry whose value is
+	// taken from a request header.
+	//
+	// +optional
+	RequestHeader *RequestHeaderDescriptor `json:"requestHeader,omitempty"`
+
+	// RequestHeaderValueMatch defines a descriptor entry that is
+	// set when the request headers match a set of conditions.
+	//
+	// +optional
+	RequestHeaderValueMatch *RequestHeaderValueMatchDescriptor `json:"requestHeaderValueMatch,omitempty"`
+
+	// RemoteAddress defines a descriptor entry whose value is
+	// the client IP address.
+	//
+	// +optional
+	RemoteAddress *RemoteAddressDescriptor `json:"remoteAddress,omitempty"`
+}
+
+// GenericKeyDescriptor defines a descriptor entry with a static
+// value. The descriptor key is always "generic_key".
+type GenericKeyDescriptor struct {
+	// Value defines the value of the descriptor entry.
+	//
+	// +kubebuilder:validation:MinLength=1
+	Value string `json:"value"`
+}
+
+// RequestHeaderDescriptor defines a descriptor entry whose value is
+// taken from a request header. If the header is not present, the
+// descriptor is not sent.
+type RequestHeaderDescriptor struct {
+	// HeaderName defines the name of the header to take the value from.
+	//
+	// +kubebuilder:validation:MinLength=1
+	HeaderName string `json:"headerName"`
+
+	// DescriptorKey defines the key of the descriptor entry.
+	//
+	// +kubebuilder:validation:MinLength=1
+	DescriptorKey string `json:"descriptorKey"`
+}
+
+// RequestHeaderValueMatchDescriptor defines a descriptor entry with
+// the key "header_match" and a static value that is only added when
+// the request headers match the given conditions.
+type RequestHeaderValueMatchDescriptor struct {
+	// Headers is a list of conditions that the request headers
+	// are matched against. All the conditions must be true for
+	// the headers to match.
+	//
+	// +kubebuilder:validation:MinItems=1
+	Headers []HeaderMatchCondition `json:"headers"`
+
+	// ExpectMatch defines whether the entry is added when the
+	// headers match (the default) or when they don't match.
+	//
+	// +optional
+	ExpectMatch *bool `json:"expectMatch,omitempty"`
+
+	// Value defines the value of the descriptor entry.
+	//
+	// +kubebuilder:validation:MinLength=1
+	Value string `json:"value"`
+}
+
+// RemoteAddressDescriptor defines a descriptor entry with the key
+// "remote_address" and a value equal to the client IP address.
+type RemoteAddressDescriptor struct{}
+
 // TLS describes tls properties. The SNI names that will be matched on
 // are described in the HTTPProxy's Spec.VirtualHost.Fqdn field.
 type TLS struct {
@@ -284,10 +522,11 @@ type Route struct {
 	// route invalid.
 	// +optional
 	Conditions []MatchCondition `json:"conditions,omitempty"`
-	// Services are the services to proxy traffic.
-	// +kubebuilder:validation:MinItems=1
-	// +kubebuilder:validation:Required
-	Services []Service `json:"services"`
+	// Services are the services to proxy traffic. Services must
+	// be specified unless the route has a request redirect policy
+	// or a direct response policy.
+	// +optional
+	Services []Service `json:"services,omitempty"`
 	// Enables websocket support for the route.
 	// +optional
 	EnableWebsockets bool `json:"enableWebsockets,omitempty"`
@@ -303,6 +542,12 @@ type Route struct {
 	// The timeout policy for this route.
 	// +optional
 	TimeoutPolicy *TimeoutPolicy `json:"timeoutPolicy,omitempty"`
+	// The policy for global rate limiting of client requests
+	// that match this route. A route rate limit policy can only
+	// be set if the root HTTPProxy configures a rate limit
+	// service in its virtual host rate limit policy.
+	// +optional
+	RateLimitPolicy *RateLimitPolicy `json:"rateLimitPolicy,omitempty"`
 	// The retry policy for this route.
 	// +optional
 	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
@@ -324,6 +569,125 @@ type Route struct {
 	// Rewriting the 'Host' header is not supported.
 	// +optional
 	ResponseHeadersPolicy *HeadersPolicy `json:"responseHeadersPolicy,omitempty"`
+	// The policy for redirecting client requests. A route with a
+	// request redirect policy responds to requests with a redirect
+	// instead of proxying them, so it must not specify services.
+	// +optional
+	RequestRedirectPolicy *HTTPRequestRedirectPolicy `json:"requestRedirectPolicy,omitempty"`
+	// The policy for responding to client requests directly. A
+	// route with a direct response policy responds to requests with
+	// a fixed status code and body instead of proxying them, so it
+	// must not specify services or a request redirect policy.
+	// +optional
+	DirectResponsePolicy *HTTPDirectResponsePolicy `json:"directResponsePolicy,omitempty"`
+	// The policy for injecting faults into client requests that
+	// match this route.
+	// +optional
+	FaultInjectionPolicy *FaultInjectionPolicy `json:"faultInjectionPolicy,omitempty"`
+}
+
+// HTTPRequestRedirectPolicy defines how a route redirects client
+// requests. Fields that are not set keep the value from the request.
+type HTTPRequestRedirectPolicy struct {
+	// Scheme is the scheme to use in the redirect location.
+	// +optional
+	// +kubebuilder:validation:Enum=http;https
+	Scheme string `json:"scheme,omitempty"`
+
+	// Hostname is the hostname to use in the redirect location.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	Hostname string `json:"hostname,omitempty"`
+
+	// Port is the port to use in the redirect location.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int `json:"port,omitempty"`
+
+	// Path replaces the full path of the request URL in the redirect
+	// location. Only one of Path or Prefix can be specified.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^/.*$`
+	Path string `json:"path,omitempty"`
+
+	// Prefix replaces the prefix of the request URL that matched the
+	// route's prefix condition in the redirect location. Only one of
+	// Path or Prefix can be specified.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^/.*$`
+	Prefix string `json:"prefix,omitempty"`
+
+	// StatusCode is the HTTP status code of the redirect response.
+	// Defaults to 302.
+	// +optional
+	// +kubebuilder:validation:Enum=301;302;307;308
+	StatusCode int `json:"statusCode,omitempty"`
+}
+
+// HTTPDirectResponsePolicy defines the response that a route sends
+// to client requests without proxying them.
+type HTTPDirectResponsePolicy struct {
+	// StatusCode is the HTTP status code of the response.
+	// +kubebuilder:validation:Minimum=200
+	// +kubebuilder:validation:Maximum=599
+	StatusCode int `json:"statusCode"`
+
+	// Body is the content of the response body. If not
+	// specified, the response has no body.
+	// +optional
+	Body string `json:"body,omitempty"`
+}
+
+// FaultInjectionPolicy defines the faults that are injected into
+// client requests. At least one of delay or abort must be set.
+type FaultInjectionPolicy struct {
+	// Delay injects a fixed delay before requests are proxied.
+	// +optional
+	Delay *FaultDelay `json:"delay,omitempty"`
+
+	// Abort responds to requests with an HTTP status code
+	// instead of proxying them.
+	// +optional
+	Abort *FaultAbort `json:"abort,omitempty"`
+
+	// HeaderName restricts fault injection to requests that
+	// have a header with this name. If not specified, faults
+	// are injected into all requests.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	HeaderName string `json:"headerName,omitempty"`
+}
+
+// FaultDelay defines a fixed delay that is injected into requests.
+type FaultDelay struct {
+	// FixedDelay is the duration that requests are delayed by.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+)$`
+	FixedDelay string `json:"fixedDelay"`
+
+	// Percentage is the percentage of requests that are delayed.
+	// If not specified, all requests are delayed.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	Percentage int64 `json:"percentage,omitempty"`
+}
+
+// FaultAbort defines an HTTP status code that is returned to
+// requests instead of proxying them.
+type FaultAbort struct {
+	// StatusCode is the HTTP status code of the response.
+	// +kubebuilder:validation:Minimum=200
+	// +kubebuilder:validation:Maximum=599
+	StatusCode int `json:"statusCode"`
+
+	// Percentage is the percentage of requests that are aborted.
+	// If not specified, all requests are aborted.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	Percentage int64 `json:"percentage,omitempty"`
 }
 
 // TCPProxy contains the set of services to proxy TCP connections.
@@ -375,7 +739,10 @@ type Service struct {
 	// +kubebuilder:validation:Enum=h2;h2c;tls
 	// +optional
 	Protocol *string `json:"protocol,omitempty"`
-	// Weight defines percentage of traffic to balance traffic
+	// Weight defines percentage of traffic to balance traffic.
+	// For a mirror service, Weight defines the percentage of
+	// traffic that is mirrored, and must not be more than 100.
+	// If not specified, all traffic is mirrored.
 	// +optional
 	// +kubebuilder:validation:Minimum=0
 	Weight int64 `json:"weight,omitempty"`
@@ -383,6 +750,8 @@ type Service struct {
 	// +optional
 	UpstreamValidation *UpstreamValidation `json:"validation,omitempty"`
 	// If Mirror is true the Service will receive a read only mirror of the traffic for this route.
+	// A route may have more than one mirror service, but
+	// may not mirror the same service and port twice.
 	Mirror bool `json:"mirror,omitempty"`
 	// The policy for managing request headers during proxying.
 	// Rewriting the 'Host' header is not supported.
@@ -392,12 +761,88 @@ type Service struct {
 	// Rewriting the 'Host' header is not supported.
 	// +optional
 	ResponseHeadersPolicy *HeadersPolicy `json:"responseHeadersPolicy,omitempty"`
+	// The policy for passive health checking of the service endpoints.
+	// +optional
+	OutlierDetection *OutlierDetection `json:"outlierDetection,omitempty"`
+	// The circuit breaking thresholds for the service. Thresholds set
+	// here override the corresponding Service annotations.
+	// +optional
+	CircuitBreakerPolicy *CircuitBreakerPolicy `json:"circuitBreakerPolicy,omitempty"`
+}
+
+// CircuitBreakerPolicy defines the circuit breaking thresholds of an
+// upstream service. Thresholds that are left empty fall back to the
+// `projectcontour.io/max-*` annotations of the Kubernetes Service, and
+// then to Envoy's defaults.
+type CircuitBreakerPolicy struct {
+	// The maximum number of connections that Envoy will make to
+	// the upstream service.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=4294967295
+	MaxConnections int64 `json:"maxConnections,omitempty"`
+	// The maximum number of pending requests that Envoy will
+	// allow to the upstream service.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=4294967295
+	MaxPendingRequests int64 `json:"maxPendingRequests,omitempty"`
+	// The maximum number of parallel requests that Envoy will
+	// make to the upstream service.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=4294967295
+	MaxRequests int64 `json:"maxRequests,omitempty"`
+	// The maximum number of parallel retries that Envoy will
+	// allow to the upstream service.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=4294967295
+	MaxRetries int64 `json:"maxRetries,omitempty"`
+}
+
+// OutlierDetection defines passive health checking of the endpoints
+// of an upstream service. Endpoints that fail repeatedly are ejected
+// from the load balancing pool for a period of time.
+type OutlierDetection struct {
+	// The number of consecutive 5xx responses, or locally
+	// originated errors, before an endpoint is ejected.
+	// If left empty (default value), Envoy's default of 5 is used.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	ConsecutiveServerErrors int64 `json:"consecutiveServerErrors,omitempty"`
+	// The number of consecutive gateway errors (502, 503 and 504
+	// responses, or connection failures) before an endpoint is ejected.
+	// If left empty (default value), ejection on gateway errors is disabled.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	ConsecutiveGatewayErrors int64 `json:"consecutiveGatewayErrors,omitempty"`
+	// The interval between ejection analysis sweeps.
+	// If left empty (default value), Envoy's default of 10s is used.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+)$`
+	Interval string `json:"interval,omitempty"`
+	// The base time that an endpoint is ejected for. The actual
+	// time is the base time multiplied by the number of times the
+	// endpoint has been ejected.
+	// If left empty (default value), Envoy's default of 30s is used.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+)$`
+	BaseEjectionTime string `json:"baseEjectionTime,omitempty"`
+	// The maximum percentage of endpoints that can be ejected at once.
+	// If left empty (default value), Envoy's default of 10% is used.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	MaxEjectionPercent int64 `json:"maxEjectionPercent,omitempty"`
 }
 
 // HTTPHealthCheckPolicy defines health checks on the upstream service.
 type HTTPHealthCheckPolicy struct {
-	// HTTP endpoint used to perform health checks on upstream service
-	Path string `json:"path"`
+	// HTTP endpoint used to perform health checks on upstream service.
+	// Path must be specified unless GRPC is set.
+	// +optional
+	Path string `json:"path,omitempty"`
 	// The value of the host header in the HTTP health check request.
 	// If left empty (default value), the name "contour-envoy-healthcheck"
 	// will be used.
@@ -416,6 +861,66 @@ type HTTPHealthCheckPolicy struct {
 	// +optional
 	// +kubebuilder:validation:Minimum=0
 	HealthyThresholdCount int64 `json:"healthyThresholdCount"`
+	// The interval (seconds) between health checks of a host that
+	// is marked unhealthy. If not specified, IntervalSeconds is used.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	UnhealthyIntervalSeconds int64 `json:"unhealthyIntervalSeconds,omitempty"`
+	// The interval (seconds) between health checks of a cluster
+	// that has not received any traffic yet. If not specified,
+	// Envoy's default of 60 seconds is used.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	NoTrafficIntervalSeconds int64 `json:"noTrafficIntervalSeconds,omitempty"`
+	// The port that health checks are sent to, if it is different
+	// from the port of the service endpoints.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int `json:"port,omitempty"`
+	// The HTTP status code ranges that are considered healthy. If
+	// not specified, only a 200 response is considered healthy.
+	// Ignored for gRPC health checks.
+	// +optional
+	ExpectedStatuses []HTTPStatusRange `json:"expectedStatuses,omitempty"`
+	// Additional headers that are sent in the HTTP health check
+	// request. Ignored for gRPC health checks.
+	// +optional
+	RequestHeaders []HeaderValue `json:"requestHeaders,omitempty"`
+	// GRPC configures the health check to use the gRPC health
+	// checking protocol instead of an HTTP request to Path. gRPC
+	// health checks can only be used with services whose protocol
+	// is h2 or h2c.
+	// +optional
+	GRPC *GRPCHealthCheck `json:"grpc,omitempty"`
+}
+
+// HTTPStatusRange defines a range of HTTP status codes.
+type HTTPStatusRange struct {
+	// Start is the first status code in the range.
+	// +kubebuilder:validation:Minimum=100
+	// +kubebuilder:validation:Maximum=599
+	Start int64 `json:"start"`
+	// End is the status code after the last status code in the
+	// range, so that the range [200, 300) includes every 2xx code.
+	// +kubebuilder:validation:Minimum=101
+	// +kubebuilder:validation:Maximum=600
+	End int64 `json:"end"`
+}
+
+// GRPCHealthCheck defines a health check that uses the
+// grpc.health.v1.Health service of the upstream.
+type GRPCHealthCheck struct {
+	// ServiceName is the name of the service whose health is
+	// checked. If not specified, the overall health of the
+	// upstream server is checked.
+	// +optional
+	ServiceName string `json:"serviceName,omitempty"`
+	// Authority is the value of the :authority header in the
+	// health check request. If not specified, the name of the
+	// Envoy cluster is used.
+	// +optional
+	Authority string `json:"authority,omitempty"`
 }
 
 // TCPHealthCheckPolicy defines health checks on the upstream service.
@@ -456,6 +961,22 @@ type TimeoutPolicy struct {
 	// +optional
 	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+|infinity|infinite)$`
 	Idle string `json:"idle,omitempty"`
+
+	// Timeout for establishing a connection to the backend.
+	// If not supplied, the global connect timeout applies, or
+	// a default of 250ms if that is not set either.
+	// This timeout cannot be disabled.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+$`
+	Connect string `json:"connect,omitempty"`
+
+	// Timeout after which an idle connection between Envoy and
+	// the backend is closed. If not supplied, the global upstream
+	// idle timeout applies, or Envoy's default of 1h if that is
+	// not set either.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+|infinity|infinite)$`
+	UpstreamIdle string `json:"upstreamIdle,omitempty"`
 }
 
 // RetryOn is a string type alias with validation to ensure that the value is valid.
@@ -539,17 +1060,169 @@ type PathRewritePolicy struct {
 	// ReplacePrefix describes how the path prefix should be replaced.
 	// +optional
 	ReplacePrefix []ReplacePrefix `json:"replacePrefix,omitempty"`
+
+	// RegexRewrite describes how the path should be rewritten
+	// with a regular expression substitution. It cannot be
+	// combined with ReplacePrefix.
+	// +optional
+	RegexRewrite *RegexRewrite `json:"regexRewrite,omitempty"`
+}
+
+// RegexRewrite describes a regular expression substitution on the
+// request URL path.
+type RegexRewrite struct {
+	// Pattern is the regular expression that is matched against
+	// the URL path, not including the query string. The regular
+	// expression must use RE2 syntax. All non-overlapping matches
+	// of the pattern are replaced.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Pattern string `json:"pattern"`
+
+	// Substitution is the string that matches of the pattern are
+	// replaced with. Capture groups of the pattern can be
+	// referenced with \1, \2 and so on.
+	//
+	// +optional
+	Substitution string `json:"substitution,omitempty"`
 }
 
 // LoadBalancerPolicy defines the load balancing policy.
 type LoadBalancerPolicy struct {
 	// Strategy specifies the policy used to balance requests
 	// across the pool of backend pods. Valid policy names are
-	// `Random`, `RoundRobin`, `WeightedLeastRequest`, `Random`
-	// and `Cookie`. If an unknown strategy name is specified
-	// or no policy is supplied, the default `RoundRobin` policy
-	// is used.
+	// `Random`, `RoundRobin`, `WeightedLeastRequest`, `Random`,
+	// `Cookie`, `RingHash` and `Maglev`. If an unknown strategy
+	// name is specified or no policy is supplied, the default
+	// `RoundRobin` policy is used.
 	Strategy string `json:"strategy,omitempty"`
+
+	// RequestHashPolicies contains a list of hash policies to apply
+	// when the `RingHash` or `Maglev` load balancing strategy is
+	// chosen. Policies are evaluated in order, and the hashes they
+	// produce are combined to select the backend pod.
+	//
+	// +optional
+	RequestHashPolicies []RequestHashPolicy `json:"requestHashPolicies,omitempty"`
+
+	// RingHash configures the hash ring that is used by the
+	// `RingHash` and `Cookie` strategies. The lookup table used
+	// by the `Maglev` strategy always has Envoy's default size.
+	//
+	// +optional
+	RingHash *RingHashConfig `json:"ringHash,omitempty"`
+}
+
+// RingHashConfig configures the size of the hash ring. Each backend
+// pod is placed on the ring several times, in proportion to its
+// weight. Larger rings distribute requests more accurately according
+// to the weights, but take longer to build when the pods change.
+type RingHashConfig struct {
+	// MinimumRingSize is the minimum number of entries in the
+	// hash ring. Defaults to 1024.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=8388608
+	MinimumRingSize uint64 `json:"minimumRingSize,omitempty"`
+
+	// MaximumRingSize is the maximum number of entries in the
+	// hash ring. Defaults to 8388608, which is also the upper
+	// limit.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=8388608
+	MaximumRingSize uint64 `json:"maximumRingSize,omitempty"`
+}
+
+// RequestHashPolicy contains configuration for an individual hash
+// policy on a request. Exactly one of HeaderHashOptions,
+// CookieHashOptions, QueryParameterHashOptions or HashSourceIP
+// must be set.
+type RequestHashPolicy struct {
+	// Terminal is a flag that allows for short-circuiting computing
+	// of a hash for a given request. If set to true, and the request
+	// attribute specified in the attribute hash options is present,
+	// no further hash policies will be used to calculate a hash for
+	// the request.
+	//
+	// +optional
+	Terminal bool `json:"terminal,omitempty"`
+
+	// HeaderHashOptions should be set when request header hash
+	// based load balancing is desired.
+	//
+	// +optional
+	HeaderHashOptions *HeaderHashOptions `json:"headerHashOptions,omitempty"`
+
+	// CookieHashOptions should be set when request cookie hash
+	// based load balancing is desired.
+	//
+	// +optional
+	CookieHashOptions *CookieHashOptions `json:"cookieHashOptions,omitempty"`
+
+	// QueryParameterHashOptions should be set when request query
+	// parameter hash based load balancing is desired.
+	//
+	// +optional
+	QueryParameterHashOptions *QueryParameterHashOptions `json:"queryParameterHashOptions,omitempty"`
+
+	// HashSourceIP should be set to true when request source IP
+	// hash based load balancing is desired.
+	//
+	// +optional
+	HashSourceIP bool `json:"hashSourceIP,omitempty"`
+}
+
+// HeaderHashOptions contains options to configure a HTTP request
+// header hash policy, used in request attribute hash based load
+// balancing.
+type HeaderHashOptions struct {
+	// HeaderName is the name of the HTTP request header that will
+	// be used to calculate the hash key.
+	//
+	// +kubebuilder:validation:MinLength=1
+	HeaderName string `json:"headerName"`
+}
+
+// CookieHashOptions contains options to configure a HTTP request
+// cookie hash policy, used in request attribute hash based load
+// balancing.
+type CookieHashOptions struct {
+	// CookieName is the name of the HTTP request cookie that will
+	// be used to calculate the hash key.
+	//
+	// +kubebuilder:validation:MinLength=1
+	CookieName string `json:"cookieName"`
+
+	// TTL is the lifetime of the cookie that Envoy generates when
+	// the request does not carry one. If not specified, Envoy does
+	// not generate the cookie, and requests without it do not
+	// produce a hash from this policy. A TTL of "0s" generates a
+	// session cookie.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+)$`
+	TTL string `json:"ttl,omitempty"`
+
+	// Path is the path of the cookie that Envoy generates. If not
+	// specified, the cookie applies to all paths.
+	//
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// QueryParameterHashOptions contains options to configure a query
+// parameter hash policy, used in request attribute hash based load
+// balancing.
+type QueryParameterHashOptions struct {
+	// ParameterName is the name of the HTTP request query parameter
+	// that will be used to calculate the hash key.
+	//
+	// +kubebuilder:validation:MinLength=1
+	ParameterName string `json:"parameterName"`
 }
 
 // HeadersPolicy defines how headers are managed during forwarding.
@@ -122,6 +122,21 @@ func (in *CertificateDelegation) DeepCopy() *CertificateDelegation {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CircuitBreakerPolicy) DeepCopyInto(out *CircuitBreakerPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CircuitBreakerPolicy.
+func (in *CircuitBreakerPolicy) DeepCopy() *CircuitBreakerPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(CircuitBreakerPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Condition) DeepCopyInto(out *Condition) {
 	*out = *in
@@ -138,6 +153,21 @@ func (in *Condition) DeepCopy() *Condition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CookieHashOptions) DeepCopyInto(out *CookieHashOptions) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CookieHashOptions.
+func (in *CookieHashOptions) DeepCopy() *CookieHashOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(CookieHashOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DetailedCondition) DeepCopyInto(out *DetailedCondition) {
 	*out = *in
@@ -194,9 +224,124 @@ func (in *ExtensionServiceReference) DeepCopy() *ExtensionServiceReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FaultAbort) DeepCopyInto(out *FaultAbort) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FaultAbort.
+func (in *FaultAbort) DeepCopy() *FaultAbort {
+	if in == nil {
+		return nil
+	}
+	out := new(FaultAbort)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FaultDelay) DeepCopyInto(out *FaultDelay) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FaultDelay.
+func (in *FaultDelay) DeepCopy() *FaultDelay {
+	if in == nil {
+		return nil
+	}
+	out := new(FaultDelay)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FaultInjectionPolicy) DeepCopyInto(out *FaultInjectionPolicy) {
+	*out = *in
+	if in.Delay != nil {
+		in, out := &in.Delay, &out.Delay
+		*out = new(FaultDelay)
+		**out = **in
+	}
+	if in.Abort != nil {
+		in, out := &in.Abort, &out.Abort
+		*out = new(FaultAbort)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FaultInjectionPolicy.
+func (in *FaultInjectionPolicy) DeepCopy() *FaultInjectionPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(FaultInjectionPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GRPCHealthCheck) DeepCopyInto(out *GRPCHealthCheck) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GRPCHealthCheck.
+func (in *GRPCHealthCheck) DeepCopy() *GRPCHealthCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(GRPCHealthCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GenericKeyDescriptor) DeepCopyInto(out *GenericKeyDescriptor) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GenericKeyDescriptor.
+func (in *GenericKeyDescriptor) DeepCopy() *GenericKeyDescriptor {
+	if in == nil {
+		return nil
+	}
+	out := new(GenericKeyDescriptor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPDirectResponsePolicy) DeepCopyInto(out *HTTPDirectResponsePolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPDirectResponsePolicy.
+func (in *HTTPDirectResponsePolicy) DeepCopy() *HTTPDirectResponsePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPDirectResponsePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HTTPHealthCheckPolicy) DeepCopyInto(out *HTTPHealthCheckPolicy) {
 	*out = *in
+	if in.ExpectedStatuses != nil {
+		in, out := &in.ExpectedStatuses, &out.ExpectedStatuses
+		*out = make([]HTTPStatusRange, len(*in))
+		copy(*out, *in)
+	}
+	if in.RequestHeaders != nil {
+		in, out := &in.RequestHeaders, &out.RequestHeaders
+		*out = make([]HeaderValue, len(*in))
+		copy(*out, *in)
+	}
+	if in.GRPC != nil {
+		in, out := &in.GRPC, &out.GRPC
+		*out = new(GRPCHealthCheck)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPHealthCheckPolicy.
@@ -330,9 +475,59 @@ func (in *HTTPProxyStatus) DeepCopy() *HTTPProxyStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPRequestRedirectPolicy) DeepCopyInto(out *HTTPRequestRedirectPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPRequestRedirectPolicy.
+func (in *HTTPRequestRedirectPolicy) DeepCopy() *HTTPRequestRedirectPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPRequestRedirectPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPStatusRange) DeepCopyInto(out *HTTPStatusRange) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPStatusRange.
+func (in *HTTPStatusRange) DeepCopy() *HTTPStatusRange {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPStatusRange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HeaderHashOptions) DeepCopyInto(out *HeaderHashOptions) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HeaderHashOptions.
+func (in *HeaderHashOptions) DeepCopy() *HeaderHashOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(HeaderHashOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HeaderMatchCondition) DeepCopyInto(out *HeaderMatchCondition) {
 	*out = *in
+	if in.In != nil {
+		in, out := &in.In, &out.In
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HeaderMatchCondition.
@@ -410,6 +605,18 @@ func (in *Include) DeepCopy() *Include {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LoadBalancerPolicy) DeepCopyInto(out *LoadBalancerPolicy) {
 	*out = *in
+	if in.RequestHashPolicies != nil {
+		in, out := &in.RequestHashPolicies, &out.RequestHashPolicies
+		*out = make([]RequestHashPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RingHash != nil {
+		in, out := &in.RingHash, &out.RingHash
+		*out = new(RingHashConfig)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerPolicy.
@@ -428,6 +635,11 @@ func (in *MatchCondition) DeepCopyInto(out *MatchCondition) {
 	if in.Header != nil {
 		in, out := &in.Header, &out.Header
 		*out = new(HeaderMatchCondition)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.QueryParameter != nil {
+		in, out := &in.QueryParameter, &out.QueryParameter
+		*out = new(QueryParameterMatchCondition)
 		**out = **in
 	}
 }
@@ -442,6 +654,21 @@ func (in *MatchCondition) DeepCopy() *MatchCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OutlierDetection) DeepCopyInto(out *OutlierDetection) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OutlierDetection.
+func (in *OutlierDetection) DeepCopy() *OutlierDetection {
+	if in == nil {
+		return nil
+	}
+	out := new(OutlierDetection)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PathRewritePolicy) DeepCopyInto(out *PathRewritePolicy) {
 	*out = *in
@@ -450,6 +677,11 @@ func (in *PathRewritePolicy) DeepCopyInto(out *PathRewritePolicy) {
 		*out = make([]ReplacePrefix, len(*in))
 		copy(*out, *in)
 	}
+	if in.RegexRewrite != nil {
+		in, out := &in.RegexRewrite, &out.RegexRewrite
+		*out = new(RegexRewrite)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PathRewritePolicy.
@@ -462,6 +694,145 @@ func (in *PathRewritePolicy) DeepCopy() *PathRewritePolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QueryParameterHashOptions) DeepCopyInto(out *QueryParameterHashOptions) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueryParameterHashOptions.
+func (in *QueryParameterHashOptions) DeepCopy() *QueryParameterHashOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(QueryParameterHashOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QueryParameterMatchCondition) DeepCopyInto(out *QueryParameterMatchCondition) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueryParameterMatchCondition.
+func (in *QueryParameterMatchCondition) DeepCopy() *QueryParameterMatchCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(QueryParameterMatchCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RateLimitDescriptor) DeepCopyInto(out *RateLimitDescriptor) {
+	*out = *in
+	if in.Entries != nil {
+		in, out := &in.Entries, &out.Entries
+		*out = make([]RateLimitDescriptorEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RateLimitDescriptor.
+func (in *RateLimitDescriptor) DeepCopy() *RateLimitDescriptor {
+	if in == nil {
+		return nil
+	}
+	out := new(RateLimitDescriptor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RateLimitDescriptorEntry) DeepCopyInto(out *RateLimitDescriptorEntry) {
+	*out = *in
+	if in.GenericKey != nil {
+		in, out := &in.GenericKey, &out.GenericKey
+		*out = new(GenericKeyDescriptor)
+		**out = **in
+	}
+	if in.RequestHeader != nil {
+		in, out := &in.RequestHeader, &out.RequestHeader
+		*out = new(RequestHeaderDescriptor)
+		**out = **in
+	}
+	if in.RequestHeaderValueMatch != nil {
+		in, out := &in.RequestHeaderValueMatch, &out.RequestHeaderValueMatch
+		*out = new(RequestHeaderValueMatchDescriptor)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RemoteAddress != nil {
+		in, out := &in.RemoteAddress, &out.RemoteAddress
+		*out = new(RemoteAddressDescriptor)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RateLimitDescriptorEntry.
+func (in *RateLimitDescriptorEntry) DeepCopy() *RateLimitDescriptorEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(RateLimitDescriptorEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RateLimitPolicy) DeepCopyInto(out *RateLimitPolicy) {
+	*out = *in
+	if in.Descriptors != nil {
+		in, out := &in.Descriptors, &out.Descriptors
+		*out = make([]RateLimitDescriptor, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RateLimitPolicy.
+func (in *RateLimitPolicy) DeepCopy() *RateLimitPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RateLimitPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegexRewrite) DeepCopyInto(out *RegexRewrite) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegexRewrite.
+func (in *RegexRewrite) DeepCopy() *RegexRewrite {
+	if in == nil {
+		return nil
+	}
+	out := new(RegexRewrite)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemoteAddressDescriptor) DeepCopyInto(out *RemoteAddressDescriptor) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteAddressDescriptor.
+func (in *RemoteAddressDescriptor) DeepCopy() *RemoteAddressDescriptor {
+	if in == nil {
+		return nil
+	}
+	out := new(RemoteAddressDescriptor)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReplacePrefix) DeepCopyInto(out *ReplacePrefix) {
 	*out = *in
@@ -477,6 +848,78 @@ func (in *ReplacePrefix) DeepCopy() *ReplacePrefix {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RequestHashPolicy) DeepCopyInto(out *RequestHashPolicy) {
+	*out = *in
+	if in.HeaderHashOptions != nil {
+		in, out := &in.HeaderHashOptions, &out.HeaderHashOptions
+		*out = new(HeaderHashOptions)
+		**out = **in
+	}
+	if in.CookieHashOptions != nil {
+		in, out := &in.CookieHashOptions, &out.CookieHashOptions
+		*out = new(CookieHashOptions)
+		**out = **in
+	}
+	if in.QueryParameterHashOptions != nil {
+		in, out := &in.QueryParameterHashOptions, &out.QueryParameterHashOptions
+		*out = new(QueryParameterHashOptions)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RequestHashPolicy.
+func (in *RequestHashPolicy) DeepCopy() *RequestHashPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RequestHashPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RequestHeaderDescriptor) DeepCopyInto(out *RequestHeaderDescriptor) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RequestHeaderDescriptor.
+func (in *RequestHeaderDescriptor) DeepCopy() *RequestHeaderDescriptor {
+	if in == nil {
+		return nil
+	}
+	out := new(RequestHeaderDescriptor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RequestHeaderValueMatchDescriptor) DeepCopyInto(out *RequestHeaderValueMatchDescriptor) {
+	*out = *in
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make([]HeaderMatchCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ExpectMatch != nil {
+		in, out := &in.ExpectMatch, &out.ExpectMatch
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RequestHeaderValueMatchDescriptor.
+func (in *RequestHeaderValueMatchDescriptor) DeepCopy() *RequestHeaderValueMatchDescriptor {
+	if in == nil {
+		return nil
+	}
+	out := new(RequestHeaderValueMatchDescriptor)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RetryPolicy) DeepCopyInto(out *RetryPolicy) {
 	*out = *in
@@ -502,6 +945,21 @@ func (in *RetryPolicy) DeepCopy() *RetryPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RingHashConfig) DeepCopyInto(out *RingHashConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RingHashConfig.
+func (in *RingHashConfig) DeepCopy() *RingHashConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RingHashConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Route) DeepCopyInto(out *Route) {
 	*out = *in
@@ -529,6 +987,11 @@ func (in *Route) DeepCopyInto(out *Route) {
 		*out = new(TimeoutPolicy)
 		**out = **in
 	}
+	if in.RateLimitPolicy != nil {
+		in, out := &in.RateLimitPolicy, &out.RateLimitPolicy
+		*out = new(RateLimitPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.RetryPolicy != nil {
 		in, out := &in.RetryPolicy, &out.RetryPolicy
 		*out = new(RetryPolicy)
@@ -537,12 +1000,12 @@ func (in *Route) DeepCopyInto(out *Route) {
 	if in.HealthCheckPolicy != nil {
 		in, out := &in.HealthCheckPolicy, &out.HealthCheckPolicy
 		*out = new(HTTPHealthCheckPolicy)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.LoadBalancerPolicy != nil {
 		in, out := &in.LoadBalancerPolicy, &out.LoadBalancerPolicy
 		*out = new(LoadBalancerPolicy)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.PathRewritePolicy != nil {
 		in, out := &in.PathRewritePolicy, &out.PathRewritePolicy
@@ -559,6 +1022,21 @@ func (in *Route) DeepCopyInto(out *Route) {
 		*out = new(HeadersPolicy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RequestRedirectPolicy != nil {
+		in, out := &in.RequestRedirectPolicy, &out.RequestRedirectPolicy
+		*out = new(HTTPRequestRedirectPolicy)
+		**out = **in
+	}
+	if in.DirectResponsePolicy != nil {
+		in, out := &in.DirectResponsePolicy, &out.DirectResponsePolicy
+		*out = new(HTTPDirectResponsePolicy)
+		**out = **in
+	}
+	if in.FaultInjectionPolicy != nil {
+		in, out := &in.FaultInjectionPolicy, &out.FaultInjectionPolicy
+		*out = new(FaultInjectionPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Route.
@@ -594,6 +1072,16 @@ func (in *Service) DeepCopyInto(out *Service) {
 		*out = new(HeadersPolicy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.OutlierDetection != nil {
+		in, out := &in.OutlierDetection, &out.OutlierDetection
+		*out = new(OutlierDetection)
+		**out = **in
+	}
+	if in.CircuitBreakerPolicy != nil {
+		in, out := &in.CircuitBreakerPolicy, &out.CircuitBreakerPolicy
+		*out = new(CircuitBreakerPolicy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Service.
@@ -642,7 +1130,7 @@ func (in *TCPProxy) DeepCopyInto(out *TCPProxy) {
 	if in.LoadBalancerPolicy != nil {
 		in, out := &in.LoadBalancerPolicy, &out.LoadBalancerPolicy
 		*out = new(LoadBalancerPolicy)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Services != nil {
 		in, out := &in.Services, &out.Services
@@ -831,6 +1319,21 @@ func (in *TimeoutPolicy) DeepCopy() *TimeoutPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TracingPolicy) DeepCopyInto(out *TracingPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TracingPolicy.
+func (in *TracingPolicy) DeepCopy() *TracingPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(TracingPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UpstreamValidation) DeepCopyInto(out *UpstreamValidation) {
 	*out = *in
@@ -864,6 +1367,16 @@ func (in *VirtualHost) DeepCopyInto(out *VirtualHost) {
 		*out = new(CORSPolicy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RateLimitPolicy != nil {
+		in, out := &in.RateLimitPolicy, &out.RateLimitPolicy
+		*out = new(VirtualHostRateLimitPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TracingPolicy != nil {
+		in, out := &in.TracingPolicy, &out.TracingPolicy
+		*out = new(TracingPolicy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualHost.
@@ -875,3 +1388,26 @@ func (in *VirtualHost) DeepCopy() *VirtualHost {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtualHostRateLimitPolicy) DeepCopyInto(out *VirtualHostRateLimitPolicy) {
+	*out = *in
+	out.ExtensionServiceRef = in.ExtensionServiceRef
+	if in.Descriptors != nil {
+		in, out := &in.Descriptors, &out.Descriptors
+		*out = make([]RateLimitDescriptor, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualHostRateLimitPolicy.
+func (in *VirtualHostRateLimitPolicy) DeepCopy() *VirtualHostRateLimitPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtualHostRateLimitPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -19,13 +19,16 @@ import (
 )
 
 // ExtensionProtocolVersion is the version of the GRPC protocol used
-// to access extension services. The only version currently supported
-// is "v2".
+// to access extension services. The supported versions are "v2" and
+// "v3".
 type ExtensionProtocolVersion string
 
 // SupportProtocolVersion2 requests the "v2" support protocol version.
 const SupportProtocolVersion2 ExtensionProtocolVersion = "v2"
 
+// SupportProtocolVersion3 requests the "v3" support protocol version.
+const SupportProtocolVersion3 ExtensionProtocolVersion = "v3"
+
 // ExtensionServiceTarget defines an Kubernetes Service to target with
 // extension service traffic.
 type ExtensionServiceTarget struct {
@@ -86,13 +89,18 @@ type ExtensionServiceSpec struct {
 	// +optional
 	TimeoutPolicy *contour_api_v1.TimeoutPolicy `json:"timeoutPolicy,omitempty"`
 
+	// The circuit breaking thresholds for the services.
+	//
+	// +optional
+	CircuitBreakerPolicy *contour_api_v1.CircuitBreakerPolicy `json:"circuitBreakerPolicy,omitempty"`
+
 	// This field sets the version of the GRPC protocol that Envoy uses to
-	// send requests to the extension service. Since Contour always uses the
-	// v2 Envoy API, this is currently fixed at "v2". However, other
-	// protocol options will be available in future.
+	// send requests to the extension service. The default is "v2". The
+	// "v3" protocol is only available to Envoy versions that support the
+	// v3 xDS API.
 	//
 	// +optional
-	// +kubebuilder:validation:Enum=v2
+	// +kubebuilder:validation:Enum=v2;v3
 	ProtocolVersion ExtensionProtocolVersion `json:"protocolVersion,omitempty"`
 }
 
@@ -105,13 +105,18 @@ func (in *ExtensionServiceSpec) DeepCopyInto(out *ExtensionServiceSpec) {
 	if in.LoadBalancerPolicy != nil {
 		in, out := &in.LoadBalancerPolicy, &out.LoadBalancerPolicy
 		*out = new(v1.LoadBalancerPolicy)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.TimeoutPolicy != nil {
 		in, out := &in.TimeoutPolicy, &out.TimeoutPolicy
 		*out = new(v1.TimeoutPolicy)
 		**out = **in
 	}
+	if in.CircuitBreakerPolicy != nil {
+		in, out := &in.CircuitBreakerPolicy, &out.CircuitBreakerPolicy
+		*out = new(v1.CircuitBreakerPolicy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtensionServiceSpec.
@@ -33,6 +33,10 @@ func registerBootstrap(app *kingpin.Application) (*kingpin.CmdClause, *envoy.Boo
 	bootstrap.Flag("envoy-cafile", "gRPC CA Filename for Envoy to load.").Envar("ENVOY_CAFILE").StringVar(&config.GrpcCABundle)
 	bootstrap.Flag("envoy-cert-file", "gRPC Client cert filename for Envoy to load.").Envar("ENVOY_CERT_FILE").StringVar(&config.GrpcClientCert)
 	bootstrap.Flag("envoy-key-file", "gRPC Client key filename for Envoy to load.").Envar("ENVOY_KEY_FILE").StringVar(&config.GrpcClientKey)
+	bootstrap.Flag("use-ads", "Fetch all xDS resources over a single Aggregated Discovery Service stream.").BoolVar(&config.UseADS)
+	bootstrap.Flag("use-delta", "Fetch xDS resources with the incremental xDS protocol.").BoolVar(&config.UseDelta)
+	bootstrap.Flag("xds-resource-version", "The Envoy xDS resource version to use, either v2 or v3.").Default("v2").EnumVar(&config.XDSResourceVersion, "v2", "v3")
+	bootstrap.Flag("xds-server-type", "The type of xDS server that Contour runs, either contour or envoy.").Default("contour").EnumVar(&config.XDSServerType, "contour", "envoy")
 	bootstrap.Flag("namespace", "The namespace the Envoy container will run in.").Envar("CONTOUR_NAMESPACE").Default("projectcontour").StringVar(&config.Namespace)
 	return bootstrap, &config
 }
@@ -15,6 +15,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -27,12 +28,15 @@ import (
 
 	envoy_api_v2_auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
 	"github.com/envoyproxy/go-control-plane/pkg/cache/v2"
+	cache_v3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/server/v2"
+	server_v3 "github.com/envoyproxy/go-control-plane/pkg/server/v3"
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
 	"github.com/projectcontour/contour/internal/annotation"
 	"github.com/projectcontour/contour/internal/contour"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/debug"
+	"github.com/projectcontour/contour/internal/envoy"
 	"github.com/projectcontour/contour/internal/health"
 	"github.com/projectcontour/contour/internal/httpsvc"
 	"github.com/projectcontour/contour/internal/k8s"
@@ -41,8 +45,10 @@ import (
 	"github.com/projectcontour/contour/internal/workgroup"
 	"github.com/projectcontour/contour/internal/xds"
 	contour_xds_v2 "github.com/projectcontour/contour/internal/xds/v2"
+	contour_xds_v3 "github.com/projectcontour/contour/internal/xds/v3"
 	"github.com/projectcontour/contour/internal/xdscache"
 	xdscache_v2 "github.com/projectcontour/contour/internal/xdscache/v2"
+	xdscache_v3 "github.com/projectcontour/contour/internal/xdscache/v3"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
@@ -346,6 +352,17 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 	if err != nil {
 		return fmt.Errorf("error parsing request timeout: %w", err)
 	}
+	connectTimeout, err := timeout.Parse(ctx.ConnectTimeout)
+	if err != nil {
+		return fmt.Errorf("error parsing connect timeout: %w", err)
+	}
+	if connectTimeout.IsDisabled() {
+		return errors.New("connect timeout cannot be disabled")
+	}
+	upstreamIdleTimeout, err := timeout.Parse(ctx.UpstreamIdleTimeout)
+	if err != nil {
+		return fmt.Errorf("error parsing upstream idle timeout: %w", err)
+	}
 
 	// Set the global minimum allowed TLS version to 1.1, which allows proxies/ingresses
 	// that are explicitly using 1.1 to continue working by default. However, the
@@ -353,6 +370,10 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 	// is 1.2, set in the DAG processors.
 	globalMinTLSVersion := annotation.MinTLSVersion(ctx.TLSConfig.MinimumProtocolVersion, envoy_api_v2_auth.TlsParameters_TLSv1_1)
 
+	if err := validateAccessLogFormat(ctx.AccessLogFormat, ctx.AccessLogFormatString, ctx.AccessLogFields); err != nil {
+		return fmt.Errorf("failed to configure access log format: %w", err)
+	}
+
 	listenerConfig := xdscache_v2.ListenerConfig{
 		UseProxyProto:                 ctx.useProxyProto,
 		HTTPAddress:                   ctx.httpAddr,
@@ -362,6 +383,7 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 		HTTPSPort:                     ctx.httpsPort,
 		HTTPSAccessLog:                ctx.httpsAccessLog,
 		AccessLogType:                 ctx.AccessLogFormat,
+		AccessLogFormatString:         ctx.AccessLogFormatString,
 		AccessLogFields:               ctx.AccessLogFields,
 		MinimumTLSVersion:             globalMinTLSVersion,
 		RequestTimeout:                requestTimeout,
@@ -378,31 +400,97 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 
 	listenerConfig.DefaultHTTPVersions = defaultHTTPVersions
 
+	namedListeners, err := parseListeners(ctx.Listeners)
+	if err != nil {
+		return fmt.Errorf("failed to configure additional listeners: %w", err)
+	}
+
+	listenerConfig.Listeners = namedListeners
+
+	var dagListeners []dag.NamedListener
+	for _, l := range namedListeners {
+		dagListeners = append(dagListeners, dag.NamedListener{
+			Name:     l.Name,
+			Address:  l.Address,
+			Port:     l.Port,
+			Protocol: l.Protocol,
+		})
+	}
+
+	tracingProcessor, err := parseTracing(ctx.Tracing)
+	if err != nil {
+		return fmt.Errorf("failed to configure tracing: %w", err)
+	}
+
+	tracingProcessor.FieldLogger = log.WithField("context", "TracingProcessor")
+	tracingProcessor.ConnectTimeout = connectTimeout
+	tracingProcessor.UpstreamIdleTimeout = upstreamIdleTimeout
+
+	accessLogServiceProcessor, err := parseAccessLogService(ctx.AccessLogService)
+	if err != nil {
+		return fmt.Errorf("failed to configure access log service: %w", err)
+	}
+
+	accessLogServiceProcessor.FieldLogger = log.WithField("context", "AccessLogServiceProcessor")
+
 	contourMetrics := metrics.NewMetrics(registry)
 
 	// Endpoints updates are handled directly by the EndpointsTranslator
 	// due to their high update rate and their orthogonal nature.
 	endpointHandler := xdscache_v2.NewEndpointsTranslator(log.WithField("context", "endpointstranslator"))
 
+	// The caches are updated in this order when the DAG is rebuilt.
+	// The contour xDS server sends ADS updates in the order given by
+	// adsOrder in internal/xds/v2/ads.go, and relies on each cache
+	// being updated before the caches that come after it in that
+	// order. That way Envoy is never sent a listener or route that
+	// refers to a cluster, endpoint or secret it doesn't have yet.
+	// This order and adsOrder must be kept the same.
 	resources := []xdscache.ResourceCache{
-		xdscache_v2.NewListenerCache(listenerConfig, ctx.statsAddr, ctx.statsPort),
-		&xdscache_v2.SecretCache{},
-		&xdscache_v2.RouteCache{},
 		&xdscache_v2.ClusterCache{},
 		endpointHandler,
+		&xdscache_v2.SecretCache{},
+		xdscache_v2.NewListenerCache(listenerConfig, ctx.statsAddr, ctx.statsPort),
+		&xdscache_v2.RouteCache{},
 	}
 
+	// The v3 resources are translated from the contents of the v2
+	// resource caches, so Envoy can request either version.
+	xdsResources := xdscache.ResourcesOf(resources)
+	xdsResources = append(xdsResources, xdscache_v3.NewResourceCaches(log.WithField("context", "xdscache_v3"),
+		contourMetrics.XDSV3Untranslated, xdsResources...)...)
+
 	// snapshotCache is used to store the state of what all xDS services should
 	// contain at any given point in time.
 	snapshotCache := cache.NewSnapshotCache(false, xds.DefaultHash,
 		log.WithField("context", "xDS"))
 
+	// Both xDS servers serve v3 resources from the v3 resource caches
+	// above, which translate each v2 entry once per version. The envoy
+	// xDS server can only serve resources from a snapshot, so a v3
+	// snapshot is built from those caches whenever they change. The
+	// contour xDS server queries the caches when Envoy requests the
+	// resources, so it needs no v3 snapshot, and nothing is translated
+	// while no v3 client is connected.
+	var snapshotCacheV3 cache_v3.SnapshotCache
+	if ctx.XDSServerType == "envoy" {
+		snapshotCacheV3 = cache_v3.NewSnapshotCache(false, contour_xds_v3.DefaultHash,
+			log.WithField("context", "xDS"))
+	}
+
 	// snapshotHandler is used to produce new snapshots when the internal state changes for any xDS resource.
-	snapshotHandler := xdscache.NewSnapshotHandler(snapshotCache, resources, log.WithField("context", "snapshotHandler"))
+	snapshotHandler := xdscache.NewSnapshotHandler(snapshotCache, snapshotCacheV3, xdsResources, log.WithField("context", "snapshotHandler"))
 
 	// register observer for endpoints updates.
 	endpointHandler.Observer = contour.ComposeObservers(snapshotHandler)
 
+	// nacks records the xDS updates rejected by Envoy. The DAG is
+	// rebuilt whenever they change so that the rejections can be
+	// reported in the status of the objects that caused them.
+	nacks := &xds.NackRecorder{
+		Counter: contourMetrics.XDSNacks,
+	}
+
 	dnsLookupFamily, err := ParseDNSLookupFamily(ctx.DNSLookupFamily)
 	if err != nil {
 		return fmt.Errorf("failed to configure configuration file parameter cluster.dns-lookup-family: %w", err)
@@ -421,25 +509,47 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 			},
 			Processors: []dag.Processor{
 				&dag.IngressProcessor{
-					FieldLogger:       log.WithField("context", "IngressProcessor"),
-					ClientCertificate: clientCert,
+					FieldLogger:         log.WithField("context", "IngressProcessor"),
+					ClientCertificate:   clientCert,
+					ConnectTimeout:      connectTimeout,
+					UpstreamIdleTimeout: upstreamIdleTimeout,
 				},
 				&dag.ExtensionServiceProcessor{
-					FieldLogger:       log.WithField("context", "ExtensionServiceProcessor"),
-					ClientCertificate: clientCert,
+					FieldLogger:         log.WithField("context", "ExtensionServiceProcessor"),
+					ClientCertificate:   clientCert,
+					ConnectTimeout:      connectTimeout,
+					UpstreamIdleTimeout: upstreamIdleTimeout,
 				},
 				&dag.HTTPProxyProcessor{
 					DisablePermitInsecure: ctx.DisablePermitInsecure,
 					FallbackCertificate:   fallbackCert,
 					DNSLookupFamily:       dnsLookupFamily,
 					ClientCertificate:     clientCert,
+					ConnectTimeout:        connectTimeout,
+					UpstreamIdleTimeout:   upstreamIdleTimeout,
+					Listeners:             dagListeners,
+				},
+				&dag.GatewayProcessor{
+					FieldLogger: log.WithField("context", "GatewayProcessor"),
+				},
+				&dag.NackProcessor{
+					FieldLogger: log.WithField("context", "NackProcessor"),
+					Nacks:       nacks,
+					ClusterName: envoy.Clustername,
+					SecretName:  envoy.Secretname,
+				},
+				&dag.ListenerProcessor{
+					Listeners: dagListeners,
 				},
-				&dag.ListenerProcessor{},
+				tracingProcessor,
+				accessLogServiceProcessor,
 			},
 		},
 		FieldLogger: log.WithField("context", "contourEventHandler"),
 	}
 
+	nacks.OnChange = eventHandler.UpdateNow
+
 	// Log that we're using the fallback certificate if configured.
 	if fallbackCert != nil {
 		log.WithField("context", "fallback-certificate").Infof("enabled fallback certificate with secret: %q", fallbackCert)
@@ -622,17 +732,25 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 
 		var grpcServer *grpc.Server
 
+		// Both the v2 and v3 xDS services are registered. Envoy
+		// selects the version it wants by the type URL it requests.
 		switch ctx.XDSServerType {
 		case "contour":
-			grpcServer = contour_xds_v2.RegisterServer(
-				contour_xds_v2.NewContourServer(log, xdscache.ResourcesOf(resources)...),
-				registry,
-				ctx.grpcOptions(log)...)
+			srv := contour_xds_v2.NewContourServer(log, nacks, xdsResources...)
+			grpcServer = contour_xds_v2.RegisterServer(srv, registry, ctx.grpcOptions(log)...)
+			contour_xds_v3.RegisterServer(grpcServer, contour_xds_v3.NewContourServer(srv))
 		case "envoy":
+			// The go-control-plane server doesn't implement the
+			// incremental xDS protocol, so reject it with an error
+			// that explains why rather than a bare Unimplemented.
 			grpcServer = contour_xds_v2.RegisterServer(
-				server.NewServer(context.Background(), snapshotCache, nil),
+				contour_xds_v2.NewNoDeltaServer(log,
+					server.NewServer(context.Background(), snapshotCache, nil)),
 				registry,
 				ctx.grpcOptions(log)...)
+			contour_xds_v3.RegisterServer(grpcServer,
+				contour_xds_v3.NewNoDeltaServer(log,
+					server_v3.NewServer(context.Background(), snapshotCacheV3, nil)))
 		default:
 			log.Fatalf("invalid xdsServerType %q configured", ctx.XDSServerType)
 		}
@@ -25,6 +25,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/internal/envoy"
 	envoy_v2 "github.com/projectcontour/contour/internal/envoy/v2"
 	xdscache_v2 "github.com/projectcontour/contour/internal/xdscache/v2"
 	"github.com/sirupsen/logrus"
@@ -96,10 +98,21 @@ type serveContext struct {
 	// Valid options are 'envoy' or 'json'
 	AccessLogFormat string `yaml:"accesslog-format,omitempty"`
 
+	// AccessLogFormatString sets the Envoy format string of
+	// access logs when AccessLogFormat is envoy. If empty,
+	// Envoy's default format is used.
+	AccessLogFormatString string `yaml:"accesslog-format-string,omitempty"`
+
 	// AccessLogFields sets the fields that JSON logging will
-	// output when AccessLogFormat is json.
+	// output when AccessLogFormat is json. Each field is either
+	// one of the built-in field names, or a custom field of the
+	// form "name=template".
 	AccessLogFields []string `yaml:"json-fields,omitempty"`
 
+	// AccessLogService configures Envoy to also stream access
+	// logs to a gRPC access log service.
+	AccessLogService *AccessLogServiceConfig `yaml:"accesslog-service,omitempty"`
+
 	// PermitInsecureGRPC disables TLS on Contour's gRPC listener.
 	PermitInsecureGRPC bool `yaml:"-"`
 
@@ -145,6 +158,14 @@ type serveContext struct {
 	// ClusterConfig holds various configurable Envoy cluster values that can
 	// be set in the config file.
 	ClusterConfig `yaml:"cluster,omitempty"`
+
+	// Listeners defines additional named Envoy listeners that
+	// HTTPProxy virtual hosts can be attached to.
+	Listeners []ListenerConfig `yaml:"listeners,omitempty"`
+
+	// Tracing configures Envoy to send distributed
+	// traces to a collector.
+	Tracing *TracingConfig `yaml:"tracing,omitempty"`
 }
 
 // newServeContext returns a serveContext initialized to defaults.
@@ -310,6 +331,25 @@ type TimeoutConfig struct {
 	// See https://www.envoyproxy.io/docs/envoy/latest/api-v2/config/filter/network/http_connection_manager/v2/http_connection_manager.proto#envoy-api-field-config-filter-network-http-connection-manager-v2-httpconnectionmanager-drain-timeout
 	// for more information.
 	ConnectionShutdownGracePeriod string `yaml:"connection-shutdown-grace-period,omitempty"`
+
+	// ConnectTimeout defines how long the proxy should wait when establishing
+	// a connection to an upstream service before giving up. It can be
+	// overridden by the connect timeout of an HTTPProxy route or an
+	// ExtensionService. This timeout cannot be disabled.
+	//
+	// See https://www.envoyproxy.io/docs/envoy/latest/api-v2/api/v2/cluster.proto#envoy-api-field-cluster-connect-timeout
+	// for more information.
+	ConnectTimeout string `yaml:"connect-timeout,omitempty"`
+
+	// UpstreamIdleTimeout defines how long the proxy should keep a connection
+	// to an upstream service open while there are no active requests before
+	// closing it. It can be overridden by the upstream idle timeout of an
+	// HTTPProxy route or an ExtensionService. Set to "infinity" to disable
+	// the timeout entirely.
+	//
+	// See https://www.envoyproxy.io/docs/envoy/latest/api-v2/api/v2/core/protocol.proto#envoy-api-field-core-httpprotocoloptions-idle-timeout
+	// for more information.
+	UpstreamIdleTimeout string `yaml:"upstream-idle-timeout,omitempty"`
 }
 
 // ClusterConfig holds various configurable cluster values.
@@ -328,6 +368,101 @@ type ClusterConfig struct {
 	DNSLookupFamily string `yaml:"dns-lookup-family"`
 }
 
+// ListenerConfig holds the configuration of an additional named listener.
+type ListenerConfig struct {
+	// Name is the unique name of the listener, which HTTPProxy
+	// virtual hosts use to attach to it.
+	Name string `yaml:"name"`
+
+	// Address is the address the listener binds to.
+	// Defaults to "0.0.0.0".
+	Address string `yaml:"address,omitempty"`
+
+	// Port is the port the listener binds to.
+	Port int `yaml:"port"`
+
+	// Protocol is the protocol the listener accepts.
+	// Valid options are 'http' or 'https'.
+	Protocol string `yaml:"protocol"`
+
+	// UseProxyProtocol configures the listener to expect
+	// a PROXY protocol V1 or V2 preamble.
+	UseProxyProtocol bool `yaml:"use-proxy-protocol,omitempty"`
+
+	// AccessLog is the path of the listener's access log.
+	// Defaults to "/dev/stdout".
+	AccessLog string `yaml:"access-log,omitempty"`
+}
+
+// AccessLogServiceConfig holds the configuration of the
+// gRPC access log service that access logs are streamed to.
+type AccessLogServiceConfig struct {
+	// ExtensionService defines the namespace/name of the ExtensionService
+	// that implements the Envoy gRPC access log service.
+	ExtensionService NamespacedName `yaml:"extension-service"`
+
+	// LogName identifies the access logs to the access log service.
+	// Defaults to "contour".
+	LogName string `yaml:"log-name,omitempty"`
+
+	// BufferFlushInterval is the interval at which buffered access
+	// logs are sent. Defaults to Envoy's default of 1s.
+	BufferFlushInterval string `yaml:"buffer-flush-interval,omitempty"`
+
+	// BufferSizeBytes is the size of the buffer that access logs are
+	// held in until they are sent. Defaults to Envoy's default of 16KiB.
+	BufferSizeBytes uint32 `yaml:"buffer-size-bytes,omitempty"`
+}
+
+// TracingConfig holds the distributed tracing configuration.
+type TracingConfig struct {
+	// Provider is the tracing provider.
+	// Valid options are 'zipkin' or 'opencensus'.
+	Provider string `yaml:"provider"`
+
+	// ExtensionService defines the namespace/name of the
+	// ExtensionService that traces are sent to.
+	ExtensionService NamespacedName `yaml:"extension-service,omitempty"`
+
+	// Service defines the namespace/name of the Kubernetes
+	// Service that traces are sent to. It can't be used
+	// together with ExtensionService.
+	Service NamespacedName `yaml:"service,omitempty"`
+
+	// ServicePort is the port of the Service that
+	// traces are sent to.
+	ServicePort int `yaml:"service-port,omitempty"`
+
+	// CollectorEndpoint is the path that Zipkin traces are posted to.
+	// Defaults to "/api/v2/spans".
+	CollectorEndpoint string `yaml:"collector-endpoint,omitempty"`
+
+	// SamplingRate is the percentage of requests that are traced.
+	// Defaults to 100.
+	SamplingRate *float64 `yaml:"sampling-rate,omitempty"`
+
+	// MaxPathTagLength is the maximum length of the request path
+	// that is recorded in a span. Defaults to Envoy's default of 256.
+	MaxPathTagLength uint32 `yaml:"max-path-tag-length,omitempty"`
+
+	// CustomTags are the additional tags that are recorded in every span.
+	CustomTags []CustomTagConfig `yaml:"custom-tags,omitempty"`
+}
+
+// CustomTagConfig holds the configuration of a tracing tag. The
+// value of the tag is either a literal or a request header.
+type CustomTagConfig struct {
+	// TagName is the name of the tag.
+	TagName string `yaml:"tag-name"`
+
+	// Literal is the static value of the tag.
+	Literal string `yaml:"literal,omitempty"`
+
+	// RequestHeader is the name of the request header
+	// that holds the value of the tag.
+	RequestHeader string `yaml:"request-header,omitempty"`
+}
+
 // grpcOptions returns a slice of grpc.ServerOptions.
 // if ctx.PermitInsecureGRPC is false, the option set will
 // include TLS configuration.
@@ -473,6 +608,193 @@ func parseDefaultHTTPVersions(versions []string) ([]envoy_v2.HTTPVersionType, er
 	return parsed, nil
 }
 
+// parseListeners validates the additional named listeners and
+// returns their Envoy listener configuration.
+func parseListeners(listeners []ListenerConfig) ([]xdscache_v2.NamedListener, error) {
+	// These names are already used by the listeners and
+	// route configurations that Contour always programs.
+	names := map[string]bool{
+		xdscache_v2.ENVOY_HTTP_LISTENER:        true,
+		xdscache_v2.ENVOY_HTTPS_LISTENER:       true,
+		xdscache_v2.ENVOY_FALLBACK_ROUTECONFIG: true,
+		"stats-health":                         true,
+	}
+
+	var parsed []xdscache_v2.NamedListener
+	for _, l := range listeners {
+		switch {
+		case strings.TrimSpace(l.Name) == "":
+			return nil, errors.New("listener name must be defined")
+		case strings.Contains(l.Name, "/"):
+			return nil, fmt.Errorf("invalid listener name %q", l.Name)
+		case names[l.Name]:
+			return nil, fmt.Errorf("duplicate listener name %q", l.Name)
+		case l.Port < 1 || l.Port > 65535:
+			return nil, fmt.Errorf("invalid port %d for listener %q", l.Port, l.Name)
+		}
+		names[l.Name] = true
+
+		protocol := strings.ToLower(l.Protocol)
+		switch protocol {
+		case dag.ListenerProtocolHTTP, dag.ListenerProtocolHTTPS:
+		default:
+			return nil, fmt.Errorf("invalid protocol %q for listener %q", l.Protocol, l.Name)
+		}
+
+		parsed = append(parsed, xdscache_v2.NamedListener{
+			Name:          l.Name,
+			Address:       stringOrDefault(l.Address, xdscache_v2.DEFAULT_HTTP_LISTENER_ADDRESS),
+			Port:          l.Port,
+			Protocol:      protocol,
+			UseProxyProto: l.UseProxyProtocol,
+			AccessLog:     stringOrDefault(l.AccessLog, xdscache_v2.DEFAULT_HTTP_ACCESS_LOG),
+		})
+	}
+
+	return parsed, nil
+}
+
+// validateAccessLogFormat checks that the access log format is
+// known, and that the format string or JSON fields that go with
+// it only use valid Envoy command operators.
+func validateAccessLogFormat(format string, formatString string, fields []string) error {
+	switch format {
+	case "envoy":
+		if formatString == "" {
+			return nil
+		}
+		if err := envoy.ValidateAccessLogFormat(formatString); err != nil {
+			return fmt.Errorf("invalid access log format string %q: %w", formatString, err)
+		}
+	case "json":
+		if formatString != "" {
+			return errors.New("access log format string can only be used with the envoy access log format")
+		}
+		for _, f := range fields {
+			if _, _, err := envoy.ParseJSONField(f); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("invalid access log format %q", format)
+	}
+
+	return nil
+}
+
+// parseAccessLogService validates the access log service configuration
+// and returns a processor that adds it to the DAG. If the access log
+// service isn't configured, the processor does nothing.
+func parseAccessLogService(config *AccessLogServiceConfig) (*dag.AccessLogServiceProcessor, error) {
+	if config == nil {
+		return &dag.AccessLogServiceProcessor{}, nil
+	}
+
+	extensionService, err := namespacedName(config.ExtensionService)
+	if err != nil {
+		return nil, fmt.Errorf("invalid access log extension service: %w", err)
+	}
+	if extensionService == nil {
+		return nil, errors.New("access log extension service must be defined")
+	}
+
+	var bufferFlushInterval time.Duration
+	if config.BufferFlushInterval != "" {
+		bufferFlushInterval, err = time.ParseDuration(config.BufferFlushInterval)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing access log buffer flush interval: %w", err)
+		}
+		if bufferFlushInterval <= 0 {
+			return nil, fmt.Errorf("invalid access log buffer flush interval %q", config.BufferFlushInterval)
+		}
+	}
+
+	return &dag.AccessLogServiceProcessor{
+		ExtensionService:    extensionService,
+		LogName:             stringOrDefault(config.LogName, "contour"),
+		BufferFlushInterval: bufferFlushInterval,
+		BufferSizeBytes:     config.BufferSizeBytes,
+	}, nil
+}
+
+// parseTracing validates the tracing configuration and returns a
+// processor that adds it to the DAG. If tracing isn't configured,
+// the processor does nothing.
+func parseTracing(config *TracingConfig) (*dag.TracingProcessor, error) {
+	if config == nil {
+		return &dag.TracingProcessor{}, nil
+	}
+
+	provider := strings.ToLower(config.Provider)
+	switch provider {
+	case dag.TracingProviderZipkin, dag.TracingProviderOpenCensus:
+	default:
+		return nil, fmt.Errorf("invalid tracing provider %q", config.Provider)
+	}
+
+	extensionService, err := namespacedName(config.ExtensionService)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tracing extension service: %w", err)
+	}
+
+	service, err := namespacedName(config.Service)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tracing service: %w", err)
+	}
+
+	switch {
+	case extensionService == nil && service == nil:
+		return nil, errors.New("tracing extension service or service must be defined")
+	case extensionService != nil && service != nil:
+		return nil, errors.New("tracing extension service and service cannot both be defined")
+	case service != nil && (config.ServicePort < 1 || config.ServicePort > 65535):
+		return nil, fmt.Errorf("invalid tracing service port %d", config.ServicePort)
+	}
+
+	samplingRate := 100.0
+	if config.SamplingRate != nil {
+		samplingRate = *config.SamplingRate
+	}
+	if samplingRate < 0 || samplingRate > 100 {
+		return nil, fmt.Errorf("invalid tracing sampling rate %v", samplingRate)
+	}
+
+	var customTags []dag.TracingCustomTag
+	for _, tag := range config.CustomTags {
+		switch {
+		case strings.TrimSpace(tag.TagName) == "":
+			return nil, errors.New("tracing custom tag name must be defined")
+		case (tag.Literal == "") == (tag.RequestHeader == ""):
+			return nil, fmt.Errorf("tracing custom tag %q must define exactly one of literal or request header", tag.TagName)
+		}
+
+		customTags = append(customTags, dag.TracingCustomTag{
+			TagName:       tag.TagName,
+			Literal:       tag.Literal,
+			RequestHeader: tag.RequestHeader,
+		})
+	}
+
+	return &dag.TracingProcessor{
+		Provider:          provider,
+		ExtensionService:  extensionService,
+		Service:           service,
+		ServicePort:       config.ServicePort,
+		CollectorEndpoint: stringOrDefault(config.CollectorEndpoint, "/api/v2/spans"),
+		SamplingRate:      samplingRate,
+		MaxPathTagLength:  config.MaxPathTagLength,
+		CustomTags:        customTags,
+	}, nil
+}
+
+// stringOrDefault returns s, or def if s is empty.
+func stringOrDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
 // Simple helper function to read an environment or return a default value
 func getEnv(key string, defaultVal string) string {
 	if value, exists := os.LookupEnv(key); exists {
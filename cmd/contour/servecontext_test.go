@@ -29,8 +29,10 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/projectcontour/contour/internal/dag"
 	envoy_v2 "github.com/projectcontour/contour/internal/envoy/v2"
 	"github.com/projectcontour/contour/internal/fixture"
+	xdscache_v2 "github.com/projectcontour/contour/internal/xdscache/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
@@ -651,3 +653,324 @@ func TestParseDNSLookupFamily(t *testing.T) {
 		})
 	}
 }
+
+func TestParseListeners(t *testing.T) {
+	cases := map[string]struct {
+		listeners     []ListenerConfig
+		expectedError error
+		expected      []xdscache_v2.NamedListener
+	}{
+		"empty": {
+			listeners:     nil,
+			expectedError: nil,
+			expected:      nil,
+		},
+		"defaults": {
+			listeners: []ListenerConfig{{
+				Name:     "admin",
+				Port:     8443,
+				Protocol: "HTTPS",
+			}},
+			expectedError: nil,
+			expected: []xdscache_v2.NamedListener{{
+				Name:      "admin",
+				Address:   "0.0.0.0",
+				Port:      8443,
+				Protocol:  "https",
+				AccessLog: "/dev/stdout",
+			}},
+		},
+		"all fields": {
+			listeners: []ListenerConfig{{
+				Name:             "internal",
+				Address:          "127.0.0.1",
+				Port:             9000,
+				Protocol:         "http",
+				UseProxyProtocol: true,
+				AccessLog:        "/tmp/internal.log",
+			}},
+			expectedError: nil,
+			expected: []xdscache_v2.NamedListener{{
+				Name:          "internal",
+				Address:       "127.0.0.1",
+				Port:          9000,
+				Protocol:      "http",
+				UseProxyProto: true,
+				AccessLog:     "/tmp/internal.log",
+			}},
+		},
+		"missing name": {
+			listeners:     []ListenerConfig{{Port: 9000, Protocol: "http"}},
+			expectedError: errors.New("listener name must be defined"),
+		},
+		"invalid name": {
+			listeners:     []ListenerConfig{{Name: "a/b", Port: 9000, Protocol: "http"}},
+			expectedError: errors.New("invalid listener name \"a/b\""),
+		},
+		"reserved name": {
+			listeners:     []ListenerConfig{{Name: "ingress_http", Port: 9000, Protocol: "http"}},
+			expectedError: errors.New("duplicate listener name \"ingress_http\""),
+		},
+		"duplicate name": {
+			listeners: []ListenerConfig{
+				{Name: "internal", Port: 9000, Protocol: "http"},
+				{Name: "internal", Port: 9001, Protocol: "http"},
+			},
+			expectedError: errors.New("duplicate listener name \"internal\""),
+		},
+		"invalid port": {
+			listeners:     []ListenerConfig{{Name: "internal", Protocol: "http"}},
+			expectedError: errors.New("invalid port 0 for listener \"internal\""),
+		},
+		"invalid protocol": {
+			listeners:     []ListenerConfig{{Name: "internal", Port: 9000, Protocol: "tcp"}},
+			expectedError: errors.New("invalid protocol \"tcp\" for listener \"internal\""),
+		},
+	}
+
+	for name, testcase := range cases {
+		testcase := testcase
+		t.Run(name, func(t *testing.T) {
+			got, err := parseListeners(testcase.listeners)
+			assert.Equal(t, testcase.expectedError, err)
+			assert.Equal(t, testcase.expected, got)
+		})
+	}
+}
+
+func TestValidateAccessLogFormat(t *testing.T) {
+	cases := map[string]struct {
+		format        string
+		formatString  string
+		fields        []string
+		expectedError string
+	}{
+		"envoy default format": {
+			format: "envoy",
+		},
+		"envoy format string": {
+			format:       "envoy",
+			formatString: "%START_TIME% %REQ(X-TENANT-ID)% %RESPONSE_CODE%\n",
+		},
+		"invalid envoy format string": {
+			format:        "envoy",
+			formatString:  "%START_TIME% %REQ(X-TENANT-ID)",
+			expectedError: "invalid access log format string \"%START_TIME% %REQ(X-TENANT-ID)\": unterminated or malformed command operator",
+		},
+		"json fields": {
+			format: "json",
+			fields: []string{"@timestamp", "tenant_id=%REQ(X-TENANT-ID)%"},
+		},
+		"unknown json field": {
+			format:        "json",
+			fields:        []string{"@timestamp", "tenant_id"},
+			expectedError: "unknown JSON field \"tenant_id\"",
+		},
+		"json with format string": {
+			format:        "json",
+			formatString:  "%START_TIME%",
+			expectedError: "access log format string can only be used with the envoy access log format",
+		},
+		"unknown format": {
+			format:        "text",
+			expectedError: "invalid access log format \"text\"",
+		},
+	}
+
+	for name, testcase := range cases {
+		testcase := testcase
+		t.Run(name, func(t *testing.T) {
+			err := validateAccessLogFormat(testcase.format, testcase.formatString, testcase.fields)
+			if testcase.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, testcase.expectedError)
+			}
+		})
+	}
+}
+
+func TestParseAccessLogService(t *testing.T) {
+	cases := map[string]struct {
+		config        *AccessLogServiceConfig
+		expectedError error
+		expected      *dag.AccessLogServiceProcessor
+	}{
+		"not configured": {
+			config:        nil,
+			expectedError: nil,
+			expected:      &dag.AccessLogServiceProcessor{},
+		},
+		"defaults": {
+			config: &AccessLogServiceConfig{
+				ExtensionService: NamespacedName{Namespace: "logging", Name: "als"},
+			},
+			expectedError: nil,
+			expected: &dag.AccessLogServiceProcessor{
+				ExtensionService: &types.NamespacedName{Namespace: "logging", Name: "als"},
+				LogName:          "contour",
+			},
+		},
+		"buffering": {
+			config: &AccessLogServiceConfig{
+				ExtensionService:    NamespacedName{Namespace: "logging", Name: "als"},
+				LogName:             "edge",
+				BufferFlushInterval: "5s",
+				BufferSizeBytes:     65536,
+			},
+			expectedError: nil,
+			expected: &dag.AccessLogServiceProcessor{
+				ExtensionService:    &types.NamespacedName{Namespace: "logging", Name: "als"},
+				LogName:             "edge",
+				BufferFlushInterval: 5 * time.Second,
+				BufferSizeBytes:     65536,
+			},
+		},
+		"missing extension service": {
+			config:        &AccessLogServiceConfig{LogName: "contour"},
+			expectedError: errors.New("access log extension service must be defined"),
+		},
+		"zero buffer flush interval": {
+			config: &AccessLogServiceConfig{
+				ExtensionService:    NamespacedName{Namespace: "logging", Name: "als"},
+				BufferFlushInterval: "0s",
+			},
+			expectedError: errors.New("invalid access log buffer flush interval \"0s\""),
+		},
+	}
+
+	for name, testcase := range cases {
+		testcase := testcase
+		t.Run(name, func(t *testing.T) {
+			got, err := parseAccessLogService(testcase.config)
+			assert.Equal(t, testcase.expectedError, err)
+			assert.Equal(t, testcase.expected, got)
+		})
+	}
+}
+
+func TestParseTracing(t *testing.T) {
+	samplingRate := func(rate float64) *float64 { return &rate }
+
+	cases := map[string]struct {
+		tracing       *TracingConfig
+		expectedError error
+		expected      *dag.TracingProcessor
+	}{
+		"not configured": {
+			tracing:       nil,
+			expectedError: nil,
+			expected:      &dag.TracingProcessor{},
+		},
+		"extension service defaults": {
+			tracing: &TracingConfig{
+				Provider:         "OpenCensus",
+				ExtensionService: NamespacedName{Namespace: "tracing", Name: "collector"},
+			},
+			expectedError: nil,
+			expected: &dag.TracingProcessor{
+				Provider:          "opencensus",
+				ExtensionService:  &types.NamespacedName{Namespace: "tracing", Name: "collector"},
+				CollectorEndpoint: "/api/v2/spans",
+				SamplingRate:      100,
+			},
+		},
+		"service": {
+			tracing: &TracingConfig{
+				Provider:          "zipkin",
+				Service:           NamespacedName{Namespace: "tracing", Name: "zipkin"},
+				ServicePort:       9411,
+				CollectorEndpoint: "/api/v1/spans",
+				SamplingRate:      samplingRate(0),
+				MaxPathTagLength:  64,
+				CustomTags: []CustomTagConfig{{
+					TagName: "environment",
+					Literal: "staging",
+				}, {
+					TagName:       "user",
+					RequestHeader: "X-User",
+				}},
+			},
+			expectedError: nil,
+			expected: &dag.TracingProcessor{
+				Provider:          "zipkin",
+				Service:           &types.NamespacedName{Namespace: "tracing", Name: "zipkin"},
+				ServicePort:       9411,
+				CollectorEndpoint: "/api/v1/spans",
+				SamplingRate:      0,
+				MaxPathTagLength:  64,
+				CustomTags: []dag.TracingCustomTag{{
+					TagName: "environment",
+					Literal: "staging",
+				}, {
+					TagName:       "user",
+					RequestHeader: "X-User",
+				}},
+			},
+		},
+		"invalid provider": {
+			tracing: &TracingConfig{
+				Provider:         "jaeger",
+				ExtensionService: NamespacedName{Namespace: "tracing", Name: "collector"},
+			},
+			expectedError: errors.New("invalid tracing provider \"jaeger\""),
+		},
+		"missing collector": {
+			tracing:       &TracingConfig{Provider: "zipkin"},
+			expectedError: errors.New("tracing extension service or service must be defined"),
+		},
+		"both collectors": {
+			tracing: &TracingConfig{
+				Provider:         "zipkin",
+				ExtensionService: NamespacedName{Namespace: "tracing", Name: "collector"},
+				Service:          NamespacedName{Namespace: "tracing", Name: "zipkin"},
+				ServicePort:      9411,
+			},
+			expectedError: errors.New("tracing extension service and service cannot both be defined"),
+		},
+		"missing service port": {
+			tracing: &TracingConfig{
+				Provider: "zipkin",
+				Service:  NamespacedName{Namespace: "tracing", Name: "zipkin"},
+			},
+			expectedError: errors.New("invalid tracing service port 0"),
+		},
+		"invalid sampling rate": {
+			tracing: &TracingConfig{
+				Provider:         "zipkin",
+				ExtensionService: NamespacedName{Namespace: "tracing", Name: "collector"},
+				SamplingRate:     samplingRate(101),
+			},
+			expectedError: errors.New("invalid tracing sampling rate 101"),
+		},
+		"custom tag without name": {
+			tracing: &TracingConfig{
+				Provider:         "zipkin",
+				ExtensionService: NamespacedName{Namespace: "tracing", Name: "collector"},
+				CustomTags:       []CustomTagConfig{{Literal: "staging"}},
+			},
+			expectedError: errors.New("tracing custom tag name must be defined"),
+		},
+		"custom tag with literal and header": {
+			tracing: &TracingConfig{
+				Provider:         "zipkin",
+				ExtensionService: NamespacedName{Namespace: "tracing", Name: "collector"},
+				CustomTags: []CustomTagConfig{{
+					TagName:       "environment",
+					Literal:       "staging",
+					RequestHeader: "X-Environment",
+				}},
+			},
+			expectedError: errors.New("tracing custom tag \"environment\" must define exactly one of literal or request header"),
+		},
+	}
+
+	for name, testcase := range cases {
+		testcase := testcase
+		t.Run(name, func(t *testing.T) {
+			got, err := parseTracing(testcase.tracing)
+			assert.Equal(t, testcase.expectedError, err)
+			assert.Equal(t, testcase.expected, got)
+		})
+	}
+}
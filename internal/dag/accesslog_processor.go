@@ -0,0 +1,79 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// AccessLogServiceProcessor configures the listeners in the DAG to
+// stream their access logs to a gRPC access log service. Since it
+// configures the listeners that are already defined as roots in the
+// DAG, it must run after the ListenerProcessor.
+//
+// If the ExtensionService implementing the access log service can't
+// be found, access logs are only written to files, so that Envoy
+// isn't configured to stream them to a missing cluster.
+type AccessLogServiceProcessor struct {
+	logrus.FieldLogger
+
+	// ExtensionService is the name of the ExtensionService that
+	// access logs are streamed to. If nil, access logs are not
+	// streamed.
+	ExtensionService *types.NamespacedName
+
+	// LogName identifies the access logs of each listener
+	// to the access log service.
+	LogName string
+
+	// BufferFlushInterval is the interval at which
+	// buffered access logs are sent.
+	BufferFlushInterval time.Duration
+
+	// BufferSizeBytes is the size of the buffer that access
+	// logs are held in until they are sent.
+	BufferSizeBytes uint32
+}
+
+var _ Processor = &AccessLogServiceProcessor{}
+
+// Run sets the access log service of each listener in the DAG.
+func (p *AccessLogServiceProcessor) Run(dag *DAG, _ *KubernetesCache) {
+	if p.ExtensionService == nil {
+		return
+	}
+
+	ext := dag.GetExtensionCluster(extensionClusterName(*p.ExtensionService))
+	if ext == nil {
+		p.WithField("extensionservice", p.ExtensionService).
+			Error("access log ExtensionService is not found or is not valid, access logs are not streamed")
+		return
+	}
+
+	als := &AccessLogService{
+		ExtensionService:    ext,
+		LogName:             p.LogName,
+		BufferFlushInterval: p.BufferFlushInterval,
+		BufferSizeBytes:     p.BufferSizeBytes,
+	}
+
+	for _, root := range dag.roots {
+		if l, ok := root.(*Listener); ok {
+			l.AccessLogService = als
+		}
+	}
+}
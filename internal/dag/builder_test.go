@@ -28,6 +28,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	serviceapis "sigs.k8s.io/service-apis/api/v1alpha1"
 )
 
 func TestDAGInsert(t *testing.T) {
@@ -2103,9 +2104,9 @@ func TestDAGInsert(t *testing.T) {
 					Port:   8080,
 					Mirror: true,
 				}, {
-					// it is legal to mention a service more that
-					// once, however it is not legal for more than one
-					// service to be marked as mirror.
+					// it is legal to mention a service more than
+					// once, however it is not legal for the same
+					// service to be marked as mirror more than once.
 					Name:   s2.Name,
 					Port:   8080,
 					Mirror: true,
@@ -2114,6 +2115,36 @@ func TestDAGInsert(t *testing.T) {
 		},
 	}
 
+	// proxy13b mirrors to two different services.
+	proxy13b := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-com",
+			Namespace: s1.Namespace,
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/",
+				}},
+				Services: []contour_api_v1.Service{{
+					Name: s1.Name,
+					Port: 8080,
+				}, {
+					Name:   s2.Name,
+					Port:   8080,
+					Mirror: true,
+				}, {
+					Name:   s2a.Name,
+					Port:   8080,
+					Mirror: true,
+				}},
+			}},
+		},
+	}
+
 	// invalid because tcpproxy both includes another and
 	// has a list of services.
 	proxy37 := &contour_api_v1.HTTPProxy{
@@ -4487,6 +4518,21 @@ func TestDAGInsert(t *testing.T) {
 			},
 			want: listeners(),
 		},
+		"insert httpproxy with two different mirrors": {
+			objs: []interface{}{
+				proxy13b, s1, s2, s2a,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com",
+							withMirror(withMirror(prefixroute("/", service(s1)), service(s2)), service(s2a)),
+						),
+					),
+				},
+			),
+		},
 		"insert httpproxy with websocket route and prefix rewrite": {
 			objs: []interface{}{
 				proxy10, s1,
@@ -6140,6 +6186,205 @@ func TestDAGInsert(t *testing.T) {
 	}
 }
 
+func TestDAGInsertGateway(t *testing.T) {
+	gc := &serviceapis.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "contour",
+		},
+		Spec: serviceapis.GatewayClassSpec{
+			Controller: "projectcontour.io/contour",
+		},
+	}
+
+	gcForeign := &serviceapis.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "contour",
+		},
+		Spec: serviceapis.GatewayClassSpec{
+			Controller: "example.com/other-controller",
+		},
+	}
+
+	sec1 := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "secret",
+			Namespace: "projectcontour",
+		},
+		Type: v1.SecretTypeTLS,
+		Data: secretdata(fixture.CERTIFICATE, fixture.RSA_PRIVATE_KEY),
+	}
+
+	kuard := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "projectcontour",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:     "http",
+				Protocol: "TCP",
+				Port:     8080,
+			}},
+		},
+	}
+
+	stringPtr := func(s string) *string { return &s }
+
+	gatewayHTTP := &serviceapis.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "contour",
+			Namespace: "projectcontour",
+		},
+		Spec: serviceapis.GatewaySpec{
+			Class: "contour",
+			Listeners: []serviceapis.Listener{{
+				Name:     "http",
+				Protocol: stringPtr(serviceapis.HTTPProcotol),
+			}},
+			Routes: []v1.TypedLocalObjectReference{{
+				Kind: "HTTPRoute",
+				Name: "basic",
+			}},
+		},
+	}
+
+	gatewayHTTPS := &serviceapis.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "contour",
+			Namespace: "projectcontour",
+		},
+		Spec: serviceapis.GatewaySpec{
+			Class: "contour",
+			Listeners: []serviceapis.Listener{{
+				Name:     "https",
+				Protocol: stringPtr(serviceapis.HTTPSProcotol),
+				TLS: &serviceapis.ListenerTLS{
+					Certificates: []v1.TypedLocalObjectReference{{
+						Kind: "Secret",
+						Name: sec1.Name,
+					}},
+				},
+			}},
+			Routes: []v1.TypedLocalObjectReference{{
+				Kind: "HTTPRoute",
+				Name: "basic",
+			}},
+		},
+	}
+
+	basicRoute := &serviceapis.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "basic",
+			Namespace: "projectcontour",
+		},
+		Spec: serviceapis.HTTPRouteSpec{
+			Hosts: []serviceapis.HTTPRouteHost{{
+				Hostnames: []string{"test.projectcontour.io"},
+				Rules: []serviceapis.HTTPRouteRule{{
+					Match: &serviceapis.HTTPRouteMatch{
+						PathType: serviceapis.PathTypePrefix,
+						Path:     stringPtr("/api"),
+					},
+					Action: &serviceapis.HTTPRouteAction{
+						ForwardTo: &v1.TypedLocalObjectReference{
+							Kind: "Service",
+							Name: kuard.Name,
+						},
+					},
+				}},
+			}},
+		},
+	}
+
+	tests := map[string]struct {
+		objs       []interface{}
+		want       []Vertex
+		wantStatus bool
+	}{
+		"gateway without a matching gatewayclass": {
+			objs:       []interface{}{kuard, gatewayHTTP, basicRoute},
+			want:       listeners(),
+			wantStatus: false,
+		},
+		"gateway with a gatewayclass of another controller": {
+			objs:       []interface{}{gcForeign, kuard, gatewayHTTP, basicRoute},
+			want:       listeners(),
+			wantStatus: false,
+		},
+		"http listener with http route": {
+			objs: []interface{}{gc, kuard, gatewayHTTP, basicRoute},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("test.projectcontour.io", prefixroute("/api", service(kuard))),
+					),
+				},
+			),
+			wantStatus: true,
+		},
+		"https listener with http route": {
+			objs: []interface{}{gc, sec1, kuard, gatewayHTTPS, basicRoute},
+			want: listeners(
+				&Listener{
+					Port: 443,
+					VirtualHosts: virtualhosts(
+						securevirtualhost("test.projectcontour.io", sec1, prefixroute("/api", service(kuard))),
+					),
+				},
+			),
+			wantStatus: true,
+		},
+		"https listener with missing secret": {
+			objs:       []interface{}{gc, kuard, gatewayHTTPS, basicRoute},
+			want:       listeners(),
+			wantStatus: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			builder := Builder{
+				Source: KubernetesCache{
+					FieldLogger: fixture.NewTestLogger(t),
+				},
+				Processors: []Processor{
+					&GatewayProcessor{
+						FieldLogger: fixture.NewTestLogger(t),
+					},
+					&ListenerProcessor{},
+				},
+			}
+
+			for _, o := range tc.objs {
+				builder.Source.Insert(o)
+			}
+			dag := builder.Build()
+
+			got := make(map[int]*Listener)
+			dag.Visit(listenerMap(got).Visit)
+
+			want := make(map[int]*Listener)
+			for _, v := range tc.want {
+				if l, ok := v.(*Listener); ok {
+					want[l.Port] = l
+				}
+			}
+			assert.Equal(t, want, got)
+
+			// Status is only written for the Gateways that
+			// Contour controls.
+			gotStatus := false
+			for _, o := range tc.objs {
+				if gw, ok := o.(*serviceapis.Gateway); ok {
+					gotStatus = gotStatus || dag.StatusCache.Get(gw) != nil
+				}
+			}
+			assert.Equal(t, tc.wantStatus, gotStatus)
+		})
+	}
+}
+
 type listenerMap map[int]*Listener
 
 func (lm listenerMap) Visit(v Vertex) {
@@ -6738,11 +6983,12 @@ func prefix(prefix string) MatchCondition { return &PrefixMatchCondition{Prefix:
 func regex(regex string) MatchCondition   { return &RegexMatchCondition{Regex: regex} }
 
 func withMirror(r *Route, mirror *Service) *Route {
-	r.MirrorPolicy = &MirrorPolicy{
+	r.MirrorPolicies = append(r.MirrorPolicies, MirrorPolicy{
 		Cluster: &Cluster{
 			Upstream: mirror,
 		},
-	}
+		Weight: 100,
+	})
 	return r
 
 }
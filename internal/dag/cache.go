@@ -168,31 +168,15 @@ func (kc *KubernetesCache) Insert(obj interface{}) bool {
 		kc.httpproxydelegations[k8s.NamespacedNameOf(obj)] = obj
 		return true
 	case *serviceapis.GatewayClass:
-		m := k8s.NamespacedNameOf(obj)
-		// TODO(youngnick): Remove this once service-apis actually have behavior
-		// other than being added to the cache.
-		kc.WithField("experimental", "service-apis").WithField("name", m.Name).WithField("namespace", m.Namespace).Debug("Adding GatewayClass")
 		kc.gatewayclasses[k8s.NamespacedNameOf(obj)] = obj
 		return true
 	case *serviceapis.Gateway:
-		m := k8s.NamespacedNameOf(obj)
-		// TODO(youngnick): Remove this once service-apis actually have behavior
-		// other than being added to the cache.
-		kc.WithField("experimental", "service-apis").WithField("name", m.Name).WithField("namespace", m.Namespace).Debug("Adding Gateway")
 		kc.gateways[k8s.NamespacedNameOf(obj)] = obj
 		return true
 	case *serviceapis.HTTPRoute:
-		m := k8s.NamespacedNameOf(obj)
-		// TODO(youngnick): Remove this once service-apis actually have behavior
-		// other than being added to the cache.
-		kc.WithField("experimental", "service-apis").WithField("name", m.Name).WithField("namespace", m.Namespace).Debug("Adding HTTPRoute")
 		kc.httproutes[k8s.NamespacedNameOf(obj)] = obj
 		return true
 	case *serviceapis.TcpRoute:
-		m := k8s.NamespacedNameOf(obj)
-		// TODO(youngnick): Remove this once service-apis actually have behavior
-		// other than being added to the cache.
-		kc.WithField("experimental", "service-apis").WithField("name", m.Name).WithField("namespace", m.Namespace).Debug("Adding TcpRoute")
 		kc.tcproutes[k8s.NamespacedNameOf(obj)] = obj
 		return true
 	case *contour_api_v1alpha1.ExtensionService:
@@ -251,33 +235,21 @@ func (kc *KubernetesCache) remove(obj interface{}) bool {
 	case *serviceapis.GatewayClass:
 		m := k8s.NamespacedNameOf(obj)
 		_, ok := kc.gatewayclasses[m]
-		// TODO(youngnick): Remove this once service-apis actually have behavior
-		// other than being removed from the cache.
-		kc.WithField("experimental", "service-apis").WithField("name", m.Name).WithField("namespace", m.Namespace).Debug("Removing GatewayClass")
 		delete(kc.gatewayclasses, m)
 		return ok
 	case *serviceapis.Gateway:
 		m := k8s.NamespacedNameOf(obj)
 		_, ok := kc.gateways[m]
-		// TODO(youngnick): Remove this once service-apis actually have behavior
-		// other than being removed from the cache.
-		kc.WithField("experimental", "service-apis").WithField("name", m.Name).WithField("namespace", m.Namespace).Debug("Removing Gateway")
 		delete(kc.gateways, m)
 		return ok
 	case *serviceapis.HTTPRoute:
 		m := k8s.NamespacedNameOf(obj)
 		_, ok := kc.httproutes[m]
-		// TODO(youngnick): Remove this once service-apis actually have behavior
-		// other than being removed from the cache.
-		kc.WithField("experimental", "service-apis").WithField("name", m.Name).WithField("namespace", m.Namespace).Debug("Removing HTTPRoute")
 		delete(kc.httproutes, m)
 		return ok
 	case *serviceapis.TcpRoute:
 		m := k8s.NamespacedNameOf(obj)
 		_, ok := kc.tcproutes[m]
-		// TODO(youngnick): Remove this once service-apis actually have behavior
-		// other than being removed from the cache.
-		kc.WithField("experimental", "service-apis").WithField("name", m.Name).WithField("namespace", m.Namespace).Debug("Removing TcpRoute")
 		delete(kc.tcproutes, m)
 		return ok
 	case *contour_api_v1alpha1.ExtensionService:
@@ -294,7 +266,7 @@ func (kc *KubernetesCache) remove(obj interface{}) bool {
 }
 
 // serviceTriggersRebuild returns true if this service is referenced
-// by an Ingress or HTTPProxy in this cache.
+// by an Ingress, HTTPProxy or HTTPRoute in this cache.
 func (kc *KubernetesCache) serviceTriggersRebuild(service *v1.Service) bool {
 	for _, ingress := range kc.ingresses {
 		if ingress.Namespace != service.Namespace {
@@ -339,11 +311,33 @@ func (kc *KubernetesCache) serviceTriggersRebuild(service *v1.Service) bool {
 		}
 	}
 
+	for _, route := range kc.httproutes {
+		if route.Namespace != service.Namespace {
+			continue
+		}
+
+		hosts := route.Spec.Hosts
+		if route.Spec.Default != nil {
+			hosts = append(hosts, *route.Spec.Default)
+		}
+
+		for _, host := range hosts {
+			for _, rule := range host.Rules {
+				if rule.Action == nil || rule.Action.ForwardTo == nil {
+					continue
+				}
+				if rule.Action.ForwardTo.Kind == "Service" && rule.Action.ForwardTo.Name == service.Name {
+					return true
+				}
+			}
+		}
+	}
+
 	return false
 }
 
-// secretTriggersRebuild returns true if this secret is referenced by an Ingress
-// or HTTPProxy object in this cache. If the secret is not in the same namespace
+// secretTriggersRebuild returns true if this secret is referenced by an Ingress,
+// HTTPProxy or Gateway object in this cache. If the secret is not in the same namespace
 // it must be mentioned by a TLSCertificateDelegation.
 func (kc *KubernetesCache) secretTriggersRebuild(secret *v1.Secret) bool {
 	if _, isCA := secret.Data[CACertificateKey]; isCA {
@@ -418,6 +412,23 @@ func (kc *KubernetesCache) secretTriggersRebuild(secret *v1.Secret) bool {
 		}
 	}
 
+	for _, gw := range kc.gateways {
+		if gw.Namespace != secret.Namespace {
+			continue
+		}
+
+		for _, l := range gw.Spec.Listeners {
+			if l.TLS == nil {
+				continue
+			}
+			for _, cert := range l.TLS.Certificates {
+				if cert.Kind == "Secret" && cert.Name == secret.Name {
+					return true
+				}
+			}
+		}
+	}
+
 	return false
 }
 
@@ -22,17 +22,41 @@ import (
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
 )
 
-// mergePathMatchConditions merges the given slice of prefix MatchConditions into a single
-// prefix Condition.
-// pathMatchConditionsValid guarantees that if a prefix is present, it will start with a
-// / character, so we can simply concatenate.
+// mergePathMatchConditions merges the given slice of path MatchConditions into a single
+// path Condition. Prefix conditions are concatenated, and an exact or regex condition
+// is appended to the merged prefix.
+// pathMatchConditionsValid guarantees that if a prefix or exact path is present, it will
+// start with a / character, so we can simply concatenate.
 func mergePathMatchConditions(conds []contour_api_v1.MatchCondition) MatchCondition {
+	re := regexp.MustCompile(`//+`)
+
 	prefix := ""
 	for _, cond := range conds {
+		switch {
+		case cond.Exact != "":
+			return &ExactMatchCondition{
+				Path: re.ReplaceAllString(prefix+cond.Exact, `/`),
+			}
+		case cond.Regex != "":
+			// The regex has to match the whole path, so quote
+			// the inherited prefix and drop its trailing slash
+			// to avoid matching a double slash.
+			prefix = strings.TrimRight(re.ReplaceAllString(prefix, `/`), `/`)
+			if prefix == "" {
+				return &RegexMatchCondition{
+					Regex: cond.Regex,
+				}
+			}
+			// Group the regex so that an alternation in it
+			// can't match paths outside the inherited prefix.
+			return &RegexMatchCondition{
+				Regex: regexp.QuoteMeta(prefix) + "(?:" + cond.Regex + ")",
+			}
+		}
+
 		prefix = prefix + cond.Prefix
 	}
 
-	re := regexp.MustCompile(`//+`)
 	prefix = re.ReplaceAllString(prefix, `/`)
 
 	// After the merge operation is done, if the string is still empty, then
@@ -50,20 +74,66 @@ func mergePathMatchConditions(conds []contour_api_v1.MatchCondition) MatchCondit
 }
 
 // pathMatchConditionsValid validates a slice of MatchConditions can be correctly merged.
-// It encodes the business rules about what is allowed for prefix MatchConditions.
+// It encodes the business rules about what is allowed for path MatchConditions.
 func pathMatchConditionsValid(conds []contour_api_v1.MatchCondition) error {
 	prefixCount := 0
+	pathCount := 0
 
 	for _, cond := range conds {
 		if cond.Prefix != "" {
 			prefixCount++
+			pathCount++
 			if cond.Prefix[0] != '/' {
 				return fmt.Errorf("prefix conditions must start with /, %s was supplied", cond.Prefix)
 			}
 		}
+		if cond.Exact != "" {
+			pathCount++
+			if cond.Exact[0] != '/' {
+				return fmt.Errorf("exact conditions must start with /, %s was supplied", cond.Exact)
+			}
+		}
+		if cond.Regex != "" {
+			pathCount++
+			if _, err := regexp.Compile(cond.Regex); err != nil {
+				return fmt.Errorf("regex condition %q is invalid: %s", cond.Regex, err)
+			}
+		}
 		if prefixCount > 1 {
 			return errors.New("more than one prefix is not allowed in a condition block")
 		}
+		if pathCount > 1 {
+			return errors.New("more than one of prefix, exact or regex is not allowed in a condition block")
+		}
+	}
+
+	return nil
+}
+
+// includeMatchConditionsValid validates the path MatchConditions of an include.
+// Includes delegate a path prefix to the included HTTPProxy, so exact and
+// regex conditions are not allowed.
+func includeMatchConditionsValid(conds []contour_api_v1.MatchCondition) error {
+	for _, cond := range conds {
+		if cond.Exact != "" || cond.Regex != "" {
+			return errors.New("exact and regex conditions are not allowed on includes")
+		}
+	}
+
+	return nil
+}
+
+// mergedPathMatchConditionsValid validates that the path MatchConditions of
+// a route can be merged with the prefix conditions inherited from its parent
+// includes. A regex condition is appended to the inherited prefix, so it has
+// to start with a / to match beneath it.
+func mergedPathMatchConditionsValid(conds []contour_api_v1.MatchCondition) error {
+	prefix := ""
+	for _, cond := range conds {
+		prefix = prefix + cond.Prefix
+		if cond.Regex != "" && strings.Trim(prefix, "/") != "" && cond.Regex[0] != '/' {
+			return fmt.Errorf("regex condition %q must start with / to be merged with prefix %q", cond.Regex, prefix)
+		}
 	}
 
 	return nil
@@ -95,17 +165,39 @@ func mergeHeaderMatchConditions(conds []contour_api_v1.MatchCondition) []HeaderM
 			})
 		case cond.Header.Exact != "":
 			hc = append(hc, HeaderMatchCondition{
-				Name:      cond.Header.Name,
-				Value:     cond.Header.Exact,
-				MatchType: "exact",
+				Name:       cond.Header.Name,
+				Value:      cond.Header.Exact,
+				MatchType:  "exact",
+				IgnoreCase: cond.Header.IgnoreCase,
 			})
 		case cond.Header.NotExact != "":
+			hc = append(hc, HeaderMatchCondition{
+				Name:       cond.Header.Name,
+				Value:      cond.Header.NotExact,
+				MatchType:  "exact",
+				Invert:     true,
+				IgnoreCase: cond.Header.IgnoreCase,
+			})
+		case cond.Header.Regex != "":
 			hc = append(hc, HeaderMatchCondition{
 				Name:      cond.Header.Name,
-				Value:     cond.Header.NotExact,
-				MatchType: "exact",
+				Value:     cond.Header.Regex,
+				MatchType: "regex",
+			})
+		case cond.Header.NotRegex != "":
+			hc = append(hc, HeaderMatchCondition{
+				Name:      cond.Header.Name,
+				Value:     cond.Header.NotRegex,
+				MatchType: "regex",
 				Invert:    true,
 			})
+		case len(cond.Header.In) > 0:
+			hc = append(hc, HeaderMatchCondition{
+				Name:       cond.Header.Name,
+				Values:     cond.Header.In,
+				MatchType:  "in",
+				IgnoreCase: cond.Header.IgnoreCase,
+			})
 		}
 	}
 	return hc
@@ -117,11 +209,22 @@ func mergeHeaderMatchConditions(conds []contour_api_v1.MatchCondition) []HeaderM
 //	- more than 1 'exact' condition for the same header
 //	- an 'exact' and a 'notexact' condition for the same header, with the same values
 //	- a 'contains' and a 'notcontains' condition for the same header, with the same values
+//	- a 'regex' and a 'notregex' condition for the same header, with the same values
+//	- a 'regex' or 'notregex' condition that doesn't compile
+//	- an 'in' condition with an empty value
+//	- 'ignoreCase' on a condition other than 'exact', 'notexact' or 'in'
 //
 // Note that there are additional, more complex scenarios that we could check for here. For
 // example, "exact: foo" and "notcontains: <any substring of foo>" are contradictory.
 func headerMatchConditionsValid(conditions []contour_api_v1.MatchCondition) error {
-	seenMatchConditions := map[contour_api_v1.HeaderMatchCondition]bool{}
+	// headerMatch is a comparable summary of a header condition.
+	type headerMatch struct {
+		name     string
+		operator string
+		value    string
+	}
+
+	seenMatchConditions := map[headerMatch]bool{}
 	headersWithExactMatch := map[string]bool{}
 
 	for _, v := range conditions {
@@ -129,7 +232,10 @@ func headerMatchConditionsValid(conditions []contour_api_v1.MatchCondition) erro
 			continue
 		}
 
+		// use the lower-cased header name so comparisons are case-insensitive
 		headerName := strings.ToLower(v.Header.Name)
+
+		var key headerMatch
 		switch {
 		case v.Header.Exact != "":
 			// Look for duplicate "exact match" headers on conditions
@@ -139,42 +245,146 @@ func headerMatchConditionsValid(conditions []contour_api_v1.MatchCondition) erro
 			headersWithExactMatch[headerName] = true
 
 			// look for a NotExact condition on the same header with the same value
-			if seenMatchConditions[contour_api_v1.HeaderMatchCondition{
-				Name:     headerName,
-				NotExact: v.Header.Exact,
-			}] {
+			if seenMatchConditions[headerMatch{headerName, "notexact", v.Header.Exact}] {
 				return errors.New("cannot specify contradictory 'exact' and 'notexact' conditions for the same route and header")
 			}
+			key = headerMatch{headerName, "exact", v.Header.Exact}
 		case v.Header.NotExact != "":
 			// look for an Exact condition on the same header with the same value
-			if seenMatchConditions[contour_api_v1.HeaderMatchCondition{
-				Name:  headerName,
-				Exact: v.Header.NotExact,
-			}] {
+			if seenMatchConditions[headerMatch{headerName, "exact", v.Header.NotExact}] {
 				return errors.New("cannot specify contradictory 'exact' and 'notexact' conditions for the same route and header")
 			}
+			key = headerMatch{headerName, "notexact", v.Header.NotExact}
 		case v.Header.Contains != "":
 			// look for a NotContains condition on the same header with the same value
-			if seenMatchConditions[contour_api_v1.HeaderMatchCondition{
-				Name:        headerName,
-				NotContains: v.Header.Contains,
-			}] {
+			if seenMatchConditions[headerMatch{headerName, "notcontains", v.Header.Contains}] {
 				return errors.New("cannot specify contradictory 'contains' and 'notcontains' conditions for the same route and header")
 			}
+			key = headerMatch{headerName, "contains", v.Header.Contains}
 		case v.Header.NotContains != "":
 			// look for a Contains condition on the same header with the same value
-			if seenMatchConditions[contour_api_v1.HeaderMatchCondition{
-				Name:     headerName,
-				Contains: v.Header.NotContains,
-			}] {
+			if seenMatchConditions[headerMatch{headerName, "contains", v.Header.NotContains}] {
 				return errors.New("cannot specify contradictory 'contains' and 'notcontains' conditions for the same route and header")
 			}
+			key = headerMatch{headerName, "notcontains", v.Header.NotContains}
+		case v.Header.Regex != "":
+			if _, err := regexp.Compile(v.Header.Regex); err != nil {
+				return fmt.Errorf("header %q regex %q is invalid: %s", v.Header.Name, v.Header.Regex, err)
+			}
+			// look for a NotRegex condition on the same header with the same value
+			if seenMatchConditions[headerMatch{headerName, "notregex", v.Header.Regex}] {
+				return errors.New("cannot specify contradictory 'regex' and 'notregex' conditions for the same route and header")
+			}
+			key = headerMatch{headerName, "regex", v.Header.Regex}
+		case v.Header.NotRegex != "":
+			if _, err := regexp.Compile(v.Header.NotRegex); err != nil {
+				return fmt.Errorf("header %q notregex %q is invalid: %s", v.Header.Name, v.Header.NotRegex, err)
+			}
+			// look for a Regex condition on the same header with the same value
+			if seenMatchConditions[headerMatch{headerName, "regex", v.Header.NotRegex}] {
+				return errors.New("cannot specify contradictory 'regex' and 'notregex' conditions for the same route and header")
+			}
+			key = headerMatch{headerName, "notregex", v.Header.NotRegex}
+		case len(v.Header.In) > 0:
+			for _, value := range v.Header.In {
+				if value == "" {
+					return fmt.Errorf("header %q 'in' condition cannot contain an empty value", v.Header.Name)
+				}
+			}
 		}
 
-		key := *v.Header
-		// use the lower-cased header name so comparisons are case-insensitive
-		key.Name = headerName
-		seenMatchConditions[key] = true
+		if v.Header.IgnoreCase && v.Header.Exact == "" && v.Header.NotExact == "" && len(v.Header.In) == 0 {
+			return fmt.Errorf("header %q 'ignoreCase' can only be used with 'exact', 'notexact' or 'in' conditions", v.Header.Name)
+		}
+
+		if key.operator != "" {
+			seenMatchConditions[key] = true
+		}
+	}
+
+	return nil
+}
+
+func mergeQueryParamMatchConditions(conds []contour_api_v1.MatchCondition) []QueryParamMatchCondition {
+	var qc []QueryParamMatchCondition
+	for _, cond := range conds {
+		switch {
+		case cond.QueryParameter == nil:
+			// skip it
+		case cond.QueryParameter.Present:
+			qc = append(qc, QueryParamMatchCondition{
+				Name:      cond.QueryParameter.Name,
+				MatchType: "present",
+			})
+		case cond.QueryParameter.Exact != "":
+			qc = append(qc, QueryParamMatchCondition{
+				Name:      cond.QueryParameter.Name,
+				Value:     cond.QueryParameter.Exact,
+				MatchType: "exact",
+			})
+		case cond.QueryParameter.Contains != "":
+			qc = append(qc, QueryParamMatchCondition{
+				Name:      cond.QueryParameter.Name,
+				Value:     cond.QueryParameter.Contains,
+				MatchType: "contains",
+			})
+		case cond.QueryParameter.Regex != "":
+			qc = append(qc, QueryParamMatchCondition{
+				Name:      cond.QueryParameter.Name,
+				Value:     cond.QueryParameter.Regex,
+				MatchType: "regex",
+			})
+		}
+	}
+	return qc
+}
+
+// queryParamMatchConditionsValid validates that the query parameter conditions
+// within a slice of MatchConditions are valid. Specifically, it returns an error
+// for any of the following scenarios:
+//	- a condition without a name
+//	- a condition that doesn't specify exactly one match operator
+//	- a regex condition that doesn't compile
+//	- more than 1 'exact' condition for the same query parameter
+func queryParamMatchConditionsValid(conditions []contour_api_v1.MatchCondition) error {
+	queryParamsWithExactMatch := map[string]bool{}
+
+	for _, v := range conditions {
+		if v.QueryParameter == nil {
+			continue
+		}
+
+		if v.QueryParameter.Name == "" {
+			return errors.New("query parameter conditions must specify a name")
+		}
+
+		operators := 0
+		for _, set := range []bool{
+			v.QueryParameter.Present,
+			v.QueryParameter.Exact != "",
+			v.QueryParameter.Contains != "",
+			v.QueryParameter.Regex != "",
+		} {
+			if set {
+				operators++
+			}
+		}
+		if operators != 1 {
+			return fmt.Errorf("query parameter %q condition must specify exactly one of present, exact, contains or regex", v.QueryParameter.Name)
+		}
+
+		switch {
+		case v.QueryParameter.Exact != "":
+			// Look for duplicate "exact match" query parameters on conditions
+			if queryParamsWithExactMatch[v.QueryParameter.Name] {
+				return errors.New("cannot specify duplicate query parameter 'exact match' conditions in the same route")
+			}
+			queryParamsWithExactMatch[v.QueryParameter.Name] = true
+		case v.QueryParameter.Regex != "":
+			if _, err := regexp.Compile(v.QueryParameter.Regex); err != nil {
+				return fmt.Errorf("query parameter %q regex %q is invalid: %s", v.QueryParameter.Name, v.QueryParameter.Regex, err)
+			}
+		}
 	}
 
 	return nil
@@ -82,6 +82,52 @@ func TestPathMatchCondition(t *testing.T) {
 			}},
 			want: &PrefixMatchCondition{Prefix: "/"},
 		},
+		"exact condition": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Exact: "/robots.txt",
+			}},
+			want: &ExactMatchCondition{Path: "/robots.txt"},
+		},
+		"exact condition with inherited prefix": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Prefix: "/api/",
+			}, {
+				Exact: "/v1",
+			}},
+			want: &ExactMatchCondition{Path: "/api/v1"},
+		},
+		"regex condition": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Regex: "/users/[0-9]+",
+			}},
+			want: &RegexMatchCondition{Regex: "/users/[0-9]+"},
+		},
+		"regex condition with inherited prefix": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Prefix: "/api/",
+			}, {
+				Prefix: "/v1.0",
+			}, {
+				Regex: "/users/[0-9]+",
+			}},
+			want: &RegexMatchCondition{Regex: `/api/v1\.0(?:/users/[0-9]+)`},
+		},
+		"regex condition with alternation and inherited prefix": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Prefix: "/api",
+			}, {
+				Regex: "/a|/.*",
+			}},
+			want: &RegexMatchCondition{Regex: `/api(?:/a|/.*)`},
+		},
+		"regex condition with inherited slash": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Prefix: "/",
+			}, {
+				Regex: ".*\\.png",
+			}},
+			want: &RegexMatchCondition{Regex: ".*\\.png"},
+		},
 	}
 
 	for name, tc := range tests {
@@ -125,6 +171,56 @@ func TestHeaderMatchConditions(t *testing.T) {
 				MatchType: "present",
 			}},
 		},
+		"header regex": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Header: &contour_api_v1.HeaderMatchCondition{
+					Name:  "user-agent",
+					Regex: ".*Firefox.*",
+				},
+			}, {
+				Header: &contour_api_v1.HeaderMatchCondition{
+					Name:     "x-tenant-id",
+					NotRegex: "test-[0-9]+",
+				},
+			}},
+			want: []HeaderMatchCondition{{
+				Name:      "user-agent",
+				Value:     ".*Firefox.*",
+				MatchType: "regex",
+			}, {
+				Name:      "x-tenant-id",
+				Value:     "test-[0-9]+",
+				MatchType: "regex",
+				Invert:    true,
+			}},
+		},
+		"header in, ignoring case": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Header: &contour_api_v1.HeaderMatchCondition{
+					Name:       "x-tenant-id",
+					In:         []string{"alpha", "beta"},
+					IgnoreCase: true,
+				},
+			}, {
+				Header: &contour_api_v1.HeaderMatchCondition{
+					Name:       "x-env",
+					NotExact:   "Prod",
+					IgnoreCase: true,
+				},
+			}},
+			want: []HeaderMatchCondition{{
+				Name:       "x-tenant-id",
+				Values:     []string{"alpha", "beta"},
+				MatchType:  "in",
+				IgnoreCase: true,
+			}, {
+				Name:       "x-env",
+				Value:      "Prod",
+				MatchType:  "exact",
+				Invert:     true,
+				IgnoreCase: true,
+			}},
+		},
 		"header name but missing condition": {
 			matchconditions: []contour_api_v1.MatchCondition{{
 				Header: &contour_api_v1.HeaderMatchCondition{
@@ -243,6 +339,70 @@ func TestHeaderMatchConditions(t *testing.T) {
 	}
 }
 
+func TestQueryParamMatchConditions(t *testing.T) {
+	tests := map[string]struct {
+		matchconditions []contour_api_v1.MatchCondition
+		want            []QueryParamMatchCondition
+	}{
+		"empty condition list": {
+			matchconditions: nil,
+			want:            nil,
+		},
+		"prefix": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Prefix: "/",
+			}},
+			want: nil,
+		},
+		"query parameter conditions": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				QueryParameter: &contour_api_v1.QueryParameterMatchCondition{
+					Name:    "debug",
+					Present: true,
+				},
+			}, {
+				QueryParameter: &contour_api_v1.QueryParameterMatchCondition{
+					Name:  "version",
+					Exact: "2",
+				},
+			}, {
+				QueryParameter: &contour_api_v1.QueryParameterMatchCondition{
+					Name:     "features",
+					Contains: "beta",
+				},
+			}, {
+				QueryParameter: &contour_api_v1.QueryParameterMatchCondition{
+					Name:  "user",
+					Regex: "[0-9]+",
+				},
+			}},
+			want: []QueryParamMatchCondition{{
+				Name:      "debug",
+				MatchType: "present",
+			}, {
+				Name:      "version",
+				Value:     "2",
+				MatchType: "exact",
+			}, {
+				Name:      "features",
+				Value:     "beta",
+				MatchType: "contains",
+			}, {
+				Name:      "user",
+				Value:     "[0-9]+",
+				MatchType: "regex",
+			}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := mergeQueryParamMatchConditions(tc.matchconditions)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
 func TestPrefixMatchConditionsValid(t *testing.T) {
 	tests := map[string]struct {
 		matchconditions []contour_api_v1.MatchCondition
@@ -304,6 +464,45 @@ func TestPrefixMatchConditionsValid(t *testing.T) {
 			}},
 			want: false,
 		},
+		"valid exact condition": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Exact: "/api",
+			}},
+			want: true,
+		},
+		"invalid exact condition": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Exact: "api",
+			}},
+			want: false,
+		},
+		"valid regex condition": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Regex: "/api/v[0-9]+",
+			}},
+			want: true,
+		},
+		"invalid regex condition": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Regex: "/api/v[0-9+",
+			}},
+			want: false,
+		},
+		"prefix and exact conditions": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Prefix: "/api",
+			}, {
+				Exact: "/v1",
+			}},
+			want: false,
+		},
+		"exact and regex in one condition": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Exact: "/api",
+				Regex: "/api.*",
+			}},
+			want: false,
+		},
 	}
 
 	for name, tc := range tests {
@@ -488,6 +687,81 @@ func TestValidateHeaderMatchConditions(t *testing.T) {
 			},
 			wantErr: false,
 		},
+			"valid regex and notregex headers": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Header: &contour_api_v1.HeaderMatchCondition{
+					Name:  "x-header",
+					Regex: "a.*",
+				},
+			}, {
+				Header: &contour_api_v1.HeaderMatchCondition{
+					Name:     "x-header",
+					NotRegex: "ab.*",
+				},
+			}},
+			wantErr: false,
+		},
+		"contradictory regex and notregex headers": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Header: &contour_api_v1.HeaderMatchCondition{
+					Name:  "x-header",
+					Regex: "a.*",
+				},
+			}, {
+				Header: &contour_api_v1.HeaderMatchCondition{
+					Name:     "X-Header",
+					NotRegex: "a.*",
+				},
+			}},
+			wantErr: true,
+		},
+		"invalid regex header": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Header: &contour_api_v1.HeaderMatchCondition{
+					Name:  "x-header",
+					Regex: "a[",
+				},
+			}},
+			wantErr: true,
+		},
+		"invalid notregex header": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Header: &contour_api_v1.HeaderMatchCondition{
+					Name:     "x-header",
+					NotRegex: "a[",
+				},
+			}},
+			wantErr: true,
+		},
+		"valid in header ignoring case": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Header: &contour_api_v1.HeaderMatchCondition{
+					Name:       "x-header",
+					In:         []string{"a", "b"},
+					IgnoreCase: true,
+				},
+			}},
+			wantErr: false,
+		},
+		"in header with empty value": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Header: &contour_api_v1.HeaderMatchCondition{
+					Name: "x-header",
+					In:   []string{"a", ""},
+				},
+			}},
+			wantErr: true,
+		},
+		"ignore case on contains header": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Header: &contour_api_v1.HeaderMatchCondition{
+					Name:       "x-header",
+					Contains:   "a",
+					IgnoreCase: true,
+				},
+			}},
+			wantErr: true,
+		},
 	}
 
 	for name, tc := range tests {
@@ -504,3 +778,130 @@ func TestValidateHeaderMatchConditions(t *testing.T) {
 		})
 	}
 }
+
+func TestMergedPathMatchConditionsValid(t *testing.T) {
+	tests := map[string]struct {
+		matchconditions []contour_api_v1.MatchCondition
+		want            bool
+	}{
+		"regex without inherited prefix": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Regex: ".*\\.png",
+			}},
+			want: true,
+		},
+		"regex with inherited slash": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Prefix: "/",
+			}, {
+				Regex: ".*\\.png",
+			}},
+			want: true,
+		},
+		"regex beneath inherited prefix": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Prefix: "/static",
+			}, {
+				Regex: "/.*\\.png",
+			}},
+			want: true,
+		},
+		"regex not beneath inherited prefix": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Prefix: "/static",
+			}, {
+				Regex: ".*\\.png",
+			}},
+			want: false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := mergedPathMatchConditionsValid(tc.matchconditions)
+			assert.Equal(t, tc.want, err == nil)
+		})
+	}
+}
+
+func TestValidateQueryParamMatchConditions(t *testing.T) {
+	tests := map[string]struct {
+		matchconditions []contour_api_v1.MatchCondition
+		wantErr         bool
+	}{
+		"empty condition list": {
+			matchconditions: nil,
+			wantErr:         false,
+		},
+		"valid conditions": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				QueryParameter: &contour_api_v1.QueryParameterMatchCondition{
+					Name:  "version",
+					Exact: "2",
+				},
+			}, {
+				QueryParameter: &contour_api_v1.QueryParameterMatchCondition{
+					Name:  "user",
+					Regex: "[0-9]+",
+				},
+			}},
+			wantErr: false,
+		},
+		"missing name": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				QueryParameter: &contour_api_v1.QueryParameterMatchCondition{
+					Exact: "2",
+				},
+			}},
+			wantErr: true,
+		},
+		"no operator": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				QueryParameter: &contour_api_v1.QueryParameterMatchCondition{
+					Name: "version",
+				},
+			}},
+			wantErr: true,
+		},
+		"two operators": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				QueryParameter: &contour_api_v1.QueryParameterMatchCondition{
+					Name:     "version",
+					Exact:    "2",
+					Contains: "2",
+				},
+			}},
+			wantErr: true,
+		},
+		"invalid regex": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				QueryParameter: &contour_api_v1.QueryParameterMatchCondition{
+					Name:  "user",
+					Regex: "[0-9+",
+				},
+			}},
+			wantErr: true,
+		},
+		"duplicate exact conditions": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				QueryParameter: &contour_api_v1.QueryParameterMatchCondition{
+					Name:  "version",
+					Exact: "2",
+				},
+			}, {
+				QueryParameter: &contour_api_v1.QueryParameterMatchCondition{
+					Name:  "version",
+					Exact: "3",
+				},
+			}},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			gotErr := queryParamMatchConditionsValid(tc.matchconditions)
+			assert.Equal(t, tc.wantErr, gotErr != nil)
+		})
+	}
+}
@@ -24,6 +24,7 @@ import (
 
 	envoy_api_v2_auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	contour_api_v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
 	"github.com/projectcontour/contour/internal/status"
 	"github.com/projectcontour/contour/internal/timeout"
 	"github.com/projectcontour/contour/internal/xds"
@@ -126,6 +127,15 @@ func (pc *PrefixMatchCondition) String() string {
 	return "prefix: " + pc.Prefix
 }
 
+// ExactMatchCondition matches the whole path of a URL.
+type ExactMatchCondition struct {
+	Path string
+}
+
+func (ec *ExactMatchCondition) String() string {
+	return "exact: " + ec.Path
+}
+
 // RegexMatchCondition matches the URL by regular expression.
 type RegexMatchCondition struct {
 	Regex string
@@ -141,6 +151,13 @@ type HeaderMatchCondition struct {
 	Value     string
 	MatchType string
 	Invert    bool
+
+	// Values is the set of values for the "in" MatchType.
+	Values []string
+
+	// IgnoreCase specifies that the "exact" and "in" MatchTypes
+	// compare values case insensitively.
+	IgnoreCase bool
 }
 
 func (hc *HeaderMatchCondition) String() string {
@@ -149,11 +166,30 @@ func (hc *HeaderMatchCondition) String() string {
 		"value=" + hc.Value,
 		"matchtype=", hc.MatchType,
 		"invert=", strconv.FormatBool(hc.Invert),
+		"values=" + strings.Join(hc.Values, "|"),
+		"ignorecase=" + strconv.FormatBool(hc.IgnoreCase),
 	}, "&")
 
 	return "header: " + details
 }
 
+// QueryParamMatchCondition matches request query parameters by MatchType
+type QueryParamMatchCondition struct {
+	Name      string
+	Value     string
+	MatchType string
+}
+
+func (qc *QueryParamMatchCondition) String() string {
+	details := strings.Join([]string{
+		"name=" + qc.Name,
+		"value=" + qc.Value,
+		"matchtype=" + qc.MatchType,
+	}, "&")
+
+	return "queryparam: " + details
+}
+
 // Route defines the properties of a route to a Cluster.
 type Route struct {
 
@@ -165,8 +201,23 @@ type Route struct {
 	// match on the request headers.
 	HeaderMatchConditions []HeaderMatchCondition
 
+	// QueryParamMatchConditions specifies a set of additional Conditions to
+	// match on the request query parameters.
+	QueryParamMatchConditions []QueryParamMatchCondition
+
+	// Clusters is the, possibly weighted, set of upstream clusters
+	// that requests are forwarded to. It is empty if the route
+	// responds to requests itself.
 	Clusters []*Cluster
 
+	// Redirect is the redirect that the route responds with.
+	// If nil, requests are forwarded to the route's clusters.
+	Redirect *Redirect
+
+	// DirectResponse is the response that the route responds with.
+	// If nil, requests are forwarded to the route's clusters.
+	DirectResponse *DirectResponse
+
 	// Should this route generate a 301 upgrade if accessed
 	// over HTTP?
 	HTTPSUpgrade bool
@@ -192,14 +243,32 @@ type Route struct {
 	// Indicates that during forwarding, the matched prefix (or path) should be swapped with this value
 	PrefixRewrite string
 
-	// Mirror Policy defines the mirroring policy for this Route.
-	MirrorPolicy *MirrorPolicy
+	// RegexRewrite is the regular expression substitution that
+	// is applied to the path during forwarding.
+	RegexRewrite *RegexRewrite
+
+	// MirrorPolicies defines the mirroring policies for this Route.
+	MirrorPolicies []MirrorPolicy
 
 	// RequestHeadersPolicy defines how headers are managed during forwarding
 	RequestHeadersPolicy *HeadersPolicy
 
 	// ResponseHeadersPolicy defines how headers are managed during forwarding
 	ResponseHeadersPolicy *HeadersPolicy
+
+	// RateLimitPolicy defines the rate limit descriptors that
+	// are sent for requests to this route, in addition to the
+	// descriptors of the virtual host.
+	RateLimitPolicy *RateLimitPolicy
+
+	// RequestHashPolicies is a list of policies for configuring
+	// hashes on request attributes, used by hash based load
+	// balancing strategies.
+	RequestHashPolicies []RequestHashPolicy
+
+	// FaultInjectionPolicy defines the faults that are
+	// injected into requests for this route.
+	FaultInjectionPolicy *FaultInjectionPolicy
 }
 
 // HasPathPrefix returns whether this route has a PrefixPathCondition.
@@ -208,6 +277,12 @@ func (r *Route) HasPathPrefix() bool {
 	return ok
 }
 
+// HasPathExact returns whether this route has a ExactMatchCondition.
+func (r *Route) HasPathExact() bool {
+	_, ok := r.PathMatchCondition.(*ExactMatchCondition)
+	return ok
+}
+
 // HasPathRegex returns whether this route has a RegexPathCondition.
 func (r *Route) HasPathRegex() bool {
 	_, ok := r.PathMatchCondition.(*RegexMatchCondition)
@@ -224,6 +299,18 @@ type TimeoutPolicy struct {
 	IdleTimeout timeout.Setting
 }
 
+// ClusterTimeoutPolicy defines the timeout policy for
+// connections to a cluster.
+type ClusterTimeoutPolicy struct {
+	// ConnectTimeout is the timeout for establishing
+	// a connection to the upstream.
+	ConnectTimeout timeout.Setting
+
+	// IdleTimeout is the timeout applied to idle
+	// upstream connections.
+	IdleTimeout timeout.Setting
+}
+
 // RetryPolicy defines the retry / number / timeout options
 type RetryPolicy struct {
 	// RetryOn specifies the conditions under which retry takes place.
@@ -242,9 +329,206 @@ type RetryPolicy struct {
 	PerTryTimeout timeout.Setting
 }
 
+// RateLimitPolicy defines the global rate limit descriptors
+// for a virtual host or route.
+type RateLimitPolicy struct {
+	Descriptors []*RateLimitDescriptor
+}
+
+// RateLimitDescriptor is a list of entries that are built
+// from a client request and sent to the rate limit service.
+type RateLimitDescriptor struct {
+	Entries []RateLimitDescriptorEntry
+}
+
+// RateLimitDescriptorEntry is a single rate limit descriptor
+// entry. Exactly one field is set.
+type RateLimitDescriptorEntry struct {
+	GenericKey       *GenericKeyDescriptorEntry
+	RequestHeader    *RequestHeaderDescriptorEntry
+	HeaderValueMatch *HeaderValueMatchDescriptorEntry
+	RemoteAddress    *RemoteAddressDescriptorEntry
+}
+
+// GenericKeyDescriptorEntry configures a descriptor entry
+// that has a static value.
+type GenericKeyDescriptorEntry struct {
+	Value string
+}
+
+// RequestHeaderDescriptorEntry configures a descriptor entry
+// that has a value taken from a request header.
+type RequestHeaderDescriptorEntry struct {
+	HeaderName string
+	Key        string
+}
+
+// HeaderValueMatchDescriptorEntry configures a descriptor entry
+// that is added when the request headers match (or don't match,
+// if ExpectMatch is false) the given conditions.
+type HeaderValueMatchDescriptorEntry struct {
+	Headers     []HeaderMatchCondition
+	ExpectMatch bool
+	Value       string
+}
+
+// RemoteAddressDescriptorEntry configures a descriptor entry
+// that has the client IP address as its value.
+type RemoteAddressDescriptorEntry struct{}
+
+// RateLimitService describes the extension service that
+// makes rate limit decisions for a virtual host.
+type RateLimitService struct {
+	// ExtensionService is the extension that rate limit
+	// requests are sent to.
+	ExtensionService *ExtensionCluster
+
+	// Domain is the rate limit domain sent with each request.
+	Domain string
+
+	// ResponseTimeout sets how long the proxy should wait
+	// for rate limit service responses.
+	ResponseTimeout timeout.Setting
+
+	// FailOpen sets whether requests are allowed when the
+	// rate limit service fails to respond.
+	FailOpen bool
+
+	// Stage is the rate limit filter stage that this service
+	// is configured in. Virtual hosts that share a HTTP
+	// connection manager but use different rate limit services
+	// are given different stages.
+	Stage uint32
+}
+
+// Redirect defines the redirect that a route responds with.
+// Fields that are empty keep the value from the request.
+type Redirect struct {
+	// Scheme is the scheme of the redirect location.
+	Scheme string
+
+	// Hostname is the hostname of the redirect location.
+	Hostname string
+
+	// Port is the port of the redirect location.
+	Port uint32
+
+	// PathRewrite replaces the full path of the redirect location.
+	PathRewrite string
+
+	// PrefixRewrite replaces the matched prefix of the redirect location.
+	PrefixRewrite string
+
+	// StatusCode is the HTTP status code of the redirect response.
+	StatusCode int
+}
+
+// RegexRewrite defines a regular expression substitution
+// on the request path.
+type RegexRewrite struct {
+	// Pattern is the RE2 regular expression to match.
+	Pattern string
+
+	// Substitution replaces each match of the pattern.
+	Substitution string
+}
+
+// FaultInjectionPolicy defines the faults that a route injects
+// into requests. A nil Delay or Abort injects no fault of that kind.
+type FaultInjectionPolicy struct {
+	Delay *FaultDelay
+	Abort *FaultAbort
+
+	// HeaderName, if set, limits fault injection to requests
+	// that have a header with this name.
+	HeaderName string
+}
+
+// FaultDelay defines a fixed delay that is injected into
+// the given percentage of requests.
+type FaultDelay struct {
+	FixedDelay time.Duration
+	Percentage uint32
+}
+
+// FaultAbort defines an HTTP status code that is returned to
+// the given percentage of requests.
+type FaultAbort struct {
+	StatusCode uint32
+	Percentage uint32
+}
+
+// DirectResponse defines the response that a route sends
+// without forwarding the request upstream.
+type DirectResponse struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode uint32
+
+	// Body is the response body. If empty, the response has
+	// no body.
+	Body string
+}
+
+// RequestHashPolicy contains configuration for a hash policy
+// on a request attribute. Exactly one of the options is set.
+type RequestHashPolicy struct {
+	// Terminal stops the evaluation of further hash policies
+	// if this policy produces a hash.
+	Terminal bool
+
+	// HeaderHashOptions hashes on a request header.
+	HeaderHashOptions *HeaderHashOptions
+
+	// CookieHashOptions hashes on a request cookie.
+	CookieHashOptions *CookieHashOptions
+
+	// QueryParameterHashOptions hashes on a request query parameter.
+	QueryParameterHashOptions *QueryParameterHashOptions
+
+	// HashSourceIP hashes on the request source IP.
+	HashSourceIP bool
+}
+
+// HeaderHashOptions contains options for hashing a request header.
+type HeaderHashOptions struct {
+	// HeaderName is the name of the header to hash.
+	HeaderName string
+}
+
+// CookieHashOptions contains options for hashing a request cookie.
+type CookieHashOptions struct {
+	// CookieName is the name of the cookie to hash.
+	CookieName string
+
+	// TTL is the lifetime of a cookie generated by Envoy. If nil,
+	// Envoy does not generate the cookie.
+	TTL *time.Duration
+
+	// Path is the path of a cookie generated by Envoy.
+	Path string
+}
+
+// QueryParameterHashOptions contains options for hashing a
+// request query parameter.
+type QueryParameterHashOptions struct {
+	// ParameterName is the name of the query parameter to hash.
+	ParameterName string
+}
+
+// RingHashPolicy defines the minimum and maximum size of a
+// hash ring. A zero size means Envoy's default is used.
+type RingHashPolicy struct {
+	MinimumRingSize uint64
+	MaximumRingSize uint64
+}
+
 // MirrorPolicy defines the mirroring policy for a route.
 type MirrorPolicy struct {
 	Cluster *Cluster
+
+	// Weight is the percentage of requests that are mirrored
+	// to the cluster.
+	Weight uint32
 }
 
 // HeadersPolicy defines how headers are managed during forwarding
@@ -313,8 +597,10 @@ func (r *Route) Visit(f func(Vertex)) {
 	}
 	// Allow any mirror clusters to also be visited so that
 	// they are also added to CDS.
-	if r.MirrorPolicy != nil && r.MirrorPolicy.Cluster != nil {
-		f(r.MirrorPolicy.Cluster)
+	for _, mp := range r.MirrorPolicies {
+		if mp.Cluster != nil {
+			f(mp.Cluster)
+		}
 	}
 }
 
@@ -327,6 +613,24 @@ type VirtualHost struct {
 	// CORSPolicy is the cross-origin policy to apply to the VirtualHost.
 	CORSPolicy *CORSPolicy
 
+	// RateLimitService is the extension service that rate limit
+	// descriptors are sent to. If nil, rate limiting is disabled
+	// for this host.
+	RateLimitService *RateLimitService
+
+	// RateLimitPolicy defines the rate limit descriptors that
+	// are sent for every request to this host.
+	RateLimitPolicy *RateLimitPolicy
+
+	// ListenerName is the name of the additional listener that
+	// this host is attached to. If empty, the host is attached
+	// to the default HTTP or HTTPS listener.
+	ListenerName string
+
+	// TracingPolicy overrides the tracing configuration
+	// of the listener for requests to this host.
+	TracingPolicy *TracingPolicy
+
 	routes map[string]*Route
 }
 
@@ -342,6 +646,9 @@ func conditionsToString(r *Route) string {
 	for _, cond := range r.HeaderMatchConditions {
 		s = append(s, cond.String())
 	}
+	for _, cond := range r.QueryParamMatchConditions {
+		s = append(s, cond.String())
+	}
 	return strings.Join(s, ",")
 }
 
@@ -412,6 +719,10 @@ func (s *SecureVirtualHost) Valid() bool {
 // incoming connections.
 type Listener struct {
 
+	// Name is the name of an additional listener. It is
+	// empty for the default HTTP and HTTPS listeners.
+	Name string
+
 	// Address is the TCP address to listen on.
 	// If blank 0.0.0.0, or ::/0 for IPv6, is assumed.
 	Address string
@@ -419,15 +730,145 @@ type Listener struct {
 	// Port is the TCP port to listen on.
 	Port int
 
+	// Tracing is the distributed tracing configuration of
+	// the listener. If nil, requests are not traced.
+	Tracing *Tracing
+
+	// AccessLogService is the gRPC access log service that
+	// access logs are streamed to. If nil, access logs are
+	// only written to files.
+	AccessLogService *AccessLogService
+
 	VirtualHosts []Vertex
 }
 
 func (l *Listener) Visit(f func(Vertex)) {
+	if l.Tracing != nil {
+		f(l.Tracing)
+	}
 	for _, vh := range l.VirtualHosts {
 		f(vh)
 	}
 }
 
+// AccessLogService describes the extension service that
+// implements the Envoy gRPC access log service.
+type AccessLogService struct {
+	// ExtensionService is the extension service
+	// that access logs are streamed to.
+	ExtensionService *ExtensionCluster
+
+	// LogName identifies the access logs of the
+	// listener to the access log service.
+	LogName string
+
+	// BufferFlushInterval is the interval at which buffered
+	// access logs are sent. If zero, Envoy's default is used.
+	BufferFlushInterval time.Duration
+
+	// BufferSizeBytes is the size of the buffer that access
+	// logs are held in until they are sent. If zero, Envoy's
+	// default is used.
+	BufferSizeBytes uint32
+}
+
+const (
+	// TracingProviderZipkin sends traces to a Zipkin collector
+	// using the Zipkin v2 HTTP JSON protocol.
+	TracingProviderZipkin = "zipkin"
+
+	// TracingProviderOpenCensus sends traces to an OpenCensus
+	// agent, or an OpenTelemetry collector that accepts the
+	// OpenCensus protocol, over gRPC.
+	TracingProviderOpenCensus = "opencensus"
+)
+
+// Tracing holds the distributed tracing configuration
+// of a listener's HTTP connection managers.
+type Tracing struct {
+	// Provider is the tracing provider, either
+	// TracingProviderZipkin or TracingProviderOpenCensus.
+	Provider string
+
+	// ExtensionCluster is the extension service that traces
+	// are sent to, if the collector is an ExtensionService.
+	ExtensionCluster *ExtensionCluster
+
+	// Cluster is the cluster that traces are sent to,
+	// if the collector is a Kubernetes Service.
+	Cluster *Cluster
+
+	// CollectorEndpoint is the path that Zipkin
+	// traces are posted to.
+	CollectorEndpoint string
+
+	// SamplingRate is the percentage of requests that are traced.
+	SamplingRate float64
+
+	// MaxPathTagLength is the maximum length of the request
+	// path that is recorded in a span. If zero, Envoy's
+	// default is used.
+	MaxPathTagLength uint32
+
+	// CustomTags are the additional tags that are
+	// recorded in every span.
+	CustomTags []TracingCustomTag
+}
+
+func (t *Tracing) Visit(f func(Vertex)) {
+	if t.Cluster != nil {
+		f(t.Cluster)
+	}
+}
+
+// TracingCustomTag is a tag that is recorded in every span. Its
+// value is either a literal or the value of a request header.
+type TracingCustomTag struct {
+	// TagName is the name of the tag.
+	TagName string
+
+	// Literal is the static value of the tag.
+	Literal string
+
+	// RequestHeader is the name of the request
+	// header that holds the value of the tag.
+	RequestHeader string
+}
+
+// TracingPolicy overrides the tracing configuration
+// of a listener for a virtual host.
+type TracingPolicy struct {
+	// SamplingRate is the percentage of requests
+	// to the virtual host that are traced.
+	SamplingRate float64
+}
+
+// Listener protocols.
+const (
+	ListenerProtocolHTTP  = "http"
+	ListenerProtocolHTTPS = "https"
+)
+
+// NamedListener describes an additional listener that virtual
+// hosts can be attached to by name, alongside the default HTTP
+// and HTTPS listeners.
+type NamedListener struct {
+	// Name is the unique name of the listener.
+	Name string
+
+	// Address is the TCP address to listen on.
+	Address string
+
+	// Port is the TCP port to listen on.
+	Port int
+
+	// Protocol is either ListenerProtocolHTTP or
+	// ListenerProtocolHTTPS. Only secure virtual hosts
+	// can be attached to an HTTPS listener, and only
+	// insecure virtual hosts to an HTTP listener.
+	Protocol string
+}
+
 // TCPProxy represents a cluster of TCP endpoints.
 type TCPProxy struct {
 
@@ -512,12 +953,32 @@ type Cluster struct {
 	// See https://www.envoyproxy.io/docs/envoy/latest/api-v2/api/v2/cds.proto#envoy-api-enum-cluster-lbpolicy
 	LoadBalancerPolicy string
 
+	// RingHashPolicy configures the hash ring of the cluster, if
+	// its load balancer policy uses one. If nil, Envoy's default
+	// ring sizes are used.
+	RingHashPolicy *RingHashPolicy
+
 	// Cluster http health check policy
 	*HTTPHealthCheckPolicy
 
 	// Cluster tcp health check policy
 	*TCPHealthCheckPolicy
 
+	// Cluster grpc health check policy
+	*GRPCHealthCheckPolicy
+
+	// OutlierDetectionPolicy defines the passive health
+	// checking of the cluster endpoints.
+	OutlierDetectionPolicy *OutlierDetectionPolicy
+
+	// ClusterTimeoutPolicy specifies the connect and idle
+	// timeouts of connections to the Upstream service.
+	ClusterTimeoutPolicy ClusterTimeoutPolicy
+
+	// CircuitBreakerPolicy overrides the circuit breaking
+	// limits of the Upstream service.
+	CircuitBreakerPolicy *CircuitBreakerPolicy
+
 	// RequestHeadersPolicy defines how headers are managed during forwarding
 	RequestHeadersPolicy *HeadersPolicy
 
@@ -548,6 +1009,48 @@ type Cluster struct {
 
 func (c Cluster) Visit(f func(Vertex)) {
 	f(c.Upstream)
+
+	// Health checks on a separate port need a load assignment
+	// of their own, since the port is set on each endpoint.
+	if port := c.healthCheckPort(); port > 0 {
+		f(&ServiceCluster{
+			ClusterName: c.ClusterLoadAssignmentName(),
+			Services: []WeightedService{
+				c.Upstream.Weighted,
+			},
+			HealthCheckPort: port,
+		})
+	}
+}
+
+// ClusterLoadAssignmentName returns the name of the load assignment
+// that holds the endpoints of this Cluster.
+func (c *Cluster) ClusterLoadAssignmentName() string {
+	name := xds.ClusterLoadAssignmentName(
+		types.NamespacedName{
+			Name:      c.Upstream.Weighted.ServiceName,
+			Namespace: c.Upstream.Weighted.ServiceNamespace,
+		},
+		c.Upstream.Weighted.ServicePort.Name)
+
+	if port := c.healthCheckPort(); port > 0 {
+		return fmt.Sprintf("%s/healthcheck/%d", name, port)
+	}
+
+	return name
+}
+
+// healthCheckPort returns the port that the endpoints of this
+// Cluster are health checked on, or 0 if it is the endpoint port.
+func (c *Cluster) healthCheckPort() uint32 {
+	switch {
+	case c.HTTPHealthCheckPolicy != nil:
+		return c.HTTPHealthCheckPolicy.Port
+	case c.GRPCHealthCheckPolicy != nil:
+		return c.GRPCHealthCheckPolicy.Port
+	default:
+		return 0
+	}
 }
 
 // WeightedService represents the load balancing weight of a
@@ -573,13 +1076,17 @@ type ServiceCluster struct {
 	ClusterName string
 	// Services are the load balancing targets. This slice must not be empty.
 	Services []WeightedService
+	// HealthCheckPort, if non-zero, is the port that the
+	// endpoints are health checked on.
+	HealthCheckPort uint32
 }
 
 // TODO(jpeach): apply deepcopy-gen to DAG objects.
 func (s *ServiceCluster) DeepCopy() *ServiceCluster {
 	s2 := ServiceCluster{
-		ClusterName: s.ClusterName,
-		Services:    make([]WeightedService, len(s.Services)),
+		ClusterName:     s.ClusterName,
+		Services:        make([]WeightedService, len(s.Services)),
+		HealthCheckPort: s.HealthCheckPort,
 	}
 
 	for i, w := range s.Services {
@@ -685,6 +1192,27 @@ type HTTPHealthCheckPolicy struct {
 	Timeout            time.Duration
 	UnhealthyThreshold uint32
 	HealthyThreshold   uint32
+	UnhealthyInterval  time.Duration
+	NoTrafficInterval  time.Duration
+
+	// Port, if non-zero, is the port that health checks
+	// are sent to instead of the endpoint port.
+	Port uint32
+
+	// ExpectedStatuses are the status code ranges
+	// that are considered healthy.
+	ExpectedStatuses []HTTPStatusRange
+
+	// RequestHeaders are the headers that are added
+	// to health check requests.
+	RequestHeaders map[string]string
+}
+
+// HTTPStatusRange is the half-open range [Start, End)
+// of HTTP status codes.
+type HTTPStatusRange struct {
+	Start int64
+	End   int64
 }
 
 // Cluster tcp health check policy
@@ -695,6 +1223,41 @@ type TCPHealthCheckPolicy struct {
 	HealthyThreshold   uint32
 }
 
+// Cluster grpc health check policy
+type GRPCHealthCheckPolicy struct {
+	ServiceName        string
+	Authority          string
+	Interval           time.Duration
+	Timeout            time.Duration
+	UnhealthyThreshold uint32
+	HealthyThreshold   uint32
+	UnhealthyInterval  time.Duration
+	NoTrafficInterval  time.Duration
+
+	// Port, if non-zero, is the port that health checks
+	// are sent to instead of the endpoint port.
+	Port uint32
+}
+
+// OutlierDetectionPolicy defines passive health checking of
+// the endpoints of a cluster. Zero values use Envoy defaults.
+type OutlierDetectionPolicy struct {
+	ConsecutiveServerErrors  uint32
+	ConsecutiveGatewayErrors uint32
+	Interval                 time.Duration
+	BaseEjectionTime         time.Duration
+	MaxEjectionPercent       uint32
+}
+
+// CircuitBreakerPolicy defines the circuit breaking limits
+// of a cluster. Zero values are not set.
+type CircuitBreakerPolicy struct {
+	MaxConnections     uint32
+	MaxPendingRequests uint32
+	MaxRequests        uint32
+	MaxRetries         uint32
+}
+
 // ExtensionCluster generates an Envoy cluster (aka ClusterLoadAssignment)
 // for an ExtensionService resource.
 type ExtensionCluster struct {
@@ -707,6 +1270,10 @@ type ExtensionCluster struct {
 	// The protocol to use to speak to this cluster.
 	Protocol string
 
+	// ProtocolVersion is the version of the GRPC protocol
+	// that Envoy uses to speak to this extension.
+	ProtocolVersion contour_api_v1alpha1.ExtensionProtocolVersion
+
 	// UpstreamValidation defines how to verify the backend service's certificate
 	UpstreamValidation *PeerValidationContext
 
@@ -717,6 +1284,14 @@ type ExtensionCluster struct {
 	// TimeoutPolicy specifies how to handle timeouts to this extension.
 	TimeoutPolicy TimeoutPolicy
 
+	// ClusterTimeoutPolicy specifies the connect and idle
+	// timeouts of connections to this extension.
+	ClusterTimeoutPolicy ClusterTimeoutPolicy
+
+	// CircuitBreakerPolicy specifies the circuit breaking
+	// limits of this extension.
+	CircuitBreakerPolicy *CircuitBreakerPolicy
+
 	// SNI is used when a route proxies an upstream using TLS.
 	SNI string
 
@@ -21,6 +21,7 @@ import (
 	contour_api_v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
 	"github.com/projectcontour/contour/internal/k8s"
 	"github.com/projectcontour/contour/internal/status"
+	"github.com/projectcontour/contour/internal/timeout"
 	"github.com/projectcontour/contour/internal/xds"
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
@@ -35,6 +36,14 @@ type ExtensionServiceProcessor struct {
 	// secret containing client certificate and private key to be
 	// used when establishing TLS connection to upstream cluster.
 	ClientCertificate *types.NamespacedName
+
+	// ConnectTimeout is the default timeout for establishing
+	// connections to upstream clusters.
+	ConnectTimeout timeout.Setting
+
+	// UpstreamIdleTimeout is the default timeout after which
+	// idle connections to upstream clusters are closed.
+	UpstreamIdleTimeout timeout.Setting
 }
 
 var _ Processor = &ExtensionServiceProcessor{}
@@ -85,6 +94,18 @@ func (p *ExtensionServiceProcessor) buildExtensionService(
 			"spec.timeoutPolicy failed to parse: %s", err)
 	}
 
+	ctp, err := clusterTimeoutPolicy(ext.Spec.TimeoutPolicy, p.ConnectTimeout, p.UpstreamIdleTimeout)
+	if err != nil {
+		validCondition.AddErrorf("SpecError", "TimeoutPolicyNotValid",
+			"spec.timeoutPolicy failed to parse: %s", err)
+	}
+
+	cbp, err := circuitBreakerPolicy(ext.Spec.CircuitBreakerPolicy)
+	if err != nil {
+		validCondition.AddErrorf("SpecError", "CircuitBreakerPolicyNotValid",
+			"spec.circuitBreakerPolicy is invalid: %s", err)
+	}
+
 	var clientCertSecret *Secret
 	if p.ClientCertificate != nil {
 		clientCertSecret, err = cache.LookupSecret(*p.ClientCertificate, validSecret)
@@ -102,12 +123,15 @@ func (p *ExtensionServiceProcessor) buildExtensionService(
 				xds.ClusterLoadAssignmentName(k8s.NamespacedNameOf(ext), ""),
 			),
 		},
-		Protocol:           "h2",
-		UpstreamValidation: nil,
-		LoadBalancerPolicy: loadBalancerPolicy(ext.Spec.LoadBalancerPolicy),
-		TimeoutPolicy:      tp,
-		SNI:                "",
-		ClientCertificate:  clientCertSecret,
+		Protocol:             "h2",
+		ProtocolVersion:      contour_api_v1alpha1.SupportProtocolVersion2,
+		UpstreamValidation:   nil,
+		LoadBalancerPolicy:   loadBalancerPolicy(ext.Spec.LoadBalancerPolicy),
+		TimeoutPolicy:        tp,
+		ClusterTimeoutPolicy: ctp,
+		CircuitBreakerPolicy: cbp,
+		SNI:                  "",
+		ClientCertificate:    clientCertSecret,
 	}
 
 	// Timeouts are specified above the cluster (e.g.
@@ -120,11 +144,33 @@ func (p *ExtensionServiceProcessor) buildExtensionService(
 			".Spec.TimeoutPolicy.Idle")
 	}
 
+	// Request hash policies are applied on routes, and the
+	// ext_authz filter doesn't configure any, so they have
+	// no effect here.
+	if lbp := ext.Spec.LoadBalancerPolicy; lbp != nil && len(lbp.RequestHashPolicies) > 0 {
+		validCondition.AddWarningf("SpecError", "IgnoredField",
+			"ignoring field %q; request hash policies are not supported for ExtensionClusters",
+			".Spec.LoadBalancerPolicy.RequestHashPolicies")
+	}
+
+	// Without request hash policies, a hash ring only
+	// selects hosts randomly, so its size has no effect.
+	if lbp := ext.Spec.LoadBalancerPolicy; lbp != nil && lbp.RingHash != nil {
+		validCondition.AddWarningf("SpecError", "IgnoredField",
+			"ignoring field %q; hash ring sizes are not supported for ExtensionClusters",
+			".Spec.LoadBalancerPolicy.RingHash")
+	}
+
 	// API server validation ensures that the protocol is "h2" or "h2c".
 	if ext.Spec.Protocol != nil {
 		extension.Protocol = stringOrDefault(*ext.Spec.Protocol, extension.Protocol)
 	}
 
+	// API server validation ensures that the protocol version is "v2" or "v3".
+	if ext.Spec.ProtocolVersion != "" {
+		extension.ProtocolVersion = ext.Spec.ProtocolVersion
+	}
+
 	if v := ext.Spec.UpstreamValidation; v != nil {
 		if uv, err := cache.LookupUpstreamValidation(v, ext.GetNamespace()); err != nil {
 			validCondition.AddErrorf("SpecError", "TLSUpstreamValidation",
@@ -0,0 +1,456 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	envoy_api_v2_auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/annotation"
+	"github.com/projectcontour/contour/internal/k8s"
+	"github.com/projectcontour/contour/internal/status"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	serviceapis "sigs.k8s.io/service-apis/api/v1alpha1"
+)
+
+// GatewayControllerName is the GatewayClass controller name
+// of the Gateways that Contour manages.
+const GatewayControllerName = "projectcontour.io/contour"
+
+// GatewayProcessor translates service-apis Gateways and the
+// HTTPRoutes attached to them into DAG objects and adds them
+// to the DAG.
+//
+// Only Gateways whose GatewayClass is controlled by Contour are
+// processed. Gateways that belong to other controllers, or whose
+// class can't be found, are left alone so that their status is
+// not overwritten.
+//
+// Gateway listeners are mapped onto Contour's HTTP and HTTPS
+// listeners by protocol. The listener address and port are
+// owned by the Envoy deployment, so they are not used here.
+type GatewayProcessor struct {
+	logrus.FieldLogger
+
+	dag    *DAG
+	source *KubernetesCache
+}
+
+// gatewayListener is a validated Gateway listener.
+type gatewayListener struct {
+	// secure is true if this listener terminates TLS.
+	secure bool
+
+	// secret is the TLS certificate for a secure listener.
+	secret *Secret
+
+	// minTLSVersion is the minimum TLS version for a secure listener.
+	minTLSVersion envoy_api_v2_auth.TlsParameters_TlsProtocol
+}
+
+// Run translates Gateways into DAG objects and
+// adds them to the DAG.
+func (p *GatewayProcessor) Run(dag *DAG, source *KubernetesCache) {
+	p.dag = dag
+	p.source = source
+
+	// reset the processor when we're done
+	defer func() {
+		p.dag = nil
+		p.source = nil
+	}()
+
+	for _, gw := range p.source.gateways {
+		gc := p.lookupGatewayClass(gw.Spec.Class)
+		if gc == nil || gc.Spec.Controller != GatewayControllerName {
+			p.WithField("name", gw.Name).WithField("namespace", gw.Namespace).
+				WithField("gatewayclass", gw.Spec.Class).
+				Debug("skipping Gateway that is not controlled by Contour")
+			continue
+		}
+
+		p.computeGateway(gw)
+	}
+}
+
+func (p *GatewayProcessor) computeGateway(gw *serviceapis.Gateway) {
+	gwStatus, commit := status.GatewayAccessor(&p.dag.StatusCache, gw)
+	defer commit()
+
+	classCond := gwStatus.ConditionFor(status.ConditionType(serviceapis.ConditionNoSuchGatewayClass))
+	listenersCond := gwStatus.ConditionFor(status.ConditionType(serviceapis.ConditionInvalidListeners))
+	routesCond := gwStatus.ConditionFor(status.ConditionType(serviceapis.ConditionInvalidRoutes))
+
+	// Mark the conditions as resolved if processing didn't
+	// record any errors on them.
+	defer func() {
+		for _, cond := range []*contour_api_v1.DetailedCondition{classCond, listenersCond, routesCond} {
+			if len(cond.Errors) == 0 {
+				cond.Status = contour_api_v1.ConditionFalse
+				cond.Reason = "Valid"
+				cond.Message = ""
+			}
+		}
+	}()
+
+	var listeners []*gatewayListener
+	for _, l := range gw.Spec.Listeners {
+		if listener := p.computeListener(listenersCond, gw, l); listener != nil {
+			listeners = append(listeners, listener)
+		}
+	}
+
+	if len(listeners) == 0 {
+		if len(listenersCond.Errors) == 0 {
+			listenersCond.AddError("ListenerError", "NoListeners",
+				"Gateway must have at least one valid listener")
+		}
+		return
+	}
+
+	for _, ref := range gw.Spec.Routes {
+		if ref.APIGroup != nil && *ref.APIGroup != serviceapis.GroupVersion.Group {
+			routesCond.AddErrorf("RouteError", "UnsupportedRouteGroup",
+				"route %q has unsupported API group %q", ref.Name, *ref.APIGroup)
+			continue
+		}
+
+		name := types.NamespacedName{Namespace: gw.Namespace, Name: ref.Name}
+
+		switch ref.Kind {
+		case "HTTPRoute":
+			route, ok := p.source.httproutes[name]
+			if !ok {
+				routesCond.AddErrorf("RouteError", "RouteNotFound",
+					"HTTPRoute %q not found", name)
+				continue
+			}
+
+			routes, err := p.computeHTTPRoute(route)
+			if err != nil {
+				routesCond.AddErrorf("RouteError", "HTTPRouteNotValid",
+					"HTTPRoute %q is invalid: %s", name, err)
+				continue
+			}
+
+			for _, l := range listeners {
+				p.addHTTPRoutes(l, routes)
+			}
+
+			routeStatus, commitRoute := status.HTTPRouteAccessor(&p.dag.StatusCache, route)
+			routeStatus.AddGateway(gw)
+			commitRoute()
+		case "TcpRoute":
+			if _, ok := p.source.tcproutes[name]; !ok {
+				routesCond.AddErrorf("RouteError", "RouteNotFound",
+					"TcpRoute %q not found", name)
+				continue
+			}
+
+			// The TcpRoute spec in this API version has no
+			// fields, so there are no backends to proxy to.
+			routesCond.AddErrorf("RouteError", "UnsupportedRouteKind",
+				"TcpRoute %q is not supported: TcpRoute does not define any backends", name)
+		default:
+			routesCond.AddErrorf("RouteError", "UnsupportedRouteKind",
+				"route %q has unsupported kind %q", ref.Name, ref.Kind)
+		}
+	}
+}
+
+// lookupGatewayClass returns the named GatewayClass, or nil if it
+// is not present. GatewayClasses are cluster-scoped, so only the
+// name is matched.
+func (p *GatewayProcessor) lookupGatewayClass(name string) *serviceapis.GatewayClass {
+	for _, gc := range p.source.gatewayclasses {
+		if gc.Name == name {
+			return gc
+		}
+	}
+
+	return nil
+}
+
+// computeListener validates a Gateway listener, returning nil and
+// recording the error on the condition if it is invalid.
+func (p *GatewayProcessor) computeListener(cond *contour_api_v1.DetailedCondition, gw *serviceapis.Gateway, l serviceapis.Listener) *gatewayListener {
+	protocol := serviceapis.HTTPProcotol
+	if l.Protocol != nil {
+		protocol = *l.Protocol
+	}
+
+	if l.Extension != nil {
+		cond.AddErrorf("ListenerError", "UnsupportedExtension",
+			"listener %q: extensions are not supported", l.Name)
+		return nil
+	}
+
+	switch protocol {
+	case serviceapis.HTTPProcotol:
+		return &gatewayListener{}
+	case serviceapis.HTTPSProcotol:
+		if l.TLS == nil || len(l.TLS.Certificates) == 0 {
+			cond.AddErrorf("ListenerError", "TLSNotConfigured",
+				"listener %q: HTTPS listeners must specify a TLS certificate", l.Name)
+			return nil
+		}
+
+		if len(l.TLS.Certificates) > 1 {
+			cond.AddErrorf("ListenerError", "TooManyCertificates",
+				"listener %q: only one TLS certificate is supported", l.Name)
+			return nil
+		}
+
+		ref := l.TLS.Certificates[0]
+		if ref.Kind != "Secret" || (ref.APIGroup != nil && *ref.APIGroup != "") {
+			cond.AddErrorf("ListenerError", "UnsupportedCertificateKind",
+				"listener %q: TLS certificate must reference a Secret", l.Name)
+			return nil
+		}
+
+		secretName := types.NamespacedName{Namespace: gw.Namespace, Name: ref.Name}
+		sec, err := p.source.LookupSecret(secretName, validSecret)
+		if err != nil {
+			cond.AddErrorf("ListenerError", "SecretNotValid",
+				"listener %q: TLS Secret %q is invalid: %s", l.Name, secretName, err)
+			return nil
+		}
+
+		var minVersion string
+		if l.TLS.MinimumVersion != nil {
+			minVersion = strings.TrimPrefix(strings.Replace(*l.TLS.MinimumVersion, "_", ".", 1), "TLS")
+		}
+
+		return &gatewayListener{
+			secure: true,
+			secret: sec,
+			// default to a minimum TLS version of 1.2 if it's not specified
+			minTLSVersion: annotation.MinTLSVersion(minVersion, envoy_api_v2_auth.TlsParameters_TLSv1_2),
+		}
+	default:
+		cond.AddErrorf("ListenerError", "UnsupportedProtocol",
+			"listener %q: unsupported protocol %q", l.Name, protocol)
+		return nil
+	}
+}
+
+// addHTTPRoutes attaches the given routes, keyed by hostname, to the
+// virtual hosts served by the listener.
+func (p *GatewayProcessor) addHTTPRoutes(l *gatewayListener, routes map[string][]*Route) {
+	for host, hostRoutes := range routes {
+		if !l.secure {
+			addRoutes(p.dag.EnsureVirtualHost(host), hostRoutes)
+			continue
+		}
+
+		// There's no SNI to match a wildcard host against.
+		if host == "*" {
+			continue
+		}
+
+		svhost := p.dag.EnsureSecureVirtualHost(host)
+		svhost.Secret = l.secret
+		svhost.MinTLSVersion = l.minTLSVersion
+		addRoutes(svhost, hostRoutes)
+	}
+}
+
+// computeHTTPRoute builds the DAG routes for an HTTPRoute, keyed by
+// hostname. If any part of the HTTPRoute is invalid, an error is
+// returned and no routes are built.
+func (p *GatewayProcessor) computeHTTPRoute(route *serviceapis.HTTPRoute) (map[string][]*Route, error) {
+	hosts := route.Spec.Hosts
+	if route.Spec.Default != nil {
+		def := *route.Spec.Default
+		def.Hostnames = []string{"*"}
+		hosts = append(hosts, def)
+	}
+
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no hosts defined")
+	}
+
+	routes := map[string][]*Route{}
+
+	for _, host := range hosts {
+		if host.Extension != nil {
+			return nil, fmt.Errorf("host extensions are not supported")
+		}
+
+		hostnames := host.Hostnames
+		if len(hostnames) == 0 {
+			hostnames = []string{"*"}
+		}
+
+		for _, hostname := range hostnames {
+			if hostname != "*" && strings.Contains(hostname, "*") {
+				return nil, fmt.Errorf("hostname %q cannot use wildcards", hostname)
+			}
+		}
+
+		for i, rule := range host.Rules {
+			r, err := p.computeHTTPRouteRule(route.Namespace, rule)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d: %w", i, err)
+			}
+
+			for _, hostname := range hostnames {
+				routes[hostname] = append(routes[hostname], r)
+			}
+		}
+	}
+
+	return routes, nil
+}
+
+// computeHTTPRouteRule builds a DAG route for a single HTTPRoute rule.
+func (p *GatewayProcessor) computeHTTPRouteRule(namespace string, rule serviceapis.HTTPRouteRule) (*Route, error) {
+	r := &Route{
+		PathMatchCondition: &PrefixMatchCondition{Prefix: "/"},
+	}
+
+	if match := rule.Match; match != nil {
+		if match.Extension != nil {
+			return nil, fmt.Errorf("match extensions are not supported")
+		}
+
+		cond, err := httpRoutePathMatchCondition(match)
+		if err != nil {
+			return nil, err
+		}
+		r.PathMatchCondition = cond
+
+		if match.HeaderType != nil && *match.HeaderType != serviceapis.HeaderTypeExact {
+			return nil, fmt.Errorf("unsupported header match type %q", *match.HeaderType)
+		}
+
+		// Sort the header names so that the generated
+		// route is stable across DAG rebuilds.
+		var names []string
+		for name := range match.Header {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			r.HeaderMatchConditions = append(r.HeaderMatchConditions, HeaderMatchCondition{
+				Name:      name,
+				Value:     match.Header[name],
+				MatchType: "exact",
+			})
+		}
+	}
+
+	if filter := rule.Filter; filter != nil {
+		if filter.Extension != nil {
+			return nil, fmt.Errorf("filter extensions are not supported")
+		}
+
+		if headers := filter.Headers; headers != nil {
+			policy := &contour_api_v1.HeadersPolicy{
+				Remove: headers.Remove,
+			}
+			for name, value := range headers.Add {
+				policy.Set = append(policy.Set, contour_api_v1.HeaderValue{Name: name, Value: value})
+			}
+
+			reqHP, err := headersPolicyRoute(policy, true /* allow Host */)
+			if err != nil {
+				return nil, fmt.Errorf("%s on request headers", err)
+			}
+			r.RequestHeadersPolicy = reqHP
+		}
+	}
+
+	if rule.Action == nil || rule.Action.ForwardTo == nil {
+		return nil, fmt.Errorf("action must specify forwardTo")
+	}
+
+	if rule.Action.Extension != nil {
+		return nil, fmt.Errorf("action extensions are not supported")
+	}
+
+	ref := rule.Action.ForwardTo
+	if ref.Kind != "Service" || (ref.APIGroup != nil && *ref.APIGroup != "") {
+		return nil, fmt.Errorf("forwardTo must reference a Service")
+	}
+
+	s, err := p.ensureService(types.NamespacedName{Namespace: namespace, Name: ref.Name})
+	if err != nil {
+		return nil, err
+	}
+
+	tp, _ := timeoutPolicy(nil)
+	r.TimeoutPolicy = tp
+	r.Clusters = []*Cluster{{
+		Upstream: s,
+		Protocol: s.Protocol,
+		SNI:      determineSNI(r.RequestHeadersPolicy, nil, s),
+	}}
+
+	return r, nil
+}
+
+// ensureService adds the Service referenced by an HTTPRoute action
+// to the DAG. The reference doesn't carry a port, so the Service must
+// expose exactly one port.
+func (p *GatewayProcessor) ensureService(name types.NamespacedName) (*Service, error) {
+	svc, ok := p.source.services[name]
+	if !ok {
+		return nil, fmt.Errorf("service %q not found", name)
+	}
+
+	if len(svc.Spec.Ports) != 1 {
+		return nil, fmt.Errorf("service %q must have exactly one port", name)
+	}
+
+	return p.dag.EnsureService(k8s.NamespacedNameOf(svc), intstr.FromInt(int(svc.Spec.Ports[0].Port)), p.source)
+}
+
+// httpRoutePathMatchCondition converts the path match of an HTTPRoute
+// rule to a DAG MatchCondition.
+func httpRoutePathMatchCondition(match *serviceapis.HTTPRouteMatch) (MatchCondition, error) {
+	path := "/"
+	if match.Path != nil {
+		path = *match.Path
+	}
+
+	switch match.PathType {
+	case "", serviceapis.PathTypePrefix, serviceapis.PathTypeImplementionSpecific:
+		if !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("prefix path %q must start with /", path)
+		}
+		return &PrefixMatchCondition{Prefix: path}, nil
+	case serviceapis.PathTypeExact:
+		if !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("exact path %q must start with /", path)
+		}
+		// Envoy regex matches must match the whole path,
+		// so a quoted regex is an exact match.
+		return &RegexMatchCondition{Regex: regexp.QuoteMeta(path)}, nil
+	case serviceapis.PathTypeRegularExpression:
+		if _, err := regexp.Compile(path); err != nil {
+			return nil, fmt.Errorf("invalid path regex %q: %s", path, err)
+		}
+		return &RegexMatchCondition{Regex: path}, nil
+	default:
+		return nil, fmt.Errorf("unsupported path match type %q", match.PathType)
+	}
+}
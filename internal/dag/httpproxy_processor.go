@@ -16,6 +16,7 @@ package dag
 import (
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	envoy_api_v2_auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
@@ -69,6 +70,18 @@ type HTTPProxyProcessor struct {
 	// ClientCertificate is the optional identifier of the TLS secret containing client certificate and
 	// private key to be used when establishing TLS connection to upstream cluster.
 	ClientCertificate *types.NamespacedName
+
+	// ConnectTimeout is the default timeout for establishing
+	// connections to upstream clusters.
+	ConnectTimeout timeout.Setting
+
+	// UpstreamIdleTimeout is the default timeout after which
+	// idle connections to upstream clusters are closed.
+	UpstreamIdleTimeout timeout.Setting
+
+	// Listeners holds the additional named listeners that
+	// virtual hosts can be attached to.
+	Listeners []NamedListener
 }
 
 // Run translates HTTPProxies into DAG objects and
@@ -141,6 +154,27 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *contour_api_v1.HTTPProxy) {
 		return
 	}
 
+	var listener *NamedListener
+	if name := proxy.Spec.VirtualHost.Listener; name != "" {
+		listener = p.namedListener(name)
+		if listener == nil {
+			validCond.AddErrorf("ListenerError", "ListenerNotFound",
+				"Spec.VirtualHost.Listener %q is not configured", name)
+			return
+		}
+
+		switch {
+		case listener.Protocol == ListenerProtocolHTTPS && proxy.Spec.VirtualHost.TLS == nil:
+			validCond.AddErrorf("ListenerError", "TLSMustBeConfigured",
+				"Spec.VirtualHost.Listener %q requires that Spec.VirtualHost.TLS be set", name)
+			return
+		case listener.Protocol == ListenerProtocolHTTP && proxy.Spec.VirtualHost.TLS != nil:
+			validCond.AddErrorf("ListenerError", "TLSNotPermitted",
+				"Spec.VirtualHost.Listener %q cannot be used with Spec.VirtualHost.TLS", name)
+			return
+		}
+	}
+
 	var tlsEnabled bool
 	if tls := proxy.Spec.VirtualHost.TLS; tls != nil {
 		if !isBlank(tls.SecretName) && tls.Passthrough {
@@ -202,6 +236,14 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *contour_api_v1.HTTPProxy) {
 				return
 			}
 
+			// Fallback certificates and rate limiting are
+			// incompatible for the same reason.
+			if tls.EnableFallbackCertificate && proxy.Spec.VirtualHost.RateLimitPolicy != nil {
+				validCond.AddError("TLSError", "TLSIncompatibleFeatures",
+					"Spec.Virtualhost.TLS fallback & rate limiting are incompatible")
+				return
+			}
+
 			// If FallbackCertificate is enabled, but no cert passed, set error
 			if tls.EnableFallbackCertificate {
 				if p.FallbackCertificate == nil {
@@ -274,6 +316,57 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *contour_api_v1.HTTPProxy) {
 		}
 	}
 
+	if tlsEnabled {
+		p.dag.EnsureSecureVirtualHost(host).ListenerName = proxy.Spec.VirtualHost.Listener
+	}
+
+	var rlService *RateLimitService
+	var rlPolicy *RateLimitPolicy
+
+	if rl := proxy.Spec.VirtualHost.RateLimitPolicy; rl != nil {
+		ref := defaultExtensionRef(rl.ExtensionServiceRef)
+
+		if ref.APIVersion != contour_api_v1alpha1.GroupVersion.String() {
+			validCond.AddErrorf("RateLimitError", "RateLimitBadResourceVersion",
+				"Spec.Virtualhost.RateLimitPolicy.extensionRef specifies an unsupported resource version %q", rl.ExtensionServiceRef.APIVersion)
+			return
+		}
+
+		// Lookup the extension service reference.
+		extensionName := types.NamespacedName{
+			Name:      ref.Name,
+			Namespace: stringOrDefault(ref.Namespace, proxy.Namespace),
+		}
+
+		ext := p.dag.GetExtensionCluster(extensionClusterName(extensionName))
+		if ext == nil {
+			validCond.AddErrorf("RateLimitError", "ExtensionServiceNotFound",
+				"Spec.Virtualhost.RateLimitPolicy.extensionRef extension service %q not found", extensionName)
+			return
+		}
+
+		timeout, err := timeout.Parse(rl.ResponseTimeout)
+		if err != nil {
+			validCond.AddErrorf("RateLimitError", "RateLimitResponseTimeoutInvalid",
+				"Spec.Virtualhost.RateLimitPolicy.ResponseTimeout is invalid: %s", err)
+			return
+		}
+
+		rlPolicy, err = rateLimitPolicy(rl.Descriptors)
+		if err != nil {
+			validCond.AddErrorf("RateLimitError", "DescriptorsNotValid",
+				"Spec.Virtualhost.RateLimitPolicy.Descriptors is invalid: %s", err)
+			return
+		}
+
+		rlService = &RateLimitService{
+			ExtensionService: ext,
+			Domain:           stringOrDefault(rl.Domain, "contour"),
+			ResponseTimeout:  timeout,
+			FailOpen:         rl.FailOpen,
+		}
+	}
+
 	if proxy.Spec.TCPProxy != nil {
 		if !tlsEnabled {
 			validCond.AddError("TCPProxyError", "TLSMustBeConfigured",
@@ -286,25 +379,63 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *contour_api_v1.HTTPProxy) {
 	}
 
 	routes := p.computeRoutes(validCond, proxy, proxy, nil, nil, tlsEnabled)
-	insecure := p.dag.EnsureVirtualHost(host)
 	cp, err := toCORSPolicy(proxy.Spec.VirtualHost.CORSPolicy)
 	if err != nil {
 		validCond.AddErrorf("CORSError", "PolicyDidNotParse",
 			"Spec.VirtualHost.CORSPolicy: %s", err)
 		return
 	}
-	insecure.CORSPolicy = cp
-	addRoutes(insecure, routes)
+
+	tp, err := toTracingPolicy(proxy.Spec.VirtualHost.TracingPolicy)
+	if err != nil {
+		validCond.AddErrorf("TracingError", "PolicyDidNotParse",
+			"Spec.VirtualHost.TracingPolicy: %s", err)
+		return
+	}
+
+	// A virtual host attached to an HTTPS listener is only
+	// reachable over TLS, so there are no insecure routes to
+	// redirect to it.
+	if listener == nil || listener.Protocol == ListenerProtocolHTTP {
+		insecure := p.dag.EnsureVirtualHost(host)
+		insecure.ListenerName = proxy.Spec.VirtualHost.Listener
+		insecure.CORSPolicy = cp
+		insecure.RateLimitService = rlService
+		insecure.RateLimitPolicy = rlPolicy
+		insecure.TracingPolicy = tp
+		addRoutes(insecure, routes)
+	}
 
 	// if TLS is enabled for this virtual host and there is no tcp proxy defined,
 	// then add routes to the secure virtualhost definition.
 	if tlsEnabled && proxy.Spec.TCPProxy == nil {
 		secure := p.dag.EnsureSecureVirtualHost(host)
 		secure.CORSPolicy = cp
+		secure.RateLimitPolicy = rlPolicy
+		secure.TracingPolicy = tp
+		if rlService != nil {
+			// The secure virtual host has its own HTTP
+			// connection manager, so give it a separate
+			// copy of the service whose stage is not
+			// shared with the insecure virtual host.
+			rls := *rlService
+			secure.RateLimitService = &rls
+		}
 		addRoutes(secure, routes)
 	}
 }
 
+// namedListener returns the additional listener with
+// the given name, or nil if no such listener exists.
+func (p *HTTPProxyProcessor) namedListener(name string) *NamedListener {
+	for i := range p.Listeners {
+		if p.Listeners[i].Name == name {
+			return &p.Listeners[i]
+		}
+	}
+	return nil
+}
+
 type vhost interface {
 	addRoute(*Route)
 }
@@ -373,6 +504,12 @@ func (p *HTTPProxyProcessor) computeRoutes(
 			return nil
 		}
 
+		if err := includeMatchConditionsValid(include.Conditions); err != nil {
+			validCond.AddErrorf("IncludeError", "PathMatchConditionsNotValid",
+				"include: %s", err)
+			return nil
+		}
+
 		inc, incCommit := p.dag.StatusCache.ProxyAccessor(includedProxy)
 		incValidCond := inc.ConditionFor(status.ValidCondition)
 		routes = append(routes, p.computeRoutes(incValidCond, rootProxy, includedProxy, append(conditions, include.Conditions...), visited, enforceTLS)...)
@@ -391,6 +528,14 @@ func (p *HTTPProxyProcessor) computeRoutes(
 
 		conds := append(conditions, route.Conditions...)
 
+		// Look for path conditions on this route that
+		// can't be merged with the inherited prefix.
+		if err := mergedPathMatchConditionsValid(conds); err != nil {
+			validCond.AddErrorf("RouteError", "PathMatchConditionsNotValid",
+				"route: %s", err)
+			return nil
+		}
+
 		// Look for invalid header conditions on this route
 		if err := headerMatchConditionsValid(conds); err != nil {
 			validCond.AddError("RouteError", "HeaderMatchConditionsNotValid",
@@ -398,6 +543,13 @@ func (p *HTTPProxyProcessor) computeRoutes(
 			return nil
 		}
 
+		// Look for invalid query parameter conditions on this route
+		if err := queryParamMatchConditionsValid(conds); err != nil {
+			validCond.AddError("RouteError", "QueryParameterMatchConditionsNotValid",
+				err.Error())
+			return nil
+		}
+
 		reqHP, err := headersPolicyRoute(route.RequestHeadersPolicy, true /* allow Host */)
 		if err != nil {
 			validCond.AddErrorf("RouteError", "RequestHeadersPolicyInvalid",
@@ -412,12 +564,30 @@ func (p *HTTPProxyProcessor) computeRoutes(
 			return nil
 		}
 
-		if len(route.Services) < 1 {
+		if len(route.Services) < 1 && route.RequestRedirectPolicy == nil && route.DirectResponsePolicy == nil {
 			validCond.AddError("RouteError", "NoServicesPresent",
 				"route.services must have at least one entry")
 			return nil
 		}
 
+		if len(route.Services) > 0 && route.RequestRedirectPolicy != nil {
+			validCond.AddError("RouteError", "ServicesAndRedirect",
+				"route.services cannot be specified with route.requestRedirectPolicy")
+			return nil
+		}
+
+		if len(route.Services) > 0 && route.DirectResponsePolicy != nil {
+			validCond.AddError("RouteError", "ServicesAndDirectResponse",
+				"route.services cannot be specified with route.directResponsePolicy")
+			return nil
+		}
+
+		if route.RequestRedirectPolicy != nil && route.DirectResponsePolicy != nil {
+			validCond.AddError("RouteError", "RedirectAndDirectResponse",
+				"route.requestRedirectPolicy cannot be specified with route.directResponsePolicy")
+			return nil
+		}
+
 		tp, err := timeoutPolicy(route.TimeoutPolicy)
 		if err != nil {
 			validCond.AddErrorf("RouteError", "TimeoutPolicyNotValid",
@@ -425,15 +595,36 @@ func (p *HTTPProxyProcessor) computeRoutes(
 			return nil
 		}
 
+		ctp, err := clusterTimeoutPolicy(route.TimeoutPolicy, p.ConnectTimeout, p.UpstreamIdleTimeout)
+		if err != nil {
+			validCond.AddErrorf("RouteError", "TimeoutPolicyNotValid",
+				"route.timeoutPolicy failed to parse: %s", err)
+			return nil
+		}
+
+		if err := healthCheckPolicyIsValid(route.HealthCheckPolicy); err != nil {
+			validCond.AddErrorf("RouteError", "HealthCheckPolicyNotValid",
+				"route.healthCheckPolicy is invalid: %s", err)
+			return nil
+		}
+
+		hhc, err := httpHealthCheckPolicy(route.HealthCheckPolicy)
+		if err != nil {
+			validCond.AddErrorf("RouteError", "HealthCheckPolicyNotValid",
+				"route.healthCheckPolicy is invalid: %s", err)
+			return nil
+		}
+
 		r := &Route{
-			PathMatchCondition:    mergePathMatchConditions(conds),
-			HeaderMatchConditions: mergeHeaderMatchConditions(conds),
-			Websocket:             route.EnableWebsockets,
-			HTTPSUpgrade:          routeEnforceTLS(enforceTLS, route.PermitInsecure && !p.DisablePermitInsecure),
-			TimeoutPolicy:         tp,
-			RetryPolicy:           retryPolicy(route.RetryPolicy),
-			RequestHeadersPolicy:  reqHP,
-			ResponseHeadersPolicy: respHP,
+			PathMatchCondition:        mergePathMatchConditions(conds),
+			HeaderMatchConditions:     mergeHeaderMatchConditions(conds),
+			QueryParamMatchConditions: mergeQueryParamMatchConditions(conds),
+			Websocket:                 route.EnableWebsockets,
+			HTTPSUpgrade:              routeEnforceTLS(enforceTLS, route.PermitInsecure && !p.DisablePermitInsecure),
+			TimeoutPolicy:             tp,
+			RetryPolicy:               retryPolicy(route.RetryPolicy),
+			RequestHeadersPolicy:      reqHP,
+			ResponseHeadersPolicy:     respHP,
 		}
 
 		// If the enclosing root proxy enabled authorization,
@@ -458,6 +649,41 @@ func (p *HTTPProxyProcessor) computeRoutes(
 			r.AuthContext = route.AuthorizationContext(rootProxy.Spec.VirtualHost.AuthorizationContext())
 		}
 
+		// Route rate limit descriptors are sent to the rate
+		// limit service of the enclosing root proxy, so there
+		// must be one.
+		if route.RateLimitPolicy != nil {
+			if rootProxy.Spec.VirtualHost.RateLimitPolicy == nil {
+				validCond.AddError("RouteError", "RateLimitServiceNotConfigured",
+					"route.rateLimitPolicy requires the root HTTPProxy to configure a rate limit service")
+				return nil
+			}
+
+			rlp, err := rateLimitPolicy(route.RateLimitPolicy.Descriptors)
+			if err != nil {
+				validCond.AddErrorf("RouteError", "RateLimitPolicyNotValid",
+					"route.rateLimitPolicy is invalid: %s", err)
+				return nil
+			}
+			r.RateLimitPolicy = rlp
+		}
+
+		if rp := route.PathRewritePolicy; rp != nil && rp.RegexRewrite != nil {
+			if len(rp.ReplacePrefix) > 0 {
+				validCond.AddError("PathRewriteError", "RegexAndPrefixReplacement",
+					"cannot specify both a regex rewrite and prefix replacements")
+				return nil
+			}
+
+			rr, err := regexRewritePolicy(rp.RegexRewrite)
+			if err != nil {
+				validCond.AddErrorf("PathRewriteError", "RegexRewriteNotValid",
+					"pathRewritePolicy.regexRewrite is invalid: %s", err)
+				return nil
+			}
+			r.RegexRewrite = rr
+		}
+
 		if len(route.GetPrefixReplacements()) > 0 {
 			if !r.HasPathPrefix() {
 				validCond.AddError("PrefixReplaceError", "MustHavePrefix",
@@ -496,6 +722,61 @@ func (p *HTTPProxyProcessor) computeRoutes(
 
 		}
 
+		if route.RequestRedirectPolicy != nil {
+			redirect, err := redirectPolicy(route.RequestRedirectPolicy)
+			if err != nil {
+				validCond.AddErrorf("RouteError", "RequestRedirectPolicyNotValid",
+					"route.requestRedirectPolicy is invalid: %s", err)
+				return nil
+			}
+
+			if redirect.PrefixRewrite != "" && !r.HasPathPrefix() {
+				validCond.AddError("RouteError", "RequestRedirectPolicyNotValid",
+					"route.requestRedirectPolicy cannot specify a prefix without a prefix condition")
+				return nil
+			}
+
+			r.Redirect = redirect
+		}
+
+		if route.DirectResponsePolicy != nil {
+			direct, err := directResponsePolicy(route.DirectResponsePolicy)
+			if err != nil {
+				validCond.AddErrorf("RouteError", "DirectResponsePolicyNotValid",
+					"route.directResponsePolicy is invalid: %s", err)
+				return nil
+			}
+
+			r.DirectResponse = direct
+		}
+
+		fip, err := faultInjectionPolicy(route.FaultInjectionPolicy)
+		if err != nil {
+			validCond.AddErrorf("RouteError", "FaultInjectionPolicyNotValid",
+				"route.faultInjectionPolicy is invalid: %s", err)
+			return nil
+		}
+		r.FaultInjectionPolicy = fip
+
+		rhp, err := requestHashPolicies(route.LoadBalancerPolicy)
+		if err != nil {
+			validCond.AddErrorf("RouteError", "RequestHashPolicyNotValid",
+				"route.loadBalancerPolicy.requestHashPolicies is invalid: %s", err)
+			return nil
+		}
+		r.RequestHashPolicies = rhp
+
+		rhc, err := ringHashPolicy(route.LoadBalancerPolicy)
+		if err != nil {
+			validCond.AddErrorf("RouteError", "RingHashNotValid",
+				"route.loadBalancerPolicy.ringHash is invalid: %s", err)
+			return nil
+		}
+
+		// mirrors records the services that this route mirrors
+		// to, since mirroring a service twice doubles its traffic.
+		mirrors := map[types.NamespacedName]map[int]bool{}
+
 		for _, service := range route.Services {
 			if service.Port < 1 || service.Port > 65535 {
 				validCond.AddErrorf("ServiceError", "ServicePortInvalid",
@@ -517,6 +798,14 @@ func (p *HTTPProxyProcessor) computeRoutes(
 				return nil
 			}
 
+			// gRPC health checks are sent over HTTP/2, so the
+			// service must use an HTTP/2 protocol.
+			if hc := route.HealthCheckPolicy; hc != nil && hc.GRPC != nil && protocol != "h2" && protocol != "h2c" {
+				validCond.AddErrorf("ServiceError", "GRPCHealthCheckNotSupported",
+					"service %q: gRPC health checks require the h2 or h2c protocol", service.Name)
+				return nil
+			}
+
 			var uv *PeerValidationContext
 			if protocol == "tls" || protocol == "h2" {
 				// we can only validate TLS connections to services that talk TLS
@@ -542,6 +831,20 @@ func (p *HTTPProxyProcessor) computeRoutes(
 				return nil
 			}
 
+			odp, err := outlierDetectionPolicy(service.OutlierDetection)
+			if err != nil {
+				validCond.AddErrorf("ServiceError", "OutlierDetectionNotValid",
+					"service %q: outlierDetection is invalid: %s", service.Name, err)
+				return nil
+			}
+
+			cbp, err := circuitBreakerPolicy(service.CircuitBreakerPolicy)
+			if err != nil {
+				validCond.AddErrorf("ServiceError", "CircuitBreakerPolicyNotValid",
+					"service %q: circuitBreakerPolicy is invalid: %s", service.Name, err)
+				return nil
+			}
+
 			var clientCertSecret *Secret
 			if p.ClientCertificate != nil {
 				clientCertSecret, err = p.source.LookupSecret(*p.ClientCertificate, validSecret)
@@ -553,27 +856,52 @@ func (p *HTTPProxyProcessor) computeRoutes(
 			}
 
 			c := &Cluster{
-				Upstream:              s,
-				LoadBalancerPolicy:    loadBalancerPolicy(route.LoadBalancerPolicy),
-				Weight:                uint32(service.Weight),
-				HTTPHealthCheckPolicy: httpHealthCheckPolicy(route.HealthCheckPolicy),
-				UpstreamValidation:    uv,
-				RequestHeadersPolicy:  reqHP,
-				ResponseHeadersPolicy: respHP,
-				Protocol:              protocol,
-				SNI:                   determineSNI(r.RequestHeadersPolicy, reqHP, s),
-				DNSLookupFamily:       p.DNSLookupFamily,
-				ClientCertificate:     clientCertSecret,
-			}
-			if service.Mirror && r.MirrorPolicy != nil {
-				validCond.AddError("ServiceError", "OnlyOneMirror",
-					"only one service per route may be nominated as mirror")
-				return nil
+				Upstream:               s,
+				LoadBalancerPolicy:     loadBalancerPolicy(route.LoadBalancerPolicy),
+				RingHashPolicy:         rhc,
+				Weight:                 uint32(service.Weight),
+				HTTPHealthCheckPolicy:  hhc,
+				GRPCHealthCheckPolicy:  grpcHealthCheckPolicy(route.HealthCheckPolicy),
+				OutlierDetectionPolicy: odp,
+				ClusterTimeoutPolicy:   ctp,
+				CircuitBreakerPolicy:   cbp,
+				UpstreamValidation:     uv,
+				RequestHeadersPolicy:   reqHP,
+				ResponseHeadersPolicy:  respHP,
+				Protocol:               protocol,
+				SNI:                    determineSNI(r.RequestHeadersPolicy, reqHP, s),
+				DNSLookupFamily:        p.DNSLookupFamily,
+				ClientCertificate:      clientCertSecret,
 			}
 			if service.Mirror {
-				r.MirrorPolicy = &MirrorPolicy{
-					Cluster: c,
+				// The weight of a mirror is the percentage of
+				// requests that are mirrored to it. Mirrors
+				// without a weight receive every request.
+				if service.Weight > 100 {
+					validCond.AddErrorf("ServiceError", "MirrorWeightNotValid",
+						"service %q: mirror weight %d must be in the range 0-100", service.Name, service.Weight)
+					return nil
+				}
+
+				if mirrors[m][service.Port] {
+					validCond.AddErrorf("ServiceError", "DuplicateMirror",
+						"service %q: port %d is already a mirror of this route", service.Name, service.Port)
+					return nil
+				}
+				if mirrors[m] == nil {
+					mirrors[m] = map[int]bool{}
 				}
+				mirrors[m][service.Port] = true
+
+				weight := uint32(service.Weight)
+				if weight == 0 {
+					weight = 100
+				}
+
+				r.MirrorPolicies = append(r.MirrorPolicies, MirrorPolicy{
+					Cluster: c,
+					Weight:  weight,
+				})
 			} else {
 				r.Clusters = append(r.Clusters, c)
 			}
@@ -613,6 +941,19 @@ func (p *HTTPProxyProcessor) processHTTPProxyTCPProxy(validCond *contour_api_v1.
 	}
 
 	if len(tcpproxy.Services) > 0 {
+		if tcpproxy.LoadBalancerPolicy != nil && len(tcpproxy.LoadBalancerPolicy.RequestHashPolicies) > 0 {
+			validCond.AddError("TCPProxyError", "RequestHashPolicyNotSupported",
+				"tcpproxy.loadBalancerPolicy.requestHashPolicies is not supported")
+			return false
+		}
+
+		rhc, err := ringHashPolicy(tcpproxy.LoadBalancerPolicy)
+		if err != nil {
+			validCond.AddErrorf("TCPProxyError", "RingHashNotValid",
+				"tcpproxy.loadBalancerPolicy.ringHash is invalid: %s", err)
+			return false
+		}
+
 		var proxy TCPProxy
 		for _, service := range httpproxy.Spec.TCPProxy.Services {
 			m := types.NamespacedName{Name: service.Name, Namespace: httpproxy.Namespace}
@@ -622,11 +963,30 @@ func (p *HTTPProxyProcessor) processHTTPProxyTCPProxy(validCond *contour_api_v1.
 					"Spec.TCPProxy unresolved service reference: %s", err)
 				return false
 			}
+			odp, err := outlierDetectionPolicy(service.OutlierDetection)
+			if err != nil {
+				validCond.AddErrorf("TCPProxyError", "OutlierDetectionNotValid",
+					"service %q: outlierDetection is invalid: %s", service.Name, err)
+				return false
+			}
+			cbp, err := circuitBreakerPolicy(service.CircuitBreakerPolicy)
+			if err != nil {
+				validCond.AddErrorf("TCPProxyError", "CircuitBreakerPolicyNotValid",
+					"service %q: circuitBreakerPolicy is invalid: %s", service.Name, err)
+				return false
+			}
 			proxy.Clusters = append(proxy.Clusters, &Cluster{
-				Upstream:             s,
-				Protocol:             s.Protocol,
-				LoadBalancerPolicy:   loadBalancerPolicy(tcpproxy.LoadBalancerPolicy),
-				TCPHealthCheckPolicy: tcpHealthCheckPolicy(tcpproxy.HealthCheckPolicy),
+				Upstream:               s,
+				Protocol:               s.Protocol,
+				LoadBalancerPolicy:     loadBalancerPolicy(tcpproxy.LoadBalancerPolicy),
+				RingHashPolicy:         rhc,
+				TCPHealthCheckPolicy:   tcpHealthCheckPolicy(tcpproxy.HealthCheckPolicy),
+				OutlierDetectionPolicy: odp,
+				ClusterTimeoutPolicy: ClusterTimeoutPolicy{
+					ConnectTimeout: p.ConnectTimeout,
+					IdleTimeout:    p.UpstreamIdleTimeout,
+				},
+				CircuitBreakerPolicy: cbp,
 			})
 		}
 		secure := p.dag.EnsureSecureVirtualHost(host)
@@ -775,6 +1135,7 @@ func expandPrefixMatches(routes []*Route) []*Route {
 		// If there is no path prefix, we won't do any expansion, so skip it.
 		if !r.HasPathPrefix() {
 			expandedRoutes = append(expandedRoutes, r)
+			continue
 		}
 
 		routingPrefix := r.PathMatchCondition.(*PrefixMatchCondition).Prefix
@@ -901,6 +1262,19 @@ func toCORSPolicy(policy *contour_api_v1.CORSPolicy) (*CORSPolicy, error) {
 	}, nil
 }
 
+func toTracingPolicy(policy *contour_api_v1.TracingPolicy) (*TracingPolicy, error) {
+	if policy == nil || policy.SamplingRate == "" {
+		return nil, nil
+	}
+	rate, err := strconv.ParseFloat(policy.SamplingRate, 64)
+	if err != nil || rate < 0 || rate > 100 {
+		return nil, fmt.Errorf("invalid sampling rate %q", policy.SamplingRate)
+	}
+	return &TracingPolicy{
+		SamplingRate: rate,
+	}, nil
+}
+
 func toStringSlice(hvs []contour_api_v1.CORSHeaderValue) []string {
 	s := make([]string, len(hvs))
 	for i, v := range hvs {
@@ -915,7 +1289,8 @@ func includeMatchConditionsIdentical(includes []contour_api_v1.Include) bool {
 		// Now compare each include's set of conditions
 		for _, cA := range includes[i].Conditions {
 			for _, cB := range includes[j].Conditions {
-				if (cA.Prefix == cB.Prefix) && equality.Semantic.DeepEqual(cA.Header, cB.Header) {
+				if (cA.Prefix == cB.Prefix) && equality.Semantic.DeepEqual(cA.Header, cB.Header) &&
+					equality.Semantic.DeepEqual(cA.QueryParameter, cB.QueryParameter) {
 					return true
 				}
 			}
@@ -19,6 +19,7 @@ import (
 	envoy_api_v2_auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
 	"github.com/projectcontour/contour/internal/annotation"
 	"github.com/projectcontour/contour/internal/k8s"
+	"github.com/projectcontour/contour/internal/timeout"
 	"github.com/sirupsen/logrus"
 	"k8s.io/api/networking/v1beta1"
 	"k8s.io/apimachinery/pkg/types"
@@ -35,6 +36,14 @@ type IngressProcessor struct {
 	// ClientCertificate is the optional identifier of the TLS secret containing client certificate and
 	// private key to be used when establishing TLS connection to upstream cluster.
 	ClientCertificate *types.NamespacedName
+
+	// ConnectTimeout is the default timeout for establishing
+	// connections to upstream clusters.
+	ConnectTimeout timeout.Setting
+
+	// UpstreamIdleTimeout is the default timeout after which
+	// idle connections to upstream clusters are closed.
+	UpstreamIdleTimeout timeout.Setting
 }
 
 // Run translates Ingresses into DAG objects and
@@ -140,7 +149,11 @@ func (p *IngressProcessor) computeIngressRule(ing *v1beta1.Ingress, rule v1beta1
 			continue
 		}
 
-		r := route(ing, path, s, clientCertSecret, p.FieldLogger)
+		ctp := ClusterTimeoutPolicy{
+			ConnectTimeout: p.ConnectTimeout,
+			IdleTimeout:    p.UpstreamIdleTimeout,
+		}
+		r := route(ing, path, s, clientCertSecret, ctp, p.FieldLogger)
 
 		// should we create port 80 routes for this ingress
 		if annotation.TLSRequired(ing) || annotation.HTTPAllowed(ing) {
@@ -158,7 +171,7 @@ func (p *IngressProcessor) computeIngressRule(ing *v1beta1.Ingress, rule v1beta1
 }
 
 // route builds a dag.Route for the supplied Ingress.
-func route(ingress *v1beta1.Ingress, path string, service *Service, clientCertSecret *Secret, log logrus.FieldLogger) *Route {
+func route(ingress *v1beta1.Ingress, path string, service *Service, clientCertSecret *Secret, ctp ClusterTimeoutPolicy, log logrus.FieldLogger) *Route {
 	log = log.WithFields(logrus.Fields{
 		"name":      ingress.Name,
 		"namespace": ingress.Namespace,
@@ -170,9 +183,10 @@ func route(ingress *v1beta1.Ingress, path string, service *Service, clientCertSe
 		TimeoutPolicy: ingressTimeoutPolicy(ingress, log),
 		RetryPolicy:   ingressRetryPolicy(ingress, log),
 		Clusters: []*Cluster{{
-			Upstream:          service,
-			Protocol:          service.Protocol,
-			ClientCertificate: clientCertSecret,
+			Upstream:             service,
+			Protocol:             service.Protocol,
+			ClientCertificate:    clientCertSecret,
+			ClusterTimeoutPolicy: ctp,
 		}},
 	}
 
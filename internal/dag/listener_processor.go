@@ -13,26 +13,36 @@
 
 package dag
 
-import "sort"
+import (
+	"sort"
+
+	"github.com/projectcontour/contour/internal/status"
+)
 
 // ListenerProcessor adds an HTTP and an HTTPS listener to
 // the DAG if there are virtual hosts and secure virtual
-// hosts already defined as roots in the DAG.
-type ListenerProcessor struct{}
+// hosts already defined as roots in the DAG. Virtual hosts
+// that name an additional listener are added to a separate
+// listener of that name.
+type ListenerProcessor struct {
+	// Listeners holds the additional named listeners
+	// that virtual hosts can be attached to.
+	Listeners []NamedListener
+}
 
 // Run adds HTTP and HTTPS listeners to the DAG if there are
 // virtual hosts and secure virtual hosts already defined as
 // roots in the DAG.
-func (p *ListenerProcessor) Run(dag *DAG, _ *KubernetesCache) {
-	p.buildHTTPListener(dag)
+func (p *ListenerProcessor) Run(dag *DAG, cache *KubernetesCache) {
+	p.buildHTTPListener(dag, cache)
 	p.buildHTTPSListener(dag)
 }
 
-// buildHTTPListener builds a *dag.Listener for the vhosts bound to port 80.
-// The list of virtual hosts will attached to the listener will be sorted
-// by hostname.
-func (p *ListenerProcessor) buildHTTPListener(dag *DAG) {
-	var virtualhosts []Vertex
+// buildHTTPListener builds a *dag.Listener for the vhosts bound to port 80,
+// and one for the vhosts bound to each named HTTP listener. The list of
+// virtual hosts attached to each listener will be sorted by hostname.
+func (p *ListenerProcessor) buildHTTPListener(dag *DAG, cache *KubernetesCache) {
+	virtualhosts := map[string][]Vertex{}
 	var remove []Vertex
 
 	for _, root := range dag.roots {
@@ -41,7 +51,7 @@ func (p *ListenerProcessor) buildHTTPListener(dag *DAG) {
 			remove = append(remove, obj)
 
 			if obj.Valid() {
-				virtualhosts = append(virtualhosts, obj)
+				virtualhosts[obj.ListenerName] = append(virtualhosts[obj.ListenerName], obj)
 			}
 		}
 	}
@@ -51,27 +61,26 @@ func (p *ListenerProcessor) buildHTTPListener(dag *DAG) {
 		dag.RemoveRoot(r)
 	}
 
-	if len(virtualhosts) == 0 {
-		return
-	}
+	for _, l := range p.listeners(virtualhosts, ListenerProtocolHTTP, 80) {
+		sort.SliceStable(l.VirtualHosts, func(i, j int) bool {
+			return l.VirtualHosts[i].(*VirtualHost).Name < l.VirtualHosts[j].(*VirtualHost).Name
+		})
 
-	sort.SliceStable(virtualhosts, func(i, j int) bool {
-		return virtualhosts[i].(*VirtualHost).Name < virtualhosts[j].(*VirtualHost).Name
-	})
+		// Each listener has its own HTTP connection
+		// manager, so rate limit stages are not shared.
+		for _, vhost := range assignRateLimitStages(l.VirtualHosts) {
+			rateLimitStageError(dag, cache, vhost)
+		}
 
-	http := &Listener{
-		Port:         80,
-		VirtualHosts: virtualhosts,
+		dag.AddRoot(l)
 	}
-
-	dag.AddRoot(http)
 }
 
-// buildHTTPSListener builds a *dag.Listener for the vhosts bound to port 443.
-// The list of virtual hosts will attached to the listener will be sorted
-// by hostname.
+// buildHTTPSListener builds a *dag.Listener for the vhosts bound to port 443,
+// and one for the vhosts bound to each named HTTPS listener. The list of
+// virtual hosts attached to each listener will be sorted by hostname.
 func (p *ListenerProcessor) buildHTTPSListener(dag *DAG) {
-	var virtualhosts []Vertex
+	virtualhosts := map[string][]Vertex{}
 	var remove []Vertex
 
 	for _, root := range dag.roots {
@@ -80,7 +89,7 @@ func (p *ListenerProcessor) buildHTTPSListener(dag *DAG) {
 			remove = append(remove, obj)
 
 			if obj.Valid() {
-				virtualhosts = append(virtualhosts, obj)
+				virtualhosts[obj.ListenerName] = append(virtualhosts[obj.ListenerName], obj)
 			}
 		}
 	}
@@ -90,18 +99,101 @@ func (p *ListenerProcessor) buildHTTPSListener(dag *DAG) {
 		dag.RemoveRoot(r)
 	}
 
-	if len(virtualhosts) == 0 {
-		return
+	for _, l := range p.listeners(virtualhosts, ListenerProtocolHTTPS, 443) {
+		sort.SliceStable(l.VirtualHosts, func(i, j int) bool {
+			return l.VirtualHosts[i].(*SecureVirtualHost).Name < l.VirtualHosts[j].(*SecureVirtualHost).Name
+		})
+
+		dag.AddRoot(l)
+	}
+}
+
+// listeners returns a *dag.Listener for each set of virtual hosts,
+// which are keyed by the name of the listener they are attached to.
+// Virtual hosts that are not attached to a named listener are bound
+// to the default listener on port. Virtual hosts that name a listener
+// which is not configured for the given protocol are dropped.
+func (p *ListenerProcessor) listeners(virtualhosts map[string][]Vertex, protocol string, port int) []*Listener {
+	var listeners []*Listener
+
+	if vhosts := virtualhosts[""]; len(vhosts) > 0 {
+		listeners = append(listeners, &Listener{
+			Port:         port,
+			VirtualHosts: vhosts,
+		})
+	}
+
+	for _, nl := range p.Listeners {
+		vhosts := virtualhosts[nl.Name]
+		if nl.Protocol != protocol || len(vhosts) == 0 {
+			continue
+		}
+
+		listeners = append(listeners, &Listener{
+			Name:         nl.Name,
+			Address:      nl.Address,
+			Port:         nl.Port,
+			VirtualHosts: vhosts,
+		})
 	}
 
-	sort.SliceStable(virtualhosts, func(i, j int) bool {
-		return virtualhosts[i].(*SecureVirtualHost).Name < virtualhosts[j].(*SecureVirtualHost).Name
-	})
+	return listeners
+}
+
+// maxRateLimitStage is the highest rate limit filter stage
+// that Envoy supports.
+const maxRateLimitStage = 10
+
+// assignRateLimitStages gives each distinct rate limit service used by
+// the supplied virtual hosts its own rate limit filter stage. This is
+// needed because the virtual hosts share a single HTTP connection manager,
+// and rate limit actions are only sent to the filter with the same stage.
+// Since Envoy only supports a limited number of stages, rate limiting is
+// disabled on virtual hosts whose service can't be given a stage, and
+// those virtual hosts are returned.
+func assignRateLimitStages(virtualhosts []Vertex) []*VirtualHost {
+	stages := map[RateLimitService]uint32{}
+	var disabled []*VirtualHost
+
+	for _, v := range virtualhosts {
+		vhost := v.(*VirtualHost)
+		if vhost.RateLimitService == nil {
+			continue
+		}
+
+		key := *vhost.RateLimitService
+		key.Stage = 0
 
-	https := &Listener{
-		Port:         443,
-		VirtualHosts: virtualhosts,
+		stage, ok := stages[key]
+		if !ok {
+			if len(stages) > maxRateLimitStage {
+				vhost.RateLimitService = nil
+				disabled = append(disabled, vhost)
+				continue
+			}
+
+			stage = uint32(len(stages))
+			stages[key] = stage
+		}
+
+		vhost.RateLimitService.Stage = stage
 	}
 
-	dag.AddRoot(https)
+	return disabled
+}
+
+// rateLimitStageError adds an error to the Valid condition of the
+// root HTTPProxy of a virtual host whose rate limiting was disabled
+// because its rate limit service couldn't be given a stage.
+func rateLimitStageError(dag *DAG, cache *KubernetesCache, vhost *VirtualHost) {
+	for _, pu := range dag.StatusCache.GetProxyUpdates() {
+		proxy, ok := cache.httpproxies[pu.Fullname]
+		if !ok || proxy.Spec.VirtualHost == nil || proxy.Spec.VirtualHost.Fqdn != vhost.Name {
+			continue
+		}
+
+		pu.ConditionFor(status.ValidCondition).AddErrorf("RateLimitError", "TooManyRateLimitServices",
+			"Spec.Virtualhost.RateLimitPolicy: rate limiting is disabled because the listener already uses the maximum of %d rate limit services",
+			maxRateLimitStage+1)
+	}
 }
@@ -0,0 +1,215 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"path"
+	"strings"
+
+	"github.com/projectcontour/contour/internal/status"
+	"github.com/projectcontour/contour/internal/xds"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// NackProcessor reports the xDS updates that Envoy has rejected
+// against the HTTPProxies and Ingresses they were generated from.
+//
+// A rejection is attributed to an object if it rejected one of the
+// clusters, secrets or HTTPS route configurations that Contour
+// generated for the object's virtual hosts. Rejections that can't
+// be attributed are only logged by the xDS server.
+type NackProcessor struct {
+	logrus.FieldLogger
+
+	// Nacks holds the rejected xDS updates.
+	Nacks *xds.NackRecorder
+
+	// ClusterName returns the name of the Envoy cluster
+	// generated for a Cluster.
+	ClusterName func(*Cluster) string
+
+	// SecretName returns the name of the Envoy secret
+	// generated for a Secret.
+	SecretName func(*Secret) string
+}
+
+var _ Processor = &NackProcessor{}
+
+// Run adds an error to the Valid condition of each HTTPProxy that
+// a rejected xDS update is attributed to. Ingress has no status
+// conditions, so rejections attributed to an Ingress are logged.
+func (p *NackProcessor) Run(dag *DAG, cache *KubernetesCache) {
+	nacks := uniqueNacks(p.Nacks.Nacks())
+	if len(nacks) == 0 {
+		return
+	}
+
+	names := p.resourceNames(dag)
+
+	updates := map[types.NamespacedName]*status.ProxyUpdate{}
+	for _, pu := range dag.StatusCache.GetProxyUpdates() {
+		updates[pu.Fullname] = pu
+	}
+
+	for name, proxy := range cache.httpproxies {
+		pu, ok := updates[name]
+		if !ok || proxy.Spec.VirtualHost == nil {
+			continue
+		}
+
+		for _, n := range nacks {
+			if rejectsAny(n, names[proxy.Spec.VirtualHost.Fqdn]) {
+				pu.ConditionFor(status.ValidCondition).AddErrorf("EnvoyError", "UpdateRejected",
+					"Envoy rejected the %s update: %s", typeName(n.TypeURL), n.Message)
+			}
+		}
+	}
+
+	for name, ing := range cache.ingresses {
+		hosts := map[string]bool{}
+		if ing.Spec.Backend != nil {
+			hosts["*"] = true
+		}
+		for _, rule := range ing.Spec.Rules {
+			host := rule.Host
+			if host == "" {
+				host = "*"
+			}
+			hosts[host] = true
+		}
+
+		for _, n := range nacks {
+			for host := range hosts {
+				if rejectsAny(n, names[host]) {
+					p.WithField("name", name.Name).
+						WithField("namespace", name.Namespace).
+						WithField("type_url", n.TypeURL).
+						Errorf("Envoy rejected the Ingress configuration: %s", n.Message)
+					break
+				}
+			}
+		}
+	}
+}
+
+// resourceNames returns the names of the Envoy resources that are
+// generated for each virtual host, indexed by host name. The route
+// configurations of insecure virtual hosts are shared by all the
+// hosts of a listener, so they are not included.
+func (p *NackProcessor) resourceNames(dag *DAG) map[string]map[string]bool {
+	names := map[string]map[string]bool{}
+
+	add := func(host string, name string) {
+		if names[host] == nil {
+			names[host] = map[string]bool{}
+		}
+		names[host][name] = true
+	}
+
+	var visit func(host string, v Vertex)
+	visit = func(host string, v Vertex) {
+		switch v := v.(type) {
+		case *Cluster:
+			if p.ClusterName != nil {
+				add(host, p.ClusterName(v))
+			}
+		case *Secret:
+			if p.SecretName != nil {
+				add(host, p.SecretName(v))
+			}
+		}
+
+		v.Visit(func(v Vertex) {
+			visit(host, v)
+		})
+	}
+
+	dag.Visit(func(v Vertex) {
+		switch v := v.(type) {
+		case *VirtualHost:
+			visit(v.Name, v)
+		case *SecureVirtualHost:
+			add(v.Name, path.Join("https", v.Name))
+			visit(v.Name, v)
+		}
+	})
+
+	return names
+}
+
+// rejectsAny returns true if the Nack rejected any of the named
+// resources.
+//
+// A request for a single named resource, as Envoy makes for each
+// route configuration and secret when it doesn't use ADS, identifies
+// the rejected resource. Otherwise, the resources are identified by
+// Envoy's error message, which lists the rejected clusters and
+// listeners as "Error adding/updating cluster(s) name1: error1, ...".
+func rejectsAny(n xds.Nack, names map[string]bool) bool {
+	if len(n.ResourceNames) == 1 {
+		return names[n.ResourceNames[0]]
+	}
+
+	const prefix = "Error adding/updating "
+	if !strings.HasPrefix(n.Message, prefix) {
+		return false
+	}
+
+	i := strings.Index(n.Message, "(s) ")
+	if i < 0 {
+		return false
+	}
+
+	for _, entry := range strings.Split(n.Message[i+len("(s) "):], ", ") {
+		if j := strings.Index(entry, ": "); j > 0 && names[entry[:j]] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// uniqueNacks returns the first of each set of Nacks that rejected
+// the same resources with the same error message, so that an update
+// that was rejected by several Envoy nodes is only reported once.
+func uniqueNacks(nacks []xds.Nack) []xds.Nack {
+	type key struct {
+		typeURL, resources, message string
+	}
+
+	seen := map[key]bool{}
+	var unique []xds.Nack
+
+	for _, n := range nacks {
+		k := key{
+			typeURL:   n.TypeURL,
+			resources: strings.Join(n.ResourceNames, ","),
+			message:   n.Message,
+		}
+		if seen[k] {
+			continue
+		}
+
+		seen[k] = true
+		unique = append(unique, n)
+	}
+
+	return unique
+}
+
+// typeName returns the name of the message type in a type URL.
+func typeName(typeURL string) string {
+	return path.Base(typeURL)
+}
@@ -0,0 +1,254 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"fmt"
+	"testing"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/projectcontour/contour/internal/xds"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestNackProcessor(t *testing.T) {
+	svc := fixture.NewService("default/kuard").
+		WithPorts(v1.ServicePort{Name: "http", Port: 8080})
+
+	sec := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "secret",
+			Namespace: "default",
+		},
+		Type: v1.SecretTypeTLS,
+		Data: secretdata(fixture.CERTIFICATE, fixture.RSA_PRIVATE_KEY),
+	}
+
+	proxy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:  "default",
+			Name:       "example",
+			Generation: 3,
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// other uses the same Service as proxy, but its different
+	// load balancing policy generates a different cluster.
+	other := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:  "default",
+			Name:       "other",
+			Generation: 1,
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "other.example.com",
+				TLS: &contour_api_v1.TLS{
+					SecretName: "secret",
+				},
+			},
+			Routes: []contour_api_v1.Route{{
+				LoadBalancerPolicy: &contour_api_v1.LoadBalancerPolicy{
+					Strategy: "Random",
+				},
+				Services: []contour_api_v1.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	ingress := &v1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "ingress",
+		},
+		Spec: v1beta1.IngressSpec{
+			Rules: []v1beta1.IngressRule{{
+				Host: "ingress.example.com",
+				IngressRuleValue: v1beta1.IngressRuleValue{
+					HTTP: &v1beta1.HTTPIngressRuleValue{
+						Paths: []v1beta1.HTTPIngressPath{{
+							Backend: v1beta1.IngressBackend{
+								ServiceName: "kuard",
+								ServicePort: intstr.FromInt(8080),
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+
+	name := types.NamespacedName{Namespace: "default", Name: "example"}
+	otherName := types.NamespacedName{Namespace: "default", Name: "other"}
+
+	tests := map[string]struct {
+		nacks []xds.Nack
+		want  map[types.NamespacedName]contour_api_v1.DetailedCondition
+	}{
+		"no rejections": {
+			want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+				name:      fixture.NewValidCondition().WithGeneration(3).Valid(),
+				otherName: fixture.NewValidCondition().WithGeneration(1).Valid(),
+			},
+		},
+		"rejected cluster": {
+			nacks: []xds.Nack{{
+				NodeID:  "envoy-1",
+				TypeURL: "type.googleapis.com/envoy.api.v2.Cluster",
+				Message: "Error adding/updating cluster(s) default/kuard/8080/RoundRobin: potato",
+			}, {
+				NodeID:  "envoy-2",
+				TypeURL: "type.googleapis.com/envoy.api.v2.Cluster",
+				Message: "Error adding/updating cluster(s) default/kuard/8080/RoundRobin: potato",
+			}},
+			want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+				name: fixture.NewValidCondition().WithGeneration(3).WithError("EnvoyError", "UpdateRejected",
+					"Envoy rejected the envoy.api.v2.Cluster update: Error adding/updating cluster(s) default/kuard/8080/RoundRobin: potato"),
+				otherName: fixture.NewValidCondition().WithGeneration(1).Valid(),
+			},
+		},
+		"rejected clusters": {
+			nacks: []xds.Nack{{
+				NodeID:  "envoy-1",
+				TypeURL: "type.googleapis.com/envoy.api.v2.Cluster",
+				Message: "Error adding/updating cluster(s) other/kuard/8080/RoundRobin: potato, default/kuard/8080/Random: potato",
+			}},
+			want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+				name: fixture.NewValidCondition().WithGeneration(3).Valid(),
+				otherName: fixture.NewValidCondition().WithGeneration(1).WithError("EnvoyError", "UpdateRejected",
+					"Envoy rejected the envoy.api.v2.Cluster update: Error adding/updating cluster(s) other/kuard/8080/RoundRobin: potato, default/kuard/8080/Random: potato"),
+			},
+		},
+		"rejected route configuration": {
+			nacks: []xds.Nack{{
+				NodeID:        "envoy-1",
+				TypeURL:       "type.googleapis.com/envoy.config.route.v3.RouteConfiguration",
+				Message:       "Only unique values for domains are permitted. Duplicate entry of domain other.example.com",
+				ResourceNames: []string{"https/other.example.com"},
+			}},
+			want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+				name: fixture.NewValidCondition().WithGeneration(3).Valid(),
+				otherName: fixture.NewValidCondition().WithGeneration(1).WithError("EnvoyError", "UpdateRejected",
+					"Envoy rejected the envoy.config.route.v3.RouteConfiguration update: Only unique values for domains are permitted. Duplicate entry of domain other.example.com"),
+			},
+		},
+		"rejected secret": {
+			nacks: []xds.Nack{{
+				NodeID:        "envoy-1",
+				TypeURL:       "type.googleapis.com/envoy.api.v2.auth.Secret",
+				Message:       "potato",
+				ResourceNames: []string{"default/secret"},
+			}},
+			want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+				name: fixture.NewValidCondition().WithGeneration(3).Valid(),
+				otherName: fixture.NewValidCondition().WithGeneration(1).WithError("EnvoyError", "UpdateRejected",
+					"Envoy rejected the envoy.api.v2.auth.Secret update: potato"),
+			},
+		},
+		"unrelated rejection": {
+			nacks: []xds.Nack{{
+				NodeID:  "envoy-1",
+				TypeURL: "type.googleapis.com/envoy.api.v2.Cluster",
+				Message: "Error adding/updating cluster(s) default/kuard-canary/8080/RoundRobin, other/kuard/8080/RoundRobin: potato",
+			}, {
+				NodeID:  "envoy-1",
+				TypeURL: "type.googleapis.com/envoy.api.v2.Cluster",
+				Message: "cluster default/kuard/8080/RoundRobin is potato",
+			}, {
+				NodeID:        "envoy-1",
+				TypeURL:       "type.googleapis.com/envoy.api.v2.RouteConfiguration",
+				Message:       "Duplicate entry of domain example.com",
+				ResourceNames: []string{"ingress_http"},
+			}, {
+				NodeID:        "envoy-1",
+				TypeURL:       "type.googleapis.com/envoy.api.v2.RouteConfiguration",
+				Message:       "Duplicate entry of domain other.example.com",
+				ResourceNames: []string{"ingress_http", "https/other.example.com"},
+			}, {
+				NodeID:  "envoy-1",
+				TypeURL: "type.googleapis.com/envoy.api.v2.Listener",
+				Message: "Error adding/updating listener(s) ingress_https: potato",
+			}},
+			want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+				name:      fixture.NewValidCondition().WithGeneration(3).Valid(),
+				otherName: fixture.NewValidCondition().WithGeneration(1).Valid(),
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			nacks := &xds.NackRecorder{}
+			for _, n := range tc.nacks {
+				nacks.Nack(n)
+			}
+
+			builder := Builder{
+				Source: KubernetesCache{
+					FieldLogger: fixture.NewTestLogger(t),
+				},
+				Processors: []Processor{
+					&IngressProcessor{
+						FieldLogger: fixture.NewTestLogger(t),
+					},
+					&HTTPProxyProcessor{},
+					&NackProcessor{
+						FieldLogger: fixture.NewTestLogger(t),
+						Nacks:       nacks,
+						ClusterName: func(c *Cluster) string {
+							lb := c.LoadBalancerPolicy
+							if lb == "" {
+								lb = "RoundRobin"
+							}
+							svc := c.Upstream.Weighted
+							return fmt.Sprintf("%s/%s/%d/%s", svc.ServiceNamespace, svc.ServiceName, svc.ServicePort.Port, lb)
+						},
+						SecretName: func(s *Secret) string {
+							return s.Namespace() + "/" + s.Name()
+						},
+					},
+					&ListenerProcessor{},
+				},
+			}
+
+			builder.Source.Insert(svc)
+			builder.Source.Insert(sec)
+			builder.Source.Insert(proxy)
+			builder.Source.Insert(other)
+			builder.Source.Insert(ingress)
+
+			assert.Equal(t, tc.want, builder.Build().GetProxyStatusesTesting())
+		})
+	}
+}
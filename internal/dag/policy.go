@@ -14,8 +14,12 @@
 package dag
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -205,10 +209,89 @@ func timeoutPolicy(tp *contour_api_v1.TimeoutPolicy) (TimeoutPolicy, error) {
 	}, nil
 }
 
-func httpHealthCheckPolicy(hc *contour_api_v1.HTTPHealthCheckPolicy) *HTTPHealthCheckPolicy {
-	if hc == nil {
+// clusterTimeoutPolicy returns the cluster timeout policy for the
+// supplied configuration. Timeouts that are not set in tp use the
+// supplied connect and idle timeout defaults.
+func clusterTimeoutPolicy(tp *contour_api_v1.TimeoutPolicy, connectTimeout, idleTimeout timeout.Setting) (ClusterTimeoutPolicy, error) {
+	ctp := ClusterTimeoutPolicy{
+		ConnectTimeout: connectTimeout,
+		IdleTimeout:    idleTimeout,
+	}
+
+	if tp == nil {
+		return ctp, nil
+	}
+
+	connect, err := timeout.Parse(tp.Connect)
+	if err != nil {
+		return ClusterTimeoutPolicy{}, fmt.Errorf("error parsing connect timeout: %w", err)
+	}
+	if connect.IsDisabled() {
+		return ClusterTimeoutPolicy{}, errors.New("connect timeout cannot be disabled")
+	}
+	if !connect.UseDefault() {
+		ctp.ConnectTimeout = connect
+	}
+
+	idle, err := timeout.Parse(tp.UpstreamIdle)
+	if err != nil {
+		return ClusterTimeoutPolicy{}, fmt.Errorf("error parsing upstream idle timeout: %w", err)
+	}
+	if !idle.UseDefault() {
+		ctp.IdleTimeout = idle
+	}
+
+	return ctp, nil
+}
+
+// healthCheckPolicyIsValid returns an error if the supplied route
+// health check policy specifies both or neither of an HTTP path and
+// a gRPC health check, or if its health check port is out of range.
+func healthCheckPolicyIsValid(hc *contour_api_v1.HTTPHealthCheckPolicy) error {
+	switch {
+	case hc == nil:
 		return nil
+	case hc.GRPC != nil && hc.Path != "":
+		return errors.New("cannot specify both a path and a gRPC health check")
+	case hc.GRPC == nil && hc.Path == "":
+		return errors.New("must specify either a path or a gRPC health check")
+	case hc.Port < 0 || hc.Port > 65535:
+		return fmt.Errorf("port %d must be in the range 1-65535", hc.Port)
+	default:
+		return nil
+	}
+}
+
+// httpHealthCheckPolicy returns the HTTP health check policy for the
+// supplied configuration, or nil if it does not use an HTTP health check.
+func httpHealthCheckPolicy(hc *contour_api_v1.HTTPHealthCheckPolicy) (*HTTPHealthCheckPolicy, error) {
+	if hc == nil || hc.GRPC != nil {
+		return nil, nil
+	}
+
+	var statuses []HTTPStatusRange
+	for _, r := range hc.ExpectedStatuses {
+		// Envoy treats the range as [start, end).
+		if r.Start < 100 || r.End > 600 || r.Start >= r.End {
+			return nil, fmt.Errorf("invalid expected status range [%d, %d)", r.Start, r.End)
+		}
+		statuses = append(statuses, HTTPStatusRange{
+			Start: r.Start,
+			End:   r.End,
+		})
 	}
+
+	var headers map[string]string
+	if len(hc.RequestHeaders) > 0 {
+		hp, err := headersPolicyService(&contour_api_v1.HeadersPolicy{
+			Set: hc.RequestHeaders,
+		})
+		if err != nil {
+			return nil, err
+		}
+		headers = hp.Set
+	}
+
 	return &HTTPHealthCheckPolicy{
 		Path:               hc.Path,
 		Host:               hc.Host,
@@ -216,6 +299,28 @@ func httpHealthCheckPolicy(hc *contour_api_v1.HTTPHealthCheckPolicy) *HTTPHealth
 		Timeout:            time.Duration(hc.TimeoutSeconds) * time.Second,
 		UnhealthyThreshold: uint32(hc.UnhealthyThresholdCount),
 		HealthyThreshold:   uint32(hc.HealthyThresholdCount),
+		UnhealthyInterval:  time.Duration(hc.UnhealthyIntervalSeconds) * time.Second,
+		NoTrafficInterval:  time.Duration(hc.NoTrafficIntervalSeconds) * time.Second,
+		Port:               uint32(hc.Port),
+		ExpectedStatuses:   statuses,
+		RequestHeaders:     headers,
+	}, nil
+}
+
+func grpcHealthCheckPolicy(hc *contour_api_v1.HTTPHealthCheckPolicy) *GRPCHealthCheckPolicy {
+	if hc == nil || hc.GRPC == nil {
+		return nil
+	}
+	return &GRPCHealthCheckPolicy{
+		ServiceName:        hc.GRPC.ServiceName,
+		Authority:          hc.GRPC.Authority,
+		Interval:           time.Duration(hc.IntervalSeconds) * time.Second,
+		Timeout:            time.Duration(hc.TimeoutSeconds) * time.Second,
+		UnhealthyThreshold: uint32(hc.UnhealthyThresholdCount),
+		HealthyThreshold:   uint32(hc.HealthyThresholdCount),
+		UnhealthyInterval:  time.Duration(hc.UnhealthyIntervalSeconds) * time.Second,
+		NoTrafficInterval:  time.Duration(hc.NoTrafficIntervalSeconds) * time.Second,
+		Port:               uint32(hc.Port),
 	}
 }
 
@@ -231,6 +336,72 @@ func tcpHealthCheckPolicy(hc *contour_api_v1.TCPHealthCheckPolicy) *TCPHealthChe
 	}
 }
 
+// outlierDetectionPolicy returns the outlier detection policy for
+// the supplied configuration, or nil if there is none.
+func outlierDetectionPolicy(od *contour_api_v1.OutlierDetection) (*OutlierDetectionPolicy, error) {
+	if od == nil {
+		return nil, nil
+	}
+
+	if od.MaxEjectionPercent < 0 || od.MaxEjectionPercent > 100 {
+		return nil, fmt.Errorf("max ejection percent %d must be in the range 0-100", od.MaxEjectionPercent)
+	}
+
+	policy := &OutlierDetectionPolicy{
+		ConsecutiveServerErrors:  uint32(od.ConsecutiveServerErrors),
+		ConsecutiveGatewayErrors: uint32(od.ConsecutiveGatewayErrors),
+		MaxEjectionPercent:       uint32(od.MaxEjectionPercent),
+	}
+
+	if od.Interval != "" {
+		interval, err := time.ParseDuration(od.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing interval: %w", err)
+		}
+		policy.Interval = interval
+	}
+
+	if od.BaseEjectionTime != "" {
+		baseEjectionTime, err := time.ParseDuration(od.BaseEjectionTime)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing base ejection time: %w", err)
+		}
+		policy.BaseEjectionTime = baseEjectionTime
+	}
+
+	return policy, nil
+}
+
+// circuitBreakerPolicy returns the circuit breaker policy for the
+// supplied configuration, or nil if there is none.
+func circuitBreakerPolicy(cb *contour_api_v1.CircuitBreakerPolicy) (*CircuitBreakerPolicy, error) {
+	if cb == nil {
+		return nil, nil
+	}
+
+	thresholds := []struct {
+		name  string
+		value int64
+	}{
+		{"max connections", cb.MaxConnections},
+		{"max pending requests", cb.MaxPendingRequests},
+		{"max requests", cb.MaxRequests},
+		{"max retries", cb.MaxRetries},
+	}
+	for _, t := range thresholds {
+		if t.value < 0 || t.value > math.MaxUint32 {
+			return nil, fmt.Errorf("%s %d must be in the range 0-%d", t.name, t.value, uint32(math.MaxUint32))
+		}
+	}
+
+	return &CircuitBreakerPolicy{
+		MaxConnections:     uint32(cb.MaxConnections),
+		MaxPendingRequests: uint32(cb.MaxPendingRequests),
+		MaxRequests:        uint32(cb.MaxRequests),
+		MaxRetries:         uint32(cb.MaxRetries),
+	}, nil
+}
+
 // loadBalancerPolicy returns the load balancer strategy or
 // blank if no valid strategy is supplied.
 func loadBalancerPolicy(lbp *contour_api_v1.LoadBalancerPolicy) string {
@@ -244,11 +415,334 @@ func loadBalancerPolicy(lbp *contour_api_v1.LoadBalancerPolicy) string {
 		return "Random"
 	case "Cookie":
 		return "Cookie"
+	case "RingHash":
+		return "RingHash"
+	case "Maglev":
+		return "Maglev"
 	default:
 		return ""
 	}
 }
 
+// requestHashPolicies returns the request hash policies of the
+// supplied load balancer policy, or nil if there are none.
+func requestHashPolicies(lbp *contour_api_v1.LoadBalancerPolicy) ([]RequestHashPolicy, error) {
+	if lbp == nil || len(lbp.RequestHashPolicies) == 0 {
+		return nil, nil
+	}
+
+	switch lbp.Strategy {
+	case "RingHash", "Maglev":
+	default:
+		return nil, fmt.Errorf("strategy %q does not support request hash policies", lbp.Strategy)
+	}
+
+	var policies []RequestHashPolicy
+	for i, hp := range lbp.RequestHashPolicies {
+		policy, err := requestHashPolicy(hp)
+		if err != nil {
+			return nil, fmt.Errorf("policy %d: %w", i, err)
+		}
+		policies = append(policies, policy)
+	}
+
+	return policies, nil
+}
+
+const (
+	// defaultMinimumRingSize and maxRingSize are Envoy's default
+	// minimum and upper limit of the size of a hash ring.
+	defaultMinimumRingSize = 1024
+	maxRingSize            = 8388608
+)
+
+// ringHashPolicy returns the hash ring configuration of the
+// supplied load balancer policy, or nil if there is none.
+func ringHashPolicy(lbp *contour_api_v1.LoadBalancerPolicy) (*RingHashPolicy, error) {
+	if lbp == nil || lbp.RingHash == nil {
+		return nil, nil
+	}
+
+	switch lbp.Strategy {
+	case "RingHash", "Cookie":
+	default:
+		return nil, fmt.Errorf("strategy %q does not use a hash ring", lbp.Strategy)
+	}
+
+	policy := &RingHashPolicy{
+		MinimumRingSize: lbp.RingHash.MinimumRingSize,
+		MaximumRingSize: lbp.RingHash.MaximumRingSize,
+	}
+
+	if policy.MinimumRingSize > maxRingSize || policy.MaximumRingSize > maxRingSize {
+		return nil, fmt.Errorf("ring size must not be greater than %d", maxRingSize)
+	}
+
+	min, max := policy.MinimumRingSize, policy.MaximumRingSize
+	if min == 0 {
+		min = defaultMinimumRingSize
+	}
+	if max == 0 {
+		max = maxRingSize
+	}
+	if min > max {
+		return nil, fmt.Errorf("minimum ring size %d is greater than maximum ring size %d", min, max)
+	}
+
+	return policy, nil
+}
+
+func requestHashPolicy(hp contour_api_v1.RequestHashPolicy) (RequestHashPolicy, error) {
+	policy := RequestHashPolicy{
+		Terminal: hp.Terminal,
+	}
+	var set int
+
+	if hp.HeaderHashOptions != nil {
+		set++
+		if hp.HeaderHashOptions.HeaderName == "" {
+			return RequestHashPolicy{}, errors.New("header name must be specified")
+		}
+		policy.HeaderHashOptions = &HeaderHashOptions{
+			HeaderName: hp.HeaderHashOptions.HeaderName,
+		}
+	}
+
+	if hp.CookieHashOptions != nil {
+		set++
+		if hp.CookieHashOptions.CookieName == "" {
+			return RequestHashPolicy{}, errors.New("cookie name must be specified")
+		}
+		policy.CookieHashOptions = &CookieHashOptions{
+			CookieName: hp.CookieHashOptions.CookieName,
+			Path:       hp.CookieHashOptions.Path,
+		}
+		if hp.CookieHashOptions.TTL != "" {
+			ttl, err := time.ParseDuration(hp.CookieHashOptions.TTL)
+			if err != nil {
+				return RequestHashPolicy{}, fmt.Errorf("error parsing cookie ttl: %w", err)
+			}
+			policy.CookieHashOptions.TTL = &ttl
+		}
+	}
+
+	if hp.QueryParameterHashOptions != nil {
+		set++
+		if hp.QueryParameterHashOptions.ParameterName == "" {
+			return RequestHashPolicy{}, errors.New("query parameter name must be specified")
+		}
+		policy.QueryParameterHashOptions = &QueryParameterHashOptions{
+			ParameterName: hp.QueryParameterHashOptions.ParameterName,
+		}
+	}
+
+	if hp.HashSourceIP {
+		set++
+		policy.HashSourceIP = true
+	}
+
+	if set != 1 {
+		return RequestHashPolicy{}, errors.New("exactly one hash option must be set")
+	}
+
+	return policy, nil
+}
+
+// redirectPolicy returns the redirect for the supplied request
+// redirect policy.
+func redirectPolicy(rp *contour_api_v1.HTTPRequestRedirectPolicy) (*Redirect, error) {
+	if rp.Path != "" && rp.Prefix != "" {
+		return nil, errors.New("cannot specify both path and prefix")
+	}
+
+	switch rp.Scheme {
+	case "", "http", "https":
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q", rp.Scheme)
+	}
+
+	if rp.Port < 0 || rp.Port > 65535 {
+		return nil, fmt.Errorf("port %d must be in the range 1-65535", rp.Port)
+	}
+
+	statusCode := rp.StatusCode
+	switch statusCode {
+	case 0:
+		statusCode = http.StatusFound
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return nil, fmt.Errorf("unsupported status code %d", rp.StatusCode)
+	}
+
+	return &Redirect{
+		Scheme:        rp.Scheme,
+		Hostname:      rp.Hostname,
+		Port:          uint32(rp.Port),
+		PathRewrite:   rp.Path,
+		PrefixRewrite: rp.Prefix,
+		StatusCode:    statusCode,
+	}, nil
+}
+
+// directResponsePolicy returns the direct response for the given
+// policy.
+func directResponsePolicy(dp *contour_api_v1.HTTPDirectResponsePolicy) (*DirectResponse, error) {
+	if dp.StatusCode < 200 || dp.StatusCode > 599 {
+		return nil, fmt.Errorf("status code %d must be in the range 200-599", dp.StatusCode)
+	}
+
+	return &DirectResponse{
+		StatusCode: uint32(dp.StatusCode),
+		Body:       dp.Body,
+	}, nil
+}
+
+// faultInjectionPolicy returns the fault injection policy for the
+// supplied configuration, or nil if there is none. Percentages that
+// are not set default to 100.
+func faultInjectionPolicy(fp *contour_api_v1.FaultInjectionPolicy) (*FaultInjectionPolicy, error) {
+	if fp == nil {
+		return nil, nil
+	}
+
+	if fp.Delay == nil && fp.Abort == nil {
+		return nil, errors.New("at least one of delay or abort must be set")
+	}
+
+	policy := &FaultInjectionPolicy{
+		HeaderName: fp.HeaderName,
+	}
+
+	if fp.Delay != nil {
+		delay, err := time.ParseDuration(fp.Delay.FixedDelay)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing fixed delay: %w", err)
+		}
+
+		percentage, err := faultPercentage(fp.Delay.Percentage)
+		if err != nil {
+			return nil, fmt.Errorf("delay %w", err)
+		}
+
+		policy.Delay = &FaultDelay{
+			FixedDelay: delay,
+			Percentage: percentage,
+		}
+	}
+
+	if fp.Abort != nil {
+		if fp.Abort.StatusCode < 200 || fp.Abort.StatusCode > 599 {
+			return nil, fmt.Errorf("abort status code %d must be in the range 200-599", fp.Abort.StatusCode)
+		}
+
+		percentage, err := faultPercentage(fp.Abort.Percentage)
+		if err != nil {
+			return nil, fmt.Errorf("abort %w", err)
+		}
+
+		policy.Abort = &FaultAbort{
+			StatusCode: uint32(fp.Abort.StatusCode),
+			Percentage: percentage,
+		}
+	}
+
+	return policy, nil
+}
+
+func faultPercentage(percentage int64) (uint32, error) {
+	switch {
+	case percentage == 0:
+		return 100, nil
+	case percentage < 0 || percentage > 100:
+		return 0, fmt.Errorf("percentage %d must be in the range 1-100", percentage)
+	default:
+		return uint32(percentage), nil
+	}
+}
+
+// rateLimitPolicy returns the rate limit policy for the given
+// descriptors, or nil if there are no descriptors.
+func rateLimitPolicy(descriptors []contour_api_v1.RateLimitDescriptor) (*RateLimitPolicy, error) {
+	if len(descriptors) == 0 {
+		return nil, nil
+	}
+
+	rlp := &RateLimitPolicy{}
+
+	for i, d := range descriptors {
+		if len(d.Entries) == 0 {
+			return nil, fmt.Errorf("descriptor %d has no entries", i)
+		}
+
+		descriptor := &RateLimitDescriptor{}
+
+		for j, e := range d.Entries {
+			entry, err := rateLimitDescriptorEntry(e)
+			if err != nil {
+				return nil, fmt.Errorf("descriptor %d entry %d: %w", i, j, err)
+			}
+
+			descriptor.Entries = append(descriptor.Entries, entry)
+		}
+
+		rlp.Descriptors = append(rlp.Descriptors, descriptor)
+	}
+
+	return rlp, nil
+}
+
+func rateLimitDescriptorEntry(e contour_api_v1.RateLimitDescriptorEntry) (RateLimitDescriptorEntry, error) {
+	var entry RateLimitDescriptorEntry
+	var set int
+
+	if e.GenericKey != nil {
+		set++
+		entry.GenericKey = &GenericKeyDescriptorEntry{
+			Value: e.GenericKey.Value,
+		}
+	}
+
+	if e.RequestHeader != nil {
+		set++
+		entry.RequestHeader = &RequestHeaderDescriptorEntry{
+			HeaderName: e.RequestHeader.HeaderName,
+			Key:        e.RequestHeader.DescriptorKey,
+		}
+	}
+
+	if e.RequestHeaderValueMatch != nil {
+		set++
+
+		var conds []contour_api_v1.MatchCondition
+		for i := range e.RequestHeaderValueMatch.Headers {
+			conds = append(conds, contour_api_v1.MatchCondition{
+				Header: &e.RequestHeaderValueMatch.Headers[i],
+			})
+		}
+
+		if err := headerMatchConditionsValid(conds); err != nil {
+			return RateLimitDescriptorEntry{}, err
+		}
+
+		entry.HeaderValueMatch = &HeaderValueMatchDescriptorEntry{
+			Headers:     mergeHeaderMatchConditions(conds),
+			ExpectMatch: e.RequestHeaderValueMatch.ExpectMatch == nil || *e.RequestHeaderValueMatch.ExpectMatch,
+			Value:       e.RequestHeaderValueMatch.Value,
+		}
+	}
+
+	if e.RemoteAddress != nil {
+		set++
+		entry.RemoteAddress = &RemoteAddressDescriptorEntry{}
+	}
+
+	if set != 1 {
+		return RateLimitDescriptorEntry{}, errors.New("exactly one descriptor entry type must be set")
+	}
+
+	return entry, nil
+}
+
 func max(a, b uint32) uint32 {
 	if a > b {
 		return a
@@ -256,6 +750,33 @@ func max(a, b uint32) uint32 {
 	return b
 }
 
+// captureGroupReference matches the \N references to capture
+// groups in a regex rewrite substitution.
+var captureGroupReference = regexp.MustCompile(`\\(\d+)`)
+
+// regexRewritePolicy returns the regex rewrite for the supplied
+// policy. The pattern must be a valid RE2 regular expression, and
+// the substitution must only reference its capture groups.
+func regexRewritePolicy(rr *contour_api_v1.RegexRewrite) (*RegexRewrite, error) {
+	re, err := regexp.Compile(rr.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", rr.Pattern, err)
+	}
+
+	for _, m := range captureGroupReference.FindAllStringSubmatch(rr.Substitution, -1) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil || n > re.NumSubexp() {
+			return nil, fmt.Errorf("substitution references capture group %s, but the pattern has %d capture groups",
+				m[1], re.NumSubexp())
+		}
+	}
+
+	return &RegexRewrite{
+		Pattern:      rr.Pattern,
+		Substitution: rr.Substitution,
+	}, nil
+}
+
 func prefixReplacementsAreValid(replacements []contour_api_v1.ReplacePrefix) (string, error) {
 	prefixes := map[string]bool{}
 
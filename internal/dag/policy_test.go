@@ -15,6 +15,7 @@ package dag
 
 import (
 	"io/ioutil"
+	"math"
 	"testing"
 	"time"
 
@@ -307,6 +308,204 @@ func TestTimeoutPolicy(t *testing.T) {
 	}
 }
 
+func TestClusterTimeoutPolicy(t *testing.T) {
+	defaultConnect := timeout.DurationSetting(2 * time.Second)
+	defaultIdle := timeout.DurationSetting(time.Hour)
+
+	tests := map[string]struct {
+		tp      *contour_api_v1.TimeoutPolicy
+		want    ClusterTimeoutPolicy
+		wantErr bool
+	}{
+		"nil timeout policy": {
+			tp: nil,
+			want: ClusterTimeoutPolicy{
+				ConnectTimeout: defaultConnect,
+				IdleTimeout:    defaultIdle,
+			},
+		},
+		"route timeouts only": {
+			tp: &contour_api_v1.TimeoutPolicy{
+				Response: "1m",
+				Idle:     "5m",
+			},
+			want: ClusterTimeoutPolicy{
+				ConnectTimeout: defaultConnect,
+				IdleTimeout:    defaultIdle,
+			},
+		},
+		"connect timeout": {
+			tp: &contour_api_v1.TimeoutPolicy{
+				Connect: "500ms",
+			},
+			want: ClusterTimeoutPolicy{
+				ConnectTimeout: timeout.DurationSetting(500 * time.Millisecond),
+				IdleTimeout:    defaultIdle,
+			},
+		},
+		"infinite connect timeout": {
+			tp: &contour_api_v1.TimeoutPolicy{
+				Connect: "infinity",
+			},
+			wantErr: true,
+		},
+		"invalid connect timeout": {
+			tp: &contour_api_v1.TimeoutPolicy{
+				Connect: "5",
+			},
+			wantErr: true,
+		},
+		"upstream idle timeout": {
+			tp: &contour_api_v1.TimeoutPolicy{
+				UpstreamIdle: "10m",
+			},
+			want: ClusterTimeoutPolicy{
+				ConnectTimeout: defaultConnect,
+				IdleTimeout:    timeout.DurationSetting(10 * time.Minute),
+			},
+		},
+		"infinite upstream idle timeout": {
+			tp: &contour_api_v1.TimeoutPolicy{
+				UpstreamIdle: "infinity",
+			},
+			want: ClusterTimeoutPolicy{
+				ConnectTimeout: defaultConnect,
+				IdleTimeout:    timeout.DisabledSetting(),
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, gotErr := clusterTimeoutPolicy(tc.tp, defaultConnect, defaultIdle)
+			if tc.wantErr {
+				assert.Error(t, gotErr)
+			} else {
+				assert.Equal(t, tc.want, got)
+				assert.NoError(t, gotErr)
+			}
+		})
+	}
+}
+
+func TestOutlierDetectionPolicy(t *testing.T) {
+	tests := map[string]struct {
+		od      *contour_api_v1.OutlierDetection
+		want    *OutlierDetectionPolicy
+		wantErr bool
+	}{
+		"nil": {
+			od:   nil,
+			want: nil,
+		},
+		"empty": {
+			od:   &contour_api_v1.OutlierDetection{},
+			want: &OutlierDetectionPolicy{},
+		},
+		"all fields": {
+			od: &contour_api_v1.OutlierDetection{
+				ConsecutiveServerErrors:  5,
+				ConsecutiveGatewayErrors: 3,
+				Interval:                 "10s",
+				BaseEjectionTime:         "1m",
+				MaxEjectionPercent:       50,
+			},
+			want: &OutlierDetectionPolicy{
+				ConsecutiveServerErrors:  5,
+				ConsecutiveGatewayErrors: 3,
+				Interval:                 10 * time.Second,
+				BaseEjectionTime:         time.Minute,
+				MaxEjectionPercent:       50,
+			},
+		},
+		"invalid interval": {
+			od: &contour_api_v1.OutlierDetection{
+				Interval: "10 seconds",
+			},
+			wantErr: true,
+		},
+		"invalid base ejection time": {
+			od: &contour_api_v1.OutlierDetection{
+				BaseEjectionTime: "forever",
+			},
+			wantErr: true,
+		},
+		"invalid max ejection percent": {
+			od: &contour_api_v1.OutlierDetection{
+				MaxEjectionPercent: 101,
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, gotErr := outlierDetectionPolicy(tc.od)
+			if tc.wantErr {
+				assert.Error(t, gotErr)
+			} else {
+				assert.Equal(t, tc.want, got)
+				assert.NoError(t, gotErr)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerPolicy(t *testing.T) {
+	tests := map[string]struct {
+		cb      *contour_api_v1.CircuitBreakerPolicy
+		want    *CircuitBreakerPolicy
+		wantErr bool
+	}{
+		"nil": {
+			cb:   nil,
+			want: nil,
+		},
+		"empty": {
+			cb:   &contour_api_v1.CircuitBreakerPolicy{},
+			want: &CircuitBreakerPolicy{},
+		},
+		"all fields": {
+			cb: &contour_api_v1.CircuitBreakerPolicy{
+				MaxConnections:     1024,
+				MaxPendingRequests: 512,
+				MaxRequests:        4096,
+				MaxRetries:         3,
+			},
+			want: &CircuitBreakerPolicy{
+				MaxConnections:     1024,
+				MaxPendingRequests: 512,
+				MaxRequests:        4096,
+				MaxRetries:         3,
+			},
+		},
+		"negative max retries": {
+			cb: &contour_api_v1.CircuitBreakerPolicy{
+				MaxRetries: -1,
+			},
+			wantErr: true,
+		},
+		"max connections overflow": {
+			cb: &contour_api_v1.CircuitBreakerPolicy{
+				MaxConnections: math.MaxUint32 + 1,
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, gotErr := circuitBreakerPolicy(tc.cb)
+			if tc.wantErr {
+				assert.Error(t, gotErr)
+			} else {
+				assert.Equal(t, tc.want, got)
+				assert.NoError(t, gotErr)
+			}
+		})
+	}
+}
+
 func TestLoadBalancerPolicy(t *testing.T) {
 	tests := map[string]struct {
 		lbp  *contour_api_v1.LoadBalancerPolicy
@@ -338,6 +537,18 @@ func TestLoadBalancerPolicy(t *testing.T) {
 			},
 			want: "Cookie",
 		},
+		"RingHash": {
+			lbp: &contour_api_v1.LoadBalancerPolicy{
+				Strategy: "RingHash",
+			},
+			want: "RingHash",
+		},
+		"Maglev": {
+			lbp: &contour_api_v1.LoadBalancerPolicy{
+				Strategy: "Maglev",
+			},
+			want: "Maglev",
+		},
 		"unknown": {
 			lbp: &contour_api_v1.LoadBalancerPolicy{
 				Strategy: "please",
@@ -353,3 +564,820 @@ func TestLoadBalancerPolicy(t *testing.T) {
 		})
 	}
 }
+
+func TestRequestHashPolicies(t *testing.T) {
+	ttl := 30 * time.Minute
+
+	tests := map[string]struct {
+		lbp     *contour_api_v1.LoadBalancerPolicy
+		want    []RequestHashPolicy
+		wantErr bool
+	}{
+		"nil": {
+			lbp:  nil,
+			want: nil,
+		},
+		"no policies": {
+			lbp: &contour_api_v1.LoadBalancerPolicy{
+				Strategy: "RingHash",
+			},
+			want: nil,
+		},
+		"all hash options": {
+			lbp: &contour_api_v1.LoadBalancerPolicy{
+				Strategy: "Maglev",
+				RequestHashPolicies: []contour_api_v1.RequestHashPolicy{{
+					Terminal: true,
+					HeaderHashOptions: &contour_api_v1.HeaderHashOptions{
+						HeaderName: "X-Tenant-Id",
+					},
+				}, {
+					CookieHashOptions: &contour_api_v1.CookieHashOptions{
+						CookieName: "session",
+						TTL:        "30m",
+						Path:       "/cart",
+					},
+				}, {
+					CookieHashOptions: &contour_api_v1.CookieHashOptions{
+						CookieName: "user",
+					},
+				}, {
+					QueryParameterHashOptions: &contour_api_v1.QueryParameterHashOptions{
+						ParameterName: "key",
+					},
+				}, {
+					HashSourceIP: true,
+				}},
+			},
+			want: []RequestHashPolicy{{
+				Terminal: true,
+				HeaderHashOptions: &HeaderHashOptions{
+					HeaderName: "X-Tenant-Id",
+				},
+			}, {
+				CookieHashOptions: &CookieHashOptions{
+					CookieName: "session",
+					TTL:        &ttl,
+					Path:       "/cart",
+				},
+			}, {
+				CookieHashOptions: &CookieHashOptions{
+					CookieName: "user",
+				},
+			}, {
+				QueryParameterHashOptions: &QueryParameterHashOptions{
+					ParameterName: "key",
+				},
+			}, {
+				HashSourceIP: true,
+			}},
+		},
+		"unsupported strategy": {
+			lbp: &contour_api_v1.LoadBalancerPolicy{
+				Strategy: "Cookie",
+				RequestHashPolicies: []contour_api_v1.RequestHashPolicy{{
+					HashSourceIP: true,
+				}},
+			},
+			wantErr: true,
+		},
+		"no hash option": {
+			lbp: &contour_api_v1.LoadBalancerPolicy{
+				Strategy: "RingHash",
+				RequestHashPolicies: []contour_api_v1.RequestHashPolicy{{
+					Terminal: true,
+				}},
+			},
+			wantErr: true,
+		},
+		"multiple hash options": {
+			lbp: &contour_api_v1.LoadBalancerPolicy{
+				Strategy: "RingHash",
+				RequestHashPolicies: []contour_api_v1.RequestHashPolicy{{
+					HeaderHashOptions: &contour_api_v1.HeaderHashOptions{
+						HeaderName: "X-Tenant-Id",
+					},
+					HashSourceIP: true,
+				}},
+			},
+			wantErr: true,
+		},
+		"empty header name": {
+			lbp: &contour_api_v1.LoadBalancerPolicy{
+				Strategy: "RingHash",
+				RequestHashPolicies: []contour_api_v1.RequestHashPolicy{{
+					HeaderHashOptions: &contour_api_v1.HeaderHashOptions{},
+				}},
+			},
+			wantErr: true,
+		},
+		"invalid cookie ttl": {
+			lbp: &contour_api_v1.LoadBalancerPolicy{
+				Strategy: "RingHash",
+				RequestHashPolicies: []contour_api_v1.RequestHashPolicy{{
+					CookieHashOptions: &contour_api_v1.CookieHashOptions{
+						CookieName: "session",
+						TTL:        "forever",
+					},
+				}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, gotErr := requestHashPolicies(tc.lbp)
+			if tc.wantErr {
+				assert.Error(t, gotErr)
+			} else {
+				assert.Equal(t, tc.want, got)
+				assert.NoError(t, gotErr)
+			}
+		})
+	}
+}
+
+func TestRingHashPolicy(t *testing.T) {
+	tests := map[string]struct {
+		lbp     *contour_api_v1.LoadBalancerPolicy
+		want    *RingHashPolicy
+		wantErr bool
+	}{
+		"nil": {
+			lbp:  nil,
+			want: nil,
+		},
+		"no ring hash": {
+			lbp: &contour_api_v1.LoadBalancerPolicy{
+				Strategy: "RingHash",
+			},
+			want: nil,
+		},
+		"ring sizes": {
+			lbp: &contour_api_v1.LoadBalancerPolicy{
+				Strategy: "RingHash",
+				RingHash: &contour_api_v1.RingHashConfig{
+					MinimumRingSize: 4096,
+					MaximumRingSize: 65536,
+				},
+			},
+			want: &RingHashPolicy{
+				MinimumRingSize: 4096,
+				MaximumRingSize: 65536,
+			},
+		},
+		"cookie strategy": {
+			lbp: &contour_api_v1.LoadBalancerPolicy{
+				Strategy: "Cookie",
+				RingHash: &contour_api_v1.RingHashConfig{
+					MaximumRingSize: 2048,
+				},
+			},
+			want: &RingHashPolicy{
+				MaximumRingSize: 2048,
+			},
+		},
+		"unsupported strategy": {
+			lbp: &contour_api_v1.LoadBalancerPolicy{
+				Strategy: "Maglev",
+				RingHash: &contour_api_v1.RingHashConfig{
+					MinimumRingSize: 4096,
+				},
+			},
+			wantErr: true,
+		},
+		"minimum greater than maximum": {
+			lbp: &contour_api_v1.LoadBalancerPolicy{
+				Strategy: "RingHash",
+				RingHash: &contour_api_v1.RingHashConfig{
+					MinimumRingSize: 4096,
+					MaximumRingSize: 2048,
+				},
+			},
+			wantErr: true,
+		},
+		"maximum less than default minimum": {
+			lbp: &contour_api_v1.LoadBalancerPolicy{
+				Strategy: "RingHash",
+				RingHash: &contour_api_v1.RingHashConfig{
+					MaximumRingSize: 512,
+				},
+			},
+			wantErr: true,
+		},
+		"ring too large": {
+			lbp: &contour_api_v1.LoadBalancerPolicy{
+				Strategy: "RingHash",
+				RingHash: &contour_api_v1.RingHashConfig{
+					MinimumRingSize: 8388609,
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, gotErr := ringHashPolicy(tc.lbp)
+			if tc.wantErr {
+				assert.Error(t, gotErr)
+			} else {
+				assert.Equal(t, tc.want, got)
+				assert.NoError(t, gotErr)
+			}
+		})
+	}
+}
+
+func TestRegexRewritePolicy(t *testing.T) {
+	tests := map[string]struct {
+		rr      *contour_api_v1.RegexRewrite
+		want    *RegexRewrite
+		wantErr bool
+	}{
+		"capture group": {
+			rr: &contour_api_v1.RegexRewrite{
+				Pattern:      `^/users/(\d+)/profile$`,
+				Substitution: `/profile?id=\1`,
+			},
+			want: &RegexRewrite{
+				Pattern:      `^/users/(\d+)/profile$`,
+				Substitution: `/profile?id=\1`,
+			},
+		},
+		"empty substitution": {
+			rr: &contour_api_v1.RegexRewrite{
+				Pattern: "/v[0-9]+",
+			},
+			want: &RegexRewrite{
+				Pattern: "/v[0-9]+",
+			},
+		},
+		"invalid pattern": {
+			rr: &contour_api_v1.RegexRewrite{
+				Pattern: "/users/(",
+			},
+			wantErr: true,
+		},
+		"perl syntax is not RE2": {
+			rr: &contour_api_v1.RegexRewrite{
+				Pattern: `/(?<=api)/v1`,
+			},
+			wantErr: true,
+		},
+		"missing capture group": {
+			rr: &contour_api_v1.RegexRewrite{
+				Pattern:      `^/users/(\d+)/(\w+)$`,
+				Substitution: `/\2/\3`,
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, gotErr := regexRewritePolicy(tc.rr)
+			if tc.wantErr {
+				assert.Error(t, gotErr)
+			} else {
+				assert.Equal(t, tc.want, got)
+				assert.NoError(t, gotErr)
+			}
+		})
+	}
+}
+
+func TestRateLimitPolicy(t *testing.T) {
+	tests := map[string]struct {
+		descriptors []contour_api_v1.RateLimitDescriptor
+		want        *RateLimitPolicy
+		wantErr     bool
+	}{
+		"nil descriptors": {
+			descriptors: nil,
+			want:        nil,
+		},
+		"all entry types": {
+			descriptors: []contour_api_v1.RateLimitDescriptor{{
+				Entries: []contour_api_v1.RateLimitDescriptorEntry{{
+					GenericKey: &contour_api_v1.GenericKeyDescriptor{Value: "generic"},
+				}, {
+					RequestHeader: &contour_api_v1.RequestHeaderDescriptor{
+						HeaderName:    "X-Header",
+						DescriptorKey: "header",
+					},
+				}, {
+					RequestHeaderValueMatch: &contour_api_v1.RequestHeaderValueMatchDescriptor{
+						Headers: []contour_api_v1.HeaderMatchCondition{{
+							Name:     "X-Header",
+							NotExact: "foo",
+						}},
+						Value: "not-foo",
+					},
+				}, {
+					RemoteAddress: &contour_api_v1.RemoteAddressDescriptor{},
+				}},
+			}},
+			want: &RateLimitPolicy{
+				Descriptors: []*RateLimitDescriptor{{
+					Entries: []RateLimitDescriptorEntry{
+						{GenericKey: &GenericKeyDescriptorEntry{Value: "generic"}},
+						{RequestHeader: &RequestHeaderDescriptorEntry{HeaderName: "X-Header", Key: "header"}},
+						{HeaderValueMatch: &HeaderValueMatchDescriptorEntry{
+							Headers: []HeaderMatchCondition{{
+								Name:      "X-Header",
+								Value:     "foo",
+								MatchType: "exact",
+								Invert:    true,
+							}},
+							ExpectMatch: true,
+							Value:       "not-foo",
+						}},
+						{RemoteAddress: &RemoteAddressDescriptorEntry{}},
+					},
+				}},
+			},
+		},
+		"header value match not expected": {
+			descriptors: []contour_api_v1.RateLimitDescriptor{{
+				Entries: []contour_api_v1.RateLimitDescriptorEntry{{
+					RequestHeaderValueMatch: &contour_api_v1.RequestHeaderValueMatchDescriptor{
+						Headers: []contour_api_v1.HeaderMatchCondition{{
+							Name:    "X-Header",
+							Present: true,
+						}},
+						ExpectMatch: new(bool),
+						Value:       "no-header",
+					},
+				}},
+			}},
+			want: &RateLimitPolicy{
+				Descriptors: []*RateLimitDescriptor{{
+					Entries: []RateLimitDescriptorEntry{
+						{HeaderValueMatch: &HeaderValueMatchDescriptorEntry{
+							Headers: []HeaderMatchCondition{{
+								Name:      "X-Header",
+								MatchType: "present",
+							}},
+							ExpectMatch: false,
+							Value:       "no-header",
+						}},
+					},
+				}},
+			},
+		},
+		"descriptor without entries": {
+			descriptors: []contour_api_v1.RateLimitDescriptor{{}},
+			wantErr:     true,
+		},
+		"entry without a type": {
+			descriptors: []contour_api_v1.RateLimitDescriptor{{
+				Entries: []contour_api_v1.RateLimitDescriptorEntry{{}},
+			}},
+			wantErr: true,
+		},
+		"entry with multiple types": {
+			descriptors: []contour_api_v1.RateLimitDescriptor{{
+				Entries: []contour_api_v1.RateLimitDescriptorEntry{{
+					GenericKey:    &contour_api_v1.GenericKeyDescriptor{Value: "generic"},
+					RemoteAddress: &contour_api_v1.RemoteAddressDescriptor{},
+				}},
+			}},
+			wantErr: true,
+		},
+		"contradictory header conditions": {
+			descriptors: []contour_api_v1.RateLimitDescriptor{{
+				Entries: []contour_api_v1.RateLimitDescriptorEntry{{
+					RequestHeaderValueMatch: &contour_api_v1.RequestHeaderValueMatchDescriptor{
+						Headers: []contour_api_v1.HeaderMatchCondition{
+							{Name: "X-Header", Exact: "foo"},
+							{Name: "X-Header", Exact: "bar"},
+						},
+						Value: "foo",
+					},
+				}},
+			}},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, gotErr := rateLimitPolicy(tc.descriptors)
+			if tc.wantErr {
+				assert.Error(t, gotErr)
+			} else {
+				assert.Equal(t, tc.want, got)
+				assert.NoError(t, gotErr)
+			}
+		})
+	}
+}
+
+func TestRedirectPolicy(t *testing.T) {
+	tests := map[string]struct {
+		rp      *contour_api_v1.HTTPRequestRedirectPolicy
+		want    *Redirect
+		wantErr bool
+	}{
+		"empty policy": {
+			rp: &contour_api_v1.HTTPRequestRedirectPolicy{},
+			want: &Redirect{
+				StatusCode: 302,
+			},
+		},
+		"all fields": {
+			rp: &contour_api_v1.HTTPRequestRedirectPolicy{
+				Scheme:     "https",
+				Hostname:   "www.example.com",
+				Port:       8443,
+				Prefix:     "/v2",
+				StatusCode: 301,
+			},
+			want: &Redirect{
+				Scheme:        "https",
+				Hostname:      "www.example.com",
+				Port:          8443,
+				PrefixRewrite: "/v2",
+				StatusCode:    301,
+			},
+		},
+		"path and prefix": {
+			rp: &contour_api_v1.HTTPRequestRedirectPolicy{
+				Path:   "/path",
+				Prefix: "/prefix",
+			},
+			wantErr: true,
+		},
+		"invalid scheme": {
+			rp: &contour_api_v1.HTTPRequestRedirectPolicy{
+				Scheme: "ftp",
+			},
+			wantErr: true,
+		},
+		"invalid port": {
+			rp: &contour_api_v1.HTTPRequestRedirectPolicy{
+				Port: 65536,
+			},
+			wantErr: true,
+		},
+		"invalid status code": {
+			rp: &contour_api_v1.HTTPRequestRedirectPolicy{
+				StatusCode: 303,
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, gotErr := redirectPolicy(tc.rp)
+			if tc.wantErr {
+				assert.Error(t, gotErr)
+			} else {
+				assert.Equal(t, tc.want, got)
+				assert.NoError(t, gotErr)
+			}
+		})
+	}
+}
+
+func TestDirectResponsePolicy(t *testing.T) {
+	tests := map[string]struct {
+		dp      *contour_api_v1.HTTPDirectResponsePolicy
+		want    *DirectResponse
+		wantErr bool
+	}{
+		"status only": {
+			dp: &contour_api_v1.HTTPDirectResponsePolicy{
+				StatusCode: 410,
+			},
+			want: &DirectResponse{
+				StatusCode: 410,
+			},
+		},
+		"status and body": {
+			dp: &contour_api_v1.HTTPDirectResponsePolicy{
+				StatusCode: 503,
+				Body:       "down for maintenance",
+			},
+			want: &DirectResponse{
+				StatusCode: 503,
+				Body:       "down for maintenance",
+			},
+		},
+		"missing status": {
+			dp:      &contour_api_v1.HTTPDirectResponsePolicy{},
+			wantErr: true,
+		},
+		"status out of range": {
+			dp: &contour_api_v1.HTTPDirectResponsePolicy{
+				StatusCode: 600,
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, gotErr := directResponsePolicy(tc.dp)
+			if tc.wantErr {
+				assert.Error(t, gotErr)
+			} else {
+				assert.Equal(t, tc.want, got)
+				assert.NoError(t, gotErr)
+			}
+		})
+	}
+}
+
+func TestFaultInjectionPolicy(t *testing.T) {
+	tests := map[string]struct {
+		fp      *contour_api_v1.FaultInjectionPolicy
+		want    *FaultInjectionPolicy
+		wantErr bool
+	}{
+		"nil": {
+			fp:   nil,
+			want: nil,
+		},
+		"delay and abort": {
+			fp: &contour_api_v1.FaultInjectionPolicy{
+				Delay: &contour_api_v1.FaultDelay{
+					FixedDelay: "500ms",
+					Percentage: 20,
+				},
+				Abort: &contour_api_v1.FaultAbort{
+					StatusCode: 503,
+					Percentage: 5,
+				},
+				HeaderName: "x-inject-fault",
+			},
+			want: &FaultInjectionPolicy{
+				Delay: &FaultDelay{
+					FixedDelay: 500 * time.Millisecond,
+					Percentage: 20,
+				},
+				Abort: &FaultAbort{
+					StatusCode: 503,
+					Percentage: 5,
+				},
+				HeaderName: "x-inject-fault",
+			},
+		},
+		"default percentages": {
+			fp: &contour_api_v1.FaultInjectionPolicy{
+				Delay: &contour_api_v1.FaultDelay{
+					FixedDelay: "2s",
+				},
+				Abort: &contour_api_v1.FaultAbort{
+					StatusCode: 500,
+				},
+			},
+			want: &FaultInjectionPolicy{
+				Delay: &FaultDelay{
+					FixedDelay: 2 * time.Second,
+					Percentage: 100,
+				},
+				Abort: &FaultAbort{
+					StatusCode: 500,
+					Percentage: 100,
+				},
+			},
+		},
+		"no faults": {
+			fp: &contour_api_v1.FaultInjectionPolicy{
+				HeaderName: "x-inject-fault",
+			},
+			wantErr: true,
+		},
+		"invalid fixed delay": {
+			fp: &contour_api_v1.FaultInjectionPolicy{
+				Delay: &contour_api_v1.FaultDelay{
+					FixedDelay: "forever",
+				},
+			},
+			wantErr: true,
+		},
+		"delay percentage out of range": {
+			fp: &contour_api_v1.FaultInjectionPolicy{
+				Delay: &contour_api_v1.FaultDelay{
+					FixedDelay: "1s",
+					Percentage: 101,
+				},
+			},
+			wantErr: true,
+		},
+		"abort status out of range": {
+			fp: &contour_api_v1.FaultInjectionPolicy{
+				Abort: &contour_api_v1.FaultAbort{
+					StatusCode: 600,
+				},
+			},
+			wantErr: true,
+		},
+		"negative abort percentage": {
+			fp: &contour_api_v1.FaultInjectionPolicy{
+				Abort: &contour_api_v1.FaultAbort{
+					StatusCode: 503,
+					Percentage: -1,
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, gotErr := faultInjectionPolicy(tc.fp)
+			if tc.wantErr {
+				assert.Error(t, gotErr)
+			} else {
+				assert.Equal(t, tc.want, got)
+				assert.NoError(t, gotErr)
+			}
+		})
+	}
+}
+
+func TestHealthCheckPolicyIsValid(t *testing.T) {
+	tests := map[string]struct {
+		hc      *contour_api_v1.HTTPHealthCheckPolicy
+		wantErr bool
+	}{
+		"nil": {
+			hc: nil,
+		},
+		"http": {
+			hc: &contour_api_v1.HTTPHealthCheckPolicy{
+				Path: "/healthz",
+			},
+		},
+		"grpc": {
+			hc: &contour_api_v1.HTTPHealthCheckPolicy{
+				GRPC: &contour_api_v1.GRPCHealthCheck{
+					ServiceName: "helloworld.Greeter",
+				},
+			},
+		},
+		"path and grpc": {
+			hc: &contour_api_v1.HTTPHealthCheckPolicy{
+				Path: "/healthz",
+				GRPC: &contour_api_v1.GRPCHealthCheck{},
+			},
+			wantErr: true,
+		},
+		"neither path nor grpc": {
+			hc: &contour_api_v1.HTTPHealthCheckPolicy{
+				IntervalSeconds: 5,
+			},
+			wantErr: true,
+		},
+		"port out of range": {
+			hc: &contour_api_v1.HTTPHealthCheckPolicy{
+				Path: "/healthz",
+				Port: 70000,
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			gotErr := healthCheckPolicyIsValid(tc.hc)
+			if tc.wantErr {
+				assert.Error(t, gotErr)
+			} else {
+				assert.NoError(t, gotErr)
+			}
+		})
+	}
+}
+
+func TestGRPCHealthCheckPolicy(t *testing.T) {
+	hc := &contour_api_v1.HTTPHealthCheckPolicy{
+		IntervalSeconds:         5,
+		TimeoutSeconds:          1,
+		UnhealthyThresholdCount: 3,
+		HealthyThresholdCount:   2,
+		GRPC: &contour_api_v1.GRPCHealthCheck{
+			ServiceName: "helloworld.Greeter",
+			Authority:   "greeter.example.com",
+		},
+	}
+
+	assert.Equal(t, &GRPCHealthCheckPolicy{
+		ServiceName:        "helloworld.Greeter",
+		Authority:          "greeter.example.com",
+		Interval:           5 * time.Second,
+		Timeout:            time.Second,
+		UnhealthyThreshold: 3,
+		HealthyThreshold:   2,
+	}, grpcHealthCheckPolicy(hc))
+
+	// A gRPC health check replaces the HTTP health check.
+	hhc, err := httpHealthCheckPolicy(hc)
+	assert.NoError(t, err)
+	assert.Nil(t, hhc)
+}
+
+func TestHTTPHealthCheckPolicy(t *testing.T) {
+	tests := map[string]struct {
+		hc      *contour_api_v1.HTTPHealthCheckPolicy
+		want    *HTTPHealthCheckPolicy
+		wantErr bool
+	}{
+		"nil": {
+			hc:   nil,
+			want: nil,
+		},
+		"path only": {
+			hc: &contour_api_v1.HTTPHealthCheckPolicy{
+				Path: "/healthz",
+			},
+			want: &HTTPHealthCheckPolicy{
+				Path: "/healthz",
+			},
+		},
+		"all options": {
+			hc: &contour_api_v1.HTTPHealthCheckPolicy{
+				Path:                     "/healthz",
+				IntervalSeconds:          5,
+				UnhealthyIntervalSeconds: 1,
+				NoTrafficIntervalSeconds: 30,
+				Port:                     9000,
+				ExpectedStatuses: []contour_api_v1.HTTPStatusRange{
+					{Start: 200, End: 300},
+					{Start: 302, End: 303},
+				},
+				RequestHeaders: []contour_api_v1.HeaderValue{
+					{Name: "x-health-check", Value: "true"},
+				},
+			},
+			want: &HTTPHealthCheckPolicy{
+				Path:              "/healthz",
+				Interval:          5 * time.Second,
+				UnhealthyInterval: time.Second,
+				NoTrafficInterval: 30 * time.Second,
+				Port:              9000,
+				ExpectedStatuses: []HTTPStatusRange{
+					{Start: 200, End: 300},
+					{Start: 302, End: 303},
+				},
+				RequestHeaders: map[string]string{
+					"X-Health-Check": "true",
+				},
+			},
+		},
+		"empty status range": {
+			hc: &contour_api_v1.HTTPHealthCheckPolicy{
+				Path: "/healthz",
+				ExpectedStatuses: []contour_api_v1.HTTPStatusRange{
+					{Start: 200, End: 200},
+				},
+			},
+			wantErr: true,
+		},
+		"status range out of bounds": {
+			hc: &contour_api_v1.HTTPHealthCheckPolicy{
+				Path: "/healthz",
+				ExpectedStatuses: []contour_api_v1.HTTPStatusRange{
+					{Start: 500, End: 700},
+				},
+			},
+			wantErr: true,
+		},
+		"duplicate request header": {
+			hc: &contour_api_v1.HTTPHealthCheckPolicy{
+				Path: "/healthz",
+				RequestHeaders: []contour_api_v1.HeaderValue{
+					{Name: "x-health-check", Value: "true"},
+					{Name: "X-Health-Check", Value: "false"},
+				},
+			},
+			wantErr: true,
+		},
+		"host request header": {
+			hc: &contour_api_v1.HTTPHealthCheckPolicy{
+				Path: "/healthz",
+				RequestHeaders: []contour_api_v1.HeaderValue{
+					{Name: "Host", Value: "example.com"},
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, gotErr := httpHealthCheckPolicy(tc.hc)
+			if tc.wantErr {
+				assert.Error(t, gotErr)
+			} else {
+				assert.NoError(t, gotErr)
+				assert.Equal(t, tc.want, got)
+			}
+		})
+	}
+}
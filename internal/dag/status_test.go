@@ -14,9 +14,11 @@
 package dag
 
 import (
+	"fmt"
 	"testing"
 
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
 	"github.com/projectcontour/contour/internal/fixture"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
@@ -31,6 +33,7 @@ func TestDAGStatus(t *testing.T) {
 	type testcase struct {
 		objs                []interface{}
 		fallbackCertificate *types.NamespacedName
+		listeners           []NamedListener
 		want                map[types.NamespacedName]contour_api_v1.DetailedCondition
 	}
 
@@ -47,10 +50,16 @@ func TestDAGStatus(t *testing.T) {
 					&IngressProcessor{
 						FieldLogger: fixture.NewTestLogger(t),
 					},
+					&ExtensionServiceProcessor{
+						FieldLogger: fixture.NewTestLogger(t),
+					},
 					&HTTPProxyProcessor{
 						FallbackCertificate: tc.fallbackCertificate,
+						Listeners:           tc.listeners,
+					},
+					&ListenerProcessor{
+						Listeners: tc.listeners,
 					},
-					&ListenerProcessor{},
 				},
 			}
 			for _, o := range tc.objs {
@@ -1031,7 +1040,39 @@ func TestDAGStatus(t *testing.T) {
 		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
 			{Name: proxyInvalidTwoMirrors.Name, Namespace: proxyInvalidTwoMirrors.Namespace}: fixture.NewValidCondition().
 				WithGeneration(proxyInvalidTwoMirrors.Generation).
-				WithError("ServiceError", "OnlyOneMirror", "only one service per route may be nominated as mirror"),
+				WithError("ServiceError", "DuplicateMirror", `service "kuard": port 8080 is already a mirror of this route`),
+		},
+	})
+
+	proxyInvalidMirrorWeight := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "www",
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+					Port: 8080,
+				}, {
+					Name:   fixture.ServiceRootsKuard.Name,
+					Port:   8080,
+					Mirror: true,
+					Weight: 200,
+				}},
+			}},
+		},
+	}
+
+	run(t, "proxy with a mirror weight above 100", testcase{
+		objs: []interface{}{proxyInvalidMirrorWeight, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxyInvalidMirrorWeight.Name, Namespace: proxyInvalidMirrorWeight.Namespace}: fixture.NewValidCondition().
+				WithGeneration(proxyInvalidMirrorWeight.Generation).
+				WithError("ServiceError", "MirrorWeightNotValid", `service "kuard": mirror weight 200 must be in the range 0-100`),
 		},
 	})
 
@@ -1075,6 +1116,41 @@ func TestDAGStatus(t *testing.T) {
 		},
 	})
 
+	proxyInvalidQueryParameterCondition := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/foo",
+				}, {
+					QueryParameter: &contour_api_v1.QueryParameterMatchCondition{
+						Name:     "version",
+						Exact:    "2",
+						Contains: "2",
+					},
+				}},
+				Services: []contour_api_v1.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "route condition query parameter with two operators", testcase{
+		objs: []interface{}{proxyInvalidQueryParameterCondition, fixture.ServiceRootsHome},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxyInvalidQueryParameterCondition.Name, Namespace: proxyInvalidQueryParameterCondition.Namespace}: fixture.NewValidCondition().
+				WithError("RouteError", "QueryParameterMatchConditionsNotValid", `query parameter "version" condition must specify exactly one of present, exact, contains or regex`),
+		},
+	})
+
 	proxyInvalidDuplicateIncludeCondtionHeaders := &contour_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: "roots",
@@ -1335,6 +1411,88 @@ func TestDAGStatus(t *testing.T) {
 		},
 	})
 
+	proxyInvalidIncludeExact := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "www",
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Includes: []contour_api_v1.Include{{
+				Name:      "child",
+				Namespace: "teama",
+				Conditions: []contour_api_v1.MatchCondition{
+					{
+						Exact: "/api",
+					},
+				},
+			}},
+		},
+	}
+
+	run(t, "proxy with exact condition on include", testcase{
+		objs: []interface{}{proxyInvalidIncludeExact, proxyValidChildTeamA, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxyInvalidIncludeExact.Name, Namespace: proxyInvalidIncludeExact.Namespace}: fixture.NewValidCondition().
+				WithError("IncludeError", "PathMatchConditionsNotValid", "include: exact and regex conditions are not allowed on includes"),
+			{Name: proxyValidChildTeamA.Name, Namespace: proxyValidChildTeamA.Namespace}: fixture.NewValidCondition().
+				Orphaned(),
+		},
+	})
+
+	proxyValidIncludePrefix := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "www",
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Includes: []contour_api_v1.Include{{
+				Name:      "child",
+				Namespace: "teama",
+				Conditions: []contour_api_v1.MatchCondition{
+					{
+						Prefix: "/static",
+					},
+				},
+			}},
+		},
+	}
+
+	proxyInvalidChildRegex := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "child",
+			Namespace: "teama",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{
+					{
+						Regex: ".*\\.png",
+					},
+				},
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "proxy with regex condition that can't be merged with the include prefix", testcase{
+		objs: []interface{}{proxyValidIncludePrefix, proxyInvalidChildRegex, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxyValidIncludePrefix.Name, Namespace: proxyValidIncludePrefix.Namespace}: fixture.NewValidCondition().
+				Valid(),
+			{Name: proxyInvalidChildRegex.Name, Namespace: proxyInvalidChildRegex.Namespace}: fixture.NewValidCondition().
+				WithError("RouteError", "PathMatchConditionsNotValid", `route: regex condition ".*\\.png" must start with / to be merged with prefix "/static"`),
+		},
+	})
+
 	proxyInvalidTCPProxyIncludeAndService := &contour_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "simple",
@@ -1946,196 +2104,710 @@ func TestDAGStatus(t *testing.T) {
 		},
 	})
 
-	fallbackCertificate := &contour_api_v1.HTTPProxy{
+	proxyValidRedirect := &contour_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
-			Namespace: "roots",
-			Name:      "example",
+			Name:      "redirect",
+			Namespace: fixture.ServiceRootsKuard.Namespace,
 		},
 		Spec: contour_api_v1.HTTPProxySpec{
 			VirtualHost: &contour_api_v1.VirtualHost{
-				Fqdn: "example.com",
-				TLS: &contour_api_v1.TLS{
-					SecretName:                "ssl-cert",
-					EnableFallbackCertificate: true,
-				},
+				Fqdn: "redirect.example.com",
 			},
 			Routes: []contour_api_v1.Route{{
-				Conditions: []contour_api_v1.MatchCondition{{
-					Prefix: "/foo",
-				}},
-				Services: []contour_api_v1.Service{{
-					Name: "home",
-					Port: 8080,
-				}},
+				RequestRedirectPolicy: &contour_api_v1.HTTPRequestRedirectPolicy{
+					Hostname: "www.example.com",
+				},
 			}},
 		},
 	}
 
-	run(t, "invalid fallback certificate passed to contour", testcase{
-		fallbackCertificate: &types.NamespacedName{
-			Name:      "invalid",
-			Namespace: "invalid",
-		},
-		objs: []interface{}{fallbackCertificate, fixture.SecretRootsFallback, fixture.SecretRootsCert, fixture.ServiceRootsHome},
-		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
-			{Name: fallbackCertificate.Name,
-				Namespace: fallbackCertificate.Namespace}: fixture.NewValidCondition().
-				WithError("TLSError", "FallbackNotValid", `Spec.Virtualhost.TLS Secret "invalid/invalid" fallback certificate is invalid: Secret not found`),
-		},
-	})
-
-	run(t, "fallback certificate requested but cert not configured in contour", testcase{
-		objs: []interface{}{fallbackCertificate, fixture.SecretRootsFallback, fixture.SecretRootsCert, fixture.ServiceRootsHome},
+	run(t, "valid HTTPProxy with a redirect route and no services", testcase{
+		objs: []interface{}{proxyValidRedirect},
 		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
-			{Name: fallbackCertificate.Name,
-				Namespace: fallbackCertificate.Namespace}: fixture.NewValidCondition().
-				WithError("TLSError", "FallbackNotPresent", "Spec.Virtualhost.TLS enabled fallback but the fallback Certificate Secret is not configured in Contour configuration file"),
+			{Name: proxyValidRedirect.Name, Namespace: proxyValidRedirect.Namespace}: fixture.NewValidCondition().Valid(),
 		},
 	})
 
-	fallbackCertificateWithClientValidation := &contour_api_v1.HTTPProxy{
+	proxyInvalidRedirectServices := &contour_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
-			Namespace: "roots",
-			Name:      "example",
+			Name:      "redirect",
+			Namespace: fixture.ServiceRootsKuard.Namespace,
 		},
 		Spec: contour_api_v1.HTTPProxySpec{
 			VirtualHost: &contour_api_v1.VirtualHost{
-				Fqdn: "example.com",
-				TLS: &contour_api_v1.TLS{
-					SecretName:                "ssl-cert",
-					EnableFallbackCertificate: true,
-					ClientValidation: &contour_api_v1.DownstreamValidation{
-						CACertificate: "something",
-					},
-				},
+				Fqdn: "redirect.example.com",
 			},
 			Routes: []contour_api_v1.Route{{
-				Conditions: []contour_api_v1.MatchCondition{{
-					Prefix: "/foo",
-				}},
 				Services: []contour_api_v1.Service{{
-					Name: "home",
+					Name: fixture.ServiceRootsKuard.Name,
 					Port: 8080,
 				}},
+				RequestRedirectPolicy: &contour_api_v1.HTTPRequestRedirectPolicy{
+					Hostname: "www.example.com",
+				},
 			}},
 		},
 	}
 
-	run(t, "fallback certificate requested and clientValidation also configured", testcase{
-		objs: []interface{}{fallbackCertificateWithClientValidation, fixture.SecretRootsFallback, fixture.SecretRootsCert, fixture.ServiceRootsHome},
+	run(t, "invalid HTTPProxy with a redirect route and services", testcase{
+		objs: []interface{}{proxyInvalidRedirectServices, fixture.ServiceRootsKuard},
 		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
-			{Name: fallbackCertificateWithClientValidation.Name,
-				Namespace: fallbackCertificateWithClientValidation.Namespace}: fixture.NewValidCondition().
-				WithError("TLSError", "TLSIncompatibleFeatures", "Spec.Virtualhost.TLS fallback & client validation are incompatible"),
+			{Name: proxyInvalidRedirectServices.Name, Namespace: proxyInvalidRedirectServices.Namespace}: fixture.NewValidCondition().
+				WithError("RouteError", "ServicesAndRedirect", "route.services cannot be specified with route.requestRedirectPolicy"),
 		},
 	})
 
-	tlsPassthroughAndValidation := &contour_api_v1.HTTPProxy{
+	proxyInvalidRedirectPathAndPrefix := &contour_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "invalid",
+			Name:      "redirect",
 			Namespace: fixture.ServiceRootsKuard.Namespace,
 		},
 		Spec: contour_api_v1.HTTPProxySpec{
 			VirtualHost: &contour_api_v1.VirtualHost{
-				Fqdn: "tcpproxy.example.com",
-				TLS: &contour_api_v1.TLS{
-					Passthrough: true,
-					ClientValidation: &contour_api_v1.DownstreamValidation{
-						CACertificate: "aCAcert",
-					},
-				},
+				Fqdn: "redirect.example.com",
 			},
-			TCPProxy: &contour_api_v1.TCPProxy{},
+			Routes: []contour_api_v1.Route{{
+				RequestRedirectPolicy: &contour_api_v1.HTTPRequestRedirectPolicy{
+					Path:   "/path",
+					Prefix: "/prefix",
+				},
+			}},
 		},
 	}
 
-	run(t, "passthrough and client auth are incompatible tlsPassthroughAndValidation", testcase{
-		objs: []interface{}{fixture.SecretRootsCert, tlsPassthroughAndValidation},
-		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
-			{Name: tlsPassthroughAndValidation.Name, Namespace: tlsPassthroughAndValidation.Namespace}: fixture.NewValidCondition().
-				WithError("TLSError", "TLSIncompatibleFeatures", "Spec.VirtualHost.TLS passthrough cannot be combined with tls.clientValidation"),
-		},
-	})
-
-	tlsPassthroughAndSecretName := &contour_api_v1.HTTPProxy{
+	proxyInvalidDirectResponseServices := &contour_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "invalid",
+			Name:      "direct",
 			Namespace: fixture.ServiceRootsKuard.Namespace,
 		},
 		Spec: contour_api_v1.HTTPProxySpec{
 			VirtualHost: &contour_api_v1.VirtualHost{
-				Fqdn: "tcpproxy.example.com",
-				TLS: &contour_api_v1.TLS{
-					Passthrough: true,
-					SecretName:  fixture.SecretRootsCert.Name,
-				},
+				Fqdn: "direct.example.com",
 			},
-			TCPProxy: &contour_api_v1.TCPProxy{},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+					Port: 8080,
+				}},
+				DirectResponsePolicy: &contour_api_v1.HTTPDirectResponsePolicy{
+					StatusCode: 200,
+				},
+			}},
 		},
 	}
 
-	run(t, "tcpproxy with TLS passthrough and secret name both specified", testcase{
-		objs: []interface{}{
-			fixture.SecretRootsCert,
-			tlsPassthroughAndSecretName,
-		},
+	run(t, "invalid HTTPProxy with a direct response route and services", testcase{
+		objs: []interface{}{proxyInvalidDirectResponseServices, fixture.ServiceRootsKuard},
 		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
-			{Name: "invalid", Namespace: fixture.ServiceRootsKuard.Namespace}: fixture.NewValidCondition().
-				WithError("TLSError", "TLSConfigNotValid", "Spec.VirtualHost.TLS: both Passthrough and SecretName were specified"),
+			{Name: proxyInvalidDirectResponseServices.Name, Namespace: proxyInvalidDirectResponseServices.Namespace}: fixture.NewValidCondition().
+				WithError("RouteError", "ServicesAndDirectResponse", "route.services cannot be specified with route.directResponsePolicy"),
 		},
 	})
 
-	tlsNoPassthroughOrSecretName := &contour_api_v1.HTTPProxy{
+	proxyInvalidDirectResponseRedirect := &contour_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "invalid",
+			Name:      "direct",
 			Namespace: fixture.ServiceRootsKuard.Namespace,
 		},
 		Spec: contour_api_v1.HTTPProxySpec{
 			VirtualHost: &contour_api_v1.VirtualHost{
-				Fqdn: "tcpproxy.example.com",
-				TLS: &contour_api_v1.TLS{
-					Passthrough: false,
-					SecretName:  "",
-				},
+				Fqdn: "direct.example.com",
 			},
-			TCPProxy: &contour_api_v1.TCPProxy{},
+			Routes: []contour_api_v1.Route{{
+				RequestRedirectPolicy: &contour_api_v1.HTTPRequestRedirectPolicy{
+					Hostname: "www.example.com",
+				},
+				DirectResponsePolicy: &contour_api_v1.HTTPDirectResponsePolicy{
+					StatusCode: 200,
+				},
+			}},
 		},
 	}
 
-	run(t, "httpproxy w/ tcpproxy with neither TLS passthrough nor secret name specified", testcase{
-		objs: []interface{}{
-			fixture.SecretRootsCert,
-			tlsNoPassthroughOrSecretName,
-		},
+	run(t, "invalid HTTPProxy with a direct response route and a redirect", testcase{
+		objs: []interface{}{proxyInvalidDirectResponseRedirect},
 		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
-			{Name: "invalid", Namespace: fixture.ServiceRootsKuard.Namespace}: fixture.NewValidCondition().
-				WithError("TLSError", "TLSConfigNotValid", "Spec.VirtualHost.TLS: neither Passthrough nor SecretName were specified"),
+			{Name: proxyInvalidDirectResponseRedirect.Name, Namespace: proxyInvalidDirectResponseRedirect.Namespace}: fixture.NewValidCondition().
+				WithError("RouteError", "RedirectAndDirectResponse", "route.requestRedirectPolicy cannot be specified with route.directResponsePolicy"),
 		},
 	})
 
-	emptyProxy := &contour_api_v1.HTTPProxy{
+	proxyInvalidDirectResponseStatus := &contour_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "empty",
-			Namespace: "roots",
+			Name:      "direct",
+			Namespace: fixture.ServiceRootsKuard.Namespace,
 		},
 		Spec: contour_api_v1.HTTPProxySpec{
 			VirtualHost: &contour_api_v1.VirtualHost{
-				Fqdn: "example.com",
+				Fqdn: "direct.example.com",
 			},
+			Routes: []contour_api_v1.Route{{
+				DirectResponsePolicy: &contour_api_v1.HTTPDirectResponsePolicy{
+					StatusCode: 100,
+				},
+			}},
 		},
 	}
 
-	run(t, "proxy with no routes, includes, or tcpproxy is invalid", testcase{
-		objs: []interface{}{emptyProxy},
+	run(t, "invalid HTTPProxy with a direct response status code", testcase{
+		objs: []interface{}{proxyInvalidDirectResponseStatus},
 		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
-			{Name: emptyProxy.Name, Namespace: emptyProxy.Namespace}: fixture.NewValidCondition().
-				WithError("SpecError", "NothingDefined", "HTTPProxy.Spec must have at least one Route, Include, or a TCPProxy"),
+			{Name: proxyInvalidDirectResponseStatus.Name, Namespace: proxyInvalidDirectResponseStatus.Namespace}: fixture.NewValidCondition().
+				WithError("RouteError", "DirectResponsePolicyNotValid", "route.directResponsePolicy is invalid: status code 100 must be in the range 200-599"),
 		},
 	})
 
-	invalidRequestHeadersPolicyService := &contour_api_v1.HTTPProxy{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "invalidRHPService",
+	run(t, "invalid HTTPProxy with a redirect path and prefix", testcase{
+		objs: []interface{}{proxyInvalidRedirectPathAndPrefix},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxyInvalidRedirectPathAndPrefix.Name, Namespace: proxyInvalidRedirectPathAndPrefix.Namespace}: fixture.NewValidCondition().
+				WithError("RouteError", "RequestRedirectPolicyNotValid", "route.requestRedirectPolicy is invalid: cannot specify both path and prefix"),
+		},
+	})
+
+	proxyInvalidRequestHashStrategy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hash",
+			Namespace: "roots",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "hash.example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				LoadBalancerPolicy: &contour_api_v1.LoadBalancerPolicy{
+					Strategy: "Random",
+					RequestHashPolicies: []contour_api_v1.RequestHashPolicy{{
+						HashSourceIP: true,
+					}},
+				},
+				Services: []contour_api_v1.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "invalid HTTPProxy with request hash policies and a non-hash strategy", testcase{
+		objs: []interface{}{proxyInvalidRequestHashStrategy, fixture.ServiceRootsHome},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxyInvalidRequestHashStrategy.Name, Namespace: proxyInvalidRequestHashStrategy.Namespace}: fixture.NewValidCondition().
+				WithError("RouteError", "RequestHashPolicyNotValid", `route.loadBalancerPolicy.requestHashPolicies is invalid: strategy "Random" does not support request hash policies`),
+		},
+	})
+
+	proxyInvalidRequestHashOptions := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hash",
+			Namespace: "roots",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "hash.example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				LoadBalancerPolicy: &contour_api_v1.LoadBalancerPolicy{
+					Strategy: "RingHash",
+					RequestHashPolicies: []contour_api_v1.RequestHashPolicy{{
+						HashSourceIP: true,
+					}, {
+						HeaderHashOptions: &contour_api_v1.HeaderHashOptions{
+							HeaderName: "X-Tenant-Id",
+						},
+						QueryParameterHashOptions: &contour_api_v1.QueryParameterHashOptions{
+							ParameterName: "tenant",
+						},
+					}},
+				},
+				Services: []contour_api_v1.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "invalid HTTPProxy with multiple options in a request hash policy", testcase{
+		objs: []interface{}{proxyInvalidRequestHashOptions, fixture.ServiceRootsHome},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxyInvalidRequestHashOptions.Name, Namespace: proxyInvalidRequestHashOptions.Namespace}: fixture.NewValidCondition().
+				WithError("RouteError", "RequestHashPolicyNotValid", "route.loadBalancerPolicy.requestHashPolicies is invalid: policy 1: exactly one hash option must be set"),
+		},
+	})
+
+	proxyInvalidRingHashSize := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hash",
+			Namespace: "roots",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "hash.example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				LoadBalancerPolicy: &contour_api_v1.LoadBalancerPolicy{
+					Strategy: "RingHash",
+					RingHash: &contour_api_v1.RingHashConfig{
+						MinimumRingSize: 4096,
+						MaximumRingSize: 2048,
+					},
+				},
+				Services: []contour_api_v1.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "invalid HTTPProxy with a minimum ring size greater than the maximum", testcase{
+		objs: []interface{}{proxyInvalidRingHashSize, fixture.ServiceRootsHome},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxyInvalidRingHashSize.Name, Namespace: proxyInvalidRingHashSize.Namespace}: fixture.NewValidCondition().
+				WithError("RouteError", "RingHashNotValid", "route.loadBalancerPolicy.ringHash is invalid: minimum ring size 4096 is greater than maximum ring size 2048"),
+		},
+	})
+
+	proxyInvalidTCPProxyRequestHash := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "simple",
+			Namespace: "roots",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "passthrough.example.com",
+				TLS: &contour_api_v1.TLS{
+					Passthrough: true,
+				},
+			},
+			TCPProxy: &contour_api_v1.TCPProxy{
+				LoadBalancerPolicy: &contour_api_v1.LoadBalancerPolicy{
+					Strategy: "RingHash",
+					RequestHashPolicies: []contour_api_v1.RequestHashPolicy{{
+						HashSourceIP: true,
+					}},
+				},
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+					Port: 8080,
+				}},
+			},
+		},
+	}
+
+	run(t, "tcpproxy cannot specify request hash policies", testcase{
+		objs: []interface{}{proxyInvalidTCPProxyRequestHash, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxyInvalidTCPProxyRequestHash.Name, Namespace: proxyInvalidTCPProxyRequestHash.Namespace}: fixture.NewValidCondition().
+				WithError("TCPProxyError", "RequestHashPolicyNotSupported", "tcpproxy.loadBalancerPolicy.requestHashPolicies is not supported"),
+		},
+	})
+
+	proxyInvalidOutlierDetection := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "outlier",
+			Namespace: "roots",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "outlier.example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: "home",
+					Port: 8080,
+					OutlierDetection: &contour_api_v1.OutlierDetection{
+						Interval: "10 seconds",
+					},
+				}},
+			}},
+		},
+	}
+
+	run(t, "invalid HTTPProxy with an outlier detection interval", testcase{
+		objs: []interface{}{proxyInvalidOutlierDetection, fixture.ServiceRootsHome},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxyInvalidOutlierDetection.Name, Namespace: proxyInvalidOutlierDetection.Namespace}: fixture.NewValidCondition().
+				WithError("ServiceError", "OutlierDetectionNotValid", `service "home": outlierDetection is invalid: error parsing interval: time: unknown unit " seconds" in duration "10 seconds"`),
+		},
+	})
+
+	proxyInvalidCircuitBreakerPolicy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "circuit",
+			Namespace: "roots",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "circuit.example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: "home",
+					Port: 8080,
+					CircuitBreakerPolicy: &contour_api_v1.CircuitBreakerPolicy{
+						MaxPendingRequests: -10,
+					},
+				}},
+			}},
+		},
+	}
+
+	run(t, "invalid HTTPProxy with a negative circuit breaker threshold", testcase{
+		objs: []interface{}{proxyInvalidCircuitBreakerPolicy, fixture.ServiceRootsHome},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxyInvalidCircuitBreakerPolicy.Name, Namespace: proxyInvalidCircuitBreakerPolicy.Namespace}: fixture.NewValidCondition().
+				WithError("ServiceError", "CircuitBreakerPolicyNotValid", `service "home": circuitBreakerPolicy is invalid: max pending requests -10 must be in the range 0-4294967295`),
+		},
+	})
+
+	proxyInvalidFaultInjectionPolicy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "fault",
+			Namespace: "roots",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "fault.example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+				FaultInjectionPolicy: &contour_api_v1.FaultInjectionPolicy{
+					Abort: &contour_api_v1.FaultAbort{
+						StatusCode: 503,
+						Percentage: 150,
+					},
+				},
+			}},
+		},
+	}
+
+	run(t, "invalid HTTPProxy with a fault injection abort percentage out of range", testcase{
+		objs: []interface{}{proxyInvalidFaultInjectionPolicy, fixture.ServiceRootsHome},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxyInvalidFaultInjectionPolicy.Name, Namespace: proxyInvalidFaultInjectionPolicy.Namespace}: fixture.NewValidCondition().
+				WithError("RouteError", "FaultInjectionPolicyNotValid", "route.faultInjectionPolicy is invalid: abort percentage 150 must be in the range 1-100"),
+		},
+	})
+
+	proxyInvalidHealthCheckPolicy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "health",
+			Namespace: "roots",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "health.example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				HealthCheckPolicy: &contour_api_v1.HTTPHealthCheckPolicy{
+					Path: "/healthz",
+					GRPC: &contour_api_v1.GRPCHealthCheck{},
+				},
+				Services: []contour_api_v1.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "invalid HTTPProxy with both an HTTP and a gRPC health check", testcase{
+		objs: []interface{}{proxyInvalidHealthCheckPolicy, fixture.ServiceRootsHome},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxyInvalidHealthCheckPolicy.Name, Namespace: proxyInvalidHealthCheckPolicy.Namespace}: fixture.NewValidCondition().
+				WithError("RouteError", "HealthCheckPolicyNotValid", "route.healthCheckPolicy is invalid: cannot specify both a path and a gRPC health check"),
+		},
+	})
+
+	proxyInvalidHealthCheckHeaders := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "health",
+			Namespace: "roots",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "health.example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				HealthCheckPolicy: &contour_api_v1.HTTPHealthCheckPolicy{
+					Path: "/healthz",
+					RequestHeaders: []contour_api_v1.HeaderValue{{
+						Name:  "Host",
+						Value: "health.example.com",
+					}},
+				},
+				Services: []contour_api_v1.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "invalid HTTPProxy with a health check that rewrites the host header", testcase{
+		objs: []interface{}{proxyInvalidHealthCheckHeaders, fixture.ServiceRootsHome},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxyInvalidHealthCheckHeaders.Name, Namespace: proxyInvalidHealthCheckHeaders.Namespace}: fixture.NewValidCondition().
+				WithError("RouteError", "HealthCheckPolicyNotValid", `route.healthCheckPolicy is invalid: rewriting "Host" header is not supported`),
+		},
+	})
+
+	proxyInvalidGRPCHealthCheckProtocol := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "health",
+			Namespace: "roots",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "health.example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				HealthCheckPolicy: &contour_api_v1.HTTPHealthCheckPolicy{
+					GRPC: &contour_api_v1.GRPCHealthCheck{},
+				},
+				Services: []contour_api_v1.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "invalid HTTPProxy with a gRPC health check on an HTTP/1.1 service", testcase{
+		objs: []interface{}{proxyInvalidGRPCHealthCheckProtocol, fixture.ServiceRootsHome},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxyInvalidGRPCHealthCheckProtocol.Name, Namespace: proxyInvalidGRPCHealthCheckProtocol.Namespace}: fixture.NewValidCondition().
+				WithError("ServiceError", "GRPCHealthCheckNotSupported", `service "home": gRPC health checks require the h2 or h2c protocol`),
+		},
+	})
+
+	proxyInvalidTCPProxyOutlierDetection := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "simple",
+			Namespace: "roots",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "passthrough.example.com",
+				TLS: &contour_api_v1.TLS{
+					Passthrough: true,
+				},
+			},
+			TCPProxy: &contour_api_v1.TCPProxy{
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+					Port: 8080,
+					OutlierDetection: &contour_api_v1.OutlierDetection{
+						MaxEjectionPercent: 110,
+					},
+				}},
+			},
+		},
+	}
+
+	run(t, "tcpproxy with an invalid outlier detection max ejection percent", testcase{
+		objs: []interface{}{proxyInvalidTCPProxyOutlierDetection, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxyInvalidTCPProxyOutlierDetection.Name, Namespace: proxyInvalidTCPProxyOutlierDetection.Namespace}: fixture.NewValidCondition().
+				WithError("TCPProxyError", "OutlierDetectionNotValid", `service "kuard": outlierDetection is invalid: max ejection percent 110 must be in the range 0-100`),
+		},
+	})
+
+	fallbackCertificate := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &contour_api_v1.TLS{
+					SecretName:                "ssl-cert",
+					EnableFallbackCertificate: true,
+				},
+			},
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/foo",
+				}},
+				Services: []contour_api_v1.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "invalid fallback certificate passed to contour", testcase{
+		fallbackCertificate: &types.NamespacedName{
+			Name:      "invalid",
+			Namespace: "invalid",
+		},
+		objs: []interface{}{fallbackCertificate, fixture.SecretRootsFallback, fixture.SecretRootsCert, fixture.ServiceRootsHome},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: fallbackCertificate.Name,
+				Namespace: fallbackCertificate.Namespace}: fixture.NewValidCondition().
+				WithError("TLSError", "FallbackNotValid", `Spec.Virtualhost.TLS Secret "invalid/invalid" fallback certificate is invalid: Secret not found`),
+		},
+	})
+
+	run(t, "fallback certificate requested but cert not configured in contour", testcase{
+		objs: []interface{}{fallbackCertificate, fixture.SecretRootsFallback, fixture.SecretRootsCert, fixture.ServiceRootsHome},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: fallbackCertificate.Name,
+				Namespace: fallbackCertificate.Namespace}: fixture.NewValidCondition().
+				WithError("TLSError", "FallbackNotPresent", "Spec.Virtualhost.TLS enabled fallback but the fallback Certificate Secret is not configured in Contour configuration file"),
+		},
+	})
+
+	fallbackCertificateWithClientValidation := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &contour_api_v1.TLS{
+					SecretName:                "ssl-cert",
+					EnableFallbackCertificate: true,
+					ClientValidation: &contour_api_v1.DownstreamValidation{
+						CACertificate: "something",
+					},
+				},
+			},
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/foo",
+				}},
+				Services: []contour_api_v1.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "fallback certificate requested and clientValidation also configured", testcase{
+		objs: []interface{}{fallbackCertificateWithClientValidation, fixture.SecretRootsFallback, fixture.SecretRootsCert, fixture.ServiceRootsHome},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: fallbackCertificateWithClientValidation.Name,
+				Namespace: fallbackCertificateWithClientValidation.Namespace}: fixture.NewValidCondition().
+				WithError("TLSError", "TLSIncompatibleFeatures", "Spec.Virtualhost.TLS fallback & client validation are incompatible"),
+		},
+	})
+
+	tlsPassthroughAndValidation := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "invalid",
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "tcpproxy.example.com",
+				TLS: &contour_api_v1.TLS{
+					Passthrough: true,
+					ClientValidation: &contour_api_v1.DownstreamValidation{
+						CACertificate: "aCAcert",
+					},
+				},
+			},
+			TCPProxy: &contour_api_v1.TCPProxy{},
+		},
+	}
+
+	run(t, "passthrough and client auth are incompatible tlsPassthroughAndValidation", testcase{
+		objs: []interface{}{fixture.SecretRootsCert, tlsPassthroughAndValidation},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: tlsPassthroughAndValidation.Name, Namespace: tlsPassthroughAndValidation.Namespace}: fixture.NewValidCondition().
+				WithError("TLSError", "TLSIncompatibleFeatures", "Spec.VirtualHost.TLS passthrough cannot be combined with tls.clientValidation"),
+		},
+	})
+
+	tlsPassthroughAndSecretName := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "invalid",
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "tcpproxy.example.com",
+				TLS: &contour_api_v1.TLS{
+					Passthrough: true,
+					SecretName:  fixture.SecretRootsCert.Name,
+				},
+			},
+			TCPProxy: &contour_api_v1.TCPProxy{},
+		},
+	}
+
+	run(t, "tcpproxy with TLS passthrough and secret name both specified", testcase{
+		objs: []interface{}{
+			fixture.SecretRootsCert,
+			tlsPassthroughAndSecretName,
+		},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: "invalid", Namespace: fixture.ServiceRootsKuard.Namespace}: fixture.NewValidCondition().
+				WithError("TLSError", "TLSConfigNotValid", "Spec.VirtualHost.TLS: both Passthrough and SecretName were specified"),
+		},
+	})
+
+	tlsNoPassthroughOrSecretName := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "invalid",
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "tcpproxy.example.com",
+				TLS: &contour_api_v1.TLS{
+					Passthrough: false,
+					SecretName:  "",
+				},
+			},
+			TCPProxy: &contour_api_v1.TCPProxy{},
+		},
+	}
+
+	run(t, "httpproxy w/ tcpproxy with neither TLS passthrough nor secret name specified", testcase{
+		objs: []interface{}{
+			fixture.SecretRootsCert,
+			tlsNoPassthroughOrSecretName,
+		},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: "invalid", Namespace: fixture.ServiceRootsKuard.Namespace}: fixture.NewValidCondition().
+				WithError("TLSError", "TLSConfigNotValid", "Spec.VirtualHost.TLS: neither Passthrough nor SecretName were specified"),
+		},
+	})
+
+	emptyProxy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "empty",
+			Namespace: "roots",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+		},
+	}
+
+	run(t, "proxy with no routes, includes, or tcpproxy is invalid", testcase{
+		objs: []interface{}{emptyProxy},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: emptyProxy.Name, Namespace: emptyProxy.Namespace}: fixture.NewValidCondition().
+				WithError("SpecError", "NothingDefined", "HTTPProxy.Spec must have at least one Route, Include, or a TCPProxy"),
+		},
+	})
+
+	invalidRequestHeadersPolicyService := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "invalidRHPService",
 			Namespace: fixture.ServiceRootsKuard.Namespace,
 		},
 		Spec: contour_api_v1.HTTPProxySpec{
@@ -2333,4 +3005,168 @@ func TestDAGStatus(t *testing.T) {
 		},
 	})
 
+	namedListeners := []NamedListener{{
+		Name:     "admin",
+		Port:     8443,
+		Protocol: ListenerProtocolHTTPS,
+	}, {
+		Name:     "internal",
+		Port:     9000,
+		Protocol: ListenerProtocolHTTP,
+	}}
+
+	proxyAdminListener := fixture.NewProxy("roots/admin").
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "admin.example.com",
+				TLS: &contour_api_v1.TLS{
+					SecretName: fixture.SecretRootsCert.Name,
+				},
+				Listener: "admin",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{Name: fixture.ServiceRootsKuard.Name, Port: 8080}},
+			}},
+		})
+
+	run(t, "proxy attached to an https listener is valid", testcase{
+		objs:      []interface{}{fixture.SecretRootsCert, fixture.ServiceRootsKuard, proxyAdminListener},
+		listeners: namedListeners,
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxyAdminListener.Name, Namespace: proxyAdminListener.Namespace}: fixture.NewValidCondition().Valid(),
+		},
+	})
+
+	proxyUnknownListener := fixture.NewProxy("roots/unknown-listener").
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn:     "example.com",
+				Listener: "missing",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{Name: fixture.ServiceRootsKuard.Name, Port: 8080}},
+			}},
+		})
+
+	run(t, "proxy attached to an unknown listener is invalid", testcase{
+		objs:      []interface{}{fixture.ServiceRootsKuard, proxyUnknownListener},
+		listeners: namedListeners,
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxyUnknownListener.Name, Namespace: proxyUnknownListener.Namespace}: fixture.NewValidCondition().
+				WithError("ListenerError", "ListenerNotFound", `Spec.VirtualHost.Listener "missing" is not configured`),
+		},
+	})
+
+	proxyInsecureOnHTTPSListener := fixture.NewProxy("roots/insecure-admin").
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn:     "example.com",
+				Listener: "admin",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{Name: fixture.ServiceRootsKuard.Name, Port: 8080}},
+			}},
+		})
+
+	run(t, "proxy without TLS attached to an https listener is invalid", testcase{
+		objs:      []interface{}{fixture.ServiceRootsKuard, proxyInsecureOnHTTPSListener},
+		listeners: namedListeners,
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxyInsecureOnHTTPSListener.Name, Namespace: proxyInsecureOnHTTPSListener.Namespace}: fixture.NewValidCondition().
+				WithError("ListenerError", "TLSMustBeConfigured", `Spec.VirtualHost.Listener "admin" requires that Spec.VirtualHost.TLS be set`),
+		},
+	})
+
+	proxySecureOnHTTPListener := fixture.NewProxy("roots/secure-internal").
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &contour_api_v1.TLS{
+					SecretName: fixture.SecretRootsCert.Name,
+				},
+				Listener: "internal",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{Name: fixture.ServiceRootsKuard.Name, Port: 8080}},
+			}},
+		})
+
+	run(t, "proxy with TLS attached to an http listener is invalid", testcase{
+		objs:      []interface{}{fixture.SecretRootsCert, fixture.ServiceRootsKuard, proxySecureOnHTTPListener},
+		listeners: namedListeners,
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxySecureOnHTTPListener.Name, Namespace: proxySecureOnHTTPListener.Namespace}: fixture.NewValidCondition().
+				WithError("ListenerError", "TLSNotPermitted", `Spec.VirtualHost.Listener "internal" cannot be used with Spec.VirtualHost.TLS`),
+		},
+	})
+
+	proxyInvalidTracingPolicy := fixture.NewProxy("roots/invalid-tracing-policy").
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+				TracingPolicy: &contour_api_v1.TracingPolicy{
+					SamplingRate: "150",
+				},
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{Name: fixture.ServiceRootsKuard.Name, Port: 8080}},
+			}},
+		})
+
+	run(t, "proxy with an invalid tracing sampling rate is invalid", testcase{
+		objs: []interface{}{fixture.ServiceRootsKuard, proxyInvalidTracingPolicy},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxyInvalidTracingPolicy.Name, Namespace: proxyInvalidTracingPolicy.Namespace}: fixture.NewValidCondition().
+				WithError("TracingError", "PolicyDidNotParse", `Spec.VirtualHost.TracingPolicy: invalid sampling rate "150"`),
+		},
+	})
+
+	// Each of these proxies uses a different rate limit domain, so
+	// each needs its own rate limit filter stage. Envoy only has
+	// stages for the first 11, so rate limiting is disabled for the
+	// last host.
+	rateLimitExtension := &v1alpha1.ExtensionService{
+		ObjectMeta: fixture.ObjectMeta("roots/ratelimit"),
+		Spec: v1alpha1.ExtensionServiceSpec{
+			Services: []v1alpha1.ExtensionServiceTarget{
+				{Name: fixture.ServiceRootsKuard.Name, Port: 8080},
+			},
+		},
+	}
+
+	rateLimitObjs := []interface{}{fixture.ServiceRootsKuard, rateLimitExtension}
+	rateLimitWant := map[types.NamespacedName]contour_api_v1.DetailedCondition{}
+
+	for i := 0; i <= maxRateLimitStage+1; i++ {
+		proxy := fixture.NewProxy(fmt.Sprintf("roots/ratelimit-%02d", i)).
+			WithFQDN(fmt.Sprintf("www%02d.example.com", i)).
+			WithRateLimitPolicy(contour_api_v1.VirtualHostRateLimitPolicy{
+				ExtensionServiceRef: contour_api_v1.ExtensionServiceReference{
+					Name: rateLimitExtension.Name,
+				},
+				Domain: fmt.Sprintf("domain%02d", i),
+				Descriptors: []contour_api_v1.RateLimitDescriptor{{
+					Entries: []contour_api_v1.RateLimitDescriptorEntry{{
+						RemoteAddress: &contour_api_v1.RemoteAddressDescriptor{},
+					}},
+				}},
+			}).
+			WithSpec(contour_api_v1.HTTPProxySpec{
+				Routes: []contour_api_v1.Route{{
+					Services: []contour_api_v1.Service{{Name: fixture.ServiceRootsKuard.Name, Port: 8080}},
+				}},
+			})
+
+		rateLimitObjs = append(rateLimitObjs, proxy)
+		rateLimitWant[types.NamespacedName{Name: proxy.Name, Namespace: proxy.Namespace}] = fixture.NewValidCondition().Valid()
+	}
+
+	rateLimitWant[types.NamespacedName{Name: fmt.Sprintf("ratelimit-%02d", maxRateLimitStage+1), Namespace: "roots"}] = fixture.NewValidCondition().
+		WithError("RateLimitError", "TooManyRateLimitServices",
+			"Spec.Virtualhost.RateLimitPolicy: rate limiting is disabled because the listener already uses the maximum of 11 rate limit services")
+
+	run(t, "proxy whose rate limit service exceeds the listener's rate limit stages is invalid", testcase{
+		objs: rateLimitObjs,
+		want: rateLimitWant,
+	})
 }
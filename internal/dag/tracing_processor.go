@@ -0,0 +1,129 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"github.com/projectcontour/contour/internal/timeout"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// TracingProcessor adds the distributed tracing configuration
+// to the listeners in the DAG. Since it configures the listeners
+// that are already defined as roots in the DAG, it must run after
+// the ListenerProcessor.
+//
+// The trace collector is either an ExtensionService or a Kubernetes
+// Service. If the collector can't be found, tracing is disabled so
+// that Envoy isn't configured to send traces to a missing cluster.
+type TracingProcessor struct {
+	logrus.FieldLogger
+
+	// Provider is the tracing provider, either TracingProviderZipkin
+	// or TracingProviderOpenCensus. If empty, tracing is disabled.
+	Provider string
+
+	// ExtensionService is the name of the ExtensionService
+	// that traces are sent to.
+	ExtensionService *types.NamespacedName
+
+	// Service is the name of the Kubernetes Service that traces
+	// are sent to, and ServicePort the port of that Service.
+	Service     *types.NamespacedName
+	ServicePort int
+
+	// CollectorEndpoint is the path that Zipkin traces are posted to.
+	CollectorEndpoint string
+
+	// SamplingRate is the percentage of requests that are traced.
+	SamplingRate float64
+
+	// MaxPathTagLength is the maximum length of the request
+	// path that is recorded in a span.
+	MaxPathTagLength uint32
+
+	// CustomTags are the additional tags that are
+	// recorded in every span.
+	CustomTags []TracingCustomTag
+
+	// ConnectTimeout is the default timeout for establishing
+	// connections to the collector Service.
+	ConnectTimeout timeout.Setting
+
+	// UpstreamIdleTimeout is the default timeout after which
+	// idle connections to the collector Service are closed.
+	UpstreamIdleTimeout timeout.Setting
+}
+
+var _ Processor = &TracingProcessor{}
+
+// Run sets the tracing configuration of each listener in the DAG.
+func (p *TracingProcessor) Run(dag *DAG, cache *KubernetesCache) {
+	if p.Provider == "" {
+		return
+	}
+
+	tracing := &Tracing{
+		Provider:          p.Provider,
+		CollectorEndpoint: p.CollectorEndpoint,
+		SamplingRate:      p.SamplingRate,
+		MaxPathTagLength:  p.MaxPathTagLength,
+		CustomTags:        p.CustomTags,
+	}
+
+	switch {
+	case p.ExtensionService != nil:
+		ext := dag.GetExtensionCluster(extensionClusterName(*p.ExtensionService))
+		if ext == nil {
+			p.WithField("extensionservice", p.ExtensionService).
+				Error("tracing collector ExtensionService is not found or is not valid, tracing is disabled")
+			return
+		}
+
+		tracing.ExtensionCluster = ext
+	case p.Service != nil:
+		svc, err := dag.EnsureService(*p.Service, intstr.FromInt(p.ServicePort), cache)
+		if err != nil {
+			p.WithError(err).WithField("service", p.Service).
+				Error("tracing collector Service is not valid, tracing is disabled")
+			return
+		}
+
+		protocol := svc.Protocol
+		if protocol == "" && p.Provider == TracingProviderOpenCensus {
+			// The OpenCensus agent protocol is gRPC, so the
+			// collector must be reached over HTTP/2.
+			protocol = "h2c"
+		}
+
+		tracing.Cluster = &Cluster{
+			Upstream: svc,
+			Protocol: protocol,
+			ClusterTimeoutPolicy: ClusterTimeoutPolicy{
+				ConnectTimeout: p.ConnectTimeout,
+				IdleTimeout:    p.UpstreamIdleTimeout,
+			},
+		}
+	default:
+		p.Error("no tracing collector is configured, tracing is disabled")
+		return
+	}
+
+	for _, root := range dag.roots {
+		if l, ok := root.(*Listener); ok {
+			l.Tracing = tracing
+		}
+	}
+}
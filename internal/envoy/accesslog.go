@@ -13,9 +13,16 @@
 
 package envoy
 
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
 //JSONFields is the canonical translation table for JSON fields to Envoy log template formats,
 //used for specifying fields for Envoy to log when JSON logging is enabled.
-//Only fields specified in this map may be used for JSON logging.
+//Fields that aren't in this map must be given a template, see ParseJSONField.
 var JSONFields = map[string]string{
 	"@timestamp":                "%START_TIME%",
 	"ts":                        "%START_TIME%",
@@ -66,3 +73,124 @@ var DefaultFields = []string{
 	"user_agent",
 	"x_forwarded_for",
 }
+
+// operatorArguments describes whether an access log
+// command operator takes an argument in parentheses.
+type operatorArguments int
+
+const (
+	argumentsNone operatorArguments = iota
+	argumentsOptional
+	argumentsRequired
+)
+
+// accessLogOperators are the command operators that may be used
+// in access log format strings and JSON field templates.
+// See https://www.envoyproxy.io/docs/envoy/latest/configuration/observability/access_log/usage#command-operators
+var accessLogOperators = map[string]operatorArguments{
+	"BYTES_RECEIVED":                                argumentsNone,
+	"BYTES_SENT":                                    argumentsNone,
+	"CONNECTION_ID":                                 argumentsNone,
+	"CONNECTION_TERMINATION_DETAILS":                argumentsNone,
+	"DOWNSTREAM_DIRECT_REMOTE_ADDRESS":              argumentsNone,
+	"DOWNSTREAM_DIRECT_REMOTE_ADDRESS_WITHOUT_PORT": argumentsNone,
+	"DOWNSTREAM_LOCAL_ADDRESS":                      argumentsNone,
+	"DOWNSTREAM_LOCAL_ADDRESS_WITHOUT_PORT":         argumentsNone,
+	"DOWNSTREAM_LOCAL_PORT":                         argumentsNone,
+	"DOWNSTREAM_LOCAL_SUBJECT":                      argumentsNone,
+	"DOWNSTREAM_LOCAL_URI_SAN":                      argumentsNone,
+	"DOWNSTREAM_PEER_CERT":                          argumentsNone,
+	"DOWNSTREAM_PEER_CERT_V_END":                    argumentsOptional,
+	"DOWNSTREAM_PEER_CERT_V_START":                  argumentsOptional,
+	"DOWNSTREAM_PEER_FINGERPRINT_1":                 argumentsNone,
+	"DOWNSTREAM_PEER_FINGERPRINT_256":               argumentsNone,
+	"DOWNSTREAM_PEER_ISSUER":                        argumentsNone,
+	"DOWNSTREAM_PEER_SERIAL":                        argumentsNone,
+	"DOWNSTREAM_PEER_SUBJECT":                       argumentsNone,
+	"DOWNSTREAM_PEER_URI_SAN":                       argumentsNone,
+	"DOWNSTREAM_REMOTE_ADDRESS":                     argumentsNone,
+	"DOWNSTREAM_REMOTE_ADDRESS_WITHOUT_PORT":        argumentsNone,
+	"DOWNSTREAM_TLS_CIPHER":                         argumentsNone,
+	"DOWNSTREAM_TLS_SESSION_ID":                     argumentsNone,
+	"DOWNSTREAM_TLS_VERSION":                        argumentsNone,
+	"DURATION":                                      argumentsNone,
+	"DYNAMIC_METADATA":                              argumentsRequired,
+	"FILTER_STATE":                                  argumentsRequired,
+	"GRPC_STATUS":                                   argumentsNone,
+	"HOSTNAME":                                      argumentsNone,
+	"LOCAL_REPLY_BODY":                              argumentsNone,
+	"PROTOCOL":                                      argumentsNone,
+	"REQ":                                           argumentsRequired,
+	"REQUESTED_SERVER_NAME":                         argumentsNone,
+	"REQUEST_DURATION":                              argumentsNone,
+	"RESP":                                          argumentsRequired,
+	"RESPONSE_CODE":                                 argumentsNone,
+	"RESPONSE_CODE_DETAILS":                         argumentsNone,
+	"RESPONSE_DURATION":                             argumentsNone,
+	"RESPONSE_FLAGS":                                argumentsNone,
+	"RESPONSE_TX_DURATION":                          argumentsNone,
+	"ROUTE_NAME":                                    argumentsNone,
+	"START_TIME":                                    argumentsOptional,
+	"TRAILER":                                       argumentsRequired,
+	"UPSTREAM_CLUSTER":                              argumentsNone,
+	"UPSTREAM_HOST":                                 argumentsNone,
+	"UPSTREAM_LOCAL_ADDRESS":                        argumentsNone,
+	"UPSTREAM_TRANSPORT_FAILURE_REASON":             argumentsNone,
+}
+
+// commandOperatorRegexp matches a command operator, its optional
+// argument in parentheses and its optional maximum length.
+var commandOperatorRegexp = regexp.MustCompile(`%([A-Z0-9_]+)(\(([^)]*)\))?(:[0-9]+)?%`)
+
+// ValidateAccessLogFormat returns an error if the supplied Envoy
+// access log format contains an unknown or malformed command operator.
+func ValidateAccessLogFormat(format string) error {
+	for _, match := range commandOperatorRegexp.FindAllStringSubmatch(format, -1) {
+		operator, hasArgument, argument := match[1], match[2] != "", match[3]
+
+		args, ok := accessLogOperators[operator]
+		if !ok {
+			return fmt.Errorf("unknown command operator %q", operator)
+		}
+
+		switch {
+		case args == argumentsNone && hasArgument:
+			return fmt.Errorf("command operator %q does not take an argument", operator)
+		case args == argumentsRequired && argument == "":
+			return fmt.Errorf("command operator %q requires an argument", operator)
+		}
+	}
+
+	// Envoy rejects a format that has a '%' which
+	// doesn't belong to a complete command operator.
+	if strings.Contains(commandOperatorRegexp.ReplaceAllString(format, ""), "%") {
+		return errors.New("unterminated or malformed command operator")
+	}
+
+	return nil
+}
+
+// ParseJSONField returns the name and Envoy access log template of the
+// supplied JSON field. The field is either the name of one of the
+// JSONFields, or a custom field of the form "name=template".
+func ParseJSONField(field string) (string, string, error) {
+	if i := strings.Index(field, "="); i >= 0 {
+		name, template := field[:i], field[i+1:]
+		if name == "" {
+			return "", "", fmt.Errorf("JSON field %q must have a name", field)
+		}
+		if template == "" {
+			return "", "", fmt.Errorf("JSON field %q must have a template", field)
+		}
+		if err := ValidateAccessLogFormat(template); err != nil {
+			return "", "", fmt.Errorf("invalid template for JSON field %q: %w", name, err)
+		}
+		return name, template, nil
+	}
+
+	template, ok := JSONFields[field]
+	if !ok {
+		return "", "", fmt.Errorf("unknown JSON field %q", field)
+	}
+	return field, template, nil
+}
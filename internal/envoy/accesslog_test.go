@@ -0,0 +1,129 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAccessLogFormat(t *testing.T) {
+	tests := map[string]struct {
+		format string
+		want   error
+	}{
+		"empty": {
+			format: "",
+			want:   nil,
+		},
+		"literal text": {
+			format: "request served\n",
+			want:   nil,
+		},
+		"operators": {
+			format: "[%START_TIME%] \"%REQ(:METHOD)% %REQ(X-ENVOY-ORIGINAL-PATH?:PATH):64%\" %RESPONSE_CODE% %DURATION%\n",
+			want:   nil,
+		},
+		"operator arguments": {
+			format: "%START_TIME(%Y/%m/%dT%H:%M:%S%z)% %DYNAMIC_METADATA(envoy.filters.http.ext_authz:user)% %FILTER_STATE(my.key:PLAIN)%",
+			want:   nil,
+		},
+		"unknown operator": {
+			format: "%START_TIME% %UNKNOWN%",
+			want:   errors.New("unknown command operator \"UNKNOWN\""),
+		},
+		"missing argument": {
+			format: "%REQ% %RESPONSE_CODE%",
+			want:   errors.New("command operator \"REQ\" requires an argument"),
+		},
+		"empty argument": {
+			format: "%RESP()%",
+			want:   errors.New("command operator \"RESP\" requires an argument"),
+		},
+		"unexpected argument": {
+			format: "%RESPONSE_CODE(X-CODE)%",
+			want:   errors.New("command operator \"RESPONSE_CODE\" does not take an argument"),
+		},
+		"unterminated operator": {
+			format: "%START_TIME% %REQ(X-TENANT-ID)",
+			want:   errors.New("unterminated or malformed command operator"),
+		},
+		"lowercase operator": {
+			format: "%start_time%",
+			want:   errors.New("unterminated or malformed command operator"),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, ValidateAccessLogFormat(tc.format))
+		})
+	}
+}
+
+func TestParseJSONField(t *testing.T) {
+	tests := map[string]struct {
+		field        string
+		wantName     string
+		wantTemplate string
+		wantErr      string
+	}{
+		"built-in field": {
+			field:        "method",
+			wantName:     "method",
+			wantTemplate: "%REQ(:METHOD)%",
+		},
+		"custom field": {
+			field:        "tenant_id=%REQ(X-TENANT-ID)%",
+			wantName:     "tenant_id",
+			wantTemplate: "%REQ(X-TENANT-ID)%",
+		},
+		"custom field with literal text": {
+			field:        "route=route:%ROUTE_NAME%",
+			wantName:     "route",
+			wantTemplate: "route:%ROUTE_NAME%",
+		},
+		"unknown field": {
+			field:   "tenant_id",
+			wantErr: "unknown JSON field \"tenant_id\"",
+		},
+		"missing name": {
+			field:   "=%REQ(X-TENANT-ID)%",
+			wantErr: "JSON field \"=%REQ(X-TENANT-ID)%\" must have a name",
+		},
+		"missing template": {
+			field:   "tenant_id=",
+			wantErr: "JSON field \"tenant_id=\" must have a template",
+		},
+		"invalid template": {
+			field:   "tenant_id=%REQ%",
+			wantErr: "invalid template for JSON field \"tenant_id\": command operator \"REQ\" requires an argument",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			name, template, err := ParseJSONField(tc.field)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantName, name)
+			assert.Equal(t, tc.wantTemplate, template)
+		})
+	}
+}
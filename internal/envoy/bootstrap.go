@@ -80,6 +80,25 @@ type BootstrapConfig struct {
 	// referenced in the configuration actually exist. This option is for
 	// testing only.
 	SkipFilePathCheck bool
+
+	// UseADS specifies whether Envoy fetches its resources over a
+	// single Aggregated Discovery Service stream, rather than a
+	// separate stream for each resource type.
+	UseADS bool
+
+	// UseDelta specifies whether Envoy fetches its resources with
+	// the incremental xDS protocol, which sends only the resources
+	// that have changed, rather than the state of the world protocol.
+	UseDelta bool
+
+	// XDSResourceVersion is the version of the Envoy API that the
+	// bootstrap configuration and xDS resources use, either "v2" or "v3".
+	// Defaults to "v2".
+	XDSResourceVersion string
+
+	// XDSServerType is the type of xDS server that Contour runs,
+	// either "contour" or "envoy". Defaults to "contour".
+	XDSServerType string
 }
 
 func (c *BootstrapConfig) GetXdsAddress() string { return stringOrDefault(c.XDSAddress, "127.0.0.1") }
@@ -91,6 +110,12 @@ func (c *BootstrapConfig) GetAdminPort() int { return intOrDefault(c.AdminPort,
 func (c *BootstrapConfig) GetAdminAccessLogPath() string {
 	return stringOrDefault(c.AdminAccessLogPath, "/dev/null")
 }
+func (c *BootstrapConfig) GetXDSResourceVersion() string {
+	return stringOrDefault(c.XDSResourceVersion, "v2")
+}
+func (c *BootstrapConfig) GetXDSServerType() string {
+	return stringOrDefault(c.XDSServerType, "contour")
+}
 
 func stringOrDefault(s, def string) string {
 	if s == "" {
@@ -17,6 +17,7 @@ import (
 	"crypto/sha1" // nolint:gosec
 	"crypto/sha256"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -27,6 +28,9 @@ import (
 func Clustername(cluster *dag.Cluster) string {
 	service := cluster.Upstream
 	buf := cluster.LoadBalancerPolicy
+	if rh := cluster.RingHashPolicy; rh != nil {
+		buf += fmt.Sprintf("/%d/%d", rh.MinimumRingSize, rh.MaximumRingSize)
+	}
 	if hc := cluster.HTTPHealthCheckPolicy; hc != nil {
 		if hc.Timeout > 0 {
 			buf += hc.Timeout.String()
@@ -41,11 +45,46 @@ func Clustername(cluster *dag.Cluster) string {
 			buf += strconv.Itoa(int(hc.HealthyThreshold))
 		}
 		buf += hc.Path
+		if hc.UnhealthyInterval > 0 || hc.NoTrafficInterval > 0 || hc.Port > 0 {
+			buf += fmt.Sprintf("/%s/%s/%d", hc.UnhealthyInterval, hc.NoTrafficInterval, hc.Port)
+		}
+		for _, r := range hc.ExpectedStatuses {
+			buf += fmt.Sprintf("/%d-%d", r.Start, r.End)
+		}
+		if len(hc.RequestHeaders) > 0 {
+			keys := make([]string, 0, len(hc.RequestHeaders))
+			for k := range hc.RequestHeaders {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				buf += fmt.Sprintf("/%s=%s", k, hc.RequestHeaders[k])
+			}
+		}
+	}
+	if hc := cluster.GRPCHealthCheckPolicy; hc != nil {
+		buf += fmt.Sprintf("grpc/%s/%s/%s/%s/%d/%d", hc.ServiceName, hc.Authority,
+			hc.Timeout, hc.Interval, hc.UnhealthyThreshold, hc.HealthyThreshold)
+		if hc.UnhealthyInterval > 0 || hc.NoTrafficInterval > 0 || hc.Port > 0 {
+			buf += fmt.Sprintf("/%s/%s/%d", hc.UnhealthyInterval, hc.NoTrafficInterval, hc.Port)
+		}
 	}
 	if uv := cluster.UpstreamValidation; uv != nil {
 		buf += uv.CACertificate.Object.ObjectMeta.Name
 		buf += uv.SubjectName
 	}
+	if od := cluster.OutlierDetectionPolicy; od != nil {
+		buf += fmt.Sprintf("%d/%d/%s/%s/%d", od.ConsecutiveServerErrors, od.ConsecutiveGatewayErrors,
+			od.Interval, od.BaseEjectionTime, od.MaxEjectionPercent)
+	}
+	if cb := cluster.CircuitBreakerPolicy; cb != nil {
+		buf += fmt.Sprintf("%d/%d/%d/%d", cb.MaxConnections, cb.MaxPendingRequests,
+			cb.MaxRequests, cb.MaxRetries)
+	}
+	if tp := cluster.ClusterTimeoutPolicy; !tp.ConnectTimeout.UseDefault() || !tp.IdleTimeout.UseDefault() {
+		buf += fmt.Sprintf("%s/%s/%t", tp.ConnectTimeout.Duration(), tp.IdleTimeout.Duration(),
+			tp.IdleTimeout.IsDisabled())
+	}
 
 	// This isn't a crypto hash, we just want a unique name.
 	hash := sha1.Sum([]byte(buf)) // nolint:gosec
@@ -14,11 +14,20 @@
 package v2
 
 import (
+	"strings"
+
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	accesslogv2 "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v2"
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	accesslog "github.com/envoyproxy/go-control-plane/envoy/config/filter/accesslog/v2"
+	envoy_extensions_access_loggers_grpc_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/access_loggers/grpc/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/golang/protobuf/proto"
 	_struct "github.com/golang/protobuf/ptypes/struct"
+	contour_api_v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/envoy"
+	envoy_v3 "github.com/projectcontour/contour/internal/envoy/v3"
 	"github.com/projectcontour/contour/internal/protobuf"
 )
 
@@ -36,6 +45,28 @@ func FileAccessLogEnvoy(path string) []*accesslog.AccessLog {
 	}}
 }
 
+// FileAccessLogFormat returns a new file based access log filter
+// that will output access logs in the supplied Envoy format.
+func FileAccessLogFormat(path string, format string) []*accesslog.AccessLog {
+	// Envoy doesn't terminate custom formats, so
+	// make sure that each log entry is on its own line.
+	if !strings.HasSuffix(format, "\n") {
+		format += "\n"
+	}
+
+	return []*accesslog.AccessLog{{
+		Name: wellknown.FileAccessLog,
+		ConfigType: &accesslog.AccessLog_TypedConfig{
+			TypedConfig: protobuf.MustMarshalAny(&accesslogv2.FileAccessLog{
+				Path: path,
+				AccessLogFormat: &accesslogv2.FileAccessLog_Format{
+					Format: format,
+				},
+			}),
+		},
+	}}
+}
+
 // FileAccessLogJSON returns a new file based access log filter
 // that will log in JSON format
 func FileAccessLogJSON(path string, keys []string) []*accesslog.AccessLog {
@@ -45,11 +76,10 @@ func FileAccessLogJSON(path string, keys []string) []*accesslog.AccessLog {
 	}
 
 	for _, k := range keys {
-		// This will silently ignore invalid headers.
-		// TODO(youngnick): this should tell users if a header is not valid
-		// https://github.com/projectcontour/contour/issues/1507
-		if template, ok := envoy.JSONFields[k]; ok {
-			jsonformat.Fields[k] = sv(template)
+		// This will silently ignore invalid fields, which
+		// are rejected when the configuration is loaded.
+		if name, template, err := envoy.ParseJSONField(k); err == nil {
+			jsonformat.Fields[name] = sv(template)
 		}
 	}
 
@@ -66,6 +96,84 @@ func FileAccessLogJSON(path string, keys []string) []*accesslog.AccessLog {
 	}}
 }
 
+// HTTPGRPCAccessLog returns a new access log filter that streams
+// HTTP access logs to the supplied gRPC access log service. If als
+// is nil, no access log filter is returned.
+func HTTPGRPCAccessLog(als *dag.AccessLogService) []*accesslog.AccessLog {
+	if als == nil {
+		return nil
+	}
+
+	config := accesslogv2.HttpGrpcAccessLogConfig{
+		CommonConfig: grpcAccessLogCommonConfig(als),
+	}
+
+	var typed proto.Message = &config
+
+	// As with authorization, the transport API version can
+	// only be set in the v3 access log configuration.
+	if als.ExtensionService.ProtocolVersion == contour_api_v1alpha1.SupportProtocolVersion3 {
+		v3 := envoy_v3.MustUpgrade(&config).(*envoy_extensions_access_loggers_grpc_v3.HttpGrpcAccessLogConfig)
+		v3.CommonConfig.TransportApiVersion = envoy_config_core_v3.ApiVersion_V3
+		typed = v3
+	}
+
+	return []*accesslog.AccessLog{{
+		Name: wellknown.HTTPGRPCAccessLog,
+		ConfigType: &accesslog.AccessLog_TypedConfig{
+			TypedConfig: protobuf.MustMarshalAny(typed),
+		},
+	}}
+}
+
+// TCPGRPCAccessLog returns a new access log filter that streams
+// TCP access logs to the supplied gRPC access log service. If als
+// is nil, no access log filter is returned.
+func TCPGRPCAccessLog(als *dag.AccessLogService) []*accesslog.AccessLog {
+	if als == nil {
+		return nil
+	}
+
+	config := accesslogv2.TcpGrpcAccessLogConfig{
+		CommonConfig: grpcAccessLogCommonConfig(als),
+	}
+
+	var typed proto.Message = &config
+
+	if als.ExtensionService.ProtocolVersion == contour_api_v1alpha1.SupportProtocolVersion3 {
+		v3 := envoy_v3.MustUpgrade(&config).(*envoy_extensions_access_loggers_grpc_v3.TcpGrpcAccessLogConfig)
+		v3.CommonConfig.TransportApiVersion = envoy_config_core_v3.ApiVersion_V3
+		typed = v3
+	}
+
+	return []*accesslog.AccessLog{{
+		Name: "envoy.access_loggers.tcp_grpc",
+		ConfigType: &accesslog.AccessLog_TypedConfig{
+			TypedConfig: protobuf.MustMarshalAny(typed),
+		},
+	}}
+}
+
+func grpcAccessLogCommonConfig(als *dag.AccessLogService) *accesslogv2.CommonGrpcAccessLogConfig {
+	config := &accesslogv2.CommonGrpcAccessLogConfig{
+		LogName: als.LogName,
+		GrpcService: &envoy_api_v2_core.GrpcService{
+			TargetSpecifier: &envoy_api_v2_core.GrpcService_EnvoyGrpc_{
+				EnvoyGrpc: &envoy_api_v2_core.GrpcService_EnvoyGrpc{
+					ClusterName: als.ExtensionService.Name,
+				},
+			},
+		},
+		BufferSizeBytes: protobuf.UInt32OrNil(als.BufferSizeBytes),
+	}
+
+	if als.BufferFlushInterval > 0 {
+		config.BufferFlushInterval = protobuf.Duration(als.BufferFlushInterval)
+	}
+
+	return config
+}
+
 func sv(s string) *_struct.Value {
 	return &_struct.Value{
 		Kind: &_struct.Value_StringValue{
@@ -15,11 +15,17 @@ package v2
 
 import (
 	"testing"
+	"time"
 
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	accesslog_v2 "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v2"
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_accesslog "github.com/envoyproxy/go-control-plane/envoy/config/filter/accesslog/v2"
+	envoy_extensions_access_loggers_grpc_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/access_loggers/grpc/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	_struct "github.com/golang/protobuf/ptypes/struct"
+	contour_api_v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/envoy"
 	"github.com/projectcontour/contour/internal/protobuf"
 )
@@ -49,6 +55,48 @@ func TestFileAccessLog(t *testing.T) {
 	}
 }
 
+func TestFileAccessLogFormat(t *testing.T) {
+	tests := map[string]struct {
+		format string
+		want   []*envoy_accesslog.AccessLog
+	}{
+		"newline is added": {
+			format: "%START_TIME% %REQ(X-TENANT-ID)%",
+			want: []*envoy_accesslog.AccessLog{{
+				Name: wellknown.FileAccessLog,
+				ConfigType: &envoy_accesslog.AccessLog_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&accesslog_v2.FileAccessLog{
+						Path: "/dev/stdout",
+						AccessLogFormat: &accesslog_v2.FileAccessLog_Format{
+							Format: "%START_TIME% %REQ(X-TENANT-ID)%\n",
+						},
+					}),
+				},
+			}},
+		},
+		"newline is kept": {
+			format: "%START_TIME% %REQ(X-TENANT-ID)%\n",
+			want: []*envoy_accesslog.AccessLog{{
+				Name: wellknown.FileAccessLog,
+				ConfigType: &envoy_accesslog.AccessLog_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&accesslog_v2.FileAccessLog{
+						Path: "/dev/stdout",
+						AccessLogFormat: &accesslog_v2.FileAccessLog_Format{
+							Format: "%START_TIME% %REQ(X-TENANT-ID)%\n",
+						},
+					}),
+				},
+			}},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := FileAccessLogFormat("/dev/stdout", tc.format)
+			protobuf.ExpectEqual(t, tc.want, got)
+		})
+	}
+}
+
 func TestJSONFileAccessLog(t *testing.T) {
 	tests := map[string]struct {
 		path    string
@@ -100,6 +148,33 @@ func TestJSONFileAccessLog(t *testing.T) {
 			},
 			},
 		},
+		"custom fields": {
+			path: "/dev/stdout",
+			headers: []string{
+				"@timestamp",
+				"tenant_id=%REQ(X-TENANT-ID)%",
+				"user=%DYNAMIC_METADATA(envoy.filters.http.ext_authz:user)%",
+				"invalid=%REQ%",
+			},
+			want: []*envoy_accesslog.AccessLog{{
+				Name: wellknown.FileAccessLog,
+				ConfigType: &envoy_accesslog.AccessLog_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&accesslog_v2.FileAccessLog{
+						Path: "/dev/stdout",
+						AccessLogFormat: &accesslog_v2.FileAccessLog_JsonFormat{
+							JsonFormat: &_struct.Struct{
+								Fields: map[string]*_struct.Value{
+									"@timestamp": sv(envoy.JSONFields["@timestamp"]),
+									"tenant_id":  sv("%REQ(X-TENANT-ID)%"),
+									"user":       sv("%DYNAMIC_METADATA(envoy.filters.http.ext_authz:user)%"),
+								},
+							},
+						},
+					}),
+				},
+			},
+			},
+		},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -108,3 +183,144 @@ func TestJSONFileAccessLog(t *testing.T) {
 		})
 	}
 }
+
+func TestGRPCAccessLog(t *testing.T) {
+	grpcService := func(cluster string) *envoy_api_v2_core.GrpcService {
+		return &envoy_api_v2_core.GrpcService{
+			TargetSpecifier: &envoy_api_v2_core.GrpcService_EnvoyGrpc_{
+				EnvoyGrpc: &envoy_api_v2_core.GrpcService_EnvoyGrpc{
+					ClusterName: cluster,
+				},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		als      *dag.AccessLogService
+		wantHTTP []*envoy_accesslog.AccessLog
+		wantTCP  []*envoy_accesslog.AccessLog
+	}{
+		"not configured": {
+			als:      nil,
+			wantHTTP: nil,
+			wantTCP:  nil,
+		},
+		"defaults": {
+			als: &dag.AccessLogService{
+				ExtensionService: &dag.ExtensionCluster{
+					Name: "extension/logging/als",
+				},
+				LogName: "contour",
+			},
+			wantHTTP: []*envoy_accesslog.AccessLog{{
+				Name: wellknown.HTTPGRPCAccessLog,
+				ConfigType: &envoy_accesslog.AccessLog_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&accesslog_v2.HttpGrpcAccessLogConfig{
+						CommonConfig: &accesslog_v2.CommonGrpcAccessLogConfig{
+							LogName:     "contour",
+							GrpcService: grpcService("extension/logging/als"),
+						},
+					}),
+				},
+			}},
+			wantTCP: []*envoy_accesslog.AccessLog{{
+				Name: "envoy.access_loggers.tcp_grpc",
+				ConfigType: &envoy_accesslog.AccessLog_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&accesslog_v2.TcpGrpcAccessLogConfig{
+						CommonConfig: &accesslog_v2.CommonGrpcAccessLogConfig{
+							LogName:     "contour",
+							GrpcService: grpcService("extension/logging/als"),
+						},
+					}),
+				},
+			}},
+		},
+		"buffering": {
+			als: &dag.AccessLogService{
+				ExtensionService: &dag.ExtensionCluster{
+					Name: "extension/logging/als",
+				},
+				LogName:             "edge",
+				BufferFlushInterval: 5 * time.Second,
+				BufferSizeBytes:     65536,
+			},
+			wantHTTP: []*envoy_accesslog.AccessLog{{
+				Name: wellknown.HTTPGRPCAccessLog,
+				ConfigType: &envoy_accesslog.AccessLog_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&accesslog_v2.HttpGrpcAccessLogConfig{
+						CommonConfig: &accesslog_v2.CommonGrpcAccessLogConfig{
+							LogName:             "edge",
+							GrpcService:         grpcService("extension/logging/als"),
+							BufferFlushInterval: protobuf.Duration(5 * time.Second),
+							BufferSizeBytes:     protobuf.UInt32(65536),
+						},
+					}),
+				},
+			}},
+			wantTCP: []*envoy_accesslog.AccessLog{{
+				Name: "envoy.access_loggers.tcp_grpc",
+				ConfigType: &envoy_accesslog.AccessLog_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&accesslog_v2.TcpGrpcAccessLogConfig{
+						CommonConfig: &accesslog_v2.CommonGrpcAccessLogConfig{
+							LogName:             "edge",
+							GrpcService:         grpcService("extension/logging/als"),
+							BufferFlushInterval: protobuf.Duration(5 * time.Second),
+							BufferSizeBytes:     protobuf.UInt32(65536),
+						},
+					}),
+				},
+			}},
+		},
+		"protocol version 3": {
+			als: &dag.AccessLogService{
+				ExtensionService: &dag.ExtensionCluster{
+					Name:            "extension/logging/als",
+					ProtocolVersion: contour_api_v1alpha1.SupportProtocolVersion3,
+				},
+				LogName: "contour",
+			},
+			wantHTTP: []*envoy_accesslog.AccessLog{{
+				Name: wellknown.HTTPGRPCAccessLog,
+				ConfigType: &envoy_accesslog.AccessLog_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&envoy_extensions_access_loggers_grpc_v3.HttpGrpcAccessLogConfig{
+						CommonConfig: &envoy_extensions_access_loggers_grpc_v3.CommonGrpcAccessLogConfig{
+							LogName: "contour",
+							GrpcService: &envoy_config_core_v3.GrpcService{
+								TargetSpecifier: &envoy_config_core_v3.GrpcService_EnvoyGrpc_{
+									EnvoyGrpc: &envoy_config_core_v3.GrpcService_EnvoyGrpc{
+										ClusterName: "extension/logging/als",
+									},
+								},
+							},
+							TransportApiVersion: envoy_config_core_v3.ApiVersion_V3,
+						},
+					}),
+				},
+			}},
+			wantTCP: []*envoy_accesslog.AccessLog{{
+				Name: "envoy.access_loggers.tcp_grpc",
+				ConfigType: &envoy_accesslog.AccessLog_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&envoy_extensions_access_loggers_grpc_v3.TcpGrpcAccessLogConfig{
+						CommonConfig: &envoy_extensions_access_loggers_grpc_v3.CommonGrpcAccessLogConfig{
+							LogName: "contour",
+							GrpcService: &envoy_config_core_v3.GrpcService{
+								TargetSpecifier: &envoy_config_core_v3.GrpcService_EnvoyGrpc_{
+									EnvoyGrpc: &envoy_config_core_v3.GrpcService_EnvoyGrpc{
+										ClusterName: "extension/logging/als",
+									},
+								},
+							},
+							TransportApiVersion: envoy_config_core_v3.ApiVersion_V3,
+						},
+					}),
+				},
+			}},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			protobuf.ExpectEqual(t, tc.wantHTTP, HTTPGRPCAccessLog(tc.als))
+			protobuf.ExpectEqual(t, tc.wantTCP, TCPGRPCAccessLog(tc.als))
+		})
+	}
+}
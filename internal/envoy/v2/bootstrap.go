@@ -18,6 +18,7 @@ package v2
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"path"
 	"strconv"
@@ -33,6 +34,7 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes/any"
 	"github.com/projectcontour/contour/internal/envoy"
+	envoy_v3 "github.com/projectcontour/contour/internal/envoy/v3"
 	"github.com/projectcontour/contour/internal/protobuf"
 )
 
@@ -52,7 +54,17 @@ func WriteBootstrap(c *envoy.BootstrapConfig) error {
 
 	// Write all configuration files out to filesystem.
 	for _, step := range steps {
-		if err := envoy.WriteConfig(step(c)); err != nil {
+		path, config := step(c)
+
+		// The v3 bootstrap is the v2 bootstrap, translated
+		// to the corresponding v3 API types.
+		if c.GetXDSResourceVersion() == "v3" {
+			if config, err = envoy_v3.Upgrade(config); err != nil {
+				return err
+			}
+		}
+
+		if err := envoy.WriteConfig(path, config); err != nil {
 			return err
 		}
 	}
@@ -64,6 +76,21 @@ type bootstrapf func(*envoy.BootstrapConfig) (string, proto.Message)
 
 // bootstrap creates a new v2 bootstrap configuration and associated resource files.
 func bootstrap(c *envoy.BootstrapConfig) ([]bootstrapf, error) {
+	// Only Contour's own xDS server orders the updates it sends
+	// over ADS, and points the resources it sends at the ADS
+	// stream rather than at separate streams.
+	if c.UseADS && c.GetXDSServerType() != "contour" {
+		return nil, fmt.Errorf("%q can only be used with the %q xDS server type",
+			"--use-ads", "contour")
+	}
+
+	// The go-control-plane server behind the envoy xDS server
+	// type doesn't implement the incremental xDS protocol.
+	if c.UseDelta && c.GetXDSServerType() != "contour" {
+		return nil, fmt.Errorf("%q can only be used with the %q xDS server type",
+			"--use-delta", "contour")
+	}
+
 	steps := []bootstrapf{}
 
 	if c.GrpcClientCert == "" && c.GrpcClientKey == "" && c.GrpcCABundle == "" {
@@ -148,10 +175,7 @@ func bootstrap(c *envoy.BootstrapConfig) ([]bootstrapf, error) {
 
 func bootstrapConfig(c *envoy.BootstrapConfig) *envoy_api_bootstrap.Bootstrap {
 	return &envoy_api_bootstrap.Bootstrap{
-		DynamicResources: &envoy_api_bootstrap.Bootstrap_DynamicResources{
-			LdsConfig: ConfigSource("contour"),
-			CdsConfig: ConfigSource("contour"),
-		},
+		DynamicResources: dynamicResources(c),
 		StaticResources: &envoy_api_bootstrap.Bootstrap_StaticResources{
 			Clusters: []*api.Cluster{{
 				Name:                 "contour",
@@ -197,7 +221,7 @@ func bootstrapConfig(c *envoy.BootstrapConfig) *envoy_api_bootstrap.Bootstrap {
 				LoadAssignment: &api.ClusterLoadAssignment{
 					ClusterName: "service-stats",
 					Endpoints: Endpoints(
-						SocketAddress(c.GetAdminAddress(), c.GetAdminPort()),
+						SocketAddress(loopbackAddress(c.GetAdminAddress()), c.GetAdminPort()),
 					),
 				},
 			}},
@@ -209,6 +233,56 @@ func bootstrapConfig(c *envoy.BootstrapConfig) *envoy_api_bootstrap.Bootstrap {
 	}
 }
 
+// loopbackAddress returns the loopback address of the same family
+// if address is an unspecified address, so that a client can connect
+// to a server listening on it. Otherwise address is returned as is.
+func loopbackAddress(address string) string {
+	ip := net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(address, "["), "]"))
+	switch {
+	case ip == nil || !ip.IsUnspecified():
+		return address
+	case ip.To4() != nil:
+		return "127.0.0.1"
+	default:
+		return "::1"
+	}
+}
+
+func dynamicResources(c *envoy.BootstrapConfig) *envoy_api_bootstrap.Bootstrap_DynamicResources {
+	// Contour rewrites the configuration sources in the resources
+	// it sends over the incremental xDS protocol, so that Envoy
+	// fetches everything else the same way.
+	contour := func() *envoy_api_v2_core.ConfigSource {
+		source := ConfigSource("contour")
+		if c.UseDelta {
+			source.GetApiConfigSource().ApiType = envoy_api_v2_core.ApiConfigSource_DELTA_GRPC
+		}
+		return source
+	}
+
+	if !c.UseADS {
+		return &envoy_api_bootstrap.Bootstrap_DynamicResources{
+			LdsConfig: contour(),
+			CdsConfig: contour(),
+		}
+	}
+
+	// Contour rewrites the configuration sources in the resources
+	// it sends over ADS, so that Envoy fetches everything else over
+	// the same stream.
+	ads := &envoy_api_v2_core.ConfigSource{
+		ConfigSourceSpecifier: &envoy_api_v2_core.ConfigSource_Ads{
+			Ads: &envoy_api_v2_core.AggregatedConfigSource{},
+		},
+	}
+
+	return &envoy_api_bootstrap.Bootstrap_DynamicResources{
+		LdsConfig: ads,
+		CdsConfig: ads,
+		AdsConfig: contour().GetApiConfigSource(),
+	}
+}
+
 func upstreamFileTLSContext(c *envoy.BootstrapConfig) *envoy_api_v2_auth.UpstreamTlsContext {
 	context := &envoy_api_v2_auth.UpstreamTlsContext{
 		CommonTlsContext: &envoy_api_v2_auth.CommonTlsContext{
@@ -14,11 +14,16 @@
 package v2
 
 import (
+	"io/ioutil"
+	"os"
 	"path"
 	"testing"
 
 	api "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	envoy_api_bootstrap "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v2"
+	envoy_config_bootstrap_v3 "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v3"
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_service_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	"github.com/golang/protobuf/jsonpb"
 	"github.com/golang/protobuf/proto"
 	"github.com/projectcontour/contour/internal/envoy"
@@ -153,6 +158,242 @@ func TestBootstrap(t *testing.T) {
       }
     }
   }
+}`,
+		},
+		"--use-ads": {
+			config: envoy.BootstrapConfig{
+				Path:      "envoy.json",
+				Namespace: "testing-ns",
+				UseADS:    true},
+			wantedBootstrapConfig: `{
+  "static_resources": {
+    "clusters": [
+      {
+        "name": "contour",
+        "alt_stat_name": "testing-ns_contour_8001",
+        "type": "STRICT_DNS",
+        "connect_timeout": "5s",
+        "load_assignment": {
+          "cluster_name": "contour",
+          "endpoints": [
+            {
+              "lb_endpoints": [
+                {
+                  "endpoint": {
+                    "address": {
+                      "socket_address": {
+                        "address": "127.0.0.1",
+                        "port_value": 8001
+                      }
+                    }
+                  }
+                }
+              ]
+            }
+          ]
+        },
+        "circuit_breakers": {
+          "thresholds": [
+            {
+              "priority": "HIGH",
+              "max_connections": 100000,
+              "max_pending_requests": 100000,
+              "max_requests": 60000000,
+              "max_retries": 50
+            },
+            {
+              "max_connections": 100000,
+              "max_pending_requests": 100000,
+              "max_requests": 60000000,
+              "max_retries": 50
+            }
+          ]
+        },
+        "http2_protocol_options": {},
+        "upstream_connection_options": {
+          "tcp_keepalive": {
+            "keepalive_probes": 3,
+            "keepalive_time": 30,
+            "keepalive_interval": 5
+          }
+        }
+      },
+      {
+        "name": "service-stats",
+        "alt_stat_name": "testing-ns_service-stats_9001",
+        "type": "LOGICAL_DNS",
+        "connect_timeout": "0.250s",
+        "load_assignment": {
+          "cluster_name": "service-stats",
+          "endpoints": [
+            {
+              "lb_endpoints": [
+                {
+                  "endpoint": {
+                    "address": {
+                      "socket_address": {
+                        "address": "127.0.0.1",
+                        "port_value": 9001
+                      }
+                    }
+                  }
+                }
+              ]
+            }
+          ]
+        }
+      }
+    ]
+  },
+  "dynamic_resources": {
+    "lds_config": {
+      "ads": {}
+    },
+    "cds_config": {
+      "ads": {}
+    },
+    "ads_config": {
+      "api_type": "GRPC",
+      "grpc_services": [
+        {
+          "envoy_grpc": {
+            "cluster_name": "contour"
+          }
+        }
+      ]
+    }
+  },
+  "admin": {
+    "access_log_path": "/dev/null",
+    "address": {
+      "socket_address": {
+        "address": "127.0.0.1",
+        "port_value": 9001
+      }
+    }
+  }
+}`,
+		},
+		"--use-delta": {
+			config: envoy.BootstrapConfig{
+				Path:      "envoy.json",
+				Namespace: "testing-ns",
+				UseDelta:  true},
+			wantedBootstrapConfig: `{
+  "static_resources": {
+    "clusters": [
+      {
+        "name": "contour",
+        "alt_stat_name": "testing-ns_contour_8001",
+        "type": "STRICT_DNS",
+        "connect_timeout": "5s",
+        "load_assignment": {
+          "cluster_name": "contour",
+          "endpoints": [
+            {
+              "lb_endpoints": [
+                {
+                  "endpoint": {
+                    "address": {
+                      "socket_address": {
+                        "address": "127.0.0.1",
+                        "port_value": 8001
+                      }
+                    }
+                  }
+                }
+              ]
+            }
+          ]
+        },
+        "circuit_breakers": {
+          "thresholds": [
+            {
+              "priority": "HIGH",
+              "max_connections": 100000,
+              "max_pending_requests": 100000,
+              "max_requests": 60000000,
+              "max_retries": 50
+            },
+            {
+              "max_connections": 100000,
+              "max_pending_requests": 100000,
+              "max_requests": 60000000,
+              "max_retries": 50
+            }
+          ]
+        },
+        "http2_protocol_options": {},
+        "upstream_connection_options": {
+          "tcp_keepalive": {
+            "keepalive_probes": 3,
+            "keepalive_time": 30,
+            "keepalive_interval": 5
+          }
+        }
+      },
+      {
+        "name": "service-stats",
+        "alt_stat_name": "testing-ns_service-stats_9001",
+        "type": "LOGICAL_DNS",
+        "connect_timeout": "0.250s",
+        "load_assignment": {
+          "cluster_name": "service-stats",
+          "endpoints": [
+            {
+              "lb_endpoints": [
+                {
+                  "endpoint": {
+                    "address": {
+                      "socket_address": {
+                        "address": "127.0.0.1",
+                        "port_value": 9001
+                      }
+                    }
+                  }
+                }
+              ]
+            }
+          ]
+        }
+      }
+    ]
+  },
+  "dynamic_resources": {
+    "lds_config": {
+      "api_config_source": {
+        "api_type": "DELTA_GRPC",
+        "grpc_services": [
+          {
+            "envoy_grpc": {
+              "cluster_name": "contour"
+            }
+          }
+        ]
+      }
+    },
+    "cds_config": {
+      "api_config_source": {
+        "api_type": "DELTA_GRPC",
+        "grpc_services": [
+          {
+            "envoy_grpc": {
+              "cluster_name": "contour"
+            }
+          }
+        ]
+      }
+    }
+  },
+  "admin": {
+    "access_log_path": "/dev/null",
+    "address": {
+      "socket_address": {
+        "address": "127.0.0.1",
+        "port_value": 9001
+      }
+    }
+  }
 }`,
 		},
 		"--admin-address=8.8.8.8 --admin-port=9200": {
@@ -990,6 +1231,24 @@ func TestBootstrap(t *testing.T) {
 				GrpcClientKey:  "client.key",
 			},
 			wantedError: true,
+		},
+		"return error when using ADS with the envoy xDS server": {
+			config: envoy.BootstrapConfig{
+				Path:          "envoy.json",
+				Namespace:     "testing-ns",
+				UseADS:        true,
+				XDSServerType: "envoy",
+			},
+			wantedError: true,
+		},
+		"return error when using delta xDS with the envoy xDS server": {
+			config: envoy.BootstrapConfig{
+				Path:          "envoy.json",
+				Namespace:     "testing-ns",
+				UseDelta:      true,
+				XDSServerType: "envoy",
+			},
+			wantedError: true,
 		}}
 
 	for name, tc := range tests {
@@ -1034,6 +1293,73 @@ func TestBootstrap(t *testing.T) {
 	}
 }
 
+func TestLoopbackAddress(t *testing.T) {
+	tests := map[string]string{
+		"127.0.0.1":        "127.0.0.1",
+		"0.0.0.0":          "127.0.0.1",
+		"::":               "::1",
+		"[::]":             "::1",
+		"fd00::1":          "fd00::1",
+		"envoy.local":      "envoy.local",
+		"::ffff:127.0.0.1": "::ffff:127.0.0.1",
+	}
+
+	for address, want := range tests {
+		if got := loopbackAddress(address); got != want {
+			t.Errorf("loopbackAddress(%q): want %q, got %q", address, want, got)
+		}
+	}
+}
+
+func TestWriteBootstrapV3(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bootstrap")
+	checkErr(t, err)
+	defer os.RemoveAll(dir)
+
+	c := envoy.BootstrapConfig{
+		Path:               path.Join(dir, "envoy.json"),
+		Namespace:          "testing-ns",
+		ResourcesDir:       dir,
+		GrpcCABundle:       "CA.cert",
+		GrpcClientCert:     "client.cert",
+		GrpcClientKey:      "client.key",
+		SkipFilePathCheck:  true,
+		XDSResourceVersion: "v3",
+	}
+
+	checkErr(t, WriteBootstrap(&c))
+
+	var bootstrap envoy_config_bootstrap_v3.Bootstrap
+	unmarshalFile(t, c.Path, &bootstrap)
+
+	for _, source := range []*envoy_config_core_v3.ConfigSource{
+		bootstrap.DynamicResources.LdsConfig,
+		bootstrap.DynamicResources.CdsConfig,
+	} {
+		assert.Equal(t, envoy_config_core_v3.ApiVersion_V3, source.ResourceApiVersion)
+		assert.Equal(t, envoy_config_core_v3.ApiVersion_V3, source.GetApiConfigSource().TransportApiVersion)
+	}
+
+	assert.Equal(t,
+		"type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.UpstreamTlsContext",
+		bootstrap.StaticResources.Clusters[0].TransportSocket.GetTypedConfig().TypeUrl)
+
+	for _, file := range []string{envoy.SDSTLSCertificateFile, envoy.SDSValidationContextFile} {
+		var resp envoy_service_discovery_v3.DiscoveryResponse
+		unmarshalFile(t, path.Join(dir, envoy.SDSResourcesSubdirectory, file), &resp)
+
+		assert.Equal(t,
+			"type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.Secret",
+			resp.Resources[0].TypeUrl)
+	}
+}
+
+func unmarshalFile(t *testing.T, filename string, pb proto.Message) {
+	data, err := ioutil.ReadFile(filename)
+	checkErr(t, err)
+	unmarshal(t, string(data), pb)
+}
+
 func unmarshal(t *testing.T, data string, pb proto.Message) {
 	err := jsonpb.UnmarshalString(data, pb)
 	checkErr(t, err)
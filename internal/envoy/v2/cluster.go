@@ -14,6 +14,7 @@
 package v2
 
 import (
+	"net"
 	"strings"
 	"time"
 
@@ -44,35 +45,38 @@ func Cluster(c *dag.Cluster) *v2.Cluster {
 	cluster.Name = envoy.Clustername(c)
 	cluster.AltStatName = envoy.AltStatName(service)
 	cluster.LbPolicy = lbPolicy(c.LoadBalancerPolicy)
+	if cluster.LbPolicy == v2.Cluster_RING_HASH {
+		cluster.LbConfig = ringHashLbConfig(c.RingHashPolicy)
+	}
 	cluster.HealthChecks = edshealthcheck(c)
+	cluster.OutlierDetection = outlierDetection(c.OutlierDetectionPolicy)
 	cluster.DnsLookupFamily = parseDNSLookupFamily(c.DNSLookupFamily)
 
 	switch len(service.ExternalName) {
 	case 0:
 		// external name not set, cluster will be discovered via EDS
 		cluster.ClusterDiscoveryType = ClusterDiscoveryType(v2.Cluster_EDS)
-		cluster.EdsClusterConfig = edsconfig("contour", service)
+		cluster.EdsClusterConfig = edsconfig("contour", c)
 	default:
 		// external name set, use hard coded DNS name
 		cluster.ClusterDiscoveryType = ClusterDiscoveryType(v2.Cluster_STRICT_DNS)
 		cluster.LoadAssignment = StaticClusterLoadAssignment(service)
+
+		// An IP address needs no resolution, and resolving
+		// it could fail if its family differs from the DNS
+		// lookup family.
+		if net.ParseIP(service.ExternalName) != nil {
+			cluster.ClusterDiscoveryType = ClusterDiscoveryType(v2.Cluster_STATIC)
+		}
 	}
 
 	// Drain connections immediately if using healthchecks and the endpoint is known to be removed
-	if c.HTTPHealthCheckPolicy != nil || c.TCPHealthCheckPolicy != nil {
+	if c.HTTPHealthCheckPolicy != nil || c.TCPHealthCheckPolicy != nil || c.GRPCHealthCheckPolicy != nil {
 		cluster.DrainConnectionsOnHostRemoval = true
 	}
 
-	if envoy.AnyPositive(service.MaxConnections, service.MaxPendingRequests, service.MaxRequests, service.MaxRetries) {
-		cluster.CircuitBreakers = &envoy_cluster.CircuitBreakers{
-			Thresholds: []*envoy_cluster.CircuitBreakers_Thresholds{{
-				MaxConnections:     protobuf.UInt32OrNil(service.MaxConnections),
-				MaxPendingRequests: protobuf.UInt32OrNil(service.MaxPendingRequests),
-				MaxRequests:        protobuf.UInt32OrNil(service.MaxRequests),
-				MaxRetries:         protobuf.UInt32OrNil(service.MaxRetries),
-			}},
-		}
-	}
+	cluster.CircuitBreakers = circuitBreakers(service, c.CircuitBreakerPolicy)
+	clusterTimeouts(cluster, c.ClusterTimeoutPolicy)
 
 	switch c.Protocol {
 	case "tls":
@@ -117,6 +121,8 @@ func ExtensionCluster(ext *dag.ExtensionCluster) *v2.Cluster {
 	cluster.AltStatName = strings.ReplaceAll(cluster.Name, "/", "_")
 
 	cluster.LbPolicy = lbPolicy(ext.LoadBalancerPolicy)
+	cluster.CircuitBreakers = circuitBreakers(nil, ext.CircuitBreakerPolicy)
+	clusterTimeouts(cluster, ext.ClusterTimeoutPolicy)
 
 	// Cluster will be discovered via EDS.
 	cluster.ClusterDiscoveryType = ClusterDiscoveryType(v2.Cluster_EDS)
@@ -157,13 +163,23 @@ func StaticClusterLoadAssignment(service *dag.Service) *v2.ClusterLoadAssignment
 	}
 }
 
-func edsconfig(cluster string, service *dag.Service) *v2.Cluster_EdsClusterConfig {
+// clusterTimeouts sets the connect and idle timeouts of the
+// cluster from tp. Timeouts that use the default are left as is.
+func clusterTimeouts(cluster *v2.Cluster, tp dag.ClusterTimeoutPolicy) {
+	if !tp.ConnectTimeout.UseDefault() {
+		cluster.ConnectTimeout = envoy.Timeout(tp.ConnectTimeout)
+	}
+	if !tp.IdleTimeout.UseDefault() {
+		cluster.CommonHttpProtocolOptions = &envoy_api_v2_core.HttpProtocolOptions{
+			IdleTimeout: envoy.Timeout(tp.IdleTimeout),
+		}
+	}
+}
+
+func edsconfig(cluster string, c *dag.Cluster) *v2.Cluster_EdsClusterConfig {
 	return &v2.Cluster_EdsClusterConfig{
-		EdsConfig: ConfigSource(cluster),
-		ServiceName: xds.ClusterLoadAssignmentName(
-			types.NamespacedName{Name: service.Weighted.ServiceName, Namespace: service.Weighted.ServiceNamespace},
-			service.Weighted.ServicePort.Name,
-		),
+		EdsConfig:   ConfigSource(cluster),
+		ServiceName: c.ClusterLoadAssignmentName(),
 	}
 }
 
@@ -173,15 +189,32 @@ func lbPolicy(strategy string) v2.Cluster_LbPolicy {
 		return v2.Cluster_LEAST_REQUEST
 	case "Random":
 		return v2.Cluster_RANDOM
-	case "Cookie":
+	case "Cookie", "RingHash":
 		return v2.Cluster_RING_HASH
+	case "Maglev":
+		return v2.Cluster_MAGLEV
 	default:
 		return v2.Cluster_ROUND_ROBIN
 	}
 }
 
+// ringHashLbConfig returns the hash ring configuration for the
+// given policy, or nil to use Envoy's defaults.
+func ringHashLbConfig(rh *dag.RingHashPolicy) *v2.Cluster_RingHashLbConfig_ {
+	if rh == nil {
+		return nil
+	}
+
+	return &v2.Cluster_RingHashLbConfig_{
+		RingHashLbConfig: &v2.Cluster_RingHashLbConfig{
+			MinimumRingSize: protobuf.UInt64OrNil(rh.MinimumRingSize),
+			MaximumRingSize: protobuf.UInt64OrNil(rh.MaximumRingSize),
+		},
+	}
+}
+
 func edshealthcheck(c *dag.Cluster) []*envoy_api_v2_core.HealthCheck {
-	if c.HTTPHealthCheckPolicy == nil && c.TCPHealthCheckPolicy == nil {
+	if c.HTTPHealthCheckPolicy == nil && c.TCPHealthCheckPolicy == nil && c.GRPCHealthCheckPolicy == nil {
 		return nil
 	}
 
@@ -191,11 +224,88 @@ func edshealthcheck(c *dag.Cluster) []*envoy_api_v2_core.HealthCheck {
 		}
 	}
 
+	if c.GRPCHealthCheckPolicy != nil {
+		return []*envoy_api_v2_core.HealthCheck{
+			grpcHealthCheck(c),
+		}
+	}
+
 	return []*envoy_api_v2_core.HealthCheck{
 		tcpHealthCheck(c),
 	}
 }
 
+// circuitBreakers returns the circuit breaker thresholds from the
+// annotations of the given service, overridden by any thresholds
+// that are set in the given policy. Either argument may be nil.
+func circuitBreakers(service *dag.Service, policy *dag.CircuitBreakerPolicy) *envoy_cluster.CircuitBreakers {
+	var limits dag.CircuitBreakerPolicy
+	if service != nil {
+		limits = dag.CircuitBreakerPolicy{
+			MaxConnections:     service.MaxConnections,
+			MaxPendingRequests: service.MaxPendingRequests,
+			MaxRequests:        service.MaxRequests,
+			MaxRetries:         service.MaxRetries,
+		}
+	}
+
+	if policy != nil {
+		if policy.MaxConnections > 0 {
+			limits.MaxConnections = policy.MaxConnections
+		}
+		if policy.MaxPendingRequests > 0 {
+			limits.MaxPendingRequests = policy.MaxPendingRequests
+		}
+		if policy.MaxRequests > 0 {
+			limits.MaxRequests = policy.MaxRequests
+		}
+		if policy.MaxRetries > 0 {
+			limits.MaxRetries = policy.MaxRetries
+		}
+	}
+
+	if !envoy.AnyPositive(limits.MaxConnections, limits.MaxPendingRequests, limits.MaxRequests, limits.MaxRetries) {
+		return nil
+	}
+
+	return &envoy_cluster.CircuitBreakers{
+		Thresholds: []*envoy_cluster.CircuitBreakers_Thresholds{{
+			MaxConnections:     protobuf.UInt32OrNil(limits.MaxConnections),
+			MaxPendingRequests: protobuf.UInt32OrNil(limits.MaxPendingRequests),
+			MaxRequests:        protobuf.UInt32OrNil(limits.MaxRequests),
+			MaxRetries:         protobuf.UInt32OrNil(limits.MaxRetries),
+		}},
+	}
+}
+
+func outlierDetection(od *dag.OutlierDetectionPolicy) *envoy_cluster.OutlierDetection {
+	if od == nil {
+		return nil
+	}
+
+	// Zero values are left unset so that Envoy applies its defaults.
+	outlier := &envoy_cluster.OutlierDetection{}
+	if od.ConsecutiveServerErrors > 0 {
+		outlier.Consecutive_5Xx = protobuf.UInt32(od.ConsecutiveServerErrors)
+	}
+	if od.ConsecutiveGatewayErrors > 0 {
+		// Ejection on gateway errors is disabled by default, so it
+		// must be enforced explicitly.
+		outlier.ConsecutiveGatewayFailure = protobuf.UInt32(od.ConsecutiveGatewayErrors)
+		outlier.EnforcingConsecutiveGatewayFailure = protobuf.UInt32(100)
+	}
+	if od.Interval > 0 {
+		outlier.Interval = protobuf.Duration(od.Interval)
+	}
+	if od.BaseEjectionTime > 0 {
+		outlier.BaseEjectionTime = protobuf.Duration(od.BaseEjectionTime)
+	}
+	if od.MaxEjectionPercent > 0 {
+		outlier.MaxEjectionPercent = protobuf.UInt32(od.MaxEjectionPercent)
+	}
+	return outlier
+}
+
 // ClusterCommonLBConfig creates a *v2.Cluster_CommonLbConfig with HealthyPanicThreshold disabled.
 func ClusterCommonLBConfig() *v2.Cluster_CommonLbConfig {
 	return &v2.Cluster_CommonLbConfig{
@@ -25,6 +25,7 @@ import (
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/envoy"
 	"github.com/projectcontour/contour/internal/protobuf"
+	"github.com/projectcontour/contour/internal/timeout"
 	"github.com/projectcontour/contour/internal/xds"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
@@ -65,6 +66,22 @@ func TestCluster(t *testing.T) {
 		},
 	}
 
+	s3 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			ExternalName: "192.0.2.10",
+			Ports: []v1.ServicePort{{
+				Name:       "http",
+				Protocol:   "TCP",
+				Port:       443,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
 	svcExternal := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "kuard",
@@ -171,6 +188,19 @@ func TestCluster(t *testing.T) {
 				LoadAssignment:       StaticClusterLoadAssignment(service(s2)),
 			},
 		},
+		"externalName service - ip address": {
+			cluster: &dag.Cluster{
+				Upstream:        service(s3),
+				DNSLookupFamily: "v6",
+			},
+			want: &v2.Cluster{
+				Name:                 "default/kuard/443/da39a3ee5e",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(v2.Cluster_STATIC),
+				LoadAssignment:       StaticClusterLoadAssignment(service(s3)),
+				DnsLookupFamily:      v2.Cluster_V6_ONLY,
+			},
+		},
 		"externalName service - dns-lookup-family v4": {
 			cluster: &dag.Cluster{
 				Upstream:        service(s2),
@@ -312,6 +342,40 @@ func TestCluster(t *testing.T) {
 				},
 			},
 		},
+		"circuit breaker policy overrides annotations": {
+			cluster: &dag.Cluster{
+				Upstream: &dag.Service{
+					MaxConnections: 9000,
+					MaxRetries:     5,
+					Weighted: dag.WeightedService{
+						Weight:           1,
+						ServiceName:      s1.Name,
+						ServiceNamespace: s1.Namespace,
+						ServicePort:      s1.Spec.Ports[0],
+					},
+				},
+				CircuitBreakerPolicy: &dag.CircuitBreakerPolicy{
+					MaxConnections: 100,
+					MaxRequests:    200,
+				},
+			},
+			want: &v2.Cluster{
+				Name:                 "default/kuard/443/fef0ec707e",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(v2.Cluster_EDS),
+				EdsClusterConfig: &v2.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("contour"),
+					ServiceName: "default/kuard/http",
+				},
+				CircuitBreakers: &envoy_cluster.CircuitBreakers{
+					Thresholds: []*envoy_cluster.CircuitBreakers_Thresholds{{
+						MaxConnections: protobuf.UInt32(100),
+						MaxRequests:    protobuf.UInt32(200),
+						MaxRetries:     protobuf.UInt32(5),
+					}},
+				},
+			},
+		},
 		"projectcontour.io/max-pending-requests": {
 			cluster: &dag.Cluster{
 				Upstream: &dag.Service{
@@ -425,6 +489,75 @@ func TestCluster(t *testing.T) {
 				LbPolicy: v2.Cluster_RING_HASH,
 			},
 		},
+		"cluster with ring hash sizes": {
+			cluster: &dag.Cluster{
+				Upstream:           service(s1),
+				LoadBalancerPolicy: "RingHash",
+				RingHashPolicy: &dag.RingHashPolicy{
+					MinimumRingSize: 4096,
+				},
+			},
+			want: &v2.Cluster{
+				Name:                 "default/kuard/443/d89eb817d6",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(v2.Cluster_EDS),
+				EdsClusterConfig: &v2.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("contour"),
+					ServiceName: "default/kuard/http",
+				},
+				LbPolicy: v2.Cluster_RING_HASH,
+				LbConfig: &v2.Cluster_RingHashLbConfig_{
+					RingHashLbConfig: &v2.Cluster_RingHashLbConfig{
+						MinimumRingSize: protobuf.UInt64OrNil(4096),
+					},
+				},
+			},
+		},
+		"cluster with outlier detection": {
+			cluster: &dag.Cluster{
+				Upstream: service(s1),
+				OutlierDetectionPolicy: &dag.OutlierDetectionPolicy{
+					ConsecutiveServerErrors:  5,
+					ConsecutiveGatewayErrors: 3,
+					Interval:                 10 * time.Second,
+					BaseEjectionTime:         30 * time.Second,
+					MaxEjectionPercent:       50,
+				},
+			},
+			want: &v2.Cluster{
+				Name:                 "default/kuard/443/57b43efff1",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(v2.Cluster_EDS),
+				EdsClusterConfig: &v2.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("contour"),
+					ServiceName: "default/kuard/http",
+				},
+				OutlierDetection: &envoy_cluster.OutlierDetection{
+					Consecutive_5Xx:                    protobuf.UInt32(5),
+					ConsecutiveGatewayFailure:          protobuf.UInt32(3),
+					EnforcingConsecutiveGatewayFailure: protobuf.UInt32(100),
+					Interval:                           protobuf.Duration(10 * time.Second),
+					BaseEjectionTime:                   protobuf.Duration(30 * time.Second),
+					MaxEjectionPercent:                 protobuf.UInt32(50),
+				},
+			},
+		},
+		"cluster with default outlier detection": {
+			cluster: &dag.Cluster{
+				Upstream:               service(s1),
+				OutlierDetectionPolicy: &dag.OutlierDetectionPolicy{},
+			},
+			want: &v2.Cluster{
+				Name:                 "default/kuard/443/5d3414d305",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(v2.Cluster_EDS),
+				EdsClusterConfig: &v2.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("contour"),
+					ServiceName: "default/kuard/http",
+				},
+				OutlierDetection: &envoy_cluster.OutlierDetection{},
+			},
+		},
 
 		"tcp service": {
 			cluster: &dag.Cluster{
@@ -470,6 +603,37 @@ func TestCluster(t *testing.T) {
 				}},
 			},
 		},
+		"http healthcheck on a separate port": {
+			cluster: &dag.Cluster{
+				Upstream: service(s1),
+				HTTPHealthCheckPolicy: &dag.HTTPHealthCheckPolicy{
+					Path: "/healthz",
+					Port: 9000,
+				},
+			},
+			want: &v2.Cluster{
+				Name:                 "default/kuard/443/d2948952f8",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(v2.Cluster_EDS),
+				EdsClusterConfig: &v2.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("contour"),
+					ServiceName: "default/kuard/http/healthcheck/9000",
+				},
+				DrainConnectionsOnHostRemoval: true,
+				HealthChecks: []*envoy_api_v2_core.HealthCheck{{
+					Timeout:            protobuf.Duration(envoy.HCTimeout),
+					Interval:           protobuf.Duration(envoy.HCInterval),
+					UnhealthyThreshold: protobuf.UInt32(envoy.HCUnhealthyThreshold),
+					HealthyThreshold:   protobuf.UInt32(envoy.HCHealthyThreshold),
+					HealthChecker: &envoy_api_v2_core.HealthCheck_HttpHealthCheck_{
+						HttpHealthCheck: &envoy_api_v2_core.HealthCheck_HttpHealthCheck{
+							Path: "/healthz",
+							Host: envoy.HCHost,
+						},
+					},
+				}},
+			},
+		},
 		"use client certificate to authentication towards backend": {
 			cluster: &dag.Cluster{
 				Upstream:          service(s1, "tls"),
@@ -576,6 +740,40 @@ func TestClustername(t *testing.T) {
 			},
 			want: "default/backend/80/5c26077e1d",
 		},
+		"extended healthcheck params": {
+			cluster: &dag.Cluster{
+				Upstream: &dag.Service{
+					Weighted: dag.WeightedService{
+						Weight:           1,
+						ServiceName:      "backend",
+						ServiceNamespace: "default",
+						ServicePort: v1.ServicePort{
+							Name:       "http",
+							Protocol:   "TCP",
+							Port:       80,
+							TargetPort: intstr.FromInt(6502),
+						},
+					},
+				},
+				LoadBalancerPolicy: "Random",
+				HTTPHealthCheckPolicy: &dag.HTTPHealthCheckPolicy{
+					Path:               "/healthz",
+					Interval:           5 * time.Second,
+					Timeout:            30 * time.Second,
+					UnhealthyThreshold: 3,
+					HealthyThreshold:   1,
+					UnhealthyInterval:  time.Second,
+					Port:               9000,
+					ExpectedStatuses: []dag.HTTPStatusRange{
+						{Start: 200, End: 300},
+					},
+					RequestHeaders: map[string]string{
+						"X-Health-Check": "true",
+					},
+				},
+			},
+			want: "default/backend/80/d53ac0c3ca",
+		},
 		"upstream tls validation with subject alt name": {
 			cluster: &dag.Cluster{
 				Upstream: &dag.Service{
@@ -609,6 +807,75 @@ func TestClustername(t *testing.T) {
 			},
 			want: "default/backend/80/6bf46b7b3a",
 		},
+		"outlier detection": {
+			cluster: &dag.Cluster{
+				Upstream: &dag.Service{
+					Weighted: dag.WeightedService{
+						Weight:           1,
+						ServiceName:      "backend",
+						ServiceNamespace: "default",
+						ServicePort: v1.ServicePort{
+							Name:       "http",
+							Protocol:   "TCP",
+							Port:       80,
+							TargetPort: intstr.FromInt(6502),
+						},
+					},
+				},
+				OutlierDetectionPolicy: &dag.OutlierDetectionPolicy{
+					ConsecutiveServerErrors:  5,
+					ConsecutiveGatewayErrors: 3,
+					Interval:                 10 * time.Second,
+					BaseEjectionTime:         30 * time.Second,
+					MaxEjectionPercent:       50,
+				},
+			},
+			want: "default/backend/80/57b43efff1",
+		},
+		"circuit breaker policy": {
+			cluster: &dag.Cluster{
+				Upstream: &dag.Service{
+					Weighted: dag.WeightedService{
+						Weight:           1,
+						ServiceName:      "backend",
+						ServiceNamespace: "default",
+						ServicePort: v1.ServicePort{
+							Name:       "http",
+							Protocol:   "TCP",
+							Port:       80,
+							TargetPort: intstr.FromInt(6502),
+						},
+					},
+				},
+				CircuitBreakerPolicy: &dag.CircuitBreakerPolicy{
+					MaxConnections: 100,
+					MaxRequests:    200,
+				},
+			},
+			want: "default/backend/80/fef0ec707e",
+		},
+		"cluster timeout policy": {
+			cluster: &dag.Cluster{
+				Upstream: &dag.Service{
+					Weighted: dag.WeightedService{
+						Weight:           1,
+						ServiceName:      "backend",
+						ServiceNamespace: "default",
+						ServicePort: v1.ServicePort{
+							Name:       "http",
+							Protocol:   "TCP",
+							Port:       80,
+							TargetPort: intstr.FromInt(6502),
+						},
+					},
+				},
+				ClusterTimeoutPolicy: dag.ClusterTimeoutPolicy{
+					ConnectTimeout: timeout.DurationSetting(time.Second),
+					IdleTimeout:    timeout.DisabledSetting(),
+				},
+			},
+			want: "default/backend/80/0fd87526a7",
+		},
 	}
 
 	for name, tc := range tests {
@@ -628,10 +895,11 @@ func TestLBPolicy(t *testing.T) {
 		"unknown":              v2.Cluster_ROUND_ROBIN,
 		"Cookie":               v2.Cluster_RING_HASH,
 
-		// RingHash and Maglev were removed as options in 0.13.
-		// See #1150
-		"RingHash": v2.Cluster_ROUND_ROBIN,
-		"Maglev":   v2.Cluster_ROUND_ROBIN,
+		// RingHash and Maglev were removed as options in 0.13
+		// (see #1150), and restored together with request
+		// hash policies.
+		"RingHash": v2.Cluster_RING_HASH,
+		"Maglev":   v2.Cluster_MAGLEV,
 	}
 
 	for policy, want := range tests {
@@ -642,6 +910,62 @@ func TestLBPolicy(t *testing.T) {
 	}
 }
 
+func TestClusterTimeouts(t *testing.T) {
+	tests := map[string]struct {
+		tp   dag.ClusterTimeoutPolicy
+		want *v2.Cluster
+	}{
+		"defaults": {
+			tp:   dag.ClusterTimeoutPolicy{},
+			want: clusterDefaults(),
+		},
+		"connect timeout": {
+			tp: dag.ClusterTimeoutPolicy{
+				ConnectTimeout: timeout.DurationSetting(5 * time.Second),
+			},
+			want: &v2.Cluster{
+				ConnectTimeout: protobuf.Duration(5 * time.Second),
+				CommonLbConfig: ClusterCommonLBConfig(),
+				LbPolicy:       lbPolicy(""),
+			},
+		},
+		"idle timeout": {
+			tp: dag.ClusterTimeoutPolicy{
+				IdleTimeout: timeout.DurationSetting(10 * time.Minute),
+			},
+			want: &v2.Cluster{
+				ConnectTimeout: protobuf.Duration(250 * time.Millisecond),
+				CommonLbConfig: ClusterCommonLBConfig(),
+				LbPolicy:       lbPolicy(""),
+				CommonHttpProtocolOptions: &envoy_api_v2_core.HttpProtocolOptions{
+					IdleTimeout: protobuf.Duration(10 * time.Minute),
+				},
+			},
+		},
+		"idle timeout disabled": {
+			tp: dag.ClusterTimeoutPolicy{
+				IdleTimeout: timeout.DisabledSetting(),
+			},
+			want: &v2.Cluster{
+				ConnectTimeout: protobuf.Duration(250 * time.Millisecond),
+				CommonLbConfig: ClusterCommonLBConfig(),
+				LbPolicy:       lbPolicy(""),
+				CommonHttpProtocolOptions: &envoy_api_v2_core.HttpProtocolOptions{
+					IdleTimeout: protobuf.Duration(0),
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := clusterDefaults()
+			clusterTimeouts(got, tc.tp)
+			protobuf.ExpectEqual(t, tc.want, got)
+		})
+	}
+}
+
 func TestClusterCommonLBConfig(t *testing.T) {
 	got := ClusterCommonLBConfig()
 	want := &v2.Cluster_CommonLbConfig{
@@ -17,6 +17,7 @@ import (
 	"time"
 
 	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type"
 	"github.com/golang/protobuf/ptypes/duration"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/envoy"
@@ -31,17 +32,50 @@ func httpHealthCheck(cluster *dag.Cluster) *envoy_api_v2_core.HealthCheck {
 		host = hc.Host
 	}
 
+	var statuses []*envoy_type.Int64Range
+	for _, r := range hc.ExpectedStatuses {
+		statuses = append(statuses, &envoy_type.Int64Range{
+			Start: r.Start,
+			End:   r.End,
+		})
+	}
+
 	// TODO(dfc) why do we need to specify our own default, what is the default
 	// that envoy applies if these fields are left nil?
 	return &envoy_api_v2_core.HealthCheck{
 		Timeout:            durationOrDefault(hc.Timeout, envoy.HCTimeout),
 		Interval:           durationOrDefault(hc.Interval, envoy.HCInterval),
+		UnhealthyInterval:  durationOrNil(hc.UnhealthyInterval),
+		NoTrafficInterval:  durationOrNil(hc.NoTrafficInterval),
 		UnhealthyThreshold: protobuf.UInt32OrDefault(hc.UnhealthyThreshold, envoy.HCUnhealthyThreshold),
 		HealthyThreshold:   protobuf.UInt32OrDefault(hc.HealthyThreshold, envoy.HCHealthyThreshold),
 		HealthChecker: &envoy_api_v2_core.HealthCheck_HttpHealthCheck_{
 			HttpHealthCheck: &envoy_api_v2_core.HealthCheck_HttpHealthCheck{
-				Path: hc.Path,
-				Host: host,
+				Path:                hc.Path,
+				Host:                host,
+				ExpectedStatuses:    statuses,
+				RequestHeadersToAdd: HeaderValueList(hc.RequestHeaders, false),
+			},
+		},
+	}
+}
+
+// grpcHealthCheck returns a *envoy_api_v2_core.HealthCheck value for
+// HTTP Routes to services that implement the gRPC health checking protocol
+func grpcHealthCheck(cluster *dag.Cluster) *envoy_api_v2_core.HealthCheck {
+	hc := cluster.GRPCHealthCheckPolicy
+
+	return &envoy_api_v2_core.HealthCheck{
+		Timeout:            durationOrDefault(hc.Timeout, envoy.HCTimeout),
+		Interval:           durationOrDefault(hc.Interval, envoy.HCInterval),
+		UnhealthyInterval:  durationOrNil(hc.UnhealthyInterval),
+		NoTrafficInterval:  durationOrNil(hc.NoTrafficInterval),
+		UnhealthyThreshold: protobuf.UInt32OrDefault(hc.UnhealthyThreshold, envoy.HCUnhealthyThreshold),
+		HealthyThreshold:   protobuf.UInt32OrDefault(hc.HealthyThreshold, envoy.HCHealthyThreshold),
+		HealthChecker: &envoy_api_v2_core.HealthCheck_GrpcHealthCheck_{
+			GrpcHealthCheck: &envoy_api_v2_core.HealthCheck_GrpcHealthCheck{
+				ServiceName: hc.ServiceName,
+				Authority:   hc.Authority,
 			},
 		},
 	}
@@ -68,3 +102,10 @@ func durationOrDefault(d, def time.Duration) *duration.Duration {
 	}
 	return protobuf.Duration(def)
 }
+
+func durationOrNil(d time.Duration) *duration.Duration {
+	if d != 0 {
+		return protobuf.Duration(d)
+	}
+	return nil
+}
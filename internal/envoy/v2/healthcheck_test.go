@@ -18,6 +18,7 @@ import (
 	"time"
 
 	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/envoy"
 	"github.com/projectcontour/contour/internal/protobuf"
@@ -90,6 +91,47 @@ func TestHealthCheck(t *testing.T) {
 				},
 			},
 		},
+		"healthcheck with statuses, headers and intervals": {
+			cluster: &dag.Cluster{
+				HTTPHealthCheckPolicy: &dag.HTTPHealthCheckPolicy{
+					Path:              "/healthy",
+					UnhealthyInterval: 5 * time.Second,
+					NoTrafficInterval: 30 * time.Second,
+					ExpectedStatuses: []dag.HTTPStatusRange{
+						{Start: 200, End: 300},
+						{Start: 302, End: 303},
+					},
+					RequestHeaders: map[string]string{
+						"X-Health-Check": "true",
+					},
+				},
+			},
+			want: &envoy_api_v2_core.HealthCheck{
+				Timeout:            protobuf.Duration(envoy.HCTimeout),
+				Interval:           protobuf.Duration(envoy.HCInterval),
+				UnhealthyInterval:  protobuf.Duration(5 * time.Second),
+				NoTrafficInterval:  protobuf.Duration(30 * time.Second),
+				UnhealthyThreshold: protobuf.UInt32(3),
+				HealthyThreshold:   protobuf.UInt32(2),
+				HealthChecker: &envoy_api_v2_core.HealthCheck_HttpHealthCheck_{
+					HttpHealthCheck: &envoy_api_v2_core.HealthCheck_HttpHealthCheck{
+						Path: "/healthy",
+						Host: "contour-envoy-healthcheck",
+						ExpectedStatuses: []*envoy_type.Int64Range{
+							{Start: 200, End: 300},
+							{Start: 302, End: 303},
+						},
+						RequestHeadersToAdd: []*envoy_api_v2_core.HeaderValueOption{{
+							Header: &envoy_api_v2_core.HeaderValue{
+								Key:   "X-Health-Check",
+								Value: "true",
+							},
+							Append: protobuf.Bool(false),
+						}},
+					},
+				},
+			},
+		},
 	}
 
 	for name, tc := range tests {
@@ -100,3 +142,56 @@ func TestHealthCheck(t *testing.T) {
 		})
 	}
 }
+
+func TestGRPCHealthCheck(t *testing.T) {
+	tests := map[string]struct {
+		cluster *dag.Cluster
+		want    *envoy_api_v2_core.HealthCheck
+	}{
+		"blank healthcheck": {
+			cluster: &dag.Cluster{
+				GRPCHealthCheckPolicy: new(dag.GRPCHealthCheckPolicy),
+			},
+			want: &envoy_api_v2_core.HealthCheck{
+				Timeout:            protobuf.Duration(envoy.HCTimeout),
+				Interval:           protobuf.Duration(envoy.HCInterval),
+				UnhealthyThreshold: protobuf.UInt32(3),
+				HealthyThreshold:   protobuf.UInt32(2),
+				HealthChecker: &envoy_api_v2_core.HealthCheck_GrpcHealthCheck_{
+					GrpcHealthCheck: &envoy_api_v2_core.HealthCheck_GrpcHealthCheck{},
+				},
+			},
+		},
+		"explicit healthcheck": {
+			cluster: &dag.Cluster{
+				GRPCHealthCheckPolicy: &dag.GRPCHealthCheckPolicy{
+					ServiceName:        "helloworld.Greeter",
+					Authority:          "greeter.example.com",
+					Timeout:            99 * time.Second,
+					Interval:           98 * time.Second,
+					UnhealthyThreshold: 97,
+					HealthyThreshold:   96,
+				},
+			},
+			want: &envoy_api_v2_core.HealthCheck{
+				Timeout:            protobuf.Duration(99 * time.Second),
+				Interval:           protobuf.Duration(98 * time.Second),
+				UnhealthyThreshold: protobuf.UInt32(97),
+				HealthyThreshold:   protobuf.UInt32(96),
+				HealthChecker: &envoy_api_v2_core.HealthCheck_GrpcHealthCheck_{
+					GrpcHealthCheck: &envoy_api_v2_core.HealthCheck_GrpcHealthCheck{
+						ServiceName: "helloworld.Greeter",
+						Authority:   "greeter.example.com",
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := grpcHealthCheck(tc.cluster)
+			protobuf.ExpectEqual(t, tc.want, got)
+		})
+	}
+}
@@ -16,6 +16,7 @@ package v2
 import (
 	"fmt"
 	"log"
+	"net"
 	"sort"
 	"strings"
 	"time"
@@ -24,15 +25,23 @@ import (
 	envoy_api_v2_auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
 	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	envoy_api_v2_listener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	accesslog "github.com/envoyproxy/go-control-plane/envoy/config/filter/accesslog/v2"
 	envoy_config_filter_http_ext_authz_v2 "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/ext_authz/v2"
 	lua "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/lua/v2"
+	envoy_config_filter_http_rate_limit_v2 "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/rate_limit/v2"
 	http "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
 	tcp "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/tcp_proxy/v2"
+	envoy_config_ratelimit_v2 "github.com/envoyproxy/go-control-plane/envoy/config/ratelimit/v2"
+	envoy_extensions_filters_http_ext_authz_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_authz/v3"
+	envoy_extensions_filters_http_ratelimit_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ratelimit/v3"
 	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/golang/protobuf/proto"
+	contour_api_v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/envoy"
+	envoy_v3 "github.com/projectcontour/contour/internal/envoy/v3"
 	"github.com/projectcontour/contour/internal/protobuf"
 	"github.com/projectcontour/contour/internal/sorter"
 	"github.com/projectcontour/contour/internal/timeout"
@@ -141,6 +150,7 @@ type httpConnectionManagerBuilder struct {
 	connectionShutdownGracePeriod timeout.Setting
 	filters                       []*http.HttpFilter
 	codec                         HTTPVersionType // Note the zero value is AUTO, which is the default we want.
+	tracing                       *http.HttpConnectionManager_Tracing
 }
 
 // RouteConfigName sets the name of the RDS element that contains
@@ -202,6 +212,12 @@ func (b *httpConnectionManagerBuilder) ConnectionShutdownGracePeriod(timeout tim
 	return b
 }
 
+// Tracing sets the distributed tracing configuration on the connection manager.
+func (b *httpConnectionManagerBuilder) Tracing(tracing *http.HttpConnectionManager_Tracing) *httpConnectionManagerBuilder {
+	b.tracing = tracing
+	return b
+}
+
 func (b *httpConnectionManagerBuilder) DefaultFilters() *httpConnectionManagerBuilder {
 	b.filters = append(b.filters,
 		&http.HttpFilter{
@@ -213,6 +229,9 @@ func (b *httpConnectionManagerBuilder) DefaultFilters() *httpConnectionManagerBu
 		&http.HttpFilter{
 			Name: wellknown.CORS,
 		},
+		&http.HttpFilter{
+			Name: wellknown.Fault,
+		},
 		&http.HttpFilter{
 			Name: wellknown.Router,
 		},
@@ -298,6 +317,8 @@ func (b *httpConnectionManagerBuilder) Get() *envoy_api_v2_listener.Filter {
 		RequestTimeout:    envoy.Timeout(b.requestTimeout),
 		StreamIdleTimeout: envoy.Timeout(b.streamIdleTimeout),
 		DrainTimeout:      envoy.Timeout(b.connectionShutdownGracePeriod),
+
+		Tracing: b.tracing,
 	}
 
 	// Max connection duration is infinite/disabled by default in Envoy, so if the timeout setting
@@ -399,26 +420,24 @@ func TCPProxy(statPrefix string, proxy *dag.TCPProxy, accesslogger []*accesslog.
 }
 
 // SocketAddress creates a new TCP envoy_api_v2_core.Address.
+// IP addresses are written in their canonical form, and IPv6
+// addresses may be enclosed in brackets. If address is the IPv6
+// unspecified address, IPv4 compatibility is enabled so that a
+// listener bound to it accepts both IPv4 and IPv6 connections.
 func SocketAddress(address string, port int) *envoy_api_v2_core.Address {
-	if address == "::" {
-		return &envoy_api_v2_core.Address{
-			Address: &envoy_api_v2_core.Address_SocketAddress{
-				SocketAddress: &envoy_api_v2_core.SocketAddress{
-					Protocol:   envoy_api_v2_core.SocketAddress_TCP,
-					Address:    address,
-					Ipv4Compat: true,
-					PortSpecifier: &envoy_api_v2_core.SocketAddress_PortValue{
-						PortValue: uint32(port),
-					},
-				},
-			},
-		}
+	var ipv4Compat bool
+
+	if ip := net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(address, "["), "]")); ip != nil {
+		address = ip.String()
+		ipv4Compat = ip.Equal(net.IPv6unspecified)
 	}
+
 	return &envoy_api_v2_core.Address{
 		Address: &envoy_api_v2_core.Address_SocketAddress{
 			SocketAddress: &envoy_api_v2_core.SocketAddress{
-				Protocol: envoy_api_v2_core.SocketAddress_TCP,
-				Address:  address,
+				Protocol:   envoy_api_v2_core.SocketAddress_TCP,
+				Address:    address,
+				Ipv4Compat: ipv4Compat,
 				PortSpecifier: &envoy_api_v2_core.SocketAddress_PortValue{
 					PortValue: uint32(port),
 				},
@@ -493,7 +512,7 @@ end
 
 // FilterExternalAuthz returns an `ext_authz` filter configured with the
 // requested parameters.
-func FilterExternalAuthz(authzClusterName string, failOpen bool, timeout timeout.Setting) *http.HttpFilter {
+func FilterExternalAuthz(authzClusterName string, failOpen bool, timeout timeout.Setting, protocolVersion contour_api_v1alpha1.ExtensionProtocolVersion) *http.HttpFilter {
 	authConfig := envoy_config_filter_http_ext_authz_v2.ExtAuthz{
 		Services: &envoy_config_filter_http_ext_authz_v2.ExtAuthz_GrpcService{
 			GrpcService: &envoy_api_v2_core.GrpcService{
@@ -521,13 +540,59 @@ func FilterExternalAuthz(authzClusterName string, failOpen bool, timeout timeout
 		IncludePeerCertificate:    true,
 	}
 
-	// TODO(jpeach): When we move to the Envoy v3 API, propagate the
-	// `transport_api_version` from ExtensionServiceSpec ProtocolVersion.
+	var config proto.Message = &authConfig
+
+	// The authorization transport API version can only be set in
+	// the v3 filter configuration. Envoy accepts v3 filter
+	// configuration in v2 listeners, so we can always use it.
+	if protocolVersion == contour_api_v1alpha1.SupportProtocolVersion3 {
+		v3 := envoy_v3.MustUpgrade(&authConfig).(*envoy_extensions_filters_http_ext_authz_v3.ExtAuthz)
+		v3.TransportApiVersion = envoy_config_core_v3.ApiVersion_V3
+		config = v3
+	}
 
 	return &http.HttpFilter{
 		Name: "envoy.filters.http.ext_authz",
 		ConfigType: &http.HttpFilter_TypedConfig{
-			TypedConfig: protobuf.MustMarshalAny(&authConfig),
+			TypedConfig: protobuf.MustMarshalAny(config),
+		},
+	}
+}
+
+// FilterRateLimit returns a `ratelimit` filter that sends the rate
+// limit descriptors of the requests it handles to the supplied rate
+// limit service.
+func FilterRateLimit(rls *dag.RateLimitService) *http.HttpFilter {
+	rateLimitConfig := envoy_config_filter_http_rate_limit_v2.RateLimit{
+		Domain:          rls.Domain,
+		Stage:           rls.Stage,
+		Timeout:         envoy.Timeout(rls.ResponseTimeout),
+		FailureModeDeny: !rls.FailOpen,
+		RateLimitService: &envoy_config_ratelimit_v2.RateLimitServiceConfig{
+			GrpcService: &envoy_api_v2_core.GrpcService{
+				TargetSpecifier: &envoy_api_v2_core.GrpcService_EnvoyGrpc_{
+					EnvoyGrpc: &envoy_api_v2_core.GrpcService_EnvoyGrpc{
+						ClusterName: rls.ExtensionService.Name,
+					},
+				},
+			},
+		},
+	}
+
+	var config proto.Message = &rateLimitConfig
+
+	// As with authorization, the transport API version can
+	// only be set in the v3 filter configuration.
+	if rls.ExtensionService.ProtocolVersion == contour_api_v1alpha1.SupportProtocolVersion3 {
+		v3 := envoy_v3.MustUpgrade(&rateLimitConfig).(*envoy_extensions_filters_http_ratelimit_v3.RateLimit)
+		v3.RateLimitService.TransportApiVersion = envoy_config_core_v3.ApiVersion_V3
+		config = v3
+	}
+
+	return &http.HttpFilter{
+		Name: "envoy.filters.http.ratelimit",
+		ConfigType: &http.HttpFilter_TypedConfig{
+			TypedConfig: protobuf.MustMarshalAny(config),
 		},
 	}
 }
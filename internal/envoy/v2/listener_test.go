@@ -176,6 +176,29 @@ func TestSocketAddress(t *testing.T) {
 		},
 	}
 	assert.Equal(t, want, got)
+
+	tests := map[string]struct {
+		address    string
+		want       string
+		ipv4Compat bool
+	}{
+		"ipv4 any":                 {address: "0.0.0.0", want: "0.0.0.0"},
+		"ipv4":                     {address: "10.0.0.1", want: "10.0.0.1"},
+		"ipv6 any in brackets":     {address: "[::]", want: "::", ipv4Compat: true},
+		"ipv6 any in long form":    {address: "0:0:0:0:0:0:0:0", want: "::", ipv4Compat: true},
+		"ipv6":                     {address: "fd00:0:0::1", want: "fd00::1"},
+		"ipv6 in brackets":         {address: "[fd00::1]", want: "fd00::1"},
+		"ipv6 loopback":            {address: "::1", want: "::1"},
+		"ipv4-mapped ipv6 address": {address: "::ffff:10.0.0.1", want: "10.0.0.1"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := SocketAddress(tc.address, port).GetSocketAddress()
+			assert.Equal(t, tc.want, got.GetAddress())
+			assert.Equal(t, tc.ipv4Compat, got.GetIpv4Compat())
+		})
+	}
 }
 
 func TestDownstreamTLSContext(t *testing.T) {
@@ -360,6 +383,8 @@ func TestHTTPConnectionManager(t *testing.T) {
 							Name: wellknown.GRPCWeb,
 						}, {
 							Name: wellknown.CORS,
+						}, {
+							Name: wellknown.Fault,
 						}, {
 							Name: wellknown.Router,
 						}},
@@ -412,6 +437,8 @@ func TestHTTPConnectionManager(t *testing.T) {
 							Name: wellknown.GRPCWeb,
 						}, {
 							Name: wellknown.CORS,
+						}, {
+							Name: wellknown.Fault,
 						}, {
 							Name: wellknown.Router,
 						}},
@@ -465,6 +492,8 @@ func TestHTTPConnectionManager(t *testing.T) {
 							Name: wellknown.GRPCWeb,
 						}, {
 							Name: wellknown.CORS,
+						}, {
+							Name: wellknown.Fault,
 						}, {
 							Name: wellknown.Router,
 						}},
@@ -519,6 +548,8 @@ func TestHTTPConnectionManager(t *testing.T) {
 							Name: wellknown.GRPCWeb,
 						}, {
 							Name: wellknown.CORS,
+						}, {
+							Name: wellknown.Fault,
 						}, {
 							Name: wellknown.Router,
 						}},
@@ -572,6 +603,8 @@ func TestHTTPConnectionManager(t *testing.T) {
 							Name: wellknown.GRPCWeb,
 						}, {
 							Name: wellknown.CORS,
+						}, {
+							Name: wellknown.Fault,
 						}, {
 							Name: wellknown.Router,
 						}},
@@ -626,6 +659,8 @@ func TestHTTPConnectionManager(t *testing.T) {
 							Name: wellknown.GRPCWeb,
 						}, {
 							Name: wellknown.CORS,
+						}, {
+							Name: wellknown.Fault,
 						}, {
 							Name: wellknown.Router,
 						}},
@@ -678,6 +713,8 @@ func TestHTTPConnectionManager(t *testing.T) {
 							Name: wellknown.GRPCWeb,
 						}, {
 							Name: wellknown.CORS,
+						}, {
+							Name: wellknown.Fault,
 						}, {
 							Name: wellknown.Router,
 						}},
@@ -15,6 +15,7 @@ package v2
 
 import (
 	"fmt"
+	"net/http"
 	"regexp"
 	"sort"
 	"strings"
@@ -22,7 +23,10 @@ import (
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	envoy_config_filter_fault_v2 "github.com/envoyproxy/go-control-plane/envoy/config/filter/fault/v2"
 	envoy_config_filter_http_ext_authz_v2 "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/ext_authz/v2"
+	envoy_config_filter_http_fault_v2 "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/fault/v2"
+	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type"
 	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher"
 	"github.com/golang/protobuf/ptypes/any"
 	wrappers "github.com/golang/protobuf/ptypes/wrappers"
@@ -57,6 +61,112 @@ func RouteAuthzContext(settings map[string]string) *any.Any {
 	)
 }
 
+// RouteFault returns a per-route config for the fault filter that
+// injects the delay and abort of the supplied policy.
+func RouteFault(policy *dag.FaultInjectionPolicy) *any.Any {
+	fault := &envoy_config_filter_http_fault_v2.HTTPFault{}
+
+	if policy.Delay != nil {
+		fault.Delay = &envoy_config_filter_fault_v2.FaultDelay{
+			FaultDelaySecifier: &envoy_config_filter_fault_v2.FaultDelay_FixedDelay{
+				FixedDelay: protobuf.Duration(policy.Delay.FixedDelay),
+			},
+			Percentage: fractionalPercent(policy.Delay.Percentage),
+		}
+	}
+
+	if policy.Abort != nil {
+		fault.Abort = &envoy_config_filter_http_fault_v2.FaultAbort{
+			ErrorType: &envoy_config_filter_http_fault_v2.FaultAbort_HttpStatus{
+				HttpStatus: policy.Abort.StatusCode,
+			},
+			Percentage: fractionalPercent(policy.Abort.Percentage),
+		}
+	}
+
+	if policy.HeaderName != "" {
+		fault.Headers = []*envoy_api_v2_route.HeaderMatcher{{
+			Name: policy.HeaderName,
+			HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_PresentMatch{
+				PresentMatch: true,
+			},
+		}}
+	}
+
+	return protobuf.MustMarshalAny(fault)
+}
+
+func fractionalPercent(percentage uint32) *envoy_type.FractionalPercent {
+	return &envoy_type.FractionalPercent{
+		Numerator:   percentage,
+		Denominator: envoy_type.FractionalPercent_HUNDRED,
+	}
+}
+
+// RateLimits returns the rate limit actions for the descriptors of
+// the supplied policy. The actions are sent to the rate limit filter
+// that is configured with the same stage.
+func RateLimits(stage uint32, policy *dag.RateLimitPolicy) []*envoy_api_v2_route.RateLimit {
+	if policy == nil {
+		return nil
+	}
+
+	var rateLimits []*envoy_api_v2_route.RateLimit
+	for _, d := range policy.Descriptors {
+		rl := &envoy_api_v2_route.RateLimit{
+			Stage: protobuf.UInt32(stage),
+		}
+
+		for _, e := range d.Entries {
+			rl.Actions = append(rl.Actions, rateLimitAction(e))
+		}
+
+		rateLimits = append(rateLimits, rl)
+	}
+
+	return rateLimits
+}
+
+// rateLimitAction returns the rate limit action that builds the
+// supplied descriptor entry.
+func rateLimitAction(entry dag.RateLimitDescriptorEntry) *envoy_api_v2_route.RateLimit_Action {
+	switch {
+	case entry.GenericKey != nil:
+		return &envoy_api_v2_route.RateLimit_Action{
+			ActionSpecifier: &envoy_api_v2_route.RateLimit_Action_GenericKey_{
+				GenericKey: &envoy_api_v2_route.RateLimit_Action_GenericKey{
+					DescriptorValue: entry.GenericKey.Value,
+				},
+			},
+		}
+	case entry.RequestHeader != nil:
+		return &envoy_api_v2_route.RateLimit_Action{
+			ActionSpecifier: &envoy_api_v2_route.RateLimit_Action_RequestHeaders_{
+				RequestHeaders: &envoy_api_v2_route.RateLimit_Action_RequestHeaders{
+					HeaderName:    entry.RequestHeader.HeaderName,
+					DescriptorKey: entry.RequestHeader.Key,
+				},
+			},
+		}
+	case entry.HeaderValueMatch != nil:
+		return &envoy_api_v2_route.RateLimit_Action{
+			ActionSpecifier: &envoy_api_v2_route.RateLimit_Action_HeaderValueMatch_{
+				HeaderValueMatch: &envoy_api_v2_route.RateLimit_Action_HeaderValueMatch{
+					DescriptorValue: entry.HeaderValueMatch.Value,
+					ExpectMatch:     protobuf.Bool(entry.HeaderValueMatch.ExpectMatch),
+					Headers:         headerMatcher(entry.HeaderValueMatch.Headers),
+				},
+			},
+		}
+	default:
+		return &envoy_api_v2_route.RateLimit_Action{
+			ActionSpecifier: &envoy_api_v2_route.RateLimit_Action_RemoteAddress_{
+				RemoteAddress: &envoy_api_v2_route.RateLimit_Action_RemoteAddress{},
+			},
+		}
+	}
+}
+
 // RouteMatch creates a *envoy_api_v2_route.RouteMatch for the supplied *dag.Route.
 func RouteMatch(route *dag.Route) *envoy_api_v2_route.RouteMatch {
 	switch c := route.PathMatchCondition.(type) {
@@ -65,18 +175,29 @@ func RouteMatch(route *dag.Route) *envoy_api_v2_route.RouteMatch {
 			PathSpecifier: &envoy_api_v2_route.RouteMatch_SafeRegex{
 				SafeRegex: envoy.SafeRegexMatch(c.Regex),
 			},
-			Headers: headerMatcher(route.HeaderMatchConditions),
+			Headers:         headerMatcher(route.HeaderMatchConditions),
+			QueryParameters: queryParamMatcher(route.QueryParamMatchConditions),
 		}
 	case *dag.PrefixMatchCondition:
 		return &envoy_api_v2_route.RouteMatch{
 			PathSpecifier: &envoy_api_v2_route.RouteMatch_Prefix{
 				Prefix: c.Prefix,
 			},
-			Headers: headerMatcher(route.HeaderMatchConditions),
+			Headers:         headerMatcher(route.HeaderMatchConditions),
+			QueryParameters: queryParamMatcher(route.QueryParamMatchConditions),
+		}
+	case *dag.ExactMatchCondition:
+		return &envoy_api_v2_route.RouteMatch{
+			PathSpecifier: &envoy_api_v2_route.RouteMatch_Path{
+				Path: c.Path,
+			},
+			Headers:         headerMatcher(route.HeaderMatchConditions),
+			QueryParameters: queryParamMatcher(route.QueryParamMatchConditions),
 		}
 	default:
 		return &envoy_api_v2_route.RouteMatch{
-			Headers: headerMatcher(route.HeaderMatchConditions),
+			Headers:         headerMatcher(route.HeaderMatchConditions),
+			QueryParameters: queryParamMatcher(route.QueryParamMatchConditions),
 		}
 	}
 }
@@ -90,8 +211,9 @@ func RouteRoute(r *dag.Route) *envoy_api_v2_route.Route_Route {
 		Timeout:               envoy.Timeout(r.TimeoutPolicy.ResponseTimeout),
 		IdleTimeout:           envoy.Timeout(r.TimeoutPolicy.IdleTimeout),
 		PrefixRewrite:         r.PrefixRewrite,
+		RegexRewrite:          regexRewrite(r),
 		HashPolicy:            hashPolicy(r),
-		RequestMirrorPolicies: mirrorPolicy(r),
+		RequestMirrorPolicies: mirrorPolicies(r),
 	}
 
 	// Check for host header policy and set if found
@@ -123,9 +245,90 @@ func RouteRoute(r *dag.Route) *envoy_api_v2_route.Route_Route {
 	}
 }
 
-// hashPolicy returns a slice of hash policies iff at least one of the route's
-// clusters supplied uses the `Cookie` load balancing strategy.
+// RouteRedirect returns a *envoy_api_v2_route.Route_Redirect for the supplied *dag.Redirect.
+func RouteRedirect(r *dag.Redirect) *envoy_api_v2_route.Route_Redirect {
+	ra := &envoy_api_v2_route.RedirectAction{
+		HostRedirect: r.Hostname,
+		PortRedirect: r.Port,
+		ResponseCode: redirectResponseCode(r.StatusCode),
+	}
+
+	if r.Scheme != "" {
+		ra.SchemeRewriteSpecifier = &envoy_api_v2_route.RedirectAction_SchemeRedirect{
+			SchemeRedirect: r.Scheme,
+		}
+	}
+
+	switch {
+	case r.PathRewrite != "":
+		ra.PathRewriteSpecifier = &envoy_api_v2_route.RedirectAction_PathRedirect{
+			PathRedirect: r.PathRewrite,
+		}
+	case r.PrefixRewrite != "":
+		ra.PathRewriteSpecifier = &envoy_api_v2_route.RedirectAction_PrefixRewrite{
+			PrefixRewrite: r.PrefixRewrite,
+		}
+	}
+
+	return &envoy_api_v2_route.Route_Redirect{
+		Redirect: ra,
+	}
+}
+
+// redirectResponseCode returns the Envoy response code for the supplied
+// HTTP redirect status code. Unsupported codes map to 302 Found.
+func redirectResponseCode(code int) envoy_api_v2_route.RedirectAction_RedirectResponseCode {
+	switch code {
+	case http.StatusMovedPermanently:
+		return envoy_api_v2_route.RedirectAction_MOVED_PERMANENTLY
+	case http.StatusTemporaryRedirect:
+		return envoy_api_v2_route.RedirectAction_TEMPORARY_REDIRECT
+	case http.StatusPermanentRedirect:
+		return envoy_api_v2_route.RedirectAction_PERMANENT_REDIRECT
+	default:
+		return envoy_api_v2_route.RedirectAction_FOUND
+	}
+}
+
+// RouteDirectResponse returns a *envoy_api_v2_route.Route_DirectResponse for the supplied *dag.DirectResponse.
+func RouteDirectResponse(r *dag.DirectResponse) *envoy_api_v2_route.Route_DirectResponse {
+	dr := &envoy_api_v2_route.DirectResponseAction{
+		Status: r.StatusCode,
+	}
+
+	if r.Body != "" {
+		dr.Body = &envoy_api_v2_core.DataSource{
+			Specifier: &envoy_api_v2_core.DataSource_InlineString{
+				InlineString: r.Body,
+			},
+		}
+	}
+
+	return &envoy_api_v2_route.Route_DirectResponse{
+		DirectResponse: dr,
+	}
+}
+
+// regexRewrite returns the regex path rewrite of the route, or nil if it has none.
+func regexRewrite(r *dag.Route) *matcher.RegexMatchAndSubstitute {
+	if r.RegexRewrite == nil {
+		return nil
+	}
+
+	return &matcher.RegexMatchAndSubstitute{
+		Pattern:      envoy.SafeRegexMatch(r.RegexRewrite.Pattern),
+		Substitution: r.RegexRewrite.Substitution,
+	}
+}
+
+// hashPolicy returns a slice of hash policies iff the route has request hash
+// policies, or at least one of the route's clusters supplied uses the `Cookie`
+// load balancing strategy.
 func hashPolicy(r *dag.Route) []*envoy_api_v2_route.RouteAction_HashPolicy {
+	if len(r.RequestHashPolicies) > 0 {
+		return requestHashPolicies(r.RequestHashPolicies)
+	}
+
 	for _, c := range r.Clusters {
 		if c.LoadBalancerPolicy == "Cookie" {
 			return []*envoy_api_v2_route.RouteAction_HashPolicy{{
@@ -142,14 +345,69 @@ func hashPolicy(r *dag.Route) []*envoy_api_v2_route.RouteAction_HashPolicy {
 	return nil
 }
 
-func mirrorPolicy(r *dag.Route) []*envoy_api_v2_route.RouteAction_RequestMirrorPolicy {
-	if r.MirrorPolicy == nil {
-		return nil
+func requestHashPolicies(policies []dag.RequestHashPolicy) []*envoy_api_v2_route.RouteAction_HashPolicy {
+	var hashPolicies []*envoy_api_v2_route.RouteAction_HashPolicy
+	for _, rhp := range policies {
+		hp := &envoy_api_v2_route.RouteAction_HashPolicy{
+			Terminal: rhp.Terminal,
+		}
+		switch {
+		case rhp.HeaderHashOptions != nil:
+			hp.PolicySpecifier = &envoy_api_v2_route.RouteAction_HashPolicy_Header_{
+				Header: &envoy_api_v2_route.RouteAction_HashPolicy_Header{
+					HeaderName: rhp.HeaderHashOptions.HeaderName,
+				},
+			}
+		case rhp.CookieHashOptions != nil:
+			cookie := &envoy_api_v2_route.RouteAction_HashPolicy_Cookie{
+				Name: rhp.CookieHashOptions.CookieName,
+				Path: rhp.CookieHashOptions.Path,
+			}
+			if ttl := rhp.CookieHashOptions.TTL; ttl != nil {
+				cookie.Ttl = protobuf.Duration(*ttl)
+			}
+			hp.PolicySpecifier = &envoy_api_v2_route.RouteAction_HashPolicy_Cookie_{
+				Cookie: cookie,
+			}
+		case rhp.QueryParameterHashOptions != nil:
+			hp.PolicySpecifier = &envoy_api_v2_route.RouteAction_HashPolicy_QueryParameter_{
+				QueryParameter: &envoy_api_v2_route.RouteAction_HashPolicy_QueryParameter{
+					Name: rhp.QueryParameterHashOptions.ParameterName,
+				},
+			}
+		case rhp.HashSourceIP:
+			hp.PolicySpecifier = &envoy_api_v2_route.RouteAction_HashPolicy_ConnectionProperties_{
+				ConnectionProperties: &envoy_api_v2_route.RouteAction_HashPolicy_ConnectionProperties{
+					SourceIp: true,
+				},
+			}
+		default:
+			continue
+		}
+		hashPolicies = append(hashPolicies, hp)
 	}
+	return hashPolicies
+}
+
+func mirrorPolicies(r *dag.Route) []*envoy_api_v2_route.RouteAction_RequestMirrorPolicy {
+	var policies []*envoy_api_v2_route.RouteAction_RequestMirrorPolicy
+	for _, mp := range r.MirrorPolicies {
+		policy := &envoy_api_v2_route.RouteAction_RequestMirrorPolicy{
+			Cluster: envoy.Clustername(mp.Cluster),
+		}
+
+		// Only set a runtime fraction when part of the
+		// traffic is mirrored, since Envoy mirrors all
+		// requests by default.
+		if mp.Weight < 100 {
+			policy.RuntimeFraction = &envoy_api_v2_core.RuntimeFractionalPercent{
+				DefaultValue: fractionalPercent(mp.Weight),
+			}
+		}
 
-	return []*envoy_api_v2_route.RouteAction_RequestMirrorPolicy{{
-		Cluster: envoy.Clustername(r.MirrorPolicy.Cluster),
-	}}
+		policies = append(policies, policy)
+	}
+	return policies
 }
 
 func retryPolicy(r *dag.Route) *envoy_api_v2_route.RetryPolicy {
@@ -330,9 +588,19 @@ func headerMatcher(headers []dag.HeaderMatchCondition) []*envoy_api_v2_route.Hea
 
 		switch h.MatchType {
 		case "exact":
-			header.HeaderMatchSpecifier = &envoy_api_v2_route.HeaderMatcher_ExactMatch{ExactMatch: h.Value}
+			if h.IgnoreCase {
+				header.HeaderMatchSpecifier = inMatch([]string{h.Value}, true)
+			} else {
+				header.HeaderMatchSpecifier = &envoy_api_v2_route.HeaderMatcher_ExactMatch{ExactMatch: h.Value}
+			}
 		case "contains":
 			header.HeaderMatchSpecifier = containsMatch(h.Value)
+		case "regex":
+			header.HeaderMatchSpecifier = &envoy_api_v2_route.HeaderMatcher_SafeRegexMatch{
+				SafeRegexMatch: envoy.SafeRegexMatch(h.Value),
+			}
+		case "in":
+			header.HeaderMatchSpecifier = inMatch(h.Values, h.IgnoreCase)
 		case "present":
 			header.HeaderMatchSpecifier = &envoy_api_v2_route.HeaderMatcher_PresentMatch{PresentMatch: true}
 		}
@@ -341,6 +609,46 @@ func headerMatcher(headers []dag.HeaderMatchCondition) []*envoy_api_v2_route.Hea
 	return envoyHeaders
 }
 
+// queryParamMatcher creates a []*envoy_api_v2_route.QueryParameterMatcher for the supplied QueryParamMatchConditions.
+func queryParamMatcher(queryParams []dag.QueryParamMatchCondition) []*envoy_api_v2_route.QueryParameterMatcher {
+	var envoyQueryParams []*envoy_api_v2_route.QueryParameterMatcher
+
+	for _, q := range queryParams {
+		queryParam := &envoy_api_v2_route.QueryParameterMatcher{
+			Name: q.Name,
+		}
+
+		switch q.MatchType {
+		case "exact":
+			queryParam.QueryParameterMatchSpecifier = stringMatch(&matcher.StringMatcher{
+				MatchPattern: &matcher.StringMatcher_Exact{Exact: q.Value},
+			})
+		case "contains":
+			queryParam.QueryParameterMatchSpecifier = stringMatch(&matcher.StringMatcher{
+				MatchPattern: &matcher.StringMatcher_SafeRegex{
+					SafeRegex: envoy.SafeRegexMatch(fmt.Sprintf(".*%s.*", regexp.QuoteMeta(q.Value))),
+				},
+			})
+		case "regex":
+			queryParam.QueryParameterMatchSpecifier = stringMatch(&matcher.StringMatcher{
+				MatchPattern: &matcher.StringMatcher_SafeRegex{
+					SafeRegex: envoy.SafeRegexMatch(q.Value),
+				},
+			})
+		case "present":
+			queryParam.QueryParameterMatchSpecifier = &envoy_api_v2_route.QueryParameterMatcher_PresentMatch{PresentMatch: true}
+		}
+		envoyQueryParams = append(envoyQueryParams, queryParam)
+	}
+	return envoyQueryParams
+}
+
+func stringMatch(sm *matcher.StringMatcher) *envoy_api_v2_route.QueryParameterMatcher_StringMatch {
+	return &envoy_api_v2_route.QueryParameterMatcher_StringMatch{
+		StringMatch: sm,
+	}
+}
+
 // containsMatch returns a HeaderMatchSpecifier which will match the
 // supplied substring
 func containsMatch(s string) *envoy_api_v2_route.HeaderMatcher_SafeRegexMatch {
@@ -353,3 +661,24 @@ func containsMatch(s string) *envoy_api_v2_route.HeaderMatcher_SafeRegexMatch {
 		SafeRegexMatch: envoy.SafeRegexMatch(regex),
 	}
 }
+
+// inMatch returns a HeaderMatchSpecifier which will match any of the
+// supplied values, optionally ignoring case.
+func inMatch(values []string, ignoreCase bool) *envoy_api_v2_route.HeaderMatcher_SafeRegexMatch {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = regexp.QuoteMeta(v)
+	}
+
+	regex := strings.Join(quoted, "|")
+	if len(values) > 1 {
+		regex = "(?:" + regex + ")"
+	}
+	if ignoreCase {
+		regex = "(?i)" + regex
+	}
+
+	return &envoy_api_v2_route.HeaderMatcher_SafeRegexMatch{
+		SafeRegexMatch: envoy.SafeRegexMatch(regex),
+	}
+}
@@ -20,6 +20,9 @@ import (
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	envoy_config_filter_fault_v2 "github.com/envoyproxy/go-control-plane/envoy/config/filter/fault/v2"
+	envoy_config_filter_http_fault_v2 "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/fault/v2"
+	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type"
 	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher"
 	wrappers "github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/projectcontour/contour/internal/dag"
@@ -56,6 +59,7 @@ func TestRouteRoute(t *testing.T) {
 		},
 		LoadBalancerPolicy: "Cookie",
 	}
+	cookieTTL := time.Hour
 
 	tests := map[string]struct {
 		route *dag.Route
@@ -455,6 +459,94 @@ func TestRouteRoute(t *testing.T) {
 				},
 			},
 		},
+		"single service w/ regex rewrite": {
+			route: &dag.Route{
+				Clusters: []*dag.Cluster{c1},
+				RegexRewrite: &dag.RegexRewrite{
+					Pattern:      "/v[0-9]+/",
+					Substitution: "/",
+				},
+			},
+			want: &envoy_api_v2_route.Route_Route{
+				Route: &envoy_api_v2_route.RouteAction{
+					ClusterSpecifier: &envoy_api_v2_route.RouteAction_Cluster{
+						Cluster: "default/kuard/8080/da39a3ee5e",
+					},
+					RegexRewrite: &matcher.RegexMatchAndSubstitute{
+						Pattern:      envoy.SafeRegexMatch("/v[0-9]+/"),
+						Substitution: "/",
+					},
+				},
+			},
+		},
+		"single service w/ request hash policies": {
+			route: &dag.Route{
+				Clusters: []*dag.Cluster{c1},
+				RequestHashPolicies: []dag.RequestHashPolicy{{
+					Terminal: true,
+					HeaderHashOptions: &dag.HeaderHashOptions{
+						HeaderName: "X-Tenant-Id",
+					},
+				}, {
+					CookieHashOptions: &dag.CookieHashOptions{
+						CookieName: "session",
+						TTL:        &cookieTTL,
+						Path:       "/cart",
+					},
+				}, {
+					CookieHashOptions: &dag.CookieHashOptions{
+						CookieName: "user",
+					},
+				}, {
+					QueryParameterHashOptions: &dag.QueryParameterHashOptions{
+						ParameterName: "key",
+					},
+				}, {
+					HashSourceIP: true,
+				}},
+			},
+			want: &envoy_api_v2_route.Route_Route{
+				Route: &envoy_api_v2_route.RouteAction{
+					ClusterSpecifier: &envoy_api_v2_route.RouteAction_Cluster{
+						Cluster: "default/kuard/8080/da39a3ee5e",
+					},
+					HashPolicy: []*envoy_api_v2_route.RouteAction_HashPolicy{{
+						Terminal: true,
+						PolicySpecifier: &envoy_api_v2_route.RouteAction_HashPolicy_Header_{
+							Header: &envoy_api_v2_route.RouteAction_HashPolicy_Header{
+								HeaderName: "X-Tenant-Id",
+							},
+						},
+					}, {
+						PolicySpecifier: &envoy_api_v2_route.RouteAction_HashPolicy_Cookie_{
+							Cookie: &envoy_api_v2_route.RouteAction_HashPolicy_Cookie{
+								Name: "session",
+								Ttl:  protobuf.Duration(cookieTTL),
+								Path: "/cart",
+							},
+						},
+					}, {
+						PolicySpecifier: &envoy_api_v2_route.RouteAction_HashPolicy_Cookie_{
+							Cookie: &envoy_api_v2_route.RouteAction_HashPolicy_Cookie{
+								Name: "user",
+							},
+						},
+					}, {
+						PolicySpecifier: &envoy_api_v2_route.RouteAction_HashPolicy_QueryParameter_{
+							QueryParameter: &envoy_api_v2_route.RouteAction_HashPolicy_QueryParameter{
+								Name: "key",
+							},
+						},
+					}, {
+						PolicySpecifier: &envoy_api_v2_route.RouteAction_HashPolicy_ConnectionProperties_{
+							ConnectionProperties: &envoy_api_v2_route.RouteAction_HashPolicy_ConnectionProperties{
+								SourceIp: true,
+							},
+						},
+					}},
+				},
+			},
+		},
 		"host header rewrite": {
 			route: &dag.Route{
 				RequestHeadersPolicy: &dag.HeadersPolicy{
@@ -484,7 +576,7 @@ func TestRouteRoute(t *testing.T) {
 					},
 					Weight: 90,
 				}},
-				MirrorPolicy: &dag.MirrorPolicy{
+				MirrorPolicies: []dag.MirrorPolicy{{
 					Cluster: &dag.Cluster{
 						Upstream: &dag.Service{
 							Weighted: dag.WeightedService{
@@ -495,8 +587,31 @@ func TestRouteRoute(t *testing.T) {
 							},
 						},
 					},
+					Weight: 100,
+				}},
+			},
+			want: &envoy_api_v2_route.Route_Route{
+				Route: &envoy_api_v2_route.RouteAction{
+					ClusterSpecifier: &envoy_api_v2_route.RouteAction_Cluster{
+						Cluster: "default/kuard/8080/da39a3ee5e",
+					},
+					RequestMirrorPolicies: []*envoy_api_v2_route.RouteAction_RequestMirrorPolicy{{
+						Cluster: "default/kuard/8080/da39a3ee5e",
+					}},
 				},
 			},
+		},
+		"multiple mirrors": {
+			route: &dag.Route{
+				Clusters: []*dag.Cluster{c1},
+				MirrorPolicies: []dag.MirrorPolicy{{
+					Cluster: c1,
+					Weight:  100,
+				}, {
+					Cluster: c1,
+					Weight:  5,
+				}},
+			},
 			want: &envoy_api_v2_route.Route_Route{
 				Route: &envoy_api_v2_route.RouteAction{
 					ClusterSpecifier: &envoy_api_v2_route.RouteAction_Cluster{
@@ -504,6 +619,14 @@ func TestRouteRoute(t *testing.T) {
 					},
 					RequestMirrorPolicies: []*envoy_api_v2_route.RouteAction_RequestMirrorPolicy{{
 						Cluster: "default/kuard/8080/da39a3ee5e",
+					}, {
+						Cluster: "default/kuard/8080/da39a3ee5e",
+						RuntimeFraction: &envoy_api_v2_core.RuntimeFractionalPercent{
+							DefaultValue: &envoy_type.FractionalPercent{
+								Numerator:   5,
+								Denominator: envoy_type.FractionalPercent_HUNDRED,
+							},
+						},
 					}},
 				},
 			},
@@ -942,11 +1065,414 @@ func TestUpgradeHTTPS(t *testing.T) {
 	assert.Equal(t, want, got)
 }
 
+func TestRateLimits(t *testing.T) {
+	tests := map[string]struct {
+		stage  uint32
+		policy *dag.RateLimitPolicy
+		want   []*envoy_api_v2_route.RateLimit
+	}{
+		"nil policy": {
+			policy: nil,
+			want:   nil,
+		},
+		"all entry types": {
+			stage: 3,
+			policy: &dag.RateLimitPolicy{
+				Descriptors: []*dag.RateLimitDescriptor{{
+					Entries: []dag.RateLimitDescriptorEntry{
+						{GenericKey: &dag.GenericKeyDescriptorEntry{Value: "generic"}},
+						{RequestHeader: &dag.RequestHeaderDescriptorEntry{HeaderName: "X-Header", Key: "header"}},
+					},
+				}, {
+					Entries: []dag.RateLimitDescriptorEntry{
+						{RemoteAddress: &dag.RemoteAddressDescriptorEntry{}},
+						{HeaderValueMatch: &dag.HeaderValueMatchDescriptorEntry{
+							Headers: []dag.HeaderMatchCondition{{
+								Name:      "X-Header",
+								Value:     "foo",
+								MatchType: "exact",
+							}},
+							ExpectMatch: false,
+							Value:       "not-foo",
+						}},
+					},
+				}},
+			},
+			want: []*envoy_api_v2_route.RateLimit{{
+				Stage: protobuf.UInt32(3),
+				Actions: []*envoy_api_v2_route.RateLimit_Action{{
+					ActionSpecifier: &envoy_api_v2_route.RateLimit_Action_GenericKey_{
+						GenericKey: &envoy_api_v2_route.RateLimit_Action_GenericKey{
+							DescriptorValue: "generic",
+						},
+					},
+				}, {
+					ActionSpecifier: &envoy_api_v2_route.RateLimit_Action_RequestHeaders_{
+						RequestHeaders: &envoy_api_v2_route.RateLimit_Action_RequestHeaders{
+							HeaderName:    "X-Header",
+							DescriptorKey: "header",
+						},
+					},
+				}},
+			}, {
+				Stage: protobuf.UInt32(3),
+				Actions: []*envoy_api_v2_route.RateLimit_Action{{
+					ActionSpecifier: &envoy_api_v2_route.RateLimit_Action_RemoteAddress_{
+						RemoteAddress: &envoy_api_v2_route.RateLimit_Action_RemoteAddress{},
+					},
+				}, {
+					ActionSpecifier: &envoy_api_v2_route.RateLimit_Action_HeaderValueMatch_{
+						HeaderValueMatch: &envoy_api_v2_route.RateLimit_Action_HeaderValueMatch{
+							DescriptorValue: "not-foo",
+							ExpectMatch:     protobuf.Bool(false),
+							Headers: []*envoy_api_v2_route.HeaderMatcher{{
+								Name: "X-Header",
+								HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_ExactMatch{
+									ExactMatch: "foo",
+								},
+							}},
+						},
+					},
+				}},
+			}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := RateLimits(tc.stage, tc.policy)
+			protobuf.ExpectEqual(t, tc.want, got)
+		})
+	}
+}
+
+func TestRouteRedirect(t *testing.T) {
+	tests := map[string]struct {
+		redirect *dag.Redirect
+		want     *envoy_api_v2_route.Route_Redirect
+	}{
+		"hostname": {
+			redirect: &dag.Redirect{
+				Hostname:   "www.example.com",
+				StatusCode: 302,
+			},
+			want: &envoy_api_v2_route.Route_Redirect{
+				Redirect: &envoy_api_v2_route.RedirectAction{
+					HostRedirect: "www.example.com",
+					ResponseCode: envoy_api_v2_route.RedirectAction_FOUND,
+				},
+			},
+		},
+		"scheme, port and path": {
+			redirect: &dag.Redirect{
+				Scheme:      "https",
+				Port:        8443,
+				PathRewrite: "/new",
+				StatusCode:  301,
+			},
+			want: &envoy_api_v2_route.Route_Redirect{
+				Redirect: &envoy_api_v2_route.RedirectAction{
+					SchemeRewriteSpecifier: &envoy_api_v2_route.RedirectAction_SchemeRedirect{
+						SchemeRedirect: "https",
+					},
+					PortRedirect: 8443,
+					PathRewriteSpecifier: &envoy_api_v2_route.RedirectAction_PathRedirect{
+						PathRedirect: "/new",
+					},
+					ResponseCode: envoy_api_v2_route.RedirectAction_MOVED_PERMANENTLY,
+				},
+			},
+		},
+		"prefix": {
+			redirect: &dag.Redirect{
+				PrefixRewrite: "/v2/",
+				StatusCode:    308,
+			},
+			want: &envoy_api_v2_route.Route_Redirect{
+				Redirect: &envoy_api_v2_route.RedirectAction{
+					PathRewriteSpecifier: &envoy_api_v2_route.RedirectAction_PrefixRewrite{
+						PrefixRewrite: "/v2/",
+					},
+					ResponseCode: envoy_api_v2_route.RedirectAction_PERMANENT_REDIRECT,
+				},
+			},
+		},
+		"temporary redirect": {
+			redirect: &dag.Redirect{
+				StatusCode: 307,
+			},
+			want: &envoy_api_v2_route.Route_Redirect{
+				Redirect: &envoy_api_v2_route.RedirectAction{
+					ResponseCode: envoy_api_v2_route.RedirectAction_TEMPORARY_REDIRECT,
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := RouteRedirect(tc.redirect)
+			protobuf.ExpectEqual(t, tc.want, got)
+		})
+	}
+}
+
+func TestRouteDirectResponse(t *testing.T) {
+	tests := map[string]struct {
+		response *dag.DirectResponse
+		want     *envoy_api_v2_route.Route_DirectResponse
+	}{
+		"status only": {
+			response: &dag.DirectResponse{
+				StatusCode: 410,
+			},
+			want: &envoy_api_v2_route.Route_DirectResponse{
+				DirectResponse: &envoy_api_v2_route.DirectResponseAction{
+					Status: 410,
+				},
+			},
+		},
+		"status and body": {
+			response: &dag.DirectResponse{
+				StatusCode: 200,
+				Body:       "User-agent: *\nDisallow: /\n",
+			},
+			want: &envoy_api_v2_route.Route_DirectResponse{
+				DirectResponse: &envoy_api_v2_route.DirectResponseAction{
+					Status: 200,
+					Body: &envoy_api_v2_core.DataSource{
+						Specifier: &envoy_api_v2_core.DataSource_InlineString{
+							InlineString: "User-agent: *\nDisallow: /\n",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := RouteDirectResponse(tc.response)
+			protobuf.ExpectEqual(t, tc.want, got)
+		})
+	}
+}
+
+func TestRouteFault(t *testing.T) {
+	tests := map[string]struct {
+		policy *dag.FaultInjectionPolicy
+		want   *envoy_config_filter_http_fault_v2.HTTPFault
+	}{
+		"delay": {
+			policy: &dag.FaultInjectionPolicy{
+				Delay: &dag.FaultDelay{
+					FixedDelay: 2 * time.Second,
+					Percentage: 50,
+				},
+			},
+			want: &envoy_config_filter_http_fault_v2.HTTPFault{
+				Delay: &envoy_config_filter_fault_v2.FaultDelay{
+					FaultDelaySecifier: &envoy_config_filter_fault_v2.FaultDelay_FixedDelay{
+						FixedDelay: protobuf.Duration(2 * time.Second),
+					},
+					Percentage: &envoy_type.FractionalPercent{
+						Numerator:   50,
+						Denominator: envoy_type.FractionalPercent_HUNDRED,
+					},
+				},
+			},
+		},
+		"abort with header": {
+			policy: &dag.FaultInjectionPolicy{
+				Abort: &dag.FaultAbort{
+					StatusCode: 503,
+					Percentage: 100,
+				},
+				HeaderName: "x-inject-fault",
+			},
+			want: &envoy_config_filter_http_fault_v2.HTTPFault{
+				Abort: &envoy_config_filter_http_fault_v2.FaultAbort{
+					ErrorType: &envoy_config_filter_http_fault_v2.FaultAbort_HttpStatus{
+						HttpStatus: 503,
+					},
+					Percentage: &envoy_type.FractionalPercent{
+						Numerator:   100,
+						Denominator: envoy_type.FractionalPercent_HUNDRED,
+					},
+				},
+				Headers: []*envoy_api_v2_route.HeaderMatcher{{
+					Name: "x-inject-fault",
+					HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_PresentMatch{
+						PresentMatch: true,
+					},
+				}},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := RouteFault(tc.policy)
+			protobuf.ExpectEqual(t, protobuf.MustMarshalAny(tc.want), got)
+		})
+	}
+}
+
 func TestRouteMatch(t *testing.T) {
 	tests := map[string]struct {
 		route *dag.Route
 		want  *envoy_api_v2_route.RouteMatch
 	}{
+		"exact path match": {
+			route: &dag.Route{
+				PathMatchCondition: &dag.ExactMatchCondition{
+					Path: "/robots.txt",
+				},
+			},
+			want: &envoy_api_v2_route.RouteMatch{
+				PathSpecifier: &envoy_api_v2_route.RouteMatch_Path{
+					Path: "/robots.txt",
+				},
+			},
+		},
+		"regex path match": {
+			route: &dag.Route{
+				PathMatchCondition: &dag.RegexMatchCondition{
+					Regex: "/api/v1/users/[0-9]+",
+				},
+			},
+			want: &envoy_api_v2_route.RouteMatch{
+				PathSpecifier: &envoy_api_v2_route.RouteMatch_SafeRegex{
+					SafeRegex: envoy.SafeRegexMatch("/api/v1/users/[0-9]+"),
+				},
+			},
+		},
+		"query parameter matches": {
+			route: &dag.Route{
+				QueryParamMatchConditions: []dag.QueryParamMatchCondition{{
+					Name:      "debug",
+					MatchType: "present",
+				}, {
+					Name:      "version",
+					Value:     "2",
+					MatchType: "exact",
+				}, {
+					Name:      "features",
+					Value:     "beta.1",
+					MatchType: "contains",
+				}, {
+					Name:      "user",
+					Value:     "[0-9]+",
+					MatchType: "regex",
+				}},
+			},
+			want: &envoy_api_v2_route.RouteMatch{
+				QueryParameters: []*envoy_api_v2_route.QueryParameterMatcher{{
+					Name: "debug",
+					QueryParameterMatchSpecifier: &envoy_api_v2_route.QueryParameterMatcher_PresentMatch{
+						PresentMatch: true,
+					},
+				}, {
+					Name: "version",
+					QueryParameterMatchSpecifier: &envoy_api_v2_route.QueryParameterMatcher_StringMatch{
+						StringMatch: &matcher.StringMatcher{
+							MatchPattern: &matcher.StringMatcher_Exact{Exact: "2"},
+						},
+					},
+				}, {
+					Name: "features",
+					QueryParameterMatchSpecifier: &envoy_api_v2_route.QueryParameterMatcher_StringMatch{
+						StringMatch: &matcher.StringMatcher{
+							MatchPattern: &matcher.StringMatcher_SafeRegex{
+								SafeRegex: envoy.SafeRegexMatch(".*beta\\.1.*"),
+							},
+						},
+					},
+				}, {
+					Name: "user",
+					QueryParameterMatchSpecifier: &envoy_api_v2_route.QueryParameterMatcher_StringMatch{
+						StringMatch: &matcher.StringMatcher{
+							MatchPattern: &matcher.StringMatcher_SafeRegex{
+								SafeRegex: envoy.SafeRegexMatch("[0-9]+"),
+							},
+						},
+					},
+				}},
+			},
+		},
+		"regex match": {
+			route: &dag.Route{
+				HeaderMatchConditions: []dag.HeaderMatchCondition{{
+					Name:      "user-agent",
+					Value:     ".*Firefox.*",
+					MatchType: "regex",
+				}, {
+					Name:      "x-tenant-id",
+					Value:     "test-[0-9]+",
+					MatchType: "regex",
+					Invert:    true,
+				}},
+			},
+			want: &envoy_api_v2_route.RouteMatch{
+				Headers: []*envoy_api_v2_route.HeaderMatcher{{
+					Name: "user-agent",
+					HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_SafeRegexMatch{
+						SafeRegexMatch: envoy.SafeRegexMatch(".*Firefox.*"),
+					},
+				}, {
+					Name:        "x-tenant-id",
+					InvertMatch: true,
+					HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_SafeRegexMatch{
+						SafeRegexMatch: envoy.SafeRegexMatch("test-[0-9]+"),
+					},
+				}},
+			},
+		},
+		"exact match ignoring case": {
+			route: &dag.Route{
+				HeaderMatchConditions: []dag.HeaderMatchCondition{{
+					Name:       "x-env",
+					Value:      "prod.1",
+					MatchType:  "exact",
+					IgnoreCase: true,
+				}},
+			},
+			want: &envoy_api_v2_route.RouteMatch{
+				Headers: []*envoy_api_v2_route.HeaderMatcher{{
+					Name: "x-env",
+					HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_SafeRegexMatch{
+						SafeRegexMatch: envoy.SafeRegexMatch("(?i)prod\\.1"),
+					},
+				}},
+			},
+		},
+		"in match": {
+			route: &dag.Route{
+				HeaderMatchConditions: []dag.HeaderMatchCondition{{
+					Name:      "x-tenant-id",
+					Values:    []string{"alpha", "beta.1"},
+					MatchType: "in",
+				}, {
+					Name:       "x-env",
+					Values:     []string{"prod", "stage"},
+					MatchType:  "in",
+					IgnoreCase: true,
+				}},
+			},
+			want: &envoy_api_v2_route.RouteMatch{
+				Headers: []*envoy_api_v2_route.HeaderMatcher{{
+					Name: "x-tenant-id",
+					HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_SafeRegexMatch{
+						SafeRegexMatch: envoy.SafeRegexMatch("(?:alpha|beta\\.1)"),
+					},
+				}, {
+					Name: "x-env",
+					HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_SafeRegexMatch{
+						SafeRegexMatch: envoy.SafeRegexMatch("(?i)(?:prod|stage)"),
+					},
+				}},
+			},
+		},
 		"contains match with dashes": {
 			route: &dag.Route{
 				HeaderMatchConditions: []dag.HeaderMatchCondition{{
@@ -0,0 +1,146 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"math"
+
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	http "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	envoy_config_trace_v2 "github.com/envoyproxy/go-control-plane/envoy/config/trace/v2"
+	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type"
+	envoy_type_tracing_v2 "github.com/envoyproxy/go-control-plane/envoy/type/tracing/v2"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/golang/protobuf/proto"
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/internal/envoy"
+	"github.com/projectcontour/contour/internal/protobuf"
+)
+
+// Tracing returns the HTTP connection manager tracing configuration
+// that sends traces to the collector of the supplied dag.Tracing.
+// If tracing is nil, nil is returned and requests are not traced.
+func Tracing(tracing *dag.Tracing) *http.HttpConnectionManager_Tracing {
+	if tracing == nil {
+		return nil
+	}
+
+	var clusterName string
+	switch {
+	case tracing.ExtensionCluster != nil:
+		clusterName = tracing.ExtensionCluster.Name
+	case tracing.Cluster != nil:
+		clusterName = envoy.Clustername(tracing.Cluster)
+	default:
+		return nil
+	}
+
+	var name string
+	var config proto.Message
+
+	switch tracing.Provider {
+	case dag.TracingProviderZipkin:
+		name = wellknown.Zipkin
+		config = &envoy_config_trace_v2.ZipkinConfig{
+			CollectorCluster:         clusterName,
+			CollectorEndpoint:        tracing.CollectorEndpoint,
+			CollectorEndpointVersion: envoy_config_trace_v2.ZipkinConfig_HTTP_JSON,
+			TraceId_128Bit:           true,
+		}
+	case dag.TracingProviderOpenCensus:
+		name = "envoy.tracers.opencensus"
+		config = &envoy_config_trace_v2.OpenCensusConfig{
+			OcagentExporterEnabled: true,
+			OcagentGrpcService: &envoy_api_v2_core.GrpcService{
+				TargetSpecifier: &envoy_api_v2_core.GrpcService_EnvoyGrpc_{
+					EnvoyGrpc: &envoy_api_v2_core.GrpcService_EnvoyGrpc{
+						ClusterName: clusterName,
+					},
+				},
+			},
+			// Propagate the W3C trace context, accepting B3
+			// headers from clients that don't send it.
+			IncomingTraceContext: []envoy_config_trace_v2.OpenCensusConfig_TraceContext{
+				envoy_config_trace_v2.OpenCensusConfig_TRACE_CONTEXT,
+				envoy_config_trace_v2.OpenCensusConfig_B3,
+			},
+			OutgoingTraceContext: []envoy_config_trace_v2.OpenCensusConfig_TraceContext{
+				envoy_config_trace_v2.OpenCensusConfig_TRACE_CONTEXT,
+			},
+		}
+	default:
+		return nil
+	}
+
+	return &http.HttpConnectionManager_Tracing{
+		RandomSampling: &envoy_type.Percent{
+			Value: tracing.SamplingRate,
+		},
+		MaxPathTagLength: protobuf.UInt32OrNil(tracing.MaxPathTagLength),
+		CustomTags:       customTags(tracing.CustomTags),
+		Provider: &envoy_config_trace_v2.Tracing_Http{
+			Name: name,
+			ConfigType: &envoy_config_trace_v2.Tracing_Http_TypedConfig{
+				TypedConfig: protobuf.MustMarshalAny(config),
+			},
+		},
+	}
+}
+
+func customTags(tags []dag.TracingCustomTag) []*envoy_type_tracing_v2.CustomTag {
+	var customTags []*envoy_type_tracing_v2.CustomTag
+
+	for _, tag := range tags {
+		customTag := &envoy_type_tracing_v2.CustomTag{
+			Tag: tag.TagName,
+		}
+
+		if tag.RequestHeader != "" {
+			customTag.Type = &envoy_type_tracing_v2.CustomTag_RequestHeader{
+				RequestHeader: &envoy_type_tracing_v2.CustomTag_Header{
+					Name: tag.RequestHeader,
+				},
+			}
+		} else {
+			customTag.Type = &envoy_type_tracing_v2.CustomTag_Literal_{
+				Literal: &envoy_type_tracing_v2.CustomTag_Literal{
+					Value: tag.Literal,
+				},
+			}
+		}
+
+		customTags = append(customTags, customTag)
+	}
+
+	return customTags
+}
+
+// RouteTracing returns the per-route tracing configuration that
+// overrides the sampling rate of the HTTP connection manager for
+// the supplied policy, or nil if the policy is nil.
+func RouteTracing(policy *dag.TracingPolicy) *envoy_api_v2_route.Tracing {
+	if policy == nil {
+		return nil
+	}
+
+	// Route sampling rates are fractions, so express the
+	// percentage in millionths to keep fractional rates.
+	return &envoy_api_v2_route.Tracing{
+		RandomSampling: &envoy_type.FractionalPercent{
+			Numerator:   uint32(math.Round(policy.SamplingRate * 10000)),
+			Denominator: envoy_type.FractionalPercent_MILLION,
+		},
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"testing"
+
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	http "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	envoy_config_trace_v2 "github.com/envoyproxy/go-control-plane/envoy/config/trace/v2"
+	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type"
+	envoy_type_tracing_v2 "github.com/envoyproxy/go-control-plane/envoy/type/tracing/v2"
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/internal/protobuf"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTracing(t *testing.T) {
+	zipkin := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "zipkin",
+			Namespace: "tracing",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Protocol: "TCP",
+				Port:     9411,
+			}},
+		},
+	}
+
+	tests := map[string]struct {
+		tracing *dag.Tracing
+		want    *http.HttpConnectionManager_Tracing
+	}{
+		"nil": {
+			tracing: nil,
+			want:    nil,
+		},
+		"no collector": {
+			tracing: &dag.Tracing{
+				Provider: dag.TracingProviderZipkin,
+			},
+			want: nil,
+		},
+		"zipkin service": {
+			tracing: &dag.Tracing{
+				Provider: dag.TracingProviderZipkin,
+				Cluster: &dag.Cluster{
+					Upstream: service(zipkin),
+				},
+				CollectorEndpoint: "/api/v2/spans",
+				SamplingRate:      12.5,
+				MaxPathTagLength:  64,
+				CustomTags: []dag.TracingCustomTag{{
+					TagName: "environment",
+					Literal: "staging",
+				}},
+			},
+			want: &http.HttpConnectionManager_Tracing{
+				RandomSampling:   &envoy_type.Percent{Value: 12.5},
+				MaxPathTagLength: protobuf.UInt32(64),
+				CustomTags: []*envoy_type_tracing_v2.CustomTag{{
+					Tag: "environment",
+					Type: &envoy_type_tracing_v2.CustomTag_Literal_{
+						Literal: &envoy_type_tracing_v2.CustomTag_Literal{Value: "staging"},
+					},
+				}},
+				Provider: &envoy_config_trace_v2.Tracing_Http{
+					Name: "envoy.tracers.zipkin",
+					ConfigType: &envoy_config_trace_v2.Tracing_Http_TypedConfig{
+						TypedConfig: protobuf.MustMarshalAny(&envoy_config_trace_v2.ZipkinConfig{
+							CollectorCluster:         "tracing/zipkin/9411/da39a3ee5e",
+							CollectorEndpoint:        "/api/v2/spans",
+							CollectorEndpointVersion: envoy_config_trace_v2.ZipkinConfig_HTTP_JSON,
+							TraceId_128Bit:           true,
+						}),
+					},
+				},
+			},
+		},
+		"opencensus extension service": {
+			tracing: &dag.Tracing{
+				Provider: dag.TracingProviderOpenCensus,
+				ExtensionCluster: &dag.ExtensionCluster{
+					Name: "extension/tracing/collector",
+				},
+				SamplingRate: 100,
+				CustomTags: []dag.TracingCustomTag{{
+					TagName:       "user",
+					RequestHeader: "X-User",
+				}},
+			},
+			want: &http.HttpConnectionManager_Tracing{
+				RandomSampling: &envoy_type.Percent{Value: 100},
+				CustomTags: []*envoy_type_tracing_v2.CustomTag{{
+					Tag: "user",
+					Type: &envoy_type_tracing_v2.CustomTag_RequestHeader{
+						RequestHeader: &envoy_type_tracing_v2.CustomTag_Header{Name: "X-User"},
+					},
+				}},
+				Provider: &envoy_config_trace_v2.Tracing_Http{
+					Name: "envoy.tracers.opencensus",
+					ConfigType: &envoy_config_trace_v2.Tracing_Http_TypedConfig{
+						TypedConfig: protobuf.MustMarshalAny(&envoy_config_trace_v2.OpenCensusConfig{
+							OcagentExporterEnabled: true,
+							OcagentGrpcService: &envoy_api_v2_core.GrpcService{
+								TargetSpecifier: &envoy_api_v2_core.GrpcService_EnvoyGrpc_{
+									EnvoyGrpc: &envoy_api_v2_core.GrpcService_EnvoyGrpc{
+										ClusterName: "extension/tracing/collector",
+									},
+								},
+							},
+							IncomingTraceContext: []envoy_config_trace_v2.OpenCensusConfig_TraceContext{
+								envoy_config_trace_v2.OpenCensusConfig_TRACE_CONTEXT,
+								envoy_config_trace_v2.OpenCensusConfig_B3,
+							},
+							OutgoingTraceContext: []envoy_config_trace_v2.OpenCensusConfig_TraceContext{
+								envoy_config_trace_v2.OpenCensusConfig_TRACE_CONTEXT,
+							},
+						}),
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := Tracing(tc.tracing)
+			protobuf.ExpectEqual(t, tc.want, got)
+		})
+	}
+}
+
+func TestRouteTracing(t *testing.T) {
+	tests := map[string]struct {
+		policy *dag.TracingPolicy
+		want   *envoy_api_v2_route.Tracing
+	}{
+		"nil": {
+			policy: nil,
+			want:   nil,
+		},
+		"disabled": {
+			policy: &dag.TracingPolicy{SamplingRate: 0},
+			want: &envoy_api_v2_route.Tracing{
+				RandomSampling: &envoy_type.FractionalPercent{
+					Numerator:   0,
+					Denominator: envoy_type.FractionalPercent_MILLION,
+				},
+			},
+		},
+		"fractional": {
+			policy: &dag.TracingPolicy{SamplingRate: 12.3456},
+			want: &envoy_api_v2_route.Tracing{
+				RandomSampling: &envoy_type.FractionalPercent{
+					Numerator:   123456,
+					Denominator: envoy_type.FractionalPercent_MILLION,
+				},
+			},
+		},
+		"all": {
+			policy: &dag.TracingPolicy{SamplingRate: 100},
+			want: &envoy_api_v2_route.Tracing{
+				RandomSampling: &envoy_type.FractionalPercent{
+					Numerator:   1000000,
+					Denominator: envoy_type.FractionalPercent_MILLION,
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := RouteTracing(tc.policy)
+			protobuf.ExpectEqual(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package v3 contains APIs for translating Contour's Envoy v2
+// configuration into the equivalent Envoy v3 API types.
+package v3
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	udpa_annotations "github.com/cncf/udpa/go/udpa/annotations"
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	protov1 "github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/any"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	// Register the v3 types that Contour generates so that their
+	// v2 predecessors can be resolved.
+	_ "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/config/ratelimit/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/config/trace/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/access_loggers/file/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/access_loggers/grpc/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_authz/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/fault/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/lua/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ratelimit/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+)
+
+const typeURLPrefix = "type.googleapis.com/"
+
+var (
+	successorsOnce sync.Once
+	successors     map[protoreflect.FullName]protoreflect.MessageType
+)
+
+// successorOf returns the v3 message type that replaces the named
+// v2 message type.
+func successorOf(name protoreflect.FullName) (protoreflect.MessageType, bool) {
+	successorsOnce.Do(func() {
+		successors = map[protoreflect.FullName]protoreflect.MessageType{}
+
+		// Each v3 message is annotated with the name of the
+		// message it was derived from, so we can build the
+		// reverse mapping from the type registry.
+		protoregistry.GlobalTypes.RangeMessages(func(mt protoreflect.MessageType) bool {
+			desc := mt.Descriptor()
+			if !isV3(desc.FullName()) {
+				return true
+			}
+
+			opts := desc.Options()
+			if opts == nil || !proto.HasExtension(opts, udpa_annotations.E_Versioning) {
+				return true
+			}
+
+			v, ok := proto.GetExtension(opts, udpa_annotations.E_Versioning).(*udpa_annotations.VersioningAnnotation)
+			if ok && v.GetPreviousMessageType() != "" {
+				successors[protoreflect.FullName(v.GetPreviousMessageType())] = mt
+			}
+
+			return true
+		})
+	})
+
+	mt, ok := successors[name]
+	return mt, ok
+}
+
+// isV3 returns true if the named message belongs to a v3 Envoy API package.
+func isV3(name protoreflect.FullName) bool {
+	for _, s := range strings.Split(string(name.Parent()), ".") {
+		if s == "v3" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Upgrade translates the given Envoy v2 message into the equivalent
+// v3 message. Embedded Any messages are translated to their v3 types,
+// and any configuration sources are set to use the v3 resource and
+// transport APIs.
+//
+// v3 messages are wire compatible with their v2 predecessors, except
+// for fields that were deprecated in v2 and removed from (or hidden
+// in) v3. Upgrade returns an error if the v2 message uses any of these.
+func Upgrade(msg protov1.Message) (protov1.Message, error) {
+	out, err := upgrade(protov1.MessageV2(msg))
+	if err != nil {
+		return nil, err
+	}
+
+	return protov1.MessageV1(out), nil
+}
+
+// MustUpgrade translates the given Envoy v2 message into the equivalent
+// v3 message, panicking if there is an error.
+func MustUpgrade(msg protov1.Message) protov1.Message {
+	out, err := Upgrade(msg)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return out
+}
+
+func upgrade(msg proto.Message) (proto.Message, error) {
+	name := msg.ProtoReflect().Descriptor().FullName()
+	mt, ok := successorOf(name)
+	if !ok {
+		return nil, fmt.Errorf("no v3 equivalent for %q", name)
+	}
+
+	buf, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	out := mt.New().Interface()
+	if err := proto.Unmarshal(buf, out); err != nil {
+		return nil, err
+	}
+
+	if err := upgradeMessage(out.ProtoReflect()); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// upgradeMessage walks the fields of a message that has been decoded
+// as a v3 type, fixing up the parts that v3 doesn't decode compatibly.
+func upgradeMessage(m protoreflect.Message) error {
+	if len(m.GetUnknown()) > 0 {
+		return fmt.Errorf("%q uses fields that were removed in v3", m.Descriptor().FullName())
+	}
+
+	switch msg := m.Interface().(type) {
+	case *any.Any:
+		return upgradeAny(msg)
+	case *envoy_config_core_v3.ConfigSource:
+		msg.ResourceApiVersion = envoy_config_core_v3.ApiVersion_V3
+	case *envoy_config_core_v3.ApiConfigSource:
+		msg.TransportApiVersion = envoy_config_core_v3.ApiVersion_V3
+	}
+
+	var err error
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		// The v3 API retains fields that were deprecated in v2
+		// under this prefix, but Envoy rejects them by default.
+		if strings.HasPrefix(string(fd.Name()), "hidden_envoy_deprecated_") {
+			err = fmt.Errorf("%q uses deprecated field %q", m.Descriptor().FullName(), fd.Name())
+			return false
+		}
+
+		switch {
+		case fd.IsList() && fd.Message() != nil:
+			l := v.List()
+			for i := 0; i < l.Len() && err == nil; i++ {
+				err = upgradeMessage(l.Get(i).Message())
+			}
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			v.Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+				err = upgradeMessage(v.Message())
+				return err == nil
+			})
+		case !fd.IsList() && !fd.IsMap() && fd.Message() != nil:
+			err = upgradeMessage(v.Message())
+		}
+
+		return err == nil
+	})
+
+	return err
+}
+
+// upgradeAny replaces the v2 message embedded in a with its v3 equivalent.
+func upgradeAny(a *any.Any) error {
+	name := protoreflect.FullName(strings.TrimPrefix(a.GetTypeUrl(), typeURLPrefix))
+
+	// Messages that already have v3 (or non-Envoy) types are passed
+	// through unchanged.
+	if isV3(name) || !strings.HasPrefix(string(name), "envoy.") {
+		return nil
+	}
+
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(name)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %q: %w", name, err)
+	}
+
+	msg := mt.New().Interface()
+	if err := proto.Unmarshal(a.GetValue(), msg); err != nil {
+		return err
+	}
+
+	out, err := upgrade(msg)
+	if err != nil {
+		return err
+	}
+
+	buf, err := proto.MarshalOptions{Deterministic: true}.Marshal(out)
+	if err != nil {
+		return err
+	}
+
+	a.TypeUrl = typeURLPrefix + string(out.ProtoReflect().Descriptor().FullName())
+	a.Value = buf
+	return nil
+}
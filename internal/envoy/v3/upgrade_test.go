@@ -0,0 +1,161 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"errors"
+	"testing"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	envoy_api_v2_listener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	envoy_config_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	http_v2 "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	envoy_config_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	http_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/projectcontour/contour/internal/protobuf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpgrade(t *testing.T) {
+	tests := map[string]struct {
+		v2   proto.Message
+		want proto.Message
+		err  error
+	}{
+		"eds cluster": {
+			v2: &envoy_api_v2.Cluster{
+				Name:                 "default/kuard/443/da39a3ee5e",
+				ClusterDiscoveryType: &envoy_api_v2.Cluster_Type{Type: envoy_api_v2.Cluster_EDS},
+				EdsClusterConfig: &envoy_api_v2.Cluster_EdsClusterConfig{
+					EdsConfig: &envoy_api_v2_core.ConfigSource{
+						ConfigSourceSpecifier: &envoy_api_v2_core.ConfigSource_ApiConfigSource{
+							ApiConfigSource: &envoy_api_v2_core.ApiConfigSource{
+								ApiType: envoy_api_v2_core.ApiConfigSource_GRPC,
+								GrpcServices: []*envoy_api_v2_core.GrpcService{{
+									TargetSpecifier: &envoy_api_v2_core.GrpcService_EnvoyGrpc_{
+										EnvoyGrpc: &envoy_api_v2_core.GrpcService_EnvoyGrpc{
+											ClusterName: "contour",
+										},
+									},
+								}},
+							},
+						},
+					},
+					ServiceName: "default/kuard/https",
+				},
+			},
+			want: &envoy_config_cluster_v3.Cluster{
+				Name:                 "default/kuard/443/da39a3ee5e",
+				ClusterDiscoveryType: &envoy_config_cluster_v3.Cluster_Type{Type: envoy_config_cluster_v3.Cluster_EDS},
+				EdsClusterConfig: &envoy_config_cluster_v3.Cluster_EdsClusterConfig{
+					EdsConfig: &envoy_config_core_v3.ConfigSource{
+						ConfigSourceSpecifier: &envoy_config_core_v3.ConfigSource_ApiConfigSource{
+							ApiConfigSource: &envoy_config_core_v3.ApiConfigSource{
+								ApiType:             envoy_config_core_v3.ApiConfigSource_GRPC,
+								TransportApiVersion: envoy_config_core_v3.ApiVersion_V3,
+								GrpcServices: []*envoy_config_core_v3.GrpcService{{
+									TargetSpecifier: &envoy_config_core_v3.GrpcService_EnvoyGrpc_{
+										EnvoyGrpc: &envoy_config_core_v3.GrpcService_EnvoyGrpc{
+											ClusterName: "contour",
+										},
+									},
+								}},
+							},
+						},
+						ResourceApiVersion: envoy_config_core_v3.ApiVersion_V3,
+					},
+					ServiceName: "default/kuard/https",
+				},
+			},
+		},
+		"listener with typed filter config": {
+			v2: &envoy_api_v2.Listener{
+				Name: "ingress_http",
+				FilterChains: []*envoy_api_v2_listener.FilterChain{{
+					Filters: []*envoy_api_v2_listener.Filter{{
+						Name: "envoy.filters.network.http_connection_manager",
+						ConfigType: &envoy_api_v2_listener.Filter_TypedConfig{
+							TypedConfig: protobuf.MustMarshalAny(&http_v2.HttpConnectionManager{
+								StatPrefix: "ingress_http",
+								RouteSpecifier: &http_v2.HttpConnectionManager_Rds{
+									Rds: &http_v2.Rds{
+										RouteConfigName: "ingress_http",
+										ConfigSource: &envoy_api_v2_core.ConfigSource{
+											ConfigSourceSpecifier: &envoy_api_v2_core.ConfigSource_Ads{
+												Ads: &envoy_api_v2_core.AggregatedConfigSource{},
+											},
+										},
+									},
+								},
+							}),
+						},
+					}},
+				}},
+			},
+			want: &envoy_config_listener_v3.Listener{
+				Name: "ingress_http",
+				FilterChains: []*envoy_config_listener_v3.FilterChain{{
+					Filters: []*envoy_config_listener_v3.Filter{{
+						Name: "envoy.filters.network.http_connection_manager",
+						ConfigType: &envoy_config_listener_v3.Filter_TypedConfig{
+							TypedConfig: protobuf.MustMarshalAny(&http_v3.HttpConnectionManager{
+								StatPrefix: "ingress_http",
+								RouteSpecifier: &http_v3.HttpConnectionManager_Rds{
+									Rds: &http_v3.Rds{
+										RouteConfigName: "ingress_http",
+										ConfigSource: &envoy_config_core_v3.ConfigSource{
+											ConfigSourceSpecifier: &envoy_config_core_v3.ConfigSource_Ads{
+												Ads: &envoy_config_core_v3.AggregatedConfigSource{},
+											},
+											ResourceApiVersion: envoy_config_core_v3.ApiVersion_V3,
+										},
+									},
+								},
+							}),
+						},
+					}},
+				}},
+			},
+		},
+		"deprecated field": {
+			v2: &envoy_api_v2.Cluster{
+				Name: "static",
+				Hosts: []*envoy_api_v2_core.Address{{
+					Address: &envoy_api_v2_core.Address_Pipe{
+						Pipe: &envoy_api_v2_core.Pipe{Path: "/tmp/static"},
+					},
+				}},
+			},
+			err: errors.New(`"envoy.config.cluster.v3.Cluster" uses deprecated field "hidden_envoy_deprecated_hosts"`),
+		},
+		"not an envoy type": {
+			v2:  &wrappers.StringValue{Value: "static"},
+			err: errors.New(`no v3 equivalent for "google.protobuf.StringValue"`),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := Upgrade(tc.v2)
+			assert.Equal(t, tc.err, err)
+			if tc.err == nil {
+				protobuf.ExpectEqual(t, tc.want, got)
+			}
+		})
+	}
+}
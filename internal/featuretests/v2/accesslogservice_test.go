@@ -0,0 +1,121 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"testing"
+	"time"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+	"github.com/projectcontour/contour/internal/contour"
+	"github.com/projectcontour/contour/internal/dag"
+	envoy_v2 "github.com/projectcontour/contour/internal/envoy/v2"
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/projectcontour/contour/internal/timeout"
+	xdscache_v2 "github.com/projectcontour/contour/internal/xdscache/v2"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// withAccessLogService returns a setup option that adds the
+// supplied access log service processor to the DAG builder.
+func withAccessLogService(p *dag.AccessLogServiceProcessor) func(*contour.EventHandler) {
+	return func(eh *contour.EventHandler) {
+		p.FieldLogger = logrus.StandardLogger()
+		eh.Builder.Processors = append(eh.Builder.Processors, p)
+	}
+}
+
+func TestAccessLogService(t *testing.T) {
+	rh, c, done := setup(t, withAccessLogService(&dag.AccessLogServiceProcessor{
+		ExtensionService:    &types.NamespacedName{Namespace: "logging", Name: "als"},
+		LogName:             "contour",
+		BufferFlushInterval: time.Second,
+	}))
+	defer done()
+
+	rh.OnAdd(fixture.NewService("logging/als-server").
+		WithPorts(corev1.ServicePort{Port: 9001}))
+
+	rh.OnAdd(&v1alpha1.ExtensionService{
+		ObjectMeta: fixture.ObjectMeta("logging/als"),
+		Spec: v1alpha1.ExtensionServiceSpec{
+			Services: []v1alpha1.ExtensionServiceTarget{
+				{Name: "als-server", Port: 9001},
+			},
+		},
+	})
+
+	rh.OnAdd(fixture.NewService("app-server").
+		WithPorts(corev1.ServicePort{Port: 80}))
+
+	rh.OnAdd(fixture.NewProxy("proxy").
+		WithFQDN("als.projectcontour.io").
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{Name: "app-server", Port: 80}},
+			}},
+		}))
+
+	als := &dag.AccessLogService{
+		ExtensionService: &dag.ExtensionCluster{
+			Name: "extension/logging/als",
+		},
+		LogName:             "contour",
+		BufferFlushInterval: time.Second,
+	}
+
+	accessLoggers := envoy_v2.FileAccessLogEnvoy("/dev/stdout")
+	accessLoggers = append(accessLoggers, envoy_v2.HTTPGRPCAccessLog(als)...)
+
+	// Access logs are streamed to the access log service
+	// as well as being written to the access log file.
+	c.Request(listenerType).Equals(&envoy_api_v2.DiscoveryResponse{
+		TypeUrl: listenerType,
+		Resources: resources(t,
+			&envoy_api_v2.Listener{
+				Name:    "ingress_http",
+				Address: envoy_v2.SocketAddress("0.0.0.0", 8080),
+				FilterChains: envoy_v2.FilterChains(
+					envoy_v2.HTTPConnectionManagerBuilder().
+						DefaultFilters().
+						RouteConfigName(xdscache_v2.ENVOY_HTTP_LISTENER).
+						MetricsPrefix(xdscache_v2.ENVOY_HTTP_LISTENER).
+						AccessLoggers(accessLoggers).
+						RequestTimeout(timeout.DurationSetting(0)).
+						Get(),
+				),
+				SocketOptions: envoy_v2.TCPKeepaliveSocketOptions(),
+			},
+			staticListener(),
+		),
+	})
+
+	// Access logs are only written to the file when
+	// the access log service is not found.
+	rh.OnDelete(&v1alpha1.ExtensionService{
+		ObjectMeta: fixture.ObjectMeta("logging/als"),
+	})
+
+	c.Request(listenerType).Equals(&envoy_api_v2.DiscoveryResponse{
+		TypeUrl: listenerType,
+		Resources: resources(t,
+			defaultHTTPListener(),
+			staticListener(),
+		),
+	})
+}
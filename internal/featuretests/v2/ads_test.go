@@ -0,0 +1,72 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"context"
+	"testing"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/projectcontour/contour/internal/protobuf"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Test that resources requested over ADS are fetched from ADS.
+func TestADSClusters(t *testing.T) {
+	rh, c, done := setup(t)
+	defer done()
+
+	rh.OnAdd(&v1beta1.Ingress{
+		ObjectMeta: fixture.ObjectMeta("kuard"),
+		Spec: v1beta1.IngressSpec{
+			Backend: &v1beta1.IngressBackend{
+				ServiceName: "kuard",
+				ServicePort: intstr.FromInt(80),
+			},
+		},
+	})
+
+	rh.OnAdd(fixture.NewService("kuard").
+		WithPorts(v1.ServicePort{Port: 80}),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	st, err := discovery.NewAggregatedDiscoveryServiceClient(c.ClientConn).StreamAggregatedResources(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, st.Send(&envoy_api_v2.DiscoveryRequest{
+		TypeUrl: clusterType,
+	}))
+
+	resp, err := st.Recv()
+	require.NoError(t, err)
+
+	want := cluster("default/kuard/80/da39a3ee5e", "default/kuard", "default_kuard_80")
+	want.EdsClusterConfig.EdsConfig = &envoy_api_v2_core.ConfigSource{
+		ConfigSourceSpecifier: &envoy_api_v2_core.ConfigSource_Ads{
+			Ads: &envoy_api_v2_core.AggregatedConfigSource{},
+		},
+	}
+
+	require.Equal(t, clusterType, resp.TypeUrl)
+	protobuf.ExpectEqual(t, resources(t, want), resp.Resources)
+}
@@ -24,8 +24,11 @@ import (
 	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	envoy_api_v2_listener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
 	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_config_filter_http_ext_authz_v2 "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/ext_authz/v2"
+	envoy_extensions_filters_http_ext_authz_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_authz/v3"
 	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type"
+	envoy_type_v3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
 	"github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
 	envoy_v2 "github.com/projectcontour/contour/internal/envoy/v2"
@@ -516,6 +519,83 @@ func authzInvalidReference(t *testing.T, rh cache.ResourceEventHandler, c *Conto
 	})
 }
 
+func authzProtocolVersion3(t *testing.T, rh cache.ResourceEventHandler, c *Contour) {
+	const fqdn = "echo.projectcontour.io"
+
+	rh.OnAdd(&v1alpha1.ExtensionService{
+		ObjectMeta: fixture.ObjectMeta("auth/extension-v3"),
+		Spec: v1alpha1.ExtensionServiceSpec{
+			Services: []v1alpha1.ExtensionServiceTarget{
+				{Name: "oidc-server", Port: 8081},
+			},
+			ProtocolVersion: v1alpha1.SupportProtocolVersion3,
+		},
+	})
+
+	p := fixture.NewProxy("proxy").
+		WithFQDN(fqdn).
+		WithCertificate("certificate").
+		WithAuthServer(contour_api_v1.AuthorizationServer{
+			ExtensionServiceRef: contour_api_v1.ExtensionServiceReference{
+				Namespace: "auth",
+				Name:      "extension-v3",
+			},
+		}).
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{Name: "app-server", Port: 80}},
+			}},
+		})
+
+	rh.OnAdd(p)
+
+	c.Request(listenerType).Equals(&envoy_api_v2.DiscoveryResponse{
+		TypeUrl: listenerType,
+		Resources: resources(t,
+			defaultHTTPListener(),
+			&envoy_api_v2.Listener{
+				Name:    "ingress_https",
+				Address: envoy_v2.SocketAddress("0.0.0.0", 8443),
+				ListenerFilters: envoy_v2.ListenerFilters(
+					envoy_v2.TLSInspector(),
+				),
+				FilterChains: []*envoy_api_v2_listener.FilterChain{
+					filterchaintls(fqdn,
+						&corev1.Secret{
+							ObjectMeta: fixture.ObjectMeta("certificate"),
+							Type:       "kubernetes.io/tls",
+							Data:       featuretests.Secretdata(featuretests.CERTIFICATE, featuretests.RSA_PRIVATE_KEY),
+						},
+						authzFilterFor(
+							fqdn,
+							&envoy_extensions_filters_http_ext_authz_v3.ExtAuthz{
+								Services: &envoy_extensions_filters_http_ext_authz_v3.ExtAuthz_GrpcService{
+									GrpcService: &envoy_config_core_v3.GrpcService{
+										TargetSpecifier: &envoy_config_core_v3.GrpcService_EnvoyGrpc_{
+											EnvoyGrpc: &envoy_config_core_v3.GrpcService_EnvoyGrpc{
+												ClusterName: "extension/auth/extension-v3",
+											},
+										},
+									},
+								},
+								TransportApiVersion:    envoy_config_core_v3.ApiVersion_V3,
+								ClearRouteCache:        true,
+								IncludePeerCertificate: true,
+								StatusOnError: &envoy_type_v3.HttpStatus{
+									Code: envoy_type_v3.StatusCode_Forbidden,
+								},
+							},
+						),
+						nil, "h2", "http/1.1"),
+				},
+				SocketOptions: envoy_v2.TCPKeepaliveSocketOptions(),
+			},
+			staticListener()),
+	}).Status(p).Like(contour_api_v1.HTTPProxyStatus{
+		CurrentStatus: string(status.ProxyStatusValid),
+	})
+}
+
 func TestAuthorization(t *testing.T) {
 	subtests := map[string]func(*testing.T, cache.ResourceEventHandler, *Contour){
 		"MissingExtension":       authzInvalidReference,
@@ -525,6 +605,7 @@ func TestAuthorization(t *testing.T) {
 		"FailOpen":               authzFailOpen,
 		"ResponseTimeout":        authzResponseTimeout,
 		"InvalidResponseTimeout": authzInvalidResponseTimeout,
+		"ProtocolVersion3":       authzProtocolVersion3,
 	}
 
 	for n, f := range subtests {
@@ -15,17 +15,23 @@ package v2
 
 import (
 	"testing"
+	"time"
 
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	envoy_cluster "github.com/envoyproxy/go-control-plane/envoy/api/v2/cluster"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	envoy_api_v2_endpoint "github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
+	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type"
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
 	envoy_v2 "github.com/projectcontour/contour/internal/envoy/v2"
+	"github.com/projectcontour/contour/internal/featuretests"
 	"github.com/projectcontour/contour/internal/fixture"
 	"github.com/projectcontour/contour/internal/protobuf"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/networking/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
 )
 
 // projectcontour/contour#186
@@ -630,6 +636,254 @@ func TestClusterWithHealthChecks(t *testing.T) {
 	})
 }
 
+func TestClusterWithGRPCHealthChecks(t *testing.T) {
+	rh, c, done := setup(t)
+	defer done()
+
+	rh.OnAdd(fixture.NewService("grpc").
+		WithPorts(v1.ServicePort{Port: 80, TargetPort: intstr.FromString("8080")}),
+	)
+
+	proxy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "simple",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{Fqdn: "www.example.com"},
+			Routes: []contour_api_v1.Route{{
+				HealthCheckPolicy: &contour_api_v1.HTTPHealthCheckPolicy{
+					GRPC: &contour_api_v1.GRPCHealthCheck{
+						ServiceName: "helloworld.Greeter",
+					},
+				},
+				Services: []contour_api_v1.Service{{
+					Name:     "grpc",
+					Port:     80,
+					Protocol: pointer.StringPtr("h2c"),
+				}},
+			}},
+		},
+	}
+	rh.OnAdd(proxy)
+
+	c.Request(clusterType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: resources(t,
+			DefaultCluster(h2cCluster(&envoy_api_v2.Cluster{
+				Name:                 "default/grpc/80/69a5c7b097",
+				AltStatName:          "default_grpc_80",
+				ClusterDiscoveryType: envoy_v2.ClusterDiscoveryType(envoy_api_v2.Cluster_EDS),
+				EdsClusterConfig: &envoy_api_v2.Cluster_EdsClusterConfig{
+					EdsConfig:   envoy_v2.ConfigSource("contour"),
+					ServiceName: "default/grpc",
+				},
+				HealthChecks: []*envoy_api_v2_core.HealthCheck{{
+					Timeout:            protobuf.Duration(2 * time.Second),
+					Interval:           protobuf.Duration(10 * time.Second),
+					UnhealthyThreshold: protobuf.UInt32(3),
+					HealthyThreshold:   protobuf.UInt32(2),
+					HealthChecker: &envoy_api_v2_core.HealthCheck_GrpcHealthCheck_{
+						GrpcHealthCheck: &envoy_api_v2_core.HealthCheck_GrpcHealthCheck{
+							ServiceName: "helloworld.Greeter",
+						},
+					},
+				}},
+				DrainConnectionsOnHostRemoval: true,
+			})),
+		),
+		TypeUrl: clusterType,
+	})
+
+	// gRPC health checks can't be sent to a service that
+	// doesn't use HTTP/2.
+	updated := proxy.DeepCopy()
+	updated.Spec.Routes[0].Services[0].Protocol = nil
+	rh.OnUpdate(proxy, updated)
+
+	c.Request(clusterType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: nil,
+		TypeUrl:   clusterType,
+	}).Status(updated).HasError("ServiceError", "GRPCHealthCheckNotSupported",
+		`service "grpc": gRPC health checks require the h2 or h2c protocol`)
+}
+
+func TestClusterWithHealthCheckOptions(t *testing.T) {
+	rh, c, done := setup(t)
+	defer done()
+
+	rh.OnAdd(fixture.NewService("kuard").
+		WithPorts(v1.ServicePort{Port: 80, TargetPort: intstr.FromString("8080")}),
+	)
+
+	proxy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "simple",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{Fqdn: "www.example.com"},
+			Routes: []contour_api_v1.Route{{
+				HealthCheckPolicy: &contour_api_v1.HTTPHealthCheckPolicy{
+					Path:                     "/healthz",
+					UnhealthyIntervalSeconds: 5,
+					Port:                     9001,
+					ExpectedStatuses: []contour_api_v1.HTTPStatusRange{{
+						Start: 200,
+						End:   400,
+					}},
+					RequestHeaders: []contour_api_v1.HeaderValue{{
+						Name:  "x-health-check",
+						Value: "true",
+					}},
+				},
+				Services: []contour_api_v1.Service{{
+					Name: "kuard",
+					Port: 80,
+				}},
+			}},
+		},
+	}
+	rh.OnAdd(proxy)
+
+	c.Request(clusterType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: resources(t,
+			DefaultCluster(&envoy_api_v2.Cluster{
+				Name:                 "default/kuard/80/ae6d35e61e",
+				AltStatName:          "default_kuard_80",
+				ClusterDiscoveryType: envoy_v2.ClusterDiscoveryType(envoy_api_v2.Cluster_EDS),
+				EdsClusterConfig: &envoy_api_v2.Cluster_EdsClusterConfig{
+					EdsConfig:   envoy_v2.ConfigSource("contour"),
+					ServiceName: "default/kuard/healthcheck/9001",
+				},
+				HealthChecks: []*envoy_api_v2_core.HealthCheck{{
+					Timeout:            protobuf.Duration(2 * time.Second),
+					Interval:           protobuf.Duration(10 * time.Second),
+					UnhealthyInterval:  protobuf.Duration(5 * time.Second),
+					UnhealthyThreshold: protobuf.UInt32(3),
+					HealthyThreshold:   protobuf.UInt32(2),
+					HealthChecker: &envoy_api_v2_core.HealthCheck_HttpHealthCheck_{
+						HttpHealthCheck: &envoy_api_v2_core.HealthCheck_HttpHealthCheck{
+							Host: "contour-envoy-healthcheck",
+							Path: "/healthz",
+							ExpectedStatuses: []*envoy_type.Int64Range{{
+								Start: 200,
+								End:   400,
+							}},
+							RequestHeadersToAdd: []*envoy_api_v2_core.HeaderValueOption{{
+								Header: &envoy_api_v2_core.HeaderValue{
+									Key:   "X-Health-Check",
+									Value: "true",
+								},
+								Append: protobuf.Bool(false),
+							}},
+						},
+					},
+				}},
+				DrainConnectionsOnHostRemoval: true,
+			}),
+		),
+		TypeUrl: clusterType,
+	})
+
+	rh.OnAdd(featuretests.Endpoints("default", "kuard", v1.EndpointSubset{
+		Addresses: featuretests.Addresses("10.48.1.78"),
+		Ports:     featuretests.Ports(featuretests.Port("", 8080)),
+	}))
+
+	// The endpoints of the health checked cluster carry
+	// the health check port.
+	hc := envoy_v2.WeightedEndpoints(1, envoy_v2.SocketAddress("10.48.1.78", 8080))
+	hc[0].LbEndpoints[0].GetEndpoint().HealthCheckConfig = &envoy_api_v2_endpoint.Endpoint_HealthCheckConfig{
+		PortValue: 9001,
+	}
+
+	c.Request(endpointType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: resources(t,
+			&envoy_api_v2.ClusterLoadAssignment{
+				ClusterName: "default/kuard",
+				Endpoints:   envoy_v2.WeightedEndpoints(1, envoy_v2.SocketAddress("10.48.1.78", 8080)),
+			},
+			&envoy_api_v2.ClusterLoadAssignment{
+				ClusterName: "default/kuard/healthcheck/9001",
+				Endpoints:   hc,
+			},
+		),
+		TypeUrl: endpointType,
+	})
+
+	// An empty status range is rejected.
+	updated := proxy.DeepCopy()
+	updated.Spec.Routes[0].HealthCheckPolicy.ExpectedStatuses[0].End = 200
+	rh.OnUpdate(proxy, updated)
+
+	c.Request(clusterType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: nil,
+		TypeUrl:   clusterType,
+	}).Status(updated).HasError("RouteError", "HealthCheckPolicyNotValid",
+		"route.healthCheckPolicy is invalid: invalid expected status range [200, 200)")
+}
+
+func TestClusterWithOutlierDetection(t *testing.T) {
+	rh, c, done := setup(t)
+	defer done()
+
+	rh.OnAdd(fixture.NewService("kuard").
+		WithPorts(v1.ServicePort{Port: 80, TargetPort: intstr.FromString("8080")}),
+	)
+
+	rh.OnAdd(&contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "simple",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{Fqdn: "www.example.com"},
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/a",
+				}},
+				Services: []contour_api_v1.Service{{
+					Name: "kuard",
+					Port: 80,
+					OutlierDetection: &contour_api_v1.OutlierDetection{
+						ConsecutiveGatewayErrors: 5,
+						Interval:                 "10s",
+					},
+				}},
+			}, {
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/b",
+				}},
+				Services: []contour_api_v1.Service{{
+					Name: "kuard",
+					Port: 80,
+				}},
+			}},
+		},
+	})
+
+	c.Request(clusterType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: resources(t,
+			DefaultCluster(&envoy_api_v2.Cluster{
+				Name:                 "default/kuard/80/2a53826cf1",
+				AltStatName:          "default_kuard_80",
+				ClusterDiscoveryType: envoy_v2.ClusterDiscoveryType(envoy_api_v2.Cluster_EDS),
+				EdsClusterConfig: &envoy_api_v2.Cluster_EdsClusterConfig{
+					EdsConfig:   envoy_v2.ConfigSource("contour"),
+					ServiceName: "default/kuard",
+				},
+				OutlierDetection: &envoy_cluster.OutlierDetection{
+					ConsecutiveGatewayFailure:          protobuf.UInt32(5),
+					EnforcingConsecutiveGatewayFailure: protobuf.UInt32(100),
+					Interval:                           protobuf.Duration(10 * time.Second),
+				},
+			}),
+			cluster("default/kuard/80/da39a3ee5e", "default/kuard", "default_kuard_80"),
+		),
+		TypeUrl: clusterType,
+	})
+}
+
 // Test processing a service that exists but is not referenced
 func TestUnreferencedService(t *testing.T) {
 	rh, c, done := setup(t)
@@ -0,0 +1,123 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"testing"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	envoy_v2 "github.com/projectcontour/contour/internal/envoy/v2"
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/projectcontour/contour/internal/status"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestDirectResponsePolicy(t *testing.T) {
+	rh, c, done := setup(t)
+	defer done()
+
+	rh.OnAdd(fixture.NewService("svc1").
+		WithPorts(v1.ServicePort{Port: 80, TargetPort: intstr.FromInt(8080)}),
+	)
+
+	proxy1 := fixture.NewProxy("simple").WithSpec(
+		contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{Fqdn: "hello.world"},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: "svc1",
+					Port: 80,
+				}},
+			}, {
+				Conditions: matchconditions(prefixMatchCondition("/robots.txt")),
+				DirectResponsePolicy: &contour_api_v1.HTTPDirectResponsePolicy{
+					StatusCode: 200,
+					Body:       "User-agent: *\nDisallow: /\n",
+				},
+			}, {
+				Conditions: matchconditions(prefixMatchCondition("/deprecated")),
+				DirectResponsePolicy: &contour_api_v1.HTTPDirectResponsePolicy{
+					StatusCode: 410,
+				},
+			}},
+		})
+
+	rh.OnAdd(proxy1)
+
+	c.Request(routeType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: resources(t,
+			envoy_v2.RouteConfiguration("ingress_http",
+				envoy_v2.VirtualHost("hello.world",
+					&envoy_api_v2_route.Route{
+						Match: routePrefix("/robots.txt"),
+						Action: &envoy_api_v2_route.Route_DirectResponse{
+							DirectResponse: &envoy_api_v2_route.DirectResponseAction{
+								Status: 200,
+								Body: &envoy_api_v2_core.DataSource{
+									Specifier: &envoy_api_v2_core.DataSource_InlineString{
+										InlineString: "User-agent: *\nDisallow: /\n",
+									},
+								},
+							},
+						},
+					},
+					&envoy_api_v2_route.Route{
+						Match: routePrefix("/deprecated"),
+						Action: &envoy_api_v2_route.Route_DirectResponse{
+							DirectResponse: &envoy_api_v2_route.DirectResponseAction{
+								Status: 410,
+							},
+						},
+					},
+					&envoy_api_v2_route.Route{
+						Match:  routePrefix("/"),
+						Action: routeCluster("default/svc1/80/da39a3ee5e"),
+					},
+				),
+			),
+		),
+		TypeUrl: routeType,
+	}).Status(proxy1).Like(contour_api_v1.HTTPProxyStatus{
+		CurrentStatus: string(status.ProxyStatusValid),
+	})
+
+	// A route cannot both respond directly and forward to services.
+	proxy2 := fixture.NewProxy("simple").WithSpec(
+		contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{Fqdn: "hello.world"},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: "svc1",
+					Port: 80,
+				}},
+				DirectResponsePolicy: &contour_api_v1.HTTPDirectResponsePolicy{
+					StatusCode: 200,
+				},
+			}},
+		})
+
+	rh.OnUpdate(proxy1, proxy2)
+
+	c.Request(routeType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: resources(t,
+			envoy_v2.RouteConfiguration("ingress_http"),
+		),
+		TypeUrl: routeType,
+	}).Status(proxy2).HasError("RouteError", "ServicesAndDirectResponse",
+		"route.services cannot be specified with route.directResponsePolicy")
+}
@@ -24,13 +24,15 @@ import (
 	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	envoy_api_v2_listener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
 	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
-	envoy_config_filter_http_ext_authz_v2 "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/ext_authz/v2"
 	http "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
 	envoy_config_v2_tcpproxy "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/tcp_proxy/v2"
+	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type"
+	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes/any"
 	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/internal/envoy"
 	envoy_v2 "github.com/projectcontour/contour/internal/envoy/v2"
 	"github.com/projectcontour/contour/internal/protobuf"
 	xdscache_v2 "github.com/projectcontour/contour/internal/xdscache/v2"
@@ -193,10 +195,19 @@ func withIdleTimeout(route *envoy_api_v2_route.Route_Route, timeout time.Duratio
 	return route
 }
 
-func withMirrorPolicy(route *envoy_api_v2_route.Route_Route, mirror string) *envoy_api_v2_route.Route_Route {
-	route.Route.RequestMirrorPolicies = []*envoy_api_v2_route.RouteAction_RequestMirrorPolicy{{
+func withMirrorPolicy(route *envoy_api_v2_route.Route_Route, mirror string, weight uint32) *envoy_api_v2_route.Route_Route {
+	policy := &envoy_api_v2_route.RouteAction_RequestMirrorPolicy{
 		Cluster: mirror,
-	}}
+	}
+	if weight < 100 {
+		policy.RuntimeFraction = &envoy_api_v2_core.RuntimeFractionalPercent{
+			DefaultValue: &envoy_type.FractionalPercent{
+				Numerator:   weight,
+				Denominator: envoy_type.FractionalPercent_HUNDRED,
+			},
+		}
+	}
+	route.Route.RequestMirrorPolicies = append(route.Route.RequestMirrorPolicies, policy)
 	return route
 }
 
@@ -205,6 +216,14 @@ func withPrefixRewrite(route *envoy_api_v2_route.Route_Route, replacement string
 	return route
 }
 
+func withRegexRewrite(route *envoy_api_v2_route.Route_Route, pattern, substitution string) *envoy_api_v2_route.Route_Route {
+	route.Route.RegexRewrite = &matcher.RegexMatchAndSubstitute{
+		Pattern:      envoy.SafeRegexMatch(pattern),
+		Substitution: substitution,
+	}
+	return route
+}
+
 func withRetryPolicy(route *envoy_api_v2_route.Route_Route, retryOn string, numRetries uint32, perTryTimeout time.Duration) *envoy_api_v2_route.Route_Route {
 	route.Route.RetryPolicy = &envoy_api_v2_route.RetryPolicy{
 		RetryOn: retryOn,
@@ -337,7 +356,7 @@ func httpsFilterFor(vhost string) *envoy_api_v2_listener.Filter {
 // filter chain.
 func authzFilterFor(
 	vhost string,
-	authz *envoy_config_filter_http_ext_authz_v2.ExtAuthz,
+	authz proto.Message,
 ) *envoy_api_v2_listener.Filter {
 	return envoy_v2.HTTPConnectionManagerBuilder().
 		AddFilter(envoy_v2.FilterMisdirectedRequests(vhost)).
@@ -15,11 +15,13 @@ package v2
 
 import (
 	"testing"
+	"time"
 
 	"github.com/projectcontour/contour/internal/featuretests"
 
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	envoy_api_v2_auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
+	envoy_cluster "github.com/envoyproxy/go-control-plane/envoy/api/v2/cluster"
 	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	envoy_api_v2_endpoint "github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
 	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher"
@@ -28,6 +30,7 @@ import (
 	"github.com/projectcontour/contour/internal/dag"
 	envoy_v2 "github.com/projectcontour/contour/internal/envoy/v2"
 	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/projectcontour/contour/internal/protobuf"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/utils/pointer"
@@ -228,6 +231,88 @@ func extInvalidTimeout(_ *testing.T, rh cache.ResourceEventHandler, c *Contour)
 	})
 }
 
+func extCircuitBreakers(t *testing.T, rh cache.ResourceEventHandler, c *Contour) {
+	rh.OnAdd(&v1alpha1.ExtensionService{
+		ObjectMeta: fixture.ObjectMeta("ns/ext"),
+		Spec: v1alpha1.ExtensionServiceSpec{
+			Protocol: pointer.StringPtr("h2c"),
+			Services: []v1alpha1.ExtensionServiceTarget{
+				{Name: "svc1", Port: 8081},
+			},
+			CircuitBreakerPolicy: &contour_api_v1.CircuitBreakerPolicy{
+				MaxConnections: 50,
+				MaxRequests:    100,
+			},
+		},
+	})
+
+	c.Request(clusterType).Equals(&envoy_api_v2.DiscoveryResponse{
+		TypeUrl: clusterType,
+		Resources: resources(t,
+			DefaultCluster(
+				h2cCluster(cluster("extension/ns/ext", "extension/ns/ext", "extension_ns_ext")),
+				&envoy_api_v2.Cluster{
+					CircuitBreakers: &envoy_cluster.CircuitBreakers{
+						Thresholds: []*envoy_cluster.CircuitBreakers_Thresholds{{
+							MaxConnections: protobuf.UInt32(50),
+							MaxRequests:    protobuf.UInt32(100),
+						}},
+					},
+				},
+			),
+		),
+	})
+}
+
+func extClusterTimeouts(t *testing.T, rh cache.ResourceEventHandler, c *Contour) {
+	rh.OnAdd(&v1alpha1.ExtensionService{
+		ObjectMeta: fixture.ObjectMeta("ns/ext"),
+		Spec: v1alpha1.ExtensionServiceSpec{
+			Protocol: pointer.StringPtr("h2c"),
+			Services: []v1alpha1.ExtensionServiceTarget{
+				{Name: "svc1", Port: 8081},
+			},
+			TimeoutPolicy: &contour_api_v1.TimeoutPolicy{
+				Connect:      "2s",
+				UpstreamIdle: "5m",
+			},
+		},
+	})
+
+	ext := DefaultCluster(
+		h2cCluster(cluster("extension/ns/ext", "extension/ns/ext", "extension_ns_ext")),
+		&envoy_api_v2.Cluster{
+			CommonHttpProtocolOptions: &envoy_api_v2_core.HttpProtocolOptions{
+				IdleTimeout: protobuf.Duration(5 * time.Minute),
+			},
+		},
+	)
+	ext.ConnectTimeout = protobuf.Duration(2 * time.Second)
+
+	c.Request(clusterType).Equals(&envoy_api_v2.DiscoveryResponse{
+		TypeUrl:   clusterType,
+		Resources: resources(t, ext),
+	})
+}
+
+func extInvalidCircuitBreakers(_ *testing.T, rh cache.ResourceEventHandler, c *Contour) {
+	rh.OnAdd(&v1alpha1.ExtensionService{
+		ObjectMeta: fixture.ObjectMeta("ns/ext"),
+		Spec: v1alpha1.ExtensionServiceSpec{
+			Services: []v1alpha1.ExtensionServiceTarget{
+				{Name: "svc1", Port: 8081},
+			},
+			CircuitBreakerPolicy: &contour_api_v1.CircuitBreakerPolicy{
+				MaxRetries: -1,
+			},
+		},
+	})
+
+	c.Request(clusterType).Equals(&envoy_api_v2.DiscoveryResponse{
+		TypeUrl: clusterType,
+	})
+}
+
 func extInconsistentProto(_ *testing.T, rh cache.ResourceEventHandler, c *Contour) {
 	rh.OnAdd(&v1alpha1.ExtensionService{
 		ObjectMeta: fixture.ObjectMeta("ns/ext"),
@@ -251,13 +336,16 @@ func extInconsistentProto(_ *testing.T, rh cache.ResourceEventHandler, c *Contou
 
 func TestExtensionService(t *testing.T) {
 	subtests := map[string]func(*testing.T, cache.ResourceEventHandler, *Contour){
-		"Basic":              extBasic,
-		"Cleartext":          extCleartext,
-		"UpstreamValidation": extUpstreamValidation,
-		"ExternalName":       extExternalName,
-		"MissingService":     extMissingService,
-		"InconsistentProto":  extInconsistentProto,
-		"InvalidTimeout":     extInvalidTimeout,
+		"Basic":                  extBasic,
+		"Cleartext":              extCleartext,
+		"UpstreamValidation":     extUpstreamValidation,
+		"ExternalName":           extExternalName,
+		"MissingService":         extMissingService,
+		"InconsistentProto":      extInconsistentProto,
+		"InvalidTimeout":         extInvalidTimeout,
+		"CircuitBreakers":        extCircuitBreakers,
+		"InvalidCircuitBreakers": extInvalidCircuitBreakers,
+		"ClusterTimeouts":        extClusterTimeouts,
 	}
 
 	for n, f := range subtests {
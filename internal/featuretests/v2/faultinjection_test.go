@@ -0,0 +1,121 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"testing"
+	"time"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	"github.com/golang/protobuf/ptypes/any"
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/dag"
+	envoy_v2 "github.com/projectcontour/contour/internal/envoy/v2"
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/projectcontour/contour/internal/status"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestFaultInjection(t *testing.T) {
+	rh, c, done := setup(t)
+	defer done()
+
+	rh.OnAdd(fixture.NewService("kuard").
+		WithPorts(v1.ServicePort{Port: 8080, TargetPort: intstr.FromInt(8080)}))
+
+	vhost := fixture.NewProxy("kuard").WithSpec(
+		contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "kuard.projectcontour.io",
+			},
+			Routes: []contour_api_v1.Route{{
+				Conditions: matchconditions(prefixMatchCondition("/chaos")),
+				Services: []contour_api_v1.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+				FaultInjectionPolicy: &contour_api_v1.FaultInjectionPolicy{
+					Delay: &contour_api_v1.FaultDelay{
+						FixedDelay: "3s",
+						Percentage: 25,
+					},
+					Abort: &contour_api_v1.FaultAbort{
+						StatusCode: 503,
+						Percentage: 10,
+					},
+					HeaderName: "x-chaos",
+				},
+			}, {
+				Services: []contour_api_v1.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		})
+
+	rh.OnAdd(vhost)
+
+	// Only the route with the policy has per-route fault config.
+	c.Request(routeType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: resources(t,
+			envoy_v2.RouteConfiguration("ingress_http",
+				envoy_v2.VirtualHost("kuard.projectcontour.io",
+					&envoy_api_v2_route.Route{
+						Match:  routePrefix("/chaos"),
+						Action: routeCluster("default/kuard/8080/da39a3ee5e"),
+						TypedPerFilterConfig: map[string]*any.Any{
+							"envoy.filters.http.fault": envoy_v2.RouteFault(&dag.FaultInjectionPolicy{
+								Delay: &dag.FaultDelay{
+									FixedDelay: 3 * time.Second,
+									Percentage: 25,
+								},
+								Abort: &dag.FaultAbort{
+									StatusCode: 503,
+									Percentage: 10,
+								},
+								HeaderName: "x-chaos",
+							}),
+						},
+					},
+					&envoy_api_v2_route.Route{
+						Match:  routePrefix("/"),
+						Action: routeCluster("default/kuard/8080/da39a3ee5e"),
+					},
+				),
+			),
+		),
+		TypeUrl: routeType,
+	}).Status(vhost).Like(
+		contour_api_v1.HTTPProxyStatus{CurrentStatus: string(status.ProxyStatusValid)},
+	)
+
+	// A policy must inject at least one fault, otherwise
+	// the proxy is invalid and its routes are removed.
+	vhost = update(rh, vhost,
+		func(vhost *contour_api_v1.HTTPProxy) {
+			vhost.Spec.Routes[0].FaultInjectionPolicy = &contour_api_v1.FaultInjectionPolicy{
+				HeaderName: "x-chaos",
+			}
+		})
+
+	c.Request(routeType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: resources(t,
+			envoy_v2.RouteConfiguration("ingress_http"),
+		),
+		TypeUrl: routeType,
+	}).Status(vhost).HasError("RouteError", "FaultInjectionPolicyNotValid",
+		"route.faultInjectionPolicy is invalid: at least one of delay or abort must be set")
+}
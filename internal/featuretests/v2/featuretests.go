@@ -30,6 +30,7 @@ import (
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
 	"github.com/projectcontour/contour/internal/contour"
 	"github.com/projectcontour/contour/internal/dag"
+	envoy_v3 "github.com/projectcontour/contour/internal/envoy/v3"
 	"github.com/projectcontour/contour/internal/fixture"
 	"github.com/projectcontour/contour/internal/k8s"
 	"github.com/projectcontour/contour/internal/metrics"
@@ -38,8 +39,10 @@ import (
 	"github.com/projectcontour/contour/internal/status"
 	"github.com/projectcontour/contour/internal/workgroup"
 	contour_xds_v2 "github.com/projectcontour/contour/internal/xds/v2"
+	contour_xds_v3 "github.com/projectcontour/contour/internal/xds/v3"
 	"github.com/projectcontour/contour/internal/xdscache"
 	xdscache_v2 "github.com/projectcontour/contour/internal/xdscache/v2"
+	xdscache_v3 "github.com/projectcontour/contour/internal/xdscache/v3"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -74,12 +77,15 @@ func setup(t *testing.T, opts ...interface{}) (cache.ResourceEventHandler, *Cont
 		}
 	}
 
+	// The caches are updated in this order when the DAG is rebuilt.
+	// ADS relies on clusters, endpoints and secrets being updated
+	// before the listeners and routes that refer to them.
 	resources := []xdscache.ResourceCache{
-		xdscache_v2.NewListenerCache(conf, statsAddress, statsPort),
-		&xdscache_v2.SecretCache{},
-		&xdscache_v2.RouteCache{},
 		&xdscache_v2.ClusterCache{},
 		et,
+		&xdscache_v2.SecretCache{},
+		xdscache_v2.NewListenerCache(conf, statsAddress, statsPort),
+		&xdscache_v2.RouteCache{},
 	}
 
 	r := prometheus.NewRegistry()
@@ -130,9 +136,12 @@ func setup(t *testing.T, opts ...interface{}) (cache.ResourceEventHandler, *Cont
 	l, err := net.Listen("tcp", "127.0.0.1:0")
 	require.NoError(t, err)
 
-	srv := contour_xds_v2.RegisterServer(
-		contour_xds_v2.NewContourServer(log, xdscache.ResourcesOf(resources)...),
-		r /* Prometheus registry */)
+	xdsResources := xdscache.ResourcesOf(resources)
+	xdsResources = append(xdsResources, xdscache_v3.NewResourceCaches(log.WithField("context", "xdscache_v3"), nil, xdsResources...)...)
+
+	contourServer := contour_xds_v2.NewContourServer(log, nil, xdsResources...)
+	srv := contour_xds_v2.RegisterServer(contourServer, r /* Prometheus registry */)
+	contour_xds_v3.RegisterServer(srv, contour_xds_v3.NewContourServer(contourServer))
 
 	var g workgroup.Group
 
@@ -392,6 +401,12 @@ func (c *Contour) Request(typeurl string, names ...string) *Response {
 		TypeUrl:       typeurl,
 		ResourceNames: names,
 	})
+
+	// Everything Contour serves over v2 must also be
+	// expressible as v3 resources.
+	_, err := envoy_v3.Upgrade(resp)
+	require.NoError(c, err)
+
 	return &Response{
 		Contour:           c,
 		DiscoveryResponse: resp,
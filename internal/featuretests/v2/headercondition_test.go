@@ -478,3 +478,106 @@ func TestConditions_ContainsHeader_HTTProxy(t *testing.T) {
 		TypeUrl: routeType,
 	})
 }
+
+func TestConditions_RegexAndInHeader_HTTProxy(t *testing.T) {
+	rh, c, done := setup(t)
+	defer done()
+
+	rh.OnAdd(fixture.NewService("svc1").
+		WithPorts(v1.ServicePort{Port: 80, TargetPort: intstr.FromInt(8080)}),
+	)
+
+	rh.OnAdd(fixture.NewService("svc2").
+		WithPorts(v1.ServicePort{Port: 80, TargetPort: intstr.FromInt(8080)}),
+	)
+
+	rh.OnAdd(fixture.NewService("svc3").
+		WithPorts(v1.ServicePort{Port: 80, TargetPort: intstr.FromInt(8080)}),
+	)
+
+	proxy1 := fixture.NewProxy("simple").WithSpec(
+		contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{Fqdn: "hello.world"},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: "svc1",
+					Port: 80,
+				}},
+			}, {
+				Conditions: matchconditions(
+					prefixMatchCondition("/"),
+					headerRegexMatchCondition("user-agent", ".*(Firefox|Chrome).*"),
+				),
+				Services: []contour_api_v1.Service{{
+					Name: "svc2",
+					Port: 80,
+				}},
+			}, {
+				Conditions: matchconditions(
+					prefixMatchCondition("/"),
+					headerInMatchCondition("x-tenant-id", true, "alpha", "beta"),
+				),
+				Services: []contour_api_v1.Service{{
+					Name: "svc3",
+					Port: 80,
+				}},
+			}},
+		})
+	rh.OnAdd(proxy1)
+
+	c.Request(routeType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: resources(t,
+			envoy_v2.RouteConfiguration("ingress_http",
+				envoy_v2.VirtualHost("hello.world",
+					&envoy_api_v2_route.Route{
+						Match: routePrefix("/", dag.HeaderMatchCondition{
+							Name:      "user-agent",
+							Value:     ".*(Firefox|Chrome).*",
+							MatchType: "regex",
+						}),
+						Action: routeCluster("default/svc2/80/da39a3ee5e"),
+					},
+					&envoy_api_v2_route.Route{
+						Match: routePrefix("/", dag.HeaderMatchCondition{
+							Name:       "x-tenant-id",
+							Values:     []string{"alpha", "beta"},
+							MatchType:  "in",
+							IgnoreCase: true,
+						}),
+						Action: routeCluster("default/svc3/80/da39a3ee5e"),
+					},
+					&envoy_api_v2_route.Route{
+						Match:  routePrefix("/"),
+						Action: routeCluster("default/svc1/80/da39a3ee5e"),
+					},
+				),
+			),
+		),
+		TypeUrl: routeType,
+	})
+
+	// An invalid regex invalidates the proxy.
+	proxy2 := fixture.NewProxy("simple").WithSpec(
+		contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{Fqdn: "hello.world"},
+			Routes: []contour_api_v1.Route{{
+				Conditions: matchconditions(
+					prefixMatchCondition("/"),
+					headerRegexMatchCondition("user-agent", "(Firefox"),
+				),
+				Services: []contour_api_v1.Service{{
+					Name: "svc2",
+					Port: 80,
+				}},
+			}},
+		})
+	rh.OnUpdate(proxy1, proxy2)
+
+	c.Request(routeType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: resources(t,
+			envoy_v2.RouteConfiguration("ingress_http"),
+		),
+		TypeUrl: routeType,
+	}).Status(proxy2).HasError("RouteError", "HeaderMatchConditionsNotValid",
+		`header "user-agent" regex "(Firefox" is invalid: error parsing regexp: missing closing ): `+"`(Firefox`")
+}
@@ -21,7 +21,9 @@ import (
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	envoy_api_v2_auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
 	envoy_api_v2_listener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/contour"
 	"github.com/projectcontour/contour/internal/dag"
 	envoy_v2 "github.com/projectcontour/contour/internal/envoy/v2"
 	"github.com/projectcontour/contour/internal/fixture"
@@ -1212,3 +1214,164 @@ func TestLDSHTTPProxyRootCannotDelegateToAnotherRoot(t *testing.T) {
 		TypeUrl: listenerType,
 	})
 }
+
+func TestHTTPProxyNamedListeners(t *testing.T) {
+	rh, c, done := setup(t, func(conf *xdscache_v2.ListenerConfig) {
+		conf.Listeners = []xdscache_v2.NamedListener{{
+			Name:      "admin",
+			Address:   "0.0.0.0",
+			Port:      9443,
+			Protocol:  dag.ListenerProtocolHTTPS,
+			AccessLog: "/dev/stdout",
+		}, {
+			Name:          "internal",
+			Address:       "127.0.0.1",
+			Port:          9000,
+			Protocol:      dag.ListenerProtocolHTTP,
+			UseProxyProto: true,
+			AccessLog:     "/tmp/internal_access.log",
+		}}
+	}, func(eh *contour.EventHandler) {
+		listeners := []dag.NamedListener{{
+			Name:     "admin",
+			Address:  "0.0.0.0",
+			Port:     9443,
+			Protocol: dag.ListenerProtocolHTTPS,
+		}, {
+			Name:     "internal",
+			Address:  "127.0.0.1",
+			Port:     9000,
+			Protocol: dag.ListenerProtocolHTTP,
+		}}
+		eh.Builder.Processors = []dag.Processor{
+			&dag.HTTPProxyProcessor{
+				Listeners: listeners,
+			},
+			&dag.ListenerProcessor{
+				Listeners: listeners,
+			},
+		}
+	})
+	defer done()
+
+	sec1 := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "secret",
+			Namespace: "default",
+		},
+		Type: "kubernetes.io/tls",
+		Data: featuretests.Secretdata(featuretests.CERTIFICATE, featuretests.RSA_PRIVATE_KEY),
+	}
+	rh.OnAdd(sec1)
+
+	rh.OnAdd(fixture.NewService("backend").
+		WithPorts(v1.ServicePort{Name: "http", Port: 80}))
+
+	public := fixture.NewProxy("public").
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "www.example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{Name: "backend", Port: 80}},
+			}},
+		})
+	rh.OnAdd(public)
+
+	admin := fixture.NewProxy("admin").
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "admin.example.com",
+				TLS: &contour_api_v1.TLS{
+					SecretName: sec1.Name,
+				},
+				Listener: "admin",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{Name: "backend", Port: 80}},
+			}},
+		})
+	rh.OnAdd(admin)
+
+	internal := fixture.NewProxy("internal").
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn:     "api.internal",
+				Listener: "internal",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{Name: "backend", Port: 80}},
+			}},
+		})
+	rh.OnAdd(internal)
+
+	// The admin and internal virtual hosts are only bound to
+	// their own listeners, not to the default listeners.
+	c.Request(listenerType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: resources(t,
+			&envoy_api_v2.Listener{
+				Name:    "admin",
+				Address: envoy_v2.SocketAddress("0.0.0.0", 9443),
+				ListenerFilters: envoy_v2.ListenerFilters(
+					envoy_v2.TLSInspector(),
+				),
+				FilterChains: []*envoy_api_v2_listener.FilterChain{
+					filterchaintls("admin.example.com", sec1,
+						envoy_v2.HTTPConnectionManagerBuilder().
+							AddFilter(envoy_v2.FilterMisdirectedRequests("admin.example.com")).
+							DefaultFilters().
+							RouteConfigName("https/admin.example.com").
+							MetricsPrefix("admin").
+							AccessLoggers(envoy_v2.FileAccessLogEnvoy("/dev/stdout")).
+							Get(),
+						nil, "h2", "http/1.1"),
+				},
+				SocketOptions: envoy_v2.TCPKeepaliveSocketOptions(),
+			},
+			defaultHTTPListener(),
+			&envoy_api_v2.Listener{
+				Name:    "internal",
+				Address: envoy_v2.SocketAddress("127.0.0.1", 9000),
+				ListenerFilters: envoy_v2.ListenerFilters(
+					envoy_v2.ProxyProtocol(),
+				),
+				FilterChains: envoy_v2.FilterChains(
+					envoy_v2.HTTPConnectionManager("internal", envoy_v2.FileAccessLogEnvoy("/tmp/internal_access.log"), 0),
+				),
+				SocketOptions: envoy_v2.TCPKeepaliveSocketOptions(),
+			},
+			staticListener(),
+		),
+		TypeUrl: listenerType,
+	})
+
+	c.Request(routeType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: resources(t,
+			envoy_v2.RouteConfiguration("https/admin.example.com",
+				envoy_v2.VirtualHost("admin.example.com",
+					&envoy_api_v2_route.Route{
+						Match:  routePrefix("/"),
+						Action: routecluster("default/backend/80/da39a3ee5e"),
+					},
+				),
+			),
+			envoy_v2.RouteConfiguration("ingress_http",
+				envoy_v2.VirtualHost("www.example.com",
+					&envoy_api_v2_route.Route{
+						Match:  routePrefix("/"),
+						Action: routecluster("default/backend/80/da39a3ee5e"),
+					},
+				),
+			),
+			envoy_v2.RouteConfiguration("internal",
+				envoy_v2.VirtualHost("api.internal",
+					&envoy_api_v2_route.Route{
+						Match:  routePrefix("/"),
+						Action: routecluster("default/backend/80/da39a3ee5e"),
+					},
+				),
+			),
+		),
+		TypeUrl: routeType,
+	})
+}
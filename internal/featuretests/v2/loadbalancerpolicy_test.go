@@ -107,3 +107,119 @@ func TestLoadBalancerPolicySessionAffinity(t *testing.T) {
 		TypeUrl: routeType,
 	})
 }
+
+func TestLoadBalancerPolicyRequestHashPolicies(t *testing.T) {
+	rh, c, done := setup(t)
+	defer done()
+
+	s1 := fixture.NewService("app").WithPorts(
+		v1.ServicePort{Port: 80, TargetPort: intstr.FromInt(8080)})
+	rh.OnAdd(s1)
+
+	proxy1 := fixture.NewProxy("simple").
+		WithFQDN("www.example.com").
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Conditions: matchconditions(prefixMatchCondition("/cache")),
+				LoadBalancerPolicy: &contour_api_v1.LoadBalancerPolicy{
+					Strategy: "RingHash",
+					RequestHashPolicies: []contour_api_v1.RequestHashPolicy{{
+						Terminal: true,
+						HeaderHashOptions: &contour_api_v1.HeaderHashOptions{
+							HeaderName: "X-Cache-Key",
+						},
+					}, {
+						HashSourceIP: true,
+					}},
+				},
+				Services: []contour_api_v1.Service{{
+					Name: s1.Name,
+					Port: 80,
+				}},
+			}, {
+				Conditions: matchconditions(prefixMatchCondition("/search")),
+				LoadBalancerPolicy: &contour_api_v1.LoadBalancerPolicy{
+					Strategy: "Maglev",
+					RequestHashPolicies: []contour_api_v1.RequestHashPolicy{{
+						QueryParameterHashOptions: &contour_api_v1.QueryParameterHashOptions{
+							ParameterName: "q",
+						},
+					}},
+				},
+				Services: []contour_api_v1.Service{{
+					Name: s1.Name,
+					Port: 80,
+				}},
+			}},
+		})
+	rh.OnAdd(proxy1)
+
+	cacheRoute := routeCluster("default/app/80/40633a6ca9")
+	cacheRoute.Route.HashPolicy = []*envoy_api_v2_route.RouteAction_HashPolicy{{
+		Terminal: true,
+		PolicySpecifier: &envoy_api_v2_route.RouteAction_HashPolicy_Header_{
+			Header: &envoy_api_v2_route.RouteAction_HashPolicy_Header{
+				HeaderName: "X-Cache-Key",
+			},
+		},
+	}, {
+		PolicySpecifier: &envoy_api_v2_route.RouteAction_HashPolicy_ConnectionProperties_{
+			ConnectionProperties: &envoy_api_v2_route.RouteAction_HashPolicy_ConnectionProperties{
+				SourceIp: true,
+			},
+		},
+	}}
+
+	searchRoute := routeCluster("default/app/80/843e4ded8f")
+	searchRoute.Route.HashPolicy = []*envoy_api_v2_route.RouteAction_HashPolicy{{
+		PolicySpecifier: &envoy_api_v2_route.RouteAction_HashPolicy_QueryParameter_{
+			QueryParameter: &envoy_api_v2_route.RouteAction_HashPolicy_QueryParameter{
+				Name: "q",
+			},
+		},
+	}}
+
+	c.Request(routeType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: resources(t,
+			envoy_v2.RouteConfiguration("ingress_http",
+				envoy_v2.VirtualHost("www.example.com",
+					&envoy_api_v2_route.Route{
+						Match:  routePrefix("/search"),
+						Action: searchRoute,
+					},
+					&envoy_api_v2_route.Route{
+						Match:  routePrefix("/cache"),
+						Action: cacheRoute,
+					},
+				),
+			),
+		),
+		TypeUrl: routeType,
+	})
+
+	c.Request(clusterType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: resources(t,
+			DefaultCluster(&envoy_api_v2.Cluster{
+				Name:                 "default/app/80/40633a6ca9",
+				AltStatName:          "default_app_80",
+				ClusterDiscoveryType: envoy_v2.ClusterDiscoveryType(envoy_api_v2.Cluster_EDS),
+				EdsClusterConfig: &envoy_api_v2.Cluster_EdsClusterConfig{
+					EdsConfig:   envoy_v2.ConfigSource("contour"),
+					ServiceName: "default/app",
+				},
+				LbPolicy: envoy_api_v2.Cluster_RING_HASH,
+			}),
+			DefaultCluster(&envoy_api_v2.Cluster{
+				Name:                 "default/app/80/843e4ded8f",
+				AltStatName:          "default_app_80",
+				ClusterDiscoveryType: envoy_v2.ClusterDiscoveryType(envoy_api_v2.Cluster_EDS),
+				EdsClusterConfig: &envoy_api_v2.Cluster_EdsClusterConfig{
+					EdsConfig:   envoy_v2.ConfigSource("contour"),
+					ServiceName: "default/app",
+				},
+				LbPolicy: envoy_api_v2.Cluster_MAGLEV,
+			}),
+		),
+		TypeUrl: clusterType,
+	})
+}
@@ -66,7 +66,7 @@ func TestMirrorPolicy(t *testing.T) {
 				envoy_v2.VirtualHost(p1.Spec.VirtualHost.Fqdn,
 					&envoy_api_v2_route.Route{
 						Match:  routePrefix("/"),
-						Action: withMirrorPolicy(routeCluster("default/kuard/8080/da39a3ee5e"), "default/mirror/8080/da39a3ee5e"),
+						Action: withMirrorPolicy(routeCluster("default/kuard/8080/da39a3ee5e"), "default/mirror/8080/da39a3ee5e", 100),
 					},
 				),
 			),
@@ -83,4 +83,44 @@ func TestMirrorPolicy(t *testing.T) {
 		),
 		TypeUrl: clusterType,
 	})
+
+	// A route can mirror a percentage of its traffic to
+	// more than one service.
+	svc3 := fixture.NewService("shadow").
+		WithPorts(v1.ServicePort{Port: 8080, TargetPort: intstr.FromInt(8080)})
+	rh.OnAdd(svc3)
+
+	p2 := p1.DeepCopy()
+	p2.Spec.Routes[0].Services = append(p2.Spec.Routes[0].Services, contour_api_v1.Service{
+		Name:   svc3.Name,
+		Port:   8080,
+		Mirror: true,
+		Weight: 5,
+	})
+	rh.OnUpdate(p1, p2)
+
+	c.Request(routeType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: resources(t,
+			envoy_v2.RouteConfiguration("ingress_http",
+				envoy_v2.VirtualHost(p2.Spec.VirtualHost.Fqdn,
+					&envoy_api_v2_route.Route{
+						Match: routePrefix("/"),
+						Action: withMirrorPolicy(
+							withMirrorPolicy(routeCluster("default/kuard/8080/da39a3ee5e"), "default/mirror/8080/da39a3ee5e", 100),
+							"default/shadow/8080/da39a3ee5e", 5),
+					},
+				),
+			),
+		),
+		TypeUrl: routeType,
+	})
+
+	c.Request(clusterType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: resources(t,
+			cluster("default/kuard/8080/da39a3ee5e", "default/kuard", "default_kuard_8080"),
+			cluster("default/mirror/8080/da39a3ee5e", "default/mirror", "default_mirror_8080"),
+			cluster("default/shadow/8080/da39a3ee5e", "default/shadow", "default_shadow_8080"),
+		),
+		TypeUrl: clusterType,
+	})
 }
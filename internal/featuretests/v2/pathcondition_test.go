@@ -0,0 +1,110 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"testing"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/envoy"
+	envoy_v2 "github.com/projectcontour/contour/internal/envoy/v2"
+	"github.com/projectcontour/contour/internal/fixture"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestConditions_ExactAndRegex_HTTPProxy(t *testing.T) {
+	rh, c, done := setup(t)
+	defer done()
+
+	rh.OnAdd(fixture.NewService("svc1").
+		WithPorts(v1.ServicePort{Port: 80, TargetPort: intstr.FromInt(8080)}),
+	)
+
+	rh.OnAdd(fixture.NewService("svc2").
+		WithPorts(v1.ServicePort{Port: 80, TargetPort: intstr.FromInt(8080)}),
+	)
+
+	rh.OnAdd(fixture.NewService("svc3").
+		WithPorts(v1.ServicePort{Port: 80, TargetPort: intstr.FromInt(8080)}),
+	)
+
+	rh.OnAdd(fixture.NewProxy("child").WithSpec(
+		contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Conditions: matchconditions(exactMatchCondition("/users")),
+				Services: []contour_api_v1.Service{{
+					Name: "svc2",
+					Port: 80,
+				}},
+			}, {
+				Conditions: matchconditions(regexMatchCondition("/users/[0-9]+")),
+				Services: []contour_api_v1.Service{{
+					Name: "svc3",
+					Port: 80,
+				}},
+			}},
+		}),
+	)
+
+	rh.OnAdd(fixture.NewProxy("simple").WithSpec(
+		contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{Fqdn: "hello.world"},
+			Includes: []contour_api_v1.Include{{
+				Name:       "child",
+				Conditions: matchconditions(prefixMatchCondition("/api/v1.0")),
+			}},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: "svc1",
+					Port: 80,
+				}},
+			}},
+		}),
+	)
+
+	// Exact and regex conditions are appended to the include
+	// prefix, and sort before the prefix routes.
+	c.Request(routeType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: resources(t,
+			envoy_v2.RouteConfiguration("ingress_http",
+				envoy_v2.VirtualHost("hello.world",
+					&envoy_api_v2_route.Route{
+						Match: &envoy_api_v2_route.RouteMatch{
+							PathSpecifier: &envoy_api_v2_route.RouteMatch_Path{
+								Path: "/api/v1.0/users",
+							},
+						},
+						Action: routeCluster("default/svc2/80/da39a3ee5e"),
+					},
+					&envoy_api_v2_route.Route{
+						Match: &envoy_api_v2_route.RouteMatch{
+							PathSpecifier: &envoy_api_v2_route.RouteMatch_SafeRegex{
+								SafeRegex: envoy.SafeRegexMatch(`/api/v1\.0(?:/users/[0-9]+)`),
+							},
+						},
+						Action: routeCluster("default/svc3/80/da39a3ee5e"),
+					},
+					&envoy_api_v2_route.Route{
+						Match:  routePrefix("/"),
+						Action: routeCluster("default/svc1/80/da39a3ee5e"),
+					},
+				),
+			),
+		),
+		TypeUrl: routeType,
+	})
+}
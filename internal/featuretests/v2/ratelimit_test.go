@@ -0,0 +1,535 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"path"
+	"testing"
+	"time"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	envoy_api_v2_listener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_config_filter_http_rate_limit_v2 "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/rate_limit/v2"
+	http "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	envoy_config_ratelimit_v2 "github.com/envoyproxy/go-control-plane/envoy/config/ratelimit/v2"
+	envoy_config_ratelimit_v3 "github.com/envoyproxy/go-control-plane/envoy/config/ratelimit/v3"
+	envoy_extensions_filters_http_ratelimit_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ratelimit/v3"
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+	envoy_v2 "github.com/projectcontour/contour/internal/envoy/v2"
+	"github.com/projectcontour/contour/internal/featuretests"
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/projectcontour/contour/internal/protobuf"
+	"github.com/projectcontour/contour/internal/status"
+	"github.com/projectcontour/contour/internal/timeout"
+	xdscache_v2 "github.com/projectcontour/contour/internal/xdscache/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func rateLimitFilter(cluster string, domain string, stage uint32) *http.HttpFilter {
+	return &http.HttpFilter{
+		Name: "envoy.filters.http.ratelimit",
+		ConfigType: &http.HttpFilter_TypedConfig{
+			TypedConfig: protobuf.MustMarshalAny(&envoy_config_filter_http_rate_limit_v2.RateLimit{
+				Domain:          domain,
+				Stage:           stage,
+				FailureModeDeny: true,
+				RateLimitService: &envoy_config_ratelimit_v2.RateLimitServiceConfig{
+					GrpcService: &envoy_api_v2_core.GrpcService{
+						TargetSpecifier: &envoy_api_v2_core.GrpcService_EnvoyGrpc_{
+							EnvoyGrpc: &envoy_api_v2_core.GrpcService_EnvoyGrpc{
+								ClusterName: cluster,
+							},
+						},
+					},
+				},
+			}),
+		},
+	}
+}
+
+func remoteAddressRateLimit(stage uint32) *envoy_api_v2_route.RateLimit {
+	return &envoy_api_v2_route.RateLimit{
+		Stage: protobuf.UInt32(stage),
+		Actions: []*envoy_api_v2_route.RateLimit_Action{{
+			ActionSpecifier: &envoy_api_v2_route.RateLimit_Action_RemoteAddress_{
+				RemoteAddress: &envoy_api_v2_route.RateLimit_Action_RemoteAddress{},
+			},
+		}},
+	}
+}
+
+func remoteAddressDescriptors() []contour_api_v1.RateLimitDescriptor {
+	return []contour_api_v1.RateLimitDescriptor{{
+		Entries: []contour_api_v1.RateLimitDescriptorEntry{{
+			RemoteAddress: &contour_api_v1.RemoteAddressDescriptor{},
+		}},
+	}}
+}
+
+func ratelimitInsecureStages(t *testing.T, rh cache.ResourceEventHandler, c *Contour) {
+	p1 := fixture.NewProxy("proxy1").
+		WithFQDN("www1.projectcontour.io").
+		WithRateLimitPolicy(contour_api_v1.VirtualHostRateLimitPolicy{
+			ExtensionServiceRef: contour_api_v1.ExtensionServiceReference{
+				Namespace: "ratelimit",
+				Name:      "extension",
+			},
+			Descriptors: remoteAddressDescriptors(),
+		}).
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{Name: "app-server", Port: 80}},
+			}},
+		})
+
+	// The second proxy uses a different domain, so it needs
+	// a separate rate limit filter stage.
+	p2 := fixture.NewProxy("proxy2").
+		WithFQDN("www2.projectcontour.io").
+		WithRateLimitPolicy(contour_api_v1.VirtualHostRateLimitPolicy{
+			ExtensionServiceRef: contour_api_v1.ExtensionServiceReference{
+				Namespace: "ratelimit",
+				Name:      "extension",
+			},
+			Domain:      "www2",
+			Descriptors: remoteAddressDescriptors(),
+		}).
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{Name: "app-server", Port: 80}},
+			}},
+		})
+
+	// The third proxy shares the service of the first proxy.
+	p3 := fixture.NewProxy("proxy3").
+		WithFQDN("www3.projectcontour.io").
+		WithRateLimitPolicy(contour_api_v1.VirtualHostRateLimitPolicy{
+			ExtensionServiceRef: contour_api_v1.ExtensionServiceReference{
+				Namespace: "ratelimit",
+				Name:      "extension",
+			},
+			Descriptors: remoteAddressDescriptors(),
+		}).
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{Name: "app-server", Port: 80}},
+			}},
+		})
+
+	rh.OnAdd(p1)
+	rh.OnAdd(p2)
+	rh.OnAdd(p3)
+
+	c.Request(listenerType).Equals(&envoy_api_v2.DiscoveryResponse{
+		TypeUrl: listenerType,
+		Resources: resources(t,
+			&envoy_api_v2.Listener{
+				Name:    "ingress_http",
+				Address: envoy_v2.SocketAddress("0.0.0.0", 8080),
+				FilterChains: envoy_v2.FilterChains(
+					envoy_v2.HTTPConnectionManagerBuilder().
+						DefaultFilters().
+						RouteConfigName(xdscache_v2.ENVOY_HTTP_LISTENER).
+						MetricsPrefix(xdscache_v2.ENVOY_HTTP_LISTENER).
+						AccessLoggers(envoy_v2.FileAccessLogEnvoy("/dev/stdout")).
+						RequestTimeout(timeout.DurationSetting(0)).
+						AddFilter(rateLimitFilter("extension/ratelimit/extension", "contour", 0)).
+						AddFilter(rateLimitFilter("extension/ratelimit/extension", "www2", 1)).
+						Get(),
+				),
+				SocketOptions: envoy_v2.TCPKeepaliveSocketOptions(),
+			},
+			staticListener()),
+	}).Status(p2).Like(contour_api_v1.HTTPProxyStatus{
+		CurrentStatus: string(status.ProxyStatusValid),
+	})
+
+	vhost := func(fqdn string, stage uint32) *envoy_api_v2_route.VirtualHost {
+		vh := envoy_v2.VirtualHost(fqdn,
+			&envoy_api_v2_route.Route{
+				Match:  routePrefix("/"),
+				Action: routeCluster("default/app-server/80/da39a3ee5e"),
+			},
+		)
+		vh.RateLimits = []*envoy_api_v2_route.RateLimit{remoteAddressRateLimit(stage)}
+		return vh
+	}
+
+	c.Request(routeType).Equals(&envoy_api_v2.DiscoveryResponse{
+		TypeUrl: routeType,
+		Resources: resources(t,
+			envoy_v2.RouteConfiguration("ingress_http",
+				vhost("www1.projectcontour.io", 0),
+				vhost("www2.projectcontour.io", 1),
+				vhost("www3.projectcontour.io", 0),
+			),
+		),
+	})
+}
+
+func ratelimitRoutePolicy(t *testing.T, rh cache.ResourceEventHandler, c *Contour) {
+	const fqdn = "ratelimit.projectcontour.io"
+
+	p := fixture.NewProxy("proxy").
+		WithFQDN(fqdn).
+		WithCertificate("certificate").
+		WithRateLimitPolicy(contour_api_v1.VirtualHostRateLimitPolicy{
+			ExtensionServiceRef: contour_api_v1.ExtensionServiceReference{
+				Namespace: "ratelimit",
+				Name:      "extension",
+			},
+			ResponseTimeout: "100ms",
+			FailOpen:        true,
+			Descriptors:     remoteAddressDescriptors(),
+		}).
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Conditions: conditions(prefixCondition("/limited")),
+				Services:   []contour_api_v1.Service{{Name: "app-server", Port: 80}},
+				RateLimitPolicy: &contour_api_v1.RateLimitPolicy{
+					Descriptors: []contour_api_v1.RateLimitDescriptor{{
+						Entries: []contour_api_v1.RateLimitDescriptorEntry{{
+							GenericKey: &contour_api_v1.GenericKeyDescriptor{
+								Value: "limited",
+							},
+						}, {
+							RequestHeader: &contour_api_v1.RequestHeaderDescriptor{
+								HeaderName:    "X-User",
+								DescriptorKey: "user",
+							},
+						}},
+					}},
+				},
+			}, {
+				Services: []contour_api_v1.Service{{Name: "app-server", Port: 80}},
+			}},
+		})
+
+	rh.OnAdd(p)
+
+	filter := &envoy_config_filter_http_rate_limit_v2.RateLimit{
+		Domain:  "contour",
+		Timeout: protobuf.Duration(100 * time.Millisecond),
+		RateLimitService: &envoy_config_ratelimit_v2.RateLimitServiceConfig{
+			GrpcService: &envoy_api_v2_core.GrpcService{
+				TargetSpecifier: &envoy_api_v2_core.GrpcService_EnvoyGrpc_{
+					EnvoyGrpc: &envoy_api_v2_core.GrpcService_EnvoyGrpc{
+						ClusterName: "extension/ratelimit/extension",
+					},
+				},
+			},
+		},
+	}
+
+	c.Request(listenerType, "ingress_https").Equals(&envoy_api_v2.DiscoveryResponse{
+		TypeUrl: listenerType,
+		Resources: resources(t,
+			&envoy_api_v2.Listener{
+				Name:    "ingress_https",
+				Address: envoy_v2.SocketAddress("0.0.0.0", 8443),
+				ListenerFilters: envoy_v2.ListenerFilters(
+					envoy_v2.TLSInspector(),
+				),
+				FilterChains: []*envoy_api_v2_listener.FilterChain{
+					filterchaintls(fqdn,
+						&corev1.Secret{
+							ObjectMeta: fixture.ObjectMeta("certificate"),
+							Type:       "kubernetes.io/tls",
+							Data:       featuretests.Secretdata(featuretests.CERTIFICATE, featuretests.RSA_PRIVATE_KEY),
+						},
+						envoy_v2.HTTPConnectionManagerBuilder().
+							AddFilter(envoy_v2.FilterMisdirectedRequests(fqdn)).
+							DefaultFilters().
+							AddFilter(&http.HttpFilter{
+								Name: "envoy.filters.http.ratelimit",
+								ConfigType: &http.HttpFilter_TypedConfig{
+									TypedConfig: protobuf.MustMarshalAny(filter),
+								},
+							}).
+							RouteConfigName(path.Join("https", fqdn)).
+							MetricsPrefix(xdscache_v2.ENVOY_HTTPS_LISTENER).
+							AccessLoggers(envoy_v2.FileAccessLogEnvoy("/dev/stdout")).
+							Get(),
+						nil, "h2", "http/1.1"),
+				},
+				SocketOptions: envoy_v2.TCPKeepaliveSocketOptions(),
+			}),
+	})
+
+	limited := routeCluster("default/app-server/80/da39a3ee5e")
+	limited.Route.IncludeVhRateLimits = protobuf.Bool(true)
+	limited.Route.RateLimits = []*envoy_api_v2_route.RateLimit{{
+		Stage: protobuf.UInt32(0),
+		Actions: []*envoy_api_v2_route.RateLimit_Action{{
+			ActionSpecifier: &envoy_api_v2_route.RateLimit_Action_GenericKey_{
+				GenericKey: &envoy_api_v2_route.RateLimit_Action_GenericKey{
+					DescriptorValue: "limited",
+				},
+			},
+		}, {
+			ActionSpecifier: &envoy_api_v2_route.RateLimit_Action_RequestHeaders_{
+				RequestHeaders: &envoy_api_v2_route.RateLimit_Action_RequestHeaders{
+					HeaderName:    "X-User",
+					DescriptorKey: "user",
+				},
+			},
+		}},
+	}}
+
+	vh := envoy_v2.VirtualHost(fqdn,
+		&envoy_api_v2_route.Route{
+			Match:  routePrefix("/limited"),
+			Action: limited,
+		},
+		&envoy_api_v2_route.Route{
+			Match:  routePrefix("/"),
+			Action: routeCluster("default/app-server/80/da39a3ee5e"),
+		},
+	)
+	vh.RateLimits = []*envoy_api_v2_route.RateLimit{remoteAddressRateLimit(0)}
+
+	c.Request(routeType, path.Join("https", fqdn)).Equals(&envoy_api_v2.DiscoveryResponse{
+		TypeUrl: routeType,
+		Resources: resources(t,
+			envoy_v2.RouteConfiguration(path.Join("https", fqdn), vh),
+		),
+	})
+}
+
+func ratelimitProtocolVersion3(t *testing.T, rh cache.ResourceEventHandler, c *Contour) {
+	rh.OnAdd(&v1alpha1.ExtensionService{
+		ObjectMeta: fixture.ObjectMeta("ratelimit/extension-v3"),
+		Spec: v1alpha1.ExtensionServiceSpec{
+			Services: []v1alpha1.ExtensionServiceTarget{
+				{Name: "ratelimit-server", Port: 8081},
+			},
+			ProtocolVersion: v1alpha1.SupportProtocolVersion3,
+		},
+	})
+
+	p := fixture.NewProxy("proxy").
+		WithFQDN("ratelimit.projectcontour.io").
+		WithRateLimitPolicy(contour_api_v1.VirtualHostRateLimitPolicy{
+			ExtensionServiceRef: contour_api_v1.ExtensionServiceReference{
+				Namespace: "ratelimit",
+				Name:      "extension-v3",
+			},
+			Descriptors: remoteAddressDescriptors(),
+		}).
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{Name: "app-server", Port: 80}},
+			}},
+		})
+
+	rh.OnAdd(p)
+
+	c.Request(listenerType, "ingress_http").Equals(&envoy_api_v2.DiscoveryResponse{
+		TypeUrl: listenerType,
+		Resources: resources(t,
+			&envoy_api_v2.Listener{
+				Name:    "ingress_http",
+				Address: envoy_v2.SocketAddress("0.0.0.0", 8080),
+				FilterChains: envoy_v2.FilterChains(
+					envoy_v2.HTTPConnectionManagerBuilder().
+						DefaultFilters().
+						RouteConfigName(xdscache_v2.ENVOY_HTTP_LISTENER).
+						MetricsPrefix(xdscache_v2.ENVOY_HTTP_LISTENER).
+						AccessLoggers(envoy_v2.FileAccessLogEnvoy("/dev/stdout")).
+						RequestTimeout(timeout.DurationSetting(0)).
+						AddFilter(&http.HttpFilter{
+							Name: "envoy.filters.http.ratelimit",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(&envoy_extensions_filters_http_ratelimit_v3.RateLimit{
+									Domain:          "contour",
+									FailureModeDeny: true,
+									RateLimitService: &envoy_config_ratelimit_v3.RateLimitServiceConfig{
+										GrpcService: &envoy_config_core_v3.GrpcService{
+											TargetSpecifier: &envoy_config_core_v3.GrpcService_EnvoyGrpc_{
+												EnvoyGrpc: &envoy_config_core_v3.GrpcService_EnvoyGrpc{
+													ClusterName: "extension/ratelimit/extension-v3",
+												},
+											},
+										},
+										TransportApiVersion: envoy_config_core_v3.ApiVersion_V3,
+									},
+								}),
+							},
+						}).
+						Get(),
+				),
+				SocketOptions: envoy_v2.TCPKeepaliveSocketOptions(),
+			}),
+	}).Status(p).Like(contour_api_v1.HTTPProxyStatus{
+		CurrentStatus: string(status.ProxyStatusValid),
+	})
+}
+
+func ratelimitRouteWithoutService(t *testing.T, rh cache.ResourceEventHandler, c *Contour) {
+	p := fixture.NewProxy("proxy").
+		WithFQDN("ratelimit.projectcontour.io").
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{Name: "app-server", Port: 80}},
+				RateLimitPolicy: &contour_api_v1.RateLimitPolicy{
+					Descriptors: remoteAddressDescriptors(),
+				},
+			}},
+		})
+
+	rh.OnAdd(p)
+
+	c.Request(listenerType).Equals(&envoy_api_v2.DiscoveryResponse{
+		TypeUrl:   listenerType,
+		Resources: resources(t, staticListener()),
+	}).Status(p).HasError("RouteError", "RateLimitServiceNotConfigured", "route.rateLimitPolicy requires the root HTTPProxy to configure a rate limit service")
+}
+
+func ratelimitInvalidDescriptor(t *testing.T, rh cache.ResourceEventHandler, c *Contour) {
+	p := fixture.NewProxy("proxy").
+		WithFQDN("ratelimit.projectcontour.io").
+		WithRateLimitPolicy(contour_api_v1.VirtualHostRateLimitPolicy{
+			ExtensionServiceRef: contour_api_v1.ExtensionServiceReference{
+				Namespace: "ratelimit",
+				Name:      "extension",
+			},
+			Descriptors: []contour_api_v1.RateLimitDescriptor{{
+				Entries: []contour_api_v1.RateLimitDescriptorEntry{{
+					GenericKey:    &contour_api_v1.GenericKeyDescriptor{Value: "foo"},
+					RemoteAddress: &contour_api_v1.RemoteAddressDescriptor{},
+				}},
+			}},
+		}).
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{Name: "app-server", Port: 80}},
+			}},
+		})
+
+	rh.OnAdd(p)
+
+	c.Request(listenerType).Equals(&envoy_api_v2.DiscoveryResponse{
+		TypeUrl:   listenerType,
+		Resources: resources(t, staticListener()),
+	}).Status(p).HasError("RateLimitError", "DescriptorsNotValid", "Spec.Virtualhost.RateLimitPolicy.Descriptors is invalid: descriptor 0 entry 0: exactly one descriptor entry type must be set")
+}
+
+func ratelimitMissingExtension(t *testing.T, rh cache.ResourceEventHandler, c *Contour) {
+	p := fixture.NewProxy("proxy").
+		WithFQDN("ratelimit.projectcontour.io").
+		WithRateLimitPolicy(contour_api_v1.VirtualHostRateLimitPolicy{
+			ExtensionServiceRef: contour_api_v1.ExtensionServiceReference{
+				Namespace: "missing",
+				Name:      "extension",
+			},
+		}).
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{Name: "app-server", Port: 80}},
+			}},
+		})
+
+	rh.OnAdd(p)
+
+	c.Request(listenerType).Equals(&envoy_api_v2.DiscoveryResponse{
+		TypeUrl:   listenerType,
+		Resources: resources(t, staticListener()),
+	}).Status(p).HasError("RateLimitError", "ExtensionServiceNotFound", `Spec.Virtualhost.RateLimitPolicy.extensionRef extension service "missing/extension" not found`)
+}
+
+func ratelimitFallbackIncompat(t *testing.T, rh cache.ResourceEventHandler, c *Contour) {
+	p := fixture.NewProxy("proxy").
+		WithFQDN("ratelimit.projectcontour.io").
+		WithCertificate("certificate").
+		WithRateLimitPolicy(contour_api_v1.VirtualHostRateLimitPolicy{
+			ExtensionServiceRef: contour_api_v1.ExtensionServiceReference{
+				Namespace: "ratelimit",
+				Name:      "extension",
+			},
+		}).
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{Name: "app-server", Port: 80}},
+			}},
+		})
+
+	p.Spec.VirtualHost.TLS.EnableFallbackCertificate = true
+
+	rh.OnAdd(p)
+
+	c.Request(listenerType).Equals(&envoy_api_v2.DiscoveryResponse{
+		TypeUrl:   listenerType,
+		Resources: resources(t, staticListener()),
+	}).Status(p).HasError("TLSError", "TLSIncompatibleFeatures", "Spec.Virtualhost.TLS fallback & rate limiting are incompatible")
+}
+
+func TestRateLimit(t *testing.T) {
+	subtests := map[string]func(*testing.T, cache.ResourceEventHandler, *Contour){
+		"InsecureStages":      ratelimitInsecureStages,
+		"RoutePolicy":         ratelimitRoutePolicy,
+		"ProtocolVersion3":    ratelimitProtocolVersion3,
+		"RouteWithoutService": ratelimitRouteWithoutService,
+		"InvalidDescriptor":   ratelimitInvalidDescriptor,
+		"MissingExtension":    ratelimitMissingExtension,
+		"FallbackIncompat":    ratelimitFallbackIncompat,
+	}
+
+	for n, f := range subtests {
+		f := f
+		t.Run(n, func(t *testing.T) {
+			rh, c, done := setup(t)
+			defer done()
+
+			// Add common test fixtures.
+
+			rh.OnAdd(fixture.NewService("ratelimit/ratelimit-server").
+				WithPorts(corev1.ServicePort{Port: 8081}))
+
+			rh.OnAdd(featuretests.Endpoints("ratelimit", "ratelimit-server", corev1.EndpointSubset{
+				Addresses: featuretests.Addresses("192.168.183.21"),
+				Ports:     featuretests.Ports(featuretests.Port("", 8081)),
+			}))
+
+			rh.OnAdd(&v1alpha1.ExtensionService{
+				ObjectMeta: fixture.ObjectMeta("ratelimit/extension"),
+				Spec: v1alpha1.ExtensionServiceSpec{
+					Services: []v1alpha1.ExtensionServiceTarget{
+						{Name: "ratelimit-server", Port: 8081},
+					},
+				},
+			})
+
+			rh.OnAdd(fixture.NewService("app-server").
+				WithPorts(corev1.ServicePort{Port: 80}))
+
+			rh.OnAdd(featuretests.Endpoints("default", "app-server", corev1.EndpointSubset{
+				Addresses: featuretests.Addresses("192.168.183.21"),
+				Ports:     featuretests.Ports(featuretests.Port("", 80)),
+			}))
+
+			rh.OnAdd(&corev1.Secret{
+				ObjectMeta: fixture.ObjectMeta("certificate"),
+				Type:       "kubernetes.io/tls",
+				Data:       featuretests.Secretdata(featuretests.CERTIFICATE, featuretests.RSA_PRIVATE_KEY),
+			})
+
+			f(t, rh, c)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"testing"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	envoy_v2 "github.com/projectcontour/contour/internal/envoy/v2"
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/projectcontour/contour/internal/status"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestRequestRedirectPolicy(t *testing.T) {
+	rh, c, done := setup(t)
+	defer done()
+
+	rh.OnAdd(fixture.NewService("svc1").
+		WithPorts(v1.ServicePort{Port: 80, TargetPort: intstr.FromInt(8080)}),
+	)
+
+	proxy1 := fixture.NewProxy("simple").WithSpec(
+		contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{Fqdn: "hello.world"},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: "svc1",
+					Port: 80,
+				}},
+			}, {
+				Conditions: matchconditions(prefixMatchCondition("/old")),
+				RequestRedirectPolicy: &contour_api_v1.HTTPRequestRedirectPolicy{
+					Scheme:     "https",
+					Hostname:   "envoyproxy.io",
+					Port:       443,
+					Prefix:     "/new",
+					StatusCode: 301,
+				},
+			}},
+		})
+
+	rh.OnAdd(proxy1)
+
+	c.Request(routeType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: resources(t,
+			envoy_v2.RouteConfiguration("ingress_http",
+				envoy_v2.VirtualHost("hello.world",
+					&envoy_api_v2_route.Route{
+						Match: routePrefix("/old"),
+						Action: &envoy_api_v2_route.Route_Redirect{
+							Redirect: &envoy_api_v2_route.RedirectAction{
+								SchemeRewriteSpecifier: &envoy_api_v2_route.RedirectAction_SchemeRedirect{
+									SchemeRedirect: "https",
+								},
+								HostRedirect: "envoyproxy.io",
+								PortRedirect: 443,
+								PathRewriteSpecifier: &envoy_api_v2_route.RedirectAction_PrefixRewrite{
+									PrefixRewrite: "/new",
+								},
+								ResponseCode: envoy_api_v2_route.RedirectAction_MOVED_PERMANENTLY,
+							},
+						},
+					},
+					&envoy_api_v2_route.Route{
+						Match:  routePrefix("/"),
+						Action: routeCluster("default/svc1/80/da39a3ee5e"),
+					},
+				),
+			),
+		),
+		TypeUrl: routeType,
+	}).Status(proxy1).Like(contour_api_v1.HTTPProxyStatus{
+		CurrentStatus: string(status.ProxyStatusValid),
+	})
+
+	// A route cannot both redirect and forward to services.
+	proxy2 := fixture.NewProxy("simple").WithSpec(
+		contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{Fqdn: "hello.world"},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: "svc1",
+					Port: 80,
+				}},
+				RequestRedirectPolicy: &contour_api_v1.HTTPRequestRedirectPolicy{
+					Hostname: "envoyproxy.io",
+				},
+			}},
+		})
+
+	rh.OnUpdate(proxy1, proxy2)
+
+	c.Request(routeType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: resources(t,
+			envoy_v2.RouteConfiguration("ingress_http"),
+		),
+		TypeUrl: routeType,
+	}).Status(proxy2).HasError("RouteError", "ServicesAndRedirect",
+		"route.services cannot be specified with route.requestRedirectPolicy")
+}
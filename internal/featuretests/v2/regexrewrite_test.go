@@ -0,0 +1,136 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"testing"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	envoy_v2 "github.com/projectcontour/contour/internal/envoy/v2"
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/projectcontour/contour/internal/status"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestRegexRewrite(t *testing.T) {
+	rh, c, done := setup(t)
+	defer done()
+
+	rh.OnAdd(fixture.NewService("kuard").
+		WithPorts(v1.ServicePort{Port: 8080, TargetPort: intstr.FromInt(8080)}))
+
+	vhost := fixture.NewProxy("kuard").WithSpec(
+		contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "kuard.projectcontour.io",
+			},
+			Routes: []contour_api_v1.Route{{
+				Conditions: matchconditions(prefixMatchCondition("/users")),
+				Services: []contour_api_v1.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+				PathRewritePolicy: &contour_api_v1.PathRewritePolicy{
+					RegexRewrite: &contour_api_v1.RegexRewrite{
+						Pattern:      `^/users/(\d+)/profile$`,
+						Substitution: `/profile?id=\1`,
+					},
+				},
+			}},
+		})
+
+	rh.OnAdd(vhost)
+
+	c.Request(routeType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: resources(t,
+			envoy_v2.RouteConfiguration("ingress_http",
+				envoy_v2.VirtualHost("kuard.projectcontour.io",
+					&envoy_api_v2_route.Route{
+						Match:  routePrefix("/users"),
+						Action: withRegexRewrite(routeCluster("default/kuard/8080/da39a3ee5e"), `^/users/(\d+)/profile$`, `/profile?id=\1`),
+					},
+				),
+			),
+		),
+		TypeUrl: routeType,
+	}).Status(vhost).Like(
+		contour_api_v1.HTTPProxyStatus{CurrentStatus: string(status.ProxyStatusValid)},
+	)
+
+	// Referencing a capture group that the pattern doesn't have
+	// is invalid and removes the route.
+	vhost = update(rh, vhost,
+		func(vhost *contour_api_v1.HTTPProxy) {
+			vhost.Spec.Routes[0].PathRewritePolicy.RegexRewrite.Substitution = `/profile?id=\2`
+		})
+
+	c.Request(routeType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: resources(t,
+			envoy_v2.RouteConfiguration("ingress_http"),
+		),
+		TypeUrl: routeType,
+	}).Status(vhost).HasError("PathRewriteError", "RegexRewriteNotValid",
+		"pathRewritePolicy.regexRewrite is invalid: substitution references capture group 2, but the pattern has 1 capture groups")
+
+	// A regex rewrite can't be combined with prefix replacements.
+	vhost = update(rh, vhost,
+		func(vhost *contour_api_v1.HTTPProxy) {
+			vhost.Spec.Routes[0].PathRewritePolicy = &contour_api_v1.PathRewritePolicy{
+				ReplacePrefix: []contour_api_v1.ReplacePrefix{{
+					Replacement: "/api",
+				}},
+				RegexRewrite: &contour_api_v1.RegexRewrite{
+					Pattern: "/v[0-9]+/",
+				},
+			}
+		})
+
+	c.Request(routeType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: resources(t,
+			envoy_v2.RouteConfiguration("ingress_http"),
+		),
+		TypeUrl: routeType,
+	}).Status(vhost).HasError("PathRewriteError", "RegexAndPrefixReplacement",
+		"cannot specify both a regex rewrite and prefix replacements")
+
+	// Strip a version segment from the middle of the path.
+	vhost = update(rh, vhost,
+		func(vhost *contour_api_v1.HTTPProxy) {
+			vhost.Spec.Routes[0].PathRewritePolicy = &contour_api_v1.PathRewritePolicy{
+				RegexRewrite: &contour_api_v1.RegexRewrite{
+					Pattern:      "/v[0-9]+/",
+					Substitution: "/",
+				},
+			}
+		})
+
+	c.Request(routeType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: resources(t,
+			envoy_v2.RouteConfiguration("ingress_http",
+				envoy_v2.VirtualHost("kuard.projectcontour.io",
+					&envoy_api_v2_route.Route{
+						Match:  routePrefix("/users"),
+						Action: withRegexRewrite(routeCluster("default/kuard/8080/da39a3ee5e"), "/v[0-9]+/", "/"),
+					},
+				),
+			),
+		),
+		TypeUrl: routeType,
+	}).Status(vhost).Like(
+		contour_api_v1.HTTPProxyStatus{CurrentStatus: string(status.ProxyStatusValid)},
+	)
+}
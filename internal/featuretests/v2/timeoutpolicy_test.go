@@ -18,12 +18,16 @@ import (
 	"time"
 
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
 	"github.com/projectcontour/contour/internal/contour"
+	"github.com/projectcontour/contour/internal/dag"
 	envoyv2 "github.com/projectcontour/contour/internal/envoy/v2"
 	"github.com/projectcontour/contour/internal/featuretests"
 	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/projectcontour/contour/internal/protobuf"
+	"github.com/projectcontour/contour/internal/timeout"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/networking/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -350,3 +354,77 @@ func TestTimeoutPolicyIdleTimeout(t *testing.T) {
 	})
 
 }
+
+func TestTimeoutPolicyClusterTimeouts(t *testing.T) {
+	rh, c, done := setup(t, func(eh *contour.EventHandler) {
+		eh.Builder.Processors = []dag.Processor{
+			&dag.HTTPProxyProcessor{
+				ConnectTimeout:      timeout.DurationSetting(time.Second),
+				UpstreamIdleTimeout: timeout.DurationSetting(10 * time.Minute),
+			},
+			&dag.ListenerProcessor{},
+		}
+	})
+	defer done()
+
+	svc := fixture.NewService("kuard").
+		WithPorts(v1.ServicePort{Port: 8080, TargetPort: intstr.FromInt(8080)})
+	rh.OnAdd(svc)
+
+	p1 := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "simple",
+			Namespace: svc.Namespace,
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{Fqdn: "test2.test.com"},
+			Routes: []contour_api_v1.Route{{
+				Conditions: matchconditions(prefixMatchCondition("/a")),
+				TimeoutPolicy: &contour_api_v1.TimeoutPolicy{
+					Connect: "3s",
+				},
+				Services: []contour_api_v1.Service{{
+					Name: svc.Name,
+					Port: 8080,
+				}},
+			}, {
+				Conditions: matchconditions(prefixMatchCondition("/b")),
+				Services: []contour_api_v1.Service{{
+					Name: svc.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+	rh.OnAdd(p1)
+
+	// The route connect timeout overrides the global default,
+	// and the global upstream idle timeout applies to both.
+	c1 := cluster("default/kuard/8080/593b9e28ab", "default/kuard", "default_kuard_8080")
+	c1.ConnectTimeout = protobuf.Duration(time.Second)
+	c1.CommonHttpProtocolOptions = &envoy_api_v2_core.HttpProtocolOptions{
+		IdleTimeout: protobuf.Duration(10 * time.Minute),
+	}
+
+	c2 := cluster("default/kuard/8080/5a3fc26b77", "default/kuard", "default_kuard_8080")
+	c2.ConnectTimeout = protobuf.Duration(3 * time.Second)
+	c2.CommonHttpProtocolOptions = &envoy_api_v2_core.HttpProtocolOptions{
+		IdleTimeout: protobuf.Duration(10 * time.Minute),
+	}
+
+	c.Request(clusterType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: resources(t, c1, c2),
+		TypeUrl:   clusterType,
+	})
+
+	p2 := p1.DeepCopy()
+	p2.Spec.Routes[0].TimeoutPolicy.Connect = "infinity"
+	rh.OnUpdate(p1, p2)
+
+	// check that a connect timeout can't be disabled
+	c.Request(clusterType).Equals(&envoy_api_v2.DiscoveryResponse{
+		Resources: nil,
+		TypeUrl:   clusterType,
+	}).Status(p2).HasError("RouteError", "TimeoutPolicyNotValid",
+		"route.timeoutPolicy failed to parse: connect timeout cannot be disabled")
+}
@@ -0,0 +1,267 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"testing"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	http "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	envoy_config_trace_v2 "github.com/envoyproxy/go-control-plane/envoy/config/trace/v2"
+	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type"
+	envoy_type_tracing_v2 "github.com/envoyproxy/go-control-plane/envoy/type/tracing/v2"
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+	"github.com/projectcontour/contour/internal/contour"
+	"github.com/projectcontour/contour/internal/dag"
+	envoy_v2 "github.com/projectcontour/contour/internal/envoy/v2"
+	"github.com/projectcontour/contour/internal/featuretests"
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/projectcontour/contour/internal/protobuf"
+	"github.com/projectcontour/contour/internal/timeout"
+	xdscache_v2 "github.com/projectcontour/contour/internal/xdscache/v2"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// withTracing returns a setup option that adds the
+// supplied tracing processor to the DAG builder.
+func withTracing(tp *dag.TracingProcessor) func(*contour.EventHandler) {
+	return func(eh *contour.EventHandler) {
+		tp.FieldLogger = logrus.StandardLogger()
+		eh.Builder.Processors = append(eh.Builder.Processors, tp)
+	}
+}
+
+func tracingHTTPListener(tracing *http.HttpConnectionManager_Tracing) *envoy_api_v2.Listener {
+	return &envoy_api_v2.Listener{
+		Name:    "ingress_http",
+		Address: envoy_v2.SocketAddress("0.0.0.0", 8080),
+		FilterChains: envoy_v2.FilterChains(
+			envoy_v2.HTTPConnectionManagerBuilder().
+				DefaultFilters().
+				RouteConfigName(xdscache_v2.ENVOY_HTTP_LISTENER).
+				MetricsPrefix(xdscache_v2.ENVOY_HTTP_LISTENER).
+				AccessLoggers(envoy_v2.FileAccessLogEnvoy("/dev/stdout")).
+				RequestTimeout(timeout.DurationSetting(0)).
+				Tracing(tracing).
+				Get(),
+		),
+		SocketOptions: envoy_v2.TCPKeepaliveSocketOptions(),
+	}
+}
+
+func TestTracingOpenCensusExtensionService(t *testing.T) {
+	rh, c, done := setup(t, withTracing(&dag.TracingProcessor{
+		Provider:         dag.TracingProviderOpenCensus,
+		ExtensionService: &types.NamespacedName{Namespace: "tracing", Name: "collector"},
+		SamplingRate:     50,
+		MaxPathTagLength: 100,
+		CustomTags: []dag.TracingCustomTag{{
+			TagName: "cluster",
+			Literal: "production",
+		}, {
+			TagName:       "user",
+			RequestHeader: "X-User",
+		}},
+	}))
+	defer done()
+
+	rh.OnAdd(fixture.NewService("tracing/otel-collector").
+		WithPorts(corev1.ServicePort{Port: 55678}))
+
+	rh.OnAdd(&v1alpha1.ExtensionService{
+		ObjectMeta: fixture.ObjectMeta("tracing/collector"),
+		Spec: v1alpha1.ExtensionServiceSpec{
+			Services: []v1alpha1.ExtensionServiceTarget{
+				{Name: "otel-collector", Port: 55678},
+			},
+		},
+	})
+
+	rh.OnAdd(fixture.NewService("app-server").
+		WithPorts(corev1.ServicePort{Port: 80}))
+
+	rh.OnAdd(fixture.NewProxy("proxy").
+		WithFQDN("tracing.projectcontour.io").
+		WithTracingPolicy(contour_api_v1.TracingPolicy{
+			SamplingRate: "0.5",
+		}).
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{Name: "app-server", Port: 80}},
+			}},
+		}))
+
+	tracing := &http.HttpConnectionManager_Tracing{
+		RandomSampling:   &envoy_type.Percent{Value: 50},
+		MaxPathTagLength: protobuf.UInt32(100),
+		CustomTags: []*envoy_type_tracing_v2.CustomTag{{
+			Tag: "cluster",
+			Type: &envoy_type_tracing_v2.CustomTag_Literal_{
+				Literal: &envoy_type_tracing_v2.CustomTag_Literal{Value: "production"},
+			},
+		}, {
+			Tag: "user",
+			Type: &envoy_type_tracing_v2.CustomTag_RequestHeader{
+				RequestHeader: &envoy_type_tracing_v2.CustomTag_Header{Name: "X-User"},
+			},
+		}},
+		Provider: &envoy_config_trace_v2.Tracing_Http{
+			Name: "envoy.tracers.opencensus",
+			ConfigType: &envoy_config_trace_v2.Tracing_Http_TypedConfig{
+				TypedConfig: protobuf.MustMarshalAny(&envoy_config_trace_v2.OpenCensusConfig{
+					OcagentExporterEnabled: true,
+					OcagentGrpcService: &envoy_api_v2_core.GrpcService{
+						TargetSpecifier: &envoy_api_v2_core.GrpcService_EnvoyGrpc_{
+							EnvoyGrpc: &envoy_api_v2_core.GrpcService_EnvoyGrpc{
+								ClusterName: "extension/tracing/collector",
+							},
+						},
+					},
+					IncomingTraceContext: []envoy_config_trace_v2.OpenCensusConfig_TraceContext{
+						envoy_config_trace_v2.OpenCensusConfig_TRACE_CONTEXT,
+						envoy_config_trace_v2.OpenCensusConfig_B3,
+					},
+					OutgoingTraceContext: []envoy_config_trace_v2.OpenCensusConfig_TraceContext{
+						envoy_config_trace_v2.OpenCensusConfig_TRACE_CONTEXT,
+					},
+				}),
+			},
+		},
+	}
+
+	c.Request(listenerType).Equals(&envoy_api_v2.DiscoveryResponse{
+		TypeUrl: listenerType,
+		Resources: resources(t,
+			tracingHTTPListener(tracing),
+			staticListener(),
+		),
+	})
+
+	// The virtual host's tracing policy overrides the
+	// sampling rate of the connection manager.
+	c.Request(routeType).Equals(&envoy_api_v2.DiscoveryResponse{
+		TypeUrl: routeType,
+		Resources: resources(t,
+			envoy_v2.RouteConfiguration("ingress_http",
+				envoy_v2.VirtualHost("tracing.projectcontour.io",
+					&envoy_api_v2_route.Route{
+						Match:  routePrefix("/"),
+						Action: routeCluster("default/app-server/80/da39a3ee5e"),
+						Tracing: &envoy_api_v2_route.Tracing{
+							RandomSampling: &envoy_type.FractionalPercent{
+								Numerator:   5000,
+								Denominator: envoy_type.FractionalPercent_MILLION,
+							},
+						},
+					},
+				),
+			),
+		),
+	})
+
+	// Tracing is disabled when the collector is not found.
+	rh.OnDelete(&v1alpha1.ExtensionService{
+		ObjectMeta: fixture.ObjectMeta("tracing/collector"),
+	})
+
+	c.Request(listenerType).Equals(&envoy_api_v2.DiscoveryResponse{
+		TypeUrl: listenerType,
+		Resources: resources(t,
+			defaultHTTPListener(),
+			staticListener(),
+		),
+	})
+}
+
+func TestTracingZipkinService(t *testing.T) {
+	rh, c, done := setup(t, withTracing(&dag.TracingProcessor{
+		Provider:          dag.TracingProviderZipkin,
+		Service:           &types.NamespacedName{Namespace: "tracing", Name: "zipkin"},
+		ServicePort:       9411,
+		CollectorEndpoint: "/api/v2/spans",
+		SamplingRate:      100,
+	}))
+	defer done()
+
+	rh.OnAdd(fixture.NewService("tracing/zipkin").
+		WithPorts(corev1.ServicePort{Port: 9411}))
+
+	rh.OnAdd(featuretests.Endpoints("tracing", "zipkin", corev1.EndpointSubset{
+		Addresses: featuretests.Addresses("192.168.183.21"),
+		Ports:     featuretests.Ports(featuretests.Port("", 9411)),
+	}))
+
+	rh.OnAdd(fixture.NewService("app-server").
+		WithPorts(corev1.ServicePort{Port: 80}))
+
+	rh.OnAdd(fixture.NewProxy("proxy").
+		WithFQDN("tracing.projectcontour.io").
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{Name: "app-server", Port: 80}},
+			}},
+		}))
+
+	tracing := &http.HttpConnectionManager_Tracing{
+		RandomSampling: &envoy_type.Percent{Value: 100},
+		Provider: &envoy_config_trace_v2.Tracing_Http{
+			Name: "envoy.tracers.zipkin",
+			ConfigType: &envoy_config_trace_v2.Tracing_Http_TypedConfig{
+				TypedConfig: protobuf.MustMarshalAny(&envoy_config_trace_v2.ZipkinConfig{
+					CollectorCluster:         "tracing/zipkin/9411/da39a3ee5e",
+					CollectorEndpoint:        "/api/v2/spans",
+					CollectorEndpointVersion: envoy_config_trace_v2.ZipkinConfig_HTTP_JSON,
+					TraceId_128Bit:           true,
+				}),
+			},
+		},
+	}
+
+	c.Request(listenerType).Equals(&envoy_api_v2.DiscoveryResponse{
+		TypeUrl: listenerType,
+		Resources: resources(t,
+			tracingHTTPListener(tracing),
+			staticListener(),
+		),
+	})
+
+	// The collector Service is programmed as a cluster.
+	c.Request(clusterType).Equals(&envoy_api_v2.DiscoveryResponse{
+		TypeUrl: clusterType,
+		Resources: resources(t,
+			cluster("default/app-server/80/da39a3ee5e", "default/app-server", "default_app-server_80"),
+			cluster("tracing/zipkin/9411/da39a3ee5e", "tracing/zipkin", "tracing_zipkin_9411"),
+		),
+	})
+
+	// Routes don't override the sampling rate without a tracing policy.
+	c.Request(routeType).Equals(&envoy_api_v2.DiscoveryResponse{
+		TypeUrl: routeType,
+		Resources: resources(t,
+			envoy_v2.RouteConfiguration("ingress_http",
+				envoy_v2.VirtualHost("tracing.projectcontour.io",
+					&envoy_api_v2_route.Route{
+						Match:  routePrefix("/"),
+						Action: routeCluster("default/app-server/80/da39a3ee5e"),
+					},
+				),
+			),
+		),
+	})
+}
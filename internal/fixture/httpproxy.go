@@ -89,3 +89,15 @@ func (b *ProxyBuilder) WithAuthServer(auth contour_api_v1.AuthorizationServer) *
 	b.Spec.VirtualHost.Authorization = &auth
 	return b
 }
+
+func (b *ProxyBuilder) WithRateLimitPolicy(policy contour_api_v1.VirtualHostRateLimitPolicy) *ProxyBuilder {
+	b.ensureVirtualHost()
+	b.Spec.VirtualHost.RateLimitPolicy = &policy
+	return b
+}
+
+func (b *ProxyBuilder) WithTracingPolicy(policy contour_api_v1.TracingPolicy) *ProxyBuilder {
+	b.ensureVirtualHost()
+	b.Spec.VirtualHost.TracingPolicy = &policy
+	return b
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
 	"k8s.io/api/networking/v1beta1"
+	serviceapis "sigs.k8s.io/service-apis/api/v1alpha1"
 )
 
 // IsStatusEqual checks that two objects of supported Kubernetes types
@@ -26,6 +27,7 @@ import (
 // Currently supports:
 // networking.k8s.io/ingress/v1beta1
 // projectcontour.io/v1
+// networking.x.k8s.io/v1alpha1 (Gateway and HTTPRoute)
 func IsStatusEqual(objA, objB interface{}) bool {
 
 	switch a := objA.(type) {
@@ -48,6 +50,21 @@ func IsStatusEqual(objA, objB interface{}) bool {
 				return true
 			}
 		}
+	case *serviceapis.Gateway:
+		switch b := objB.(type) {
+		case *serviceapis.Gateway:
+			if cmp.Equal(a.Status, b.Status,
+				cmpopts.IgnoreFields(serviceapis.GatewayCondition{}, "LastTransitionTime")) {
+				return true
+			}
+		}
+	case *serviceapis.HTTPRoute:
+		switch b := objB.(type) {
+		case *serviceapis.HTTPRoute:
+			if cmp.Equal(a.Status, b.Status) {
+				return true
+			}
+		}
 	}
 
 	return false
@@ -21,6 +21,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
+	serviceapis "sigs.k8s.io/service-apis/api/v1alpha1"
 )
 
 // KindOf returns the kind string for the given Kubernetes object.
@@ -46,6 +47,14 @@ func KindOf(obj interface{}) string {
 			return "TLSCertificateDelegation"
 		case *v1alpha1.ExtensionService:
 			return "ExtensionService"
+		case *serviceapis.GatewayClass:
+			return "GatewayClass"
+		case *serviceapis.Gateway:
+			return "Gateway"
+		case *serviceapis.HTTPRoute:
+			return "HTTPRoute"
+		case *serviceapis.TcpRoute:
+			return "TcpRoute"
 		case *unstructured.Unstructured:
 			return obj.GetKind()
 		default:
@@ -73,6 +82,8 @@ func VersionOf(obj interface{}) string {
 			return contour_api_v1.GroupVersion.String()
 		case *v1alpha1.ExtensionService:
 			return v1alpha1.GroupVersion.String()
+		case *serviceapis.GatewayClass, *serviceapis.Gateway, *serviceapis.HTTPRoute, *serviceapis.TcpRoute:
+			return serviceapis.GroupVersion.String()
 		case *unstructured.Unstructured:
 			return obj.GetAPIVersion()
 		default:
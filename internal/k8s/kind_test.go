@@ -9,6 +9,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/networking/v1beta1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	serviceapis "sigs.k8s.io/service-apis/api/v1alpha1"
 )
 
 func TestKindOf(t *testing.T) {
@@ -24,6 +25,10 @@ func TestKindOf(t *testing.T) {
 		{"HTTPProxy", &contour_api_v1.HTTPProxy{}},
 		{"TLSCertificateDelegation", &contour_api_v1.TLSCertificateDelegation{}},
 		{"ExtensionService", &v1alpha1.ExtensionService{}},
+		{"GatewayClass", &serviceapis.GatewayClass{}},
+		{"Gateway", &serviceapis.Gateway{}},
+		{"HTTPRoute", &serviceapis.HTTPRoute{}},
+		{"TcpRoute", &serviceapis.TcpRoute{}},
 		{"Foo", &unstructured.Unstructured{
 			Object: map[string]interface{}{
 				"apiVersion": "test.projectcontour.io/v1",
@@ -49,6 +54,8 @@ func TestVersionOf(t *testing.T) {
 		{"projectcontour.io/v1", &contour_api_v1.HTTPProxy{}},
 		{"projectcontour.io/v1", &contour_api_v1.TLSCertificateDelegation{}},
 		{"projectcontour.io/v1alpha1", &v1alpha1.ExtensionService{}},
+		{"networking.x.k8s.io/v1alpha1", &serviceapis.Gateway{}},
+		{"networking.x.k8s.io/v1alpha1", &serviceapis.HTTPRoute{}},
 		{"test.projectcontour.io/v1", &unstructured.Unstructured{
 			Object: map[string]interface{}{
 				"apiVersion": "test.projectcontour.io/v1",
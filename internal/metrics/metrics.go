@@ -36,6 +36,8 @@ type Metrics struct {
 	dagRebuildGauge             *prometheus.GaugeVec
 	CacheHandlerOnUpdateSummary prometheus.Summary
 	EventHandlerOperations      *prometheus.CounterVec
+	XDSNacks                    *prometheus.CounterVec
+	XDSV3Untranslated           *prometheus.GaugeVec
 
 	// Keep a local cache of metrics for comparison on updates
 	proxyMetricCache *RouteMetric
@@ -67,6 +69,8 @@ const (
 	DAGRebuildGauge             = "contour_dagrebuild_timestamp"
 	cacheHandlerOnUpdateSummary = "contour_cachehandler_onupdate_duration_seconds"
 	eventHandlerOperations      = "contour_eventhandler_operation_total"
+	xdsNacks                    = "contour_xds_nack_total"
+	xdsV3Untranslated           = "contour_xds_v3_untranslated_resources"
 )
 
 // NewMetrics creates a new set of metrics and registers them with
@@ -139,6 +143,20 @@ func NewMetrics(registry *prometheus.Registry) *Metrics {
 			},
 			[]string{"op", "kind"},
 		),
+		XDSNacks: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: xdsNacks,
+				Help: "Total number of xDS updates rejected by Envoy, by resource type URL.",
+			},
+			[]string{"type_url"},
+		),
+		XDSV3Untranslated: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: xdsV3Untranslated,
+				Help: "Number of xDS resources that can't be translated to the Envoy v3 API and are not served to v3 clients, by v3 resource type URL.",
+			},
+			[]string{"type_url"},
+		),
 	}
 	m.buildInfoGauge.WithLabelValues(build.Branch, build.Sha, build.Version).Set(1)
 	m.register(registry)
@@ -157,6 +175,8 @@ func (m *Metrics) register(registry *prometheus.Registry) {
 		m.dagRebuildGauge,
 		m.CacheHandlerOnUpdateSummary,
 		m.EventHandlerOperations,
+		m.XDSNacks,
+		m.XDSV3Untranslated,
 	)
 }
 
@@ -181,6 +201,8 @@ func (m *Metrics) Zero() {
 	m.SetHTTPProxyMetric(zeroes)
 
 	m.EventHandlerOperations.WithLabelValues("add", "Secret").Inc()
+	m.XDSNacks.WithLabelValues("").Inc()
+	m.XDSV3Untranslated.WithLabelValues("").Set(0)
 
 	prometheus.NewTimer(m.CacheHandlerOnUpdateSummary).ObserveDuration()
 }
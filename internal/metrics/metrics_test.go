@@ -75,6 +75,40 @@ func TestSetDAGLastRebuilt(t *testing.T) {
 	}
 }
 
+func TestZero(t *testing.T) {
+	r := prometheus.NewRegistry()
+	m := NewMetrics(r)
+	m.Zero()
+
+	gathering, err := r.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]bool{}
+	for _, mf := range gathering {
+		got[mf.GetName()] = true
+	}
+
+	// Zero must set a value for every registered metric,
+	// otherwise it is missing from the metrics documentation.
+	for _, name := range []string{
+		BuildInfoGauge,
+		HTTPProxyTotalGauge,
+		HTTPProxyRootTotalGauge,
+		HTTPProxyInvalidGauge,
+		HTTPProxyValidGauge,
+		HTTPProxyOrphanedGauge,
+		DAGRebuildGauge,
+		cacheHandlerOnUpdateSummary,
+		eventHandlerOperations,
+		xdsNacks,
+		xdsV3Untranslated,
+	} {
+		assert.True(t, got[name], "metric %q not set by Zero", name)
+	}
+}
+
 func TestWriteProxyMetric(t *testing.T) {
 	tests := map[string]struct {
 		proxyMetrics RouteMetric
@@ -60,6 +60,18 @@ func UInt32OrNil(val uint32) *wrappers.UInt32Value {
 	}
 }
 
+// UInt64OrNil returns a wrapped UInt64Value. If val is 0, nil is returned
+func UInt64OrNil(val uint64) *wrappers.UInt64Value {
+	switch val {
+	case 0:
+		return nil
+	default:
+		return &wrappers.UInt64Value{
+			Value: val,
+		}
+	}
+}
+
 // Bool converts a bool to a pointer to a wrappers.BoolValue.
 func Bool(val bool) *wrappers.BoolValue {
 	return &wrappers.BoolValue{
@@ -85,6 +97,20 @@ func AsMessages(messages interface{}) []proto.Message {
 	return protos
 }
 
+// AsMessageMap casts the given map of string keys to values (that
+// implement the proto.Message interface) to a map of proto.Message.
+func AsMessageMap(messages interface{}) map[string]proto.Message {
+	v := reflect.ValueOf(messages)
+	protos := make(map[string]proto.Message, v.Len())
+
+	iter := v.MapRange()
+	for iter.Next() {
+		protos[iter.Key().String()] = iter.Value().Interface().(proto.Message)
+	}
+
+	return protos
+}
+
 // MustMarshalAny marshals a protobug into an any.Any type, panicing
 // if that operation fails.
 func MustMarshalAny(pb proto.Message) *any.Any {
@@ -14,7 +14,9 @@
 package sorter
 
 import (
+	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
@@ -22,7 +24,7 @@ import (
 	envoy_api_v2_listener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
 	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
 	tcp "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/tcp_proxy/v2"
-	"github.com/golang/protobuf/proto"
+	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher"
 )
 
 // Sorts the given route configuration values by name.
@@ -53,12 +55,88 @@ func (s headerMatcherSorter) Less(i, j int) bool {
 	case 1:
 		return false
 	case 0:
-		return proto.CompactTextString(s[i]) < proto.CompactTextString(s[j])
+		return headerMatcherCondition(s[i]) < headerMatcherCondition(s[j])
 	}
 
 	panic("bad comparison")
 }
 
+// headerMatcherCondition returns the matcher condition of h as a
+// string. Conditions are ordered by the kind of match, then by the
+// value to match, then by whether the match is inverted.
+func headerMatcherCondition(h *envoy_api_v2_route.HeaderMatcher) string {
+	var cond string
+
+	switch m := h.HeaderMatchSpecifier.(type) {
+	case *envoy_api_v2_route.HeaderMatcher_ExactMatch:
+		cond = "exact_match:" + m.ExactMatch
+	case *envoy_api_v2_route.HeaderMatcher_PrefixMatch:
+		cond = "prefix_match:" + m.PrefixMatch
+	case *envoy_api_v2_route.HeaderMatcher_PresentMatch:
+		cond = "present_match:" + strconv.FormatBool(m.PresentMatch)
+	case *envoy_api_v2_route.HeaderMatcher_RangeMatch:
+		cond = fmt.Sprintf("range_match:%d-%d", m.RangeMatch.GetStart(), m.RangeMatch.GetEnd())
+	case *envoy_api_v2_route.HeaderMatcher_SafeRegexMatch:
+		cond = "safe_regex_match:" + m.SafeRegexMatch.GetRegex()
+	case *envoy_api_v2_route.HeaderMatcher_SuffixMatch:
+		cond = "suffix_match:" + m.SuffixMatch
+	}
+
+	return cond + ":invert=" + strconv.FormatBool(h.InvertMatch)
+}
+
+// Sorts QueryParameterMatcher objects, first by the query parameter name,
+// then by their matcher conditions (textually).
+type queryParamMatcherSorter []*envoy_api_v2_route.QueryParameterMatcher
+
+func (s queryParamMatcherSorter) Len() int      { return len(s) }
+func (s queryParamMatcherSorter) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s queryParamMatcherSorter) Less(i, j int) bool {
+	val := strings.Compare(s[i].Name, s[j].Name)
+	switch val {
+	case -1:
+		return true
+	case 1:
+		return false
+	case 0:
+		return queryParamMatcherCondition(s[i]) < queryParamMatcherCondition(s[j])
+	}
+
+	panic("bad comparison")
+}
+
+// queryParamMatcherCondition returns the matcher condition of q as
+// a string. Conditions are ordered by the kind of match, then by the
+// value to match.
+func queryParamMatcherCondition(q *envoy_api_v2_route.QueryParameterMatcher) string {
+	switch m := q.QueryParameterMatchSpecifier.(type) {
+	case *envoy_api_v2_route.QueryParameterMatcher_PresentMatch:
+		return "present_match:" + strconv.FormatBool(m.PresentMatch)
+	case *envoy_api_v2_route.QueryParameterMatcher_StringMatch:
+		return "string_match:" + stringMatcherCondition(m.StringMatch)
+	default:
+		return ""
+	}
+}
+
+// stringMatcherCondition returns the condition of the StringMatcher
+// m as a string.
+func stringMatcherCondition(m *matcher.StringMatcher) string {
+	cond := ""
+	switch p := m.GetMatchPattern().(type) {
+	case *matcher.StringMatcher_Exact:
+		cond = "exact:" + p.Exact
+	case *matcher.StringMatcher_Prefix:
+		cond = "prefix:" + p.Prefix
+	case *matcher.StringMatcher_SafeRegex:
+		cond = "safe_regex:" + p.SafeRegex.GetRegex()
+	case *matcher.StringMatcher_Suffix:
+		cond = "suffix:" + p.Suffix
+	}
+
+	return cond + ":ignore_case=" + strconv.FormatBool(m.GetIgnoreCase())
+}
+
 // longestRouteByHeaders compares the HeaderMatcher slices for lhs and rhs and
 // returns true if lhs is longer.
 func longestRouteByHeaders(lhs, rhs *envoy_api_v2_route.Route) bool {
@@ -78,16 +156,66 @@ func longestRouteByHeaders(lhs, rhs *envoy_api_v2_route.Route) bool {
 	return len(lhs.Match.Headers) > len(rhs.Match.Headers)
 }
 
-// Sorts the given Route slice in place. Routes are ordered first by
-// longest prefix (or regex), then by the length of the HeaderMatch
-// slice (if any). The HeaderMatch slice is also ordered by the matching
-// header name.
+// longestRouteByQueryParameters compares the QueryParameterMatcher slices
+// for lhs and rhs and returns true if lhs is longer.
+func longestRouteByQueryParameters(lhs, rhs *envoy_api_v2_route.Route) bool {
+	if len(lhs.Match.QueryParameters) == len(rhs.Match.QueryParameters) {
+		pair := make([]*envoy_api_v2_route.QueryParameterMatcher, 2)
+
+		for i := 0; i < len(lhs.Match.QueryParameters); i++ {
+			pair[0] = lhs.Match.QueryParameters[i]
+			pair[1] = rhs.Match.QueryParameters[i]
+
+			if queryParamMatcherSorter(pair).Less(0, 1) {
+				return true
+			}
+		}
+	}
+
+	return len(lhs.Match.QueryParameters) > len(rhs.Match.QueryParameters)
+}
+
+// longestRouteByConditions orders routes with the same path match by
+// their header matches, then by their query parameter matches.
+func longestRouteByConditions(lhs, rhs *envoy_api_v2_route.Route) bool {
+	switch {
+	case longestRouteByHeaders(lhs, rhs):
+		return true
+	case longestRouteByHeaders(rhs, lhs):
+		return false
+	default:
+		return longestRouteByQueryParameters(lhs, rhs)
+	}
+}
+
+// Sorts the given Route slice in place. Exact path matches sort before
+// regex matches, which sort before prefix matches. Routes are then
+// ordered by longest path (or prefix, or regex), then by the length of
+// the HeaderMatch slice (if any), then by the length of the
+// QueryParameterMatcher slice (if any). The HeaderMatch slice is also
+// ordered by the matching header name.
 type routeSorter []*envoy_api_v2_route.Route
 
 func (s routeSorter) Len() int      { return len(s) }
 func (s routeSorter) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
 func (s routeSorter) Less(i, j int) bool {
 	switch a := s[i].Match.PathSpecifier.(type) {
+	case *envoy_api_v2_route.RouteMatch_Path:
+		switch b := s[j].Match.PathSpecifier.(type) {
+		case *envoy_api_v2_route.RouteMatch_Path:
+			cmp := strings.Compare(a.Path, b.Path)
+			switch cmp {
+			case 1:
+				// Sort longest path first.
+				return true
+			case -1:
+				return false
+			default:
+				return longestRouteByConditions(s[i], s[j])
+			}
+		case *envoy_api_v2_route.RouteMatch_SafeRegex, *envoy_api_v2_route.RouteMatch_Prefix:
+			return true
+		}
 	case *envoy_api_v2_route.RouteMatch_Prefix:
 		switch b := s[j].Match.PathSpecifier.(type) {
 		case *envoy_api_v2_route.RouteMatch_Prefix:
@@ -99,7 +227,7 @@ func (s routeSorter) Less(i, j int) bool {
 			case -1:
 				return false
 			default:
-				return longestRouteByHeaders(s[i], s[j])
+				return longestRouteByConditions(s[i], s[j])
 			}
 		}
 	case *envoy_api_v2_route.RouteMatch_SafeRegex:
@@ -113,7 +241,7 @@ func (s routeSorter) Less(i, j int) bool {
 			case -1:
 				return false
 			default:
-				return longestRouteByHeaders(s[i], s[j])
+				return longestRouteByConditions(s[i], s[j])
 			}
 		case *envoy_api_v2_route.RouteMatch_Prefix:
 			return true
@@ -215,6 +343,8 @@ func For(v interface{}) sort.Interface {
 		return routeSorter(v)
 	case []*envoy_api_v2_route.HeaderMatcher:
 		return headerMatcherSorter(v)
+	case []*envoy_api_v2_route.QueryParameterMatcher:
+		return queryParamMatcherSorter(v)
 	case []*v2.Cluster:
 		return clusterSorter(v)
 	case []*v2.ClusterLoadAssignment:
@@ -79,6 +79,12 @@ func matchPrefix(str string) *envoy_api_v2_route.RouteMatch_Prefix {
 	}
 }
 
+func matchPath(str string) *envoy_api_v2_route.RouteMatch_Path {
+	return &envoy_api_v2_route.RouteMatch_Path{
+		Path: str,
+	}
+}
+
 func matchRegex(str string) *envoy_api_v2_route.RouteMatch_SafeRegex {
 	return &envoy_api_v2_route.RouteMatch_SafeRegex{
 		SafeRegex: &matcher.RegexMatcher{
@@ -87,6 +93,38 @@ func matchRegex(str string) *envoy_api_v2_route.RouteMatch_SafeRegex {
 	}
 }
 
+func exactQueryParam(name string, value string) *envoy_api_v2_route.QueryParameterMatcher {
+	return &envoy_api_v2_route.QueryParameterMatcher{
+		Name: name,
+		QueryParameterMatchSpecifier: &envoy_api_v2_route.QueryParameterMatcher_StringMatch{
+			StringMatch: &matcher.StringMatcher{
+				MatchPattern: &matcher.StringMatcher_Exact{Exact: value},
+			},
+		},
+	}
+}
+
+func presentQueryParam(name string) *envoy_api_v2_route.QueryParameterMatcher {
+	return &envoy_api_v2_route.QueryParameterMatcher{
+		Name: name,
+		QueryParameterMatchSpecifier: &envoy_api_v2_route.QueryParameterMatcher_PresentMatch{
+			PresentMatch: true,
+		},
+	}
+}
+
+func regexHeader(name string, regex string, invert bool) *envoy_api_v2_route.HeaderMatcher {
+	return &envoy_api_v2_route.HeaderMatcher{
+		Name:        name,
+		InvertMatch: invert,
+		HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_SafeRegexMatch{
+			SafeRegexMatch: &matcher.RegexMatcher{
+				Regex: regex,
+			},
+		},
+	}
+}
+
 func exactHeader(name string, value string) *envoy_api_v2_route.HeaderMatcher {
 	return &envoy_api_v2_route.HeaderMatcher{
 		Name: name,
@@ -107,6 +145,17 @@ func presentHeader(name string) *envoy_api_v2_route.HeaderMatcher {
 
 func TestSortRoutesLongestPath(t *testing.T) {
 	want := []*envoy_api_v2_route.Route{
+		{
+			Match: &envoy_api_v2_route.RouteMatch{
+				PathSpecifier: matchPath("/path/exact/longest"),
+			}},
+
+		// Note that exact matches sort before regex matches.
+		{
+			Match: &envoy_api_v2_route.RouteMatch{
+				PathSpecifier: matchPath("/"),
+			}},
+
 		{
 			Match: &envoy_api_v2_route.RouteMatch{
 				PathSpecifier: matchRegex("/this/is/the/longest"),
@@ -130,10 +179,12 @@ func TestSortRoutesLongestPath(t *testing.T) {
 	}
 
 	have := []*envoy_api_v2_route.Route{
-		want[1],
 		want[3],
-		want[0],
+		want[5],
+		want[1],
 		want[2],
+		want[0],
+		want[4],
 	}
 
 	sort.Stable(For(have))
@@ -178,6 +229,57 @@ func TestSortRoutesLongestHeaders(t *testing.T) {
 	assert.Equal(t, have, want)
 }
 
+func TestSortRoutesLongestQueryParameters(t *testing.T) {
+	want := []*envoy_api_v2_route.Route{
+		// Header matches are compared before query
+		// parameter matches.
+		{
+			Match: &envoy_api_v2_route.RouteMatch{
+				PathSpecifier: matchPrefix("/path"),
+				Headers: []*envoy_api_v2_route.HeaderMatcher{
+					presentHeader("header-name"),
+				},
+			}},
+		{
+			Match: &envoy_api_v2_route.RouteMatch{
+				PathSpecifier: matchPrefix("/path"),
+				QueryParameters: []*envoy_api_v2_route.QueryParameterMatcher{
+					exactQueryParam("param-name", "param-value"),
+					presentQueryParam("other-param-name"),
+				},
+			}},
+		{
+			Match: &envoy_api_v2_route.RouteMatch{
+				PathSpecifier: matchPrefix("/path"),
+				QueryParameters: []*envoy_api_v2_route.QueryParameterMatcher{
+					presentQueryParam("param-name"),
+				},
+			}},
+		{
+			Match: &envoy_api_v2_route.RouteMatch{
+				PathSpecifier: matchPrefix("/path"),
+				QueryParameters: []*envoy_api_v2_route.QueryParameterMatcher{
+					exactQueryParam("param-name", "param-value"),
+				},
+			}},
+		{
+			Match: &envoy_api_v2_route.RouteMatch{
+				PathSpecifier: matchPrefix("/path"),
+			}},
+	}
+
+	have := []*envoy_api_v2_route.Route{
+		want[4],
+		want[3],
+		want[1],
+		want[2],
+		want[0],
+	}
+
+	sort.Stable(For(have))
+	assert.Equal(t, have, want)
+}
+
 func TestSortSecrets(t *testing.T) {
 	want := []*envoy_api_v2_auth.Secret{
 		{Name: "first"},
@@ -200,10 +302,36 @@ func TestSortHeaderMatchers(t *testing.T) {
 		// is less than "present".
 		exactHeader("header-name", "anything"),
 		presentHeader("header-name"),
+		regexHeader("header-name", "a.*", false),
+		regexHeader("header-name", "a.*", true),
+		regexHeader("header-name", "b.*", false),
 		exactHeader("long-header-name", "long-header-value"),
 	}
 
 	have := []*envoy_api_v2_route.HeaderMatcher{
+		want[5],
+		want[3],
+		want[1],
+		want[4],
+		want[2],
+		want[0],
+	}
+
+	sort.Stable(For(have))
+	assert.Equal(t, have, want)
+}
+
+func TestSortQueryParameterMatchers(t *testing.T) {
+	want := []*envoy_api_v2_route.QueryParameterMatcher{
+		exactQueryParam("long-param-name", "long-param-value"),
+		// Note that if the query parameter names are the same,
+		// we order by the protobuf string, in which case
+		// "present" is less than "string".
+		presentQueryParam("param-name"),
+		exactQueryParam("param-name", "anything"),
+	}
+
+	have := []*envoy_api_v2_route.QueryParameterMatcher{
 		want[2],
 		want[1],
 		want[0],
@@ -0,0 +1,207 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/projectcontour/contour/internal/k8s"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	serviceapis "sigs.k8s.io/service-apis/api/v1alpha1"
+)
+
+// GatewayGVR is the GroupVersionResource of the service-apis Gateway.
+var GatewayGVR = serviceapis.GroupVersion.WithResource("gateways")
+
+// HTTPRouteGVR is the GroupVersionResource of the service-apis HTTPRoute.
+var HTTPRouteGVR = serviceapis.GroupVersion.WithResource("httproutes")
+
+// GatewayCacheEntry holds status updates for a particular Gateway.
+type GatewayCacheEntry struct {
+	ConditionCache
+
+	Name           types.NamespacedName
+	Generation     int64
+	TransitionTime v1.Time
+}
+
+var _ CacheEntry = &GatewayCacheEntry{}
+
+func (g *GatewayCacheEntry) AsStatusUpdate() k8s.StatusUpdate {
+	m := k8s.StatusMutatorFunc(func(obj interface{}) interface{} {
+		o, ok := obj.(*serviceapis.Gateway)
+		if !ok {
+			panic(fmt.Sprintf("unsupported %T object %q in status mutator", obj, g.Name))
+		}
+
+		gw := o.DeepCopy()
+
+		// Sort the condition types so that the written status
+		// doesn't change just because of map iteration order.
+		var condTypes []string
+		for condType := range g.Conditions {
+			condTypes = append(condTypes, string(condType))
+		}
+		sort.Strings(condTypes)
+
+		for _, t := range condTypes {
+			cond := g.Conditions[ConditionType(t)]
+			gwCond := serviceapis.GatewayCondition{
+				Type:               serviceapis.GatewayConditionType(cond.Type),
+				Status:             corev1.ConditionStatus(cond.Status),
+				Reason:             cond.Reason,
+				Message:            cond.Message,
+				LastTransitionTime: g.TransitionTime,
+			}
+
+			// The Gateway conditions don't carry sub-conditions,
+			// so fold the first error into the message to give
+			// the user something actionable.
+			if len(cond.Errors) > 0 {
+				gwCond.Reason = cond.Errors[0].Reason
+				gwCond.Message = cond.Errors[0].Message
+			}
+
+			replaced := false
+			for i := range gw.Status.Conditions {
+				if gw.Status.Conditions[i].Type == gwCond.Type {
+					gw.Status.Conditions[i] = gwCond
+					replaced = true
+					break
+				}
+			}
+
+			if !replaced {
+				gw.Status.Conditions = append(gw.Status.Conditions, gwCond)
+			}
+		}
+
+		return gw
+	})
+
+	return k8s.StatusUpdate{
+		NamespacedName: g.Name,
+		Resource:       GatewayGVR,
+		Mutator:        m,
+	}
+}
+
+// GatewayAccessor returns a pointer to a shared status cache entry
+// for the given Gateway. If no such entry exists, a new entry is
+// added. When the caller finishes with the cache entry, it must call
+// the returned function to release the entry back to the cache.
+func GatewayAccessor(c *Cache, gw *serviceapis.Gateway) (*GatewayCacheEntry, func()) {
+	entry := c.Get(gw)
+	if entry == nil {
+		entry = &GatewayCacheEntry{
+			Name:           k8s.NamespacedNameOf(gw),
+			Generation:     gw.GetGeneration(),
+			TransitionTime: v1.NewTime(time.Now()),
+		}
+
+		// Populate the cache with the new entry
+		c.Put(gw, entry)
+	}
+
+	entry = c.Get(gw)
+	return entry.(*GatewayCacheEntry), func() {
+		c.Put(gw, entry)
+	}
+}
+
+// HTTPRouteCacheEntry holds status updates for a particular HTTPRoute.
+//
+// The HTTPRoute status has no conditions, only the list of Gateways
+// that admitted the route. Errors found while processing the route
+// are reported on the Gateway that references it.
+type HTTPRouteCacheEntry struct {
+	ConditionCache
+
+	Name     types.NamespacedName
+	Gateways []corev1.ObjectReference
+}
+
+var _ CacheEntry = &HTTPRouteCacheEntry{}
+
+// AddGateway records that the given Gateway admitted this route.
+func (r *HTTPRouteCacheEntry) AddGateway(gw *serviceapis.Gateway) {
+	ref := corev1.ObjectReference{
+		APIVersion: serviceapis.GroupVersion.String(),
+		Kind:       "Gateway",
+		Namespace:  gw.Namespace,
+		Name:       gw.Name,
+	}
+
+	for _, g := range r.Gateways {
+		if g == ref {
+			return
+		}
+	}
+
+	r.Gateways = append(r.Gateways, ref)
+}
+
+func (r *HTTPRouteCacheEntry) AsStatusUpdate() k8s.StatusUpdate {
+	m := k8s.StatusMutatorFunc(func(obj interface{}) interface{} {
+		o, ok := obj.(*serviceapis.HTTPRoute)
+		if !ok {
+			panic(fmt.Sprintf("unsupported %T object %q in status mutator", obj, r.Name))
+		}
+
+		route := o.DeepCopy()
+
+		gateways := make([]corev1.ObjectReference, len(r.Gateways))
+		copy(gateways, r.Gateways)
+		sort.Slice(gateways, func(i, j int) bool {
+			if gateways[i].Namespace == gateways[j].Namespace {
+				return gateways[i].Name < gateways[j].Name
+			}
+			return gateways[i].Namespace < gateways[j].Namespace
+		})
+
+		route.Status.Gateways = gateways
+		return route
+	})
+
+	return k8s.StatusUpdate{
+		NamespacedName: r.Name,
+		Resource:       HTTPRouteGVR,
+		Mutator:        m,
+	}
+}
+
+// HTTPRouteAccessor returns a pointer to a shared status cache entry
+// for the given HTTPRoute. If no such entry exists, a new entry is
+// added. When the caller finishes with the cache entry, it must call
+// the returned function to release the entry back to the cache.
+func HTTPRouteAccessor(c *Cache, route *serviceapis.HTTPRoute) (*HTTPRouteCacheEntry, func()) {
+	entry := c.Get(route)
+	if entry == nil {
+		entry = &HTTPRouteCacheEntry{
+			Name: k8s.NamespacedNameOf(route),
+		}
+
+		// Populate the cache with the new entry
+		c.Put(route, entry)
+	}
+
+	entry = c.Get(route)
+	return entry.(*HTTPRouteCacheEntry), func() {
+		c.Put(route, entry)
+	}
+}
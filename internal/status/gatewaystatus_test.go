@@ -0,0 +1,95 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"testing"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	serviceapis "sigs.k8s.io/service-apis/api/v1alpha1"
+)
+
+func TestGatewayStatusMutator(t *testing.T) {
+	gw := &serviceapis.Gateway{
+		ObjectMeta: fixture.ObjectMeta("projectcontour/contour"),
+		Status: serviceapis.GatewayStatus{
+			Conditions: []serviceapis.GatewayCondition{{
+				Type:   serviceapis.ConditionNoSuchGatewayClass,
+				Status: corev1.ConditionTrue,
+			}},
+		},
+	}
+
+	cache := NewCache()
+	entry, commit := GatewayAccessor(&cache, gw)
+
+	classCond := entry.ConditionFor(ConditionType(serviceapis.ConditionNoSuchGatewayClass))
+	classCond.Status = contour_api_v1.ConditionFalse
+	classCond.Reason = "Valid"
+
+	entry.ConditionFor(ConditionType(serviceapis.ConditionInvalidRoutes)).AddError(
+		"RouteError", "RouteNotFound", `HTTPRoute "projectcontour/missing" not found`)
+	commit()
+
+	updates := cache.GetStatusUpdates()
+	assert.Equal(t, 1, len(updates))
+	assert.Equal(t, GatewayGVR, updates[0].Resource)
+
+	got := updates[0].Mutator.Mutate(gw).(*serviceapis.Gateway)
+	assert.Equal(t, []serviceapis.GatewayCondition{{
+		Type:               serviceapis.ConditionNoSuchGatewayClass,
+		Status:             corev1.ConditionFalse,
+		Reason:             "Valid",
+		LastTransitionTime: entry.TransitionTime,
+	}, {
+		Type:               serviceapis.ConditionInvalidRoutes,
+		Status:             corev1.ConditionTrue,
+		Reason:             "RouteNotFound",
+		Message:            `HTTPRoute "projectcontour/missing" not found`,
+		LastTransitionTime: entry.TransitionTime,
+	}}, got.Status.Conditions)
+}
+
+func TestHTTPRouteStatusMutator(t *testing.T) {
+	route := &serviceapis.HTTPRoute{
+		ObjectMeta: fixture.ObjectMeta("projectcontour/basic"),
+	}
+
+	cache := NewCache()
+	for _, name := range []string{"projectcontour/b", "projectcontour/a", "projectcontour/b"} {
+		entry, commit := HTTPRouteAccessor(&cache, route)
+		entry.AddGateway(&serviceapis.Gateway{ObjectMeta: fixture.ObjectMeta(name)})
+		commit()
+	}
+
+	updates := cache.GetStatusUpdates()
+	assert.Equal(t, 1, len(updates))
+	assert.Equal(t, HTTPRouteGVR, updates[0].Resource)
+
+	got := updates[0].Mutator.Mutate(route).(*serviceapis.HTTPRoute)
+	assert.Equal(t, []corev1.ObjectReference{{
+		APIVersion: "networking.x.k8s.io/v1alpha1",
+		Kind:       "Gateway",
+		Namespace:  "projectcontour",
+		Name:       "a",
+	}, {
+		APIVersion: "networking.x.k8s.io/v1alpha1",
+		Kind:       "Gateway",
+		Namespace:  "projectcontour",
+		Name:       "b",
+	}}, got.Status.Gateways)
+}
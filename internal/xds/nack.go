@@ -0,0 +1,158 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Nack describes an xDS update that was rejected by an Envoy node.
+type Nack struct {
+	// NodeID is the ID of the Envoy node that rejected the update.
+	NodeID string
+
+	// TypeURL is the type URL of the rejected resources.
+	TypeURL string
+
+	// Nonce is the nonce of the rejected DiscoveryResponse.
+	Nonce string
+
+	// Message is the error message reported by Envoy.
+	Message string
+
+	// ResourceNames holds the names of the resources that Envoy
+	// requested in the rejecting request. It is empty if Envoy
+	// requested all the resources of the type.
+	ResourceNames []string
+}
+
+type nackKey struct {
+	nodeID    string
+	typeURL   string
+	resources string
+}
+
+func newNackKey(nodeID string, typeURL string, resourceNames []string) nackKey {
+	return nackKey{
+		nodeID:    nodeID,
+		typeURL:   typeURL,
+		resources: strings.Join(resourceNames, ","),
+	}
+}
+
+// NackRecorder records the xDS updates that have been rejected by
+// Envoy. Only the most recent rejection for each node, resource
+// type and set of requested resource names is retained, and it is
+// forgotten once the node accepts a subsequent update of those
+// resources, or disconnects.
+//
+// The methods of a nil NackRecorder do nothing.
+type NackRecorder struct {
+	// Counter, if not nil, counts the rejected updates by type
+	// URL. It is not labeled by node ID, since node IDs are not
+	// bounded.
+	Counter *prometheus.CounterVec
+
+	// OnChange, if not nil, is called whenever the set of rejected
+	// updates changes. It is not called for repeated rejections
+	// with the same error message.
+	OnChange func()
+
+	mu    sync.Mutex
+	nacks map[nackKey]Nack
+}
+
+// Nack records that an update was rejected.
+func (r *NackRecorder) Nack(n Nack) {
+	if r == nil {
+		return
+	}
+
+	if r.Counter != nil {
+		r.Counter.WithLabelValues(n.TypeURL).Inc()
+	}
+
+	r.update(newNackKey(n.NodeID, n.TypeURL, n.ResourceNames), &n)
+}
+
+// Clear forgets any rejected update of the given type and
+// resource names by the given node.
+func (r *NackRecorder) Clear(nodeID string, typeURL string, resourceNames ...string) {
+	if r == nil {
+		return
+	}
+
+	r.update(newNackKey(nodeID, typeURL, resourceNames), nil)
+}
+
+// update sets (or deletes, if n is nil) the Nack for the given key
+// and fires the OnChange callback if that changed anything.
+func (r *NackRecorder) update(key nackKey, n *Nack) {
+	r.mu.Lock()
+
+	prev, ok := r.nacks[key]
+
+	var changed bool
+	switch {
+	case n == nil:
+		changed = ok
+		delete(r.nacks, key)
+	default:
+		changed = !ok || prev.Message != n.Message
+		if r.nacks == nil {
+			r.nacks = map[nackKey]Nack{}
+		}
+		r.nacks[key] = *n
+	}
+
+	r.mu.Unlock()
+
+	// Fire the callback without holding the lock, since it is
+	// likely to end up calling back into Nacks.
+	if changed && r.OnChange != nil {
+		r.OnChange()
+	}
+}
+
+// Nacks returns the currently recorded rejected updates, ordered
+// by node ID, type URL and resource names.
+func (r *NackRecorder) Nacks() []Nack {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	nacks := make([]Nack, 0, len(r.nacks))
+	for _, n := range r.nacks {
+		nacks = append(nacks, n)
+	}
+
+	sort.Slice(nacks, func(i, j int) bool {
+		if nacks[i].NodeID != nacks[j].NodeID {
+			return nacks[i].NodeID < nacks[j].NodeID
+		}
+		if nacks[i].TypeURL != nacks[j].TypeURL {
+			return nacks[i].TypeURL < nacks[j].TypeURL
+		}
+		return strings.Join(nacks[i].ResourceNames, ",") < strings.Join(nacks[j].ResourceNames, ",")
+	})
+
+	return nacks
+}
@@ -0,0 +1,78 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	io_prometheus_client "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNackRecorder(t *testing.T) {
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "nacks"}, []string{"type_url"})
+
+	var changes int
+	r := &NackRecorder{
+		Counter:  counter,
+		OnChange: func() { changes++ },
+	}
+
+	r.Nack(Nack{NodeID: "b", TypeURL: "cluster", Message: "bad cluster"})
+	r.Nack(Nack{NodeID: "b", TypeURL: "cluster", Message: "bad cluster"})
+	r.Nack(Nack{NodeID: "a", TypeURL: "listener", Message: "bad listener"})
+	r.Nack(Nack{NodeID: "a", TypeURL: "cluster", Message: "bad cluster"})
+
+	assert.Equal(t, 3, changes)
+	assert.Equal(t, []Nack{
+		{NodeID: "a", TypeURL: "cluster", Message: "bad cluster"},
+		{NodeID: "a", TypeURL: "listener", Message: "bad listener"},
+		{NodeID: "b", TypeURL: "cluster", Message: "bad cluster"},
+	}, r.Nacks())
+
+	r.Clear("a", "cluster")
+	r.Clear("a", "route")
+	r.Nack(Nack{NodeID: "b", TypeURL: "cluster", Message: "worse cluster"})
+
+	assert.Equal(t, 5, changes)
+	assert.Equal(t, []Nack{
+		{NodeID: "a", TypeURL: "listener", Message: "bad listener"},
+		{NodeID: "b", TypeURL: "cluster", Message: "worse cluster"},
+	}, r.Nacks())
+
+	// Rejections of different resources of the same type are
+	// retained separately.
+	r.Nack(Nack{NodeID: "a", TypeURL: "route", Message: "bad route", ResourceNames: []string{"https/b"}})
+	r.Nack(Nack{NodeID: "a", TypeURL: "route", Message: "bad route", ResourceNames: []string{"https/a"}})
+	r.Clear("a", "route", "https/b")
+
+	assert.Equal(t, 8, changes)
+	assert.Equal(t, []Nack{
+		{NodeID: "a", TypeURL: "listener", Message: "bad listener"},
+		{NodeID: "a", TypeURL: "route", Message: "bad route", ResourceNames: []string{"https/a"}},
+		{NodeID: "b", TypeURL: "cluster", Message: "worse cluster"},
+	}, r.Nacks())
+
+	m := &io_prometheus_client.Metric{}
+	require.NoError(t, counter.WithLabelValues("cluster").Write(m))
+	assert.Equal(t, float64(4), m.GetCounter().GetValue())
+
+	// A nil recorder does nothing.
+	var none *NackRecorder
+	none.Nack(Nack{NodeID: "a", TypeURL: "cluster"})
+	none.Clear("a", "cluster")
+	assert.Empty(t, none.Nacks())
+}
@@ -13,7 +13,11 @@
 
 package xds
 
-import "github.com/golang/protobuf/proto"
+import (
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
 
 // Resource represents a source of proto.Messages that can be registered
 // for interest.
@@ -30,3 +34,18 @@ type Resource interface {
 	// TypeURL returns the typeURL of messages returned from Values.
 	TypeURL() string
 }
+
+// DeltaResource is a Resource that tracks the version of each of
+// its entries, so that the incremental xDS protocol can send only
+// the entries that have changed.
+type DeltaResource interface {
+	Resource
+
+	// Versions returns the version of each entry, keyed by name.
+	Versions() map[string]string
+}
+
+// ErrDeltaUnsupported is returned to an Envoy that uses the incremental
+// xDS protocol with an xDS server that doesn't implement it.
+var ErrDeltaUnsupported = status.Errorf(codes.Unimplemented,
+	"%q can only be used with the %q xDS server type", "--use-delta", "contour")
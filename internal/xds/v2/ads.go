@@ -0,0 +1,417 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	resource_v2 "github.com/envoyproxy/go-control-plane/pkg/resource/v2"
+	resource_v3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	protov1 "github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/any"
+	"github.com/projectcontour/contour/internal/xds"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// adsOrder is the order in which updates are sent on an ADS stream.
+// Clusters are sent before the endpoints that populate them, and
+// secrets and clusters are sent before the listeners and routes that
+// refer to them, so Envoy never sees a reference to a resource that
+// it doesn't have yet. The resource caches built in cmd/contour/serve.go
+// must be updated in the same order when the DAG is rebuilt.
+var adsOrder = map[string]int{
+	resource_v2.ClusterType:  0,
+	resource_v3.ClusterType:  0,
+	resource_v2.EndpointType: 1,
+	resource_v3.EndpointType: 1,
+	resource_v2.SecretType:   2,
+	resource_v3.SecretType:   2,
+	resource_v2.ListenerType: 3,
+	resource_v3.ListenerType: 3,
+	resource_v2.RouteType:    4,
+	resource_v3.RouteType:    4,
+}
+
+// adsRank returns the position of typeURL in the ADS update order.
+// Types with no defined order are sent last.
+func adsRank(typeURL string) int {
+	if rank, ok := adsOrder[typeURL]; ok {
+		return rank
+	}
+
+	return len(adsOrder)
+}
+
+// adsWatch tracks the state of a single resource type on an
+// ADS stream.
+type adsWatch struct {
+	typeURL  string
+	resource xds.Resource
+
+	// ch is registered with the resource to receive its version
+	// when it changes. Each registration uses a new channel, so
+	// that a registration can be abandoned if Envoy asks for a
+	// different set of resources.
+	ch chan int
+
+	// names holds the resource names from Envoy's last request,
+	// and registeredNames holds the names that ch was registered
+	// with.
+	names           []string
+	registeredNames []string
+
+	// sentNames holds the resource names from the last response.
+	sentNames []string
+
+	// waiting is true if Envoy has made a request that hasn't
+	// been answered yet. In the state of the world protocol,
+	// each request is answered by at most one response.
+	waiting bool
+
+	// version is the latest version of the resource, and sent
+	// is the version of the last response.
+	version, sent int
+}
+
+// register registers for changes to the resources that Envoy has
+// asked for.
+func (w *adsWatch) register() {
+	w.ch = make(chan int, 1)
+	w.registeredNames = w.names
+	w.resource.Register(w.ch, w.version, w.names...)
+}
+
+// notified records that the resource changed to the given version.
+func (w *adsWatch) notified(version int) {
+	if version > w.version {
+		w.version = version
+	}
+
+	// Registrations only fire once, so renew it.
+	w.register()
+}
+
+// changed returns true if the resources Envoy has asked for are
+// different to the resources in the last response.
+func (w *adsWatch) changed() bool {
+	if w.version < 0 {
+		return false
+	}
+
+	return w.version > w.sent || !sameNames(w.names, w.sentNames)
+}
+
+// streamAggregated processes an ADS stream of DiscoveryRequests for
+// multiple resource types. Updates to the resources are sent in the
+// order given by adsOrder, and a type is not updated until Envoy has
+// acknowledged the last update of each type that comes before it.
+//
+// This relies on the resources that come first in adsOrder being
+// updated first when the DAG is rebuilt. By the time a change to a
+// later resource is seen, the changes to the earlier resources are
+// already waiting to be received.
+func (s *contourServer) streamAggregated(st grpcStream) error {
+	// Bump connection counter and set it as a field on the logger.
+	log := s.WithField("connection", connections.next()).WithField("ads", true)
+
+	ctx, cancel := context.WithCancel(st.Context())
+	defer cancel()
+
+	var nodeID string
+
+	// watches holds the resource types Envoy has subscribed
+	// to, in the order their updates must be sent.
+	var watches []*adsWatch
+
+	// Notify whether the stream terminated on error.
+	done := func(log *logrus.Entry, err error) error {
+		for _, w := range watches {
+			s.nacks.Clear(nodeID, w.typeURL)
+		}
+
+		if err != nil {
+			log.WithError(err).Error("stream terminated")
+		} else {
+			log.Info("stream terminated")
+		}
+
+		return err
+	}
+
+	// Receive requests in the background, so that we can wait for
+	// requests and resource changes at the same time.
+	requests, errs := receive(ctx, func() (interface{}, error) {
+		return st.Recv()
+	})
+
+	for {
+		// Wait for a request, or a change to any of the
+		// resources that Envoy has subscribed to.
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(requests)},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(errs)},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		}
+		for _, w := range watches {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(w.ch)})
+		}
+
+		chosen, recv, _ := reflect.Select(cases)
+		switch chosen {
+		case 0:
+			req := recv.Interface().(*envoy_api_v2.DiscoveryRequest)
+
+			// note: redeclare log in this scope so the next time around the loop all is forgotten.
+			log := log.WithField("version_info", req.VersionInfo).WithField("response_nonce", req.ResponseNonce)
+			if req.Node != nil {
+				nodeID = req.Node.Id
+				log = log.WithField("node_id", req.Node.Id)
+			}
+
+			r, ok := s.resources[req.TypeUrl]
+			if !ok {
+				return done(log, fmt.Errorf("no resource registered for typeURL %q", req.TypeUrl))
+			}
+
+			// A rejection applies to every resource of the type
+			// multiplexed on the stream, so don't record the
+			// requested names against it.
+			s.recordResult(log, nodeID, req.TypeUrl, nil, req.ResponseNonce, req.ErrorDetail)
+
+			log.WithField("resource_names", req.ResourceNames).WithField("type_url", req.TypeUrl).Info("stream_wait")
+
+			w := findWatch(watches, req.TypeUrl)
+			if w == nil {
+				w = &adsWatch{typeURL: req.TypeUrl, resource: r, version: -1, sent: -1}
+				watches = append(watches, w)
+				sort.SliceStable(watches, func(i, j int) bool {
+					return adsRank(watches[i].typeURL) < adsRank(watches[j].typeURL)
+				})
+			}
+
+			w.waiting = true
+			w.names = req.ResourceNames
+
+			// Register for changes the first time we see
+			// this type, or if Envoy is interested in a
+			// different set of resources.
+			if w.ch == nil || !sameNames(w.names, w.registeredNames) {
+				w.register()
+			}
+
+		case 1:
+			return done(log, recv.Interface().(error))
+
+		case 2:
+			return done(log, ctx.Err())
+
+		default:
+			watches[chosen-3].notified(int(recv.Int()))
+		}
+
+		// Collect any other changes that have happened, so
+		// that we don't send a later type before an earlier
+		// one that changed at the same time.
+		for _, w := range watches {
+			select {
+			case version := <-w.ch:
+				w.notified(version)
+			default:
+			}
+		}
+
+		if err := sendAggregated(st, watches); err != nil {
+			return done(log, err)
+		}
+	}
+}
+
+// receive calls recv in the background until it fails or ctx is
+// canceled, and returns a channel of the values received and a
+// channel of the error that stopped it.
+func receive(ctx context.Context, recv func() (interface{}, error)) (<-chan interface{}, <-chan error) {
+	values := make(chan interface{})
+	errs := make(chan error, 1)
+
+	go func() {
+		for {
+			v, err := recv()
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			select {
+			case values <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return values, errs
+}
+
+// findWatch returns the watch for typeURL, or nil if there isn't one.
+func findWatch(watches []*adsWatch, typeURL string) *adsWatch {
+	for _, w := range watches {
+		if w.typeURL == typeURL {
+			return w
+		}
+	}
+
+	return nil
+}
+
+// sendAggregated sends any changed resources that Envoy is waiting
+// for, in order. If a changed type can't be sent because Envoy
+// hasn't acknowledged its last update yet, the types that come after
+// it are held back.
+func sendAggregated(st grpcStream, watches []*adsWatch) error {
+	for _, w := range watches {
+		if !w.changed() {
+			continue
+		}
+
+		if !w.waiting {
+			return nil
+		}
+
+		resp, err := response(w.resource, w.version, w.names, useADS)
+		if err != nil {
+			return err
+		}
+
+		if err := st.Send(resp); err != nil {
+			return err
+		}
+
+		w.waiting = false
+		w.sent = w.version
+		w.sentNames = w.names
+	}
+
+	return nil
+}
+
+// sameNames returns true if a and b hold the same set of names.
+func sameNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	set := make(map[string]int, len(a))
+	for _, name := range a {
+		set[name]++
+	}
+
+	for _, name := range b {
+		if set[name] == 0 {
+			return false
+		}
+		set[name]--
+	}
+
+	return true
+}
+
+// useADS returns a copy of msg with each of its gRPC configuration
+// sources replaced with the ADS configuration source, so that Envoy
+// fetches the resources that msg refers to over the same stream.
+func useADS(msg protov1.Message) (protov1.Message, error) {
+	return rewriteConfigSources(msg, func(source protoreflect.Message) {
+		fields := source.Descriptor().Fields()
+		if api := fields.ByName("api_config_source"); source.Has(api) {
+			ads := fields.ByName("ads")
+			source.Set(ads, source.NewField(ads))
+		}
+	})
+}
+
+// rewriteConfigSources returns a copy of msg with rewrite applied to
+// each of its configuration sources, including those embedded in
+// typed configuration.
+func rewriteConfigSources(msg protov1.Message, rewrite func(protoreflect.Message)) (protov1.Message, error) {
+	out := protov1.Clone(msg)
+	if err := rewriteMessage(protov1.MessageReflect(out), rewrite); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func rewriteMessage(m protoreflect.Message, rewrite func(protoreflect.Message)) error {
+	switch m.Descriptor().FullName() {
+	case "envoy.api.v2.core.ConfigSource", "envoy.config.core.v3.ConfigSource":
+		rewrite(m)
+		return nil
+	case "google.protobuf.Any":
+		return rewriteAny(m.Interface().(*any.Any), rewrite)
+	}
+
+	var err error
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsList() && fd.Message() != nil:
+			l := v.List()
+			for i := 0; i < l.Len() && err == nil; i++ {
+				err = rewriteMessage(l.Get(i).Message(), rewrite)
+			}
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			v.Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+				err = rewriteMessage(v.Message(), rewrite)
+				return err == nil
+			})
+		case !fd.IsList() && !fd.IsMap() && fd.Message() != nil:
+			err = rewriteMessage(v.Message(), rewrite)
+		}
+
+		return err == nil
+	})
+
+	return err
+}
+
+// rewriteAny rewrites the configuration sources of the message
+// embedded in a.
+func rewriteAny(a *any.Any, rewrite func(protoreflect.Message)) error {
+	mt, err := protoregistry.GlobalTypes.FindMessageByURL(a.GetTypeUrl())
+	if err != nil {
+		return fmt.Errorf("failed to resolve %q: %w", a.GetTypeUrl(), err)
+	}
+
+	msg := mt.New().Interface()
+	if err := proto.Unmarshal(a.GetValue(), msg); err != nil {
+		return err
+	}
+
+	if err := rewriteMessage(msg.ProtoReflect(), rewrite); err != nil {
+		return err
+	}
+
+	buf, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	a.Value = buf
+	return nil
+}
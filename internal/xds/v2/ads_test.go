@@ -0,0 +1,248 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	envoy_api_v2_listener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	http "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v2"
+	"github.com/golang/protobuf/proto"
+	"github.com/projectcontour/contour/internal/contour"
+	"github.com/projectcontour/contour/internal/protobuf"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUseADS(t *testing.T) {
+	grpcSource := &envoy_api_v2_core.ConfigSource{
+		ConfigSourceSpecifier: &envoy_api_v2_core.ConfigSource_ApiConfigSource{
+			ApiConfigSource: &envoy_api_v2_core.ApiConfigSource{
+				ApiType: envoy_api_v2_core.ApiConfigSource_GRPC,
+				GrpcServices: []*envoy_api_v2_core.GrpcService{{
+					TargetSpecifier: &envoy_api_v2_core.GrpcService_EnvoyGrpc_{
+						EnvoyGrpc: &envoy_api_v2_core.GrpcService_EnvoyGrpc{
+							ClusterName: "contour",
+						},
+					},
+				}},
+			},
+		},
+	}
+
+	adsSource := &envoy_api_v2_core.ConfigSource{
+		ConfigSourceSpecifier: &envoy_api_v2_core.ConfigSource_Ads{
+			Ads: &envoy_api_v2_core.AggregatedConfigSource{},
+		},
+	}
+
+	pathSource := &envoy_api_v2_core.ConfigSource{
+		ConfigSourceSpecifier: &envoy_api_v2_core.ConfigSource_Path{
+			Path: "/config/sds.json",
+		},
+	}
+
+	listener := func(source *envoy_api_v2_core.ConfigSource) *envoy_api_v2.Listener {
+		return &envoy_api_v2.Listener{
+			Name: "ingress_http",
+			FilterChains: []*envoy_api_v2_listener.FilterChain{{
+				Filters: []*envoy_api_v2_listener.Filter{{
+					Name: "envoy.filters.network.http_connection_manager",
+					ConfigType: &envoy_api_v2_listener.Filter_TypedConfig{
+						TypedConfig: protobuf.MustMarshalAny(&http.HttpConnectionManager{
+							RouteSpecifier: &http.HttpConnectionManager_Rds{
+								Rds: &http.Rds{
+									RouteConfigName: "ingress_http",
+									ConfigSource:    source,
+								},
+							},
+						}),
+					},
+				}},
+			}},
+		}
+	}
+
+	cluster := func(source *envoy_api_v2_core.ConfigSource) *envoy_api_v2.Cluster {
+		return &envoy_api_v2.Cluster{
+			Name: "default/kuard/80",
+			EdsClusterConfig: &envoy_api_v2.Cluster_EdsClusterConfig{
+				EdsConfig:   source,
+				ServiceName: "default/kuard",
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		msg  proto.Message
+		want proto.Message
+	}{
+		"cluster": {
+			msg:  cluster(grpcSource),
+			want: cluster(adsSource),
+		},
+		"cluster with path config source": {
+			msg:  cluster(pathSource),
+			want: cluster(pathSource),
+		},
+		"embedded config source": {
+			msg:  listener(grpcSource),
+			want: listener(adsSource),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			orig := proto.Clone(tc.msg)
+
+			got, err := useADS(tc.msg)
+			require.NoError(t, err)
+			protobuf.ExpectEqual(t, tc.want, got)
+
+			// The original message must not be modified.
+			protobuf.ExpectEqual(t, orig, tc.msg)
+		})
+	}
+}
+
+func TestStreamAggregated(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	clusters := &fakeResource{typeURL: resource.ClusterType}
+	routes := &fakeResource{typeURL: resource.RouteType}
+	listeners := &fakeResource{typeURL: resource.ListenerType}
+
+	srv := NewContourServer(log, nil, clusters, routes, listeners).(*contourServer)
+
+	st := &adsStream{
+		ctx:       context.Background(),
+		requests:  make(chan *envoy_api_v2.DiscoveryRequest),
+		responses: make(chan *envoy_api_v2.DiscoveryResponse, 10),
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- srv.streamAggregated(st)
+	}()
+
+	request := func(typeURL string, nonce string, names ...string) {
+		st.requests <- &envoy_api_v2.DiscoveryRequest{
+			Node:          &envoy_api_v2_core.Node{Id: "envoy"},
+			TypeUrl:       typeURL,
+			ResponseNonce: nonce,
+			ResourceNames: names,
+		}
+	}
+
+	expect := func(typeURL string, version string) {
+		t.Helper()
+
+		select {
+		case resp := <-st.responses:
+			assert.Equal(t, typeURL, resp.TypeUrl)
+			assert.Equal(t, version, resp.VersionInfo)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s response", typeURL)
+		}
+	}
+
+	expectNothing := func() {
+		t.Helper()
+
+		select {
+		case resp := <-st.responses:
+			t.Fatalf("unexpected %s response", resp.TypeUrl)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	// Each type is answered as soon as Envoy asks for it.
+	request(resource.ClusterType, "")
+	expect(resource.ClusterType, "0")
+	request(resource.ListenerType, "")
+	expect(resource.ListenerType, "0")
+	request(resource.RouteType, "", "ingress_http")
+	expect(resource.RouteType, "0")
+
+	// Nothing is sent until something changes.
+	request(resource.ClusterType, "0")
+	request(resource.ListenerType, "0")
+	request(resource.RouteType, "0", "ingress_http")
+	expectNothing()
+
+	// Clusters are sent before routes.
+	clusters.Notify()
+	routes.Notify()
+	expect(resource.ClusterType, "1")
+	expect(resource.RouteType, "1")
+
+	// Routes are held back until Envoy acknowledges the last
+	// cluster update, so that the new clusters are sent first.
+	request(resource.RouteType, "1", "ingress_http")
+	clusters.Notify()
+	routes.Notify()
+	expectNothing()
+
+	request(resource.ClusterType, "1")
+	expect(resource.ClusterType, "2")
+	expect(resource.RouteType, "2")
+
+	// Asking for different resources gets an immediate response.
+	request(resource.RouteType, "2", "ingress_http", "https/example.com")
+	expect(resource.RouteType, "2")
+
+	close(st.requests)
+	assert.Equal(t, io.EOF, <-done)
+}
+
+type adsStream struct {
+	ctx       context.Context
+	requests  chan *envoy_api_v2.DiscoveryRequest
+	responses chan *envoy_api_v2.DiscoveryResponse
+}
+
+func (s *adsStream) Context() context.Context { return s.ctx }
+
+func (s *adsStream) Send(resp *envoy_api_v2.DiscoveryResponse) error {
+	s.responses <- resp
+	return nil
+}
+
+func (s *adsStream) Recv() (*envoy_api_v2.DiscoveryRequest, error) {
+	req, ok := <-s.requests
+	if !ok {
+		return nil, io.EOF
+	}
+
+	return req, nil
+}
+
+// fakeResource is a resource of the given type with no contents.
+type fakeResource struct {
+	contour.Cond
+	typeURL string
+}
+
+func (f *fakeResource) Contents() []proto.Message            { return nil }
+func (f *fakeResource) Query(names []string) []proto.Message { return nil }
+func (f *fakeResource) TypeURL() string                      { return f.typeURL }
@@ -26,6 +26,7 @@ import (
 	"github.com/golang/protobuf/ptypes/any"
 	"github.com/projectcontour/contour/internal/xds"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/status"
 )
 
 type grpcStream interface {
@@ -44,11 +45,14 @@ func (c *counter) next() uint64 {
 var connections counter
 
 // NewContourServer creates an internally implemented Server that streams the
-// provided set of Resource objects. The returned Server implements the xDS
-// State of the World (SotW) variant.
-func NewContourServer(log logrus.FieldLogger, resources ...xds.Resource) Server {
+// provided set of Resource objects. The returned Server implements both the
+// xDS State of the World (SotW) and incremental variants; the incremental
+// variant requires each of the resources to be an xds.DeltaResource. Updates
+// that are rejected by Envoy are recorded in nacks, which may be nil.
+func NewContourServer(log logrus.FieldLogger, nacks *xds.NackRecorder, resources ...xds.Resource) Server {
 	c := contourServer{
 		FieldLogger: log,
+		nacks:       nacks,
 		resources:   map[string]xds.Resource{},
 	}
 
@@ -60,9 +64,9 @@ func NewContourServer(log logrus.FieldLogger, resources ...xds.Resource) Server
 }
 
 type contourServer struct {
-	// Since we only implement the streaming state of the world
-	// protocol, embed the default null implementations to handle
-	// the unimplemented gRPC endpoints.
+	// Since we only implement the streaming protocols, embed the
+	// default null implementations to handle the unimplemented
+	// gRPC fetch endpoints.
 	discovery.UnimplementedAggregatedDiscoveryServiceServer
 	discovery.UnimplementedSecretDiscoveryServiceServer
 	envoy_api_v2.UnimplementedRouteDiscoveryServiceServer
@@ -71,6 +75,7 @@ type contourServer struct {
 	envoy_api_v2.UnimplementedListenerDiscoveryServiceServer
 
 	logrus.FieldLogger
+	nacks     *xds.NackRecorder
 	resources map[string]xds.Resource
 }
 
@@ -79,8 +84,18 @@ func (s *contourServer) stream(st grpcStream) error {
 	// Bump connection counter and set it as a field on the logger.
 	log := s.WithField("connection", connections.next())
 
+	// Track the node and the resources streamed to it, so
+	// that we can forget about any updates the node rejected
+	// once it goes away.
+	var nodeID string
+	requested := map[string][]string{}
+
 	// Notify whether the stream terminated on error.
 	done := func(log *logrus.Entry, err error) error {
+		for typeURL, names := range requested {
+			s.nacks.Clear(nodeID, typeURL, names...)
+		}
+
 		if err != nil {
 			log.WithError(err).Error("stream terminated")
 		} else {
@@ -110,14 +125,13 @@ func (s *contourServer) stream(st grpcStream) error {
 		// note: redeclare log in this scope so the next time around the loop all is forgotten.
 		log := log.WithField("version_info", req.VersionInfo).WithField("response_nonce", req.ResponseNonce)
 		if req.Node != nil {
-			log = log.WithField("node_id", req.Node.Id).WithField("node_version", fmt.Sprintf("v%d.%d.%d", req.Node.GetUserAgentBuildVersion().Version.MajorNumber, req.Node.GetUserAgentBuildVersion().Version.MinorNumber, req.Node.GetUserAgentBuildVersion().Version.Patch))
+			nodeID = req.Node.Id
+			log = log.WithField("node_id", req.Node.Id).WithField("node_version", fmt.Sprintf("v%d.%d.%d", req.Node.GetUserAgentBuildVersion().GetVersion().GetMajorNumber(), req.Node.GetUserAgentBuildVersion().GetVersion().GetMinorNumber(), req.Node.GetUserAgentBuildVersion().GetVersion().GetPatch()))
 		}
 
-		if status := req.ErrorDetail; status != nil {
-			// if Envoy rejected the last update log the details here.
-			// TODO(dfc) issue 1176: handle xDS ACK/NACK
-			log.WithField("code", status.Code).Error(status.Message)
-		}
+		requested[req.TypeUrl] = req.ResourceNames
+
+		s.recordResult(log, nodeID, req.TypeUrl, req.ResourceNames, req.ResponseNonce, req.ErrorDetail)
 
 		// from the request we derive the resource to stream which have
 		// been registered according to the typeURL.
@@ -138,32 +152,9 @@ func (s *contourServer) stream(st grpcStream) error {
 			// TODO(dfc) the thing that has changed may not be in the scope of the filter
 			// so we're going to be sending an update that is a no-op. See #426
 
-			var resources []proto.Message
-			switch len(req.ResourceNames) {
-			case 0:
-				// no resource hints supplied, return the full
-				// contents of the resource
-				resources = r.Contents()
-			default:
-				// resource hints supplied, return exactly those
-				resources = r.Query(req.ResourceNames)
-			}
-
-			any := make([]*any.Any, 0, len(resources))
-			for _, r := range resources {
-				a, err := ptypes.MarshalAny(r)
-				if err != nil {
-					return done(log, err)
-				}
-
-				any = append(any, a)
-			}
-
-			resp := &envoy_api_v2.DiscoveryResponse{
-				VersionInfo: strconv.Itoa(last),
-				Resources:   any,
-				TypeUrl:     r.TypeURL(),
-				Nonce:       strconv.Itoa(last),
+			resp, err := response(r, last, req.ResourceNames, nil)
+			if err != nil {
+				return done(log, err)
 			}
 
 			if err := st.Send(resp); err != nil {
@@ -176,6 +167,71 @@ func (s *contourServer) stream(st grpcStream) error {
 	}
 }
 
+// recordResult records whether Envoy accepted or rejected the
+// response with the given nonce.
+func (s *contourServer) recordResult(log *logrus.Entry, nodeID string, typeURL string, resourceNames []string, nonce string, errorDetail *status.Status) {
+	if errorDetail != nil {
+		// if Envoy rejected the last update log the details here.
+		log.WithField("code", errorDetail.Code).Error(errorDetail.Message)
+		s.nacks.Nack(xds.Nack{
+			NodeID:        nodeID,
+			TypeURL:       typeURL,
+			Nonce:         nonce,
+			Message:       errorDetail.Message,
+			ResourceNames: resourceNames,
+		})
+	} else if nonce != "" {
+		// a request that carries the nonce of the last
+		// response without an error acknowledges it.
+		s.nacks.Clear(nodeID, typeURL, resourceNames...)
+	}
+}
+
+// response builds a DiscoveryResponse for the given version of the
+// named resources in r. If no names are given, the response holds the
+// full contents of r. If transform is not nil, it is applied to each
+// of the resources before they are marshaled.
+func response(r xds.Resource, version int, names []string, transform func(proto.Message) (proto.Message, error)) (*envoy_api_v2.DiscoveryResponse, error) {
+	var resources []proto.Message
+	switch len(names) {
+	case 0:
+		// no resource hints supplied, return the full
+		// contents of the resource
+		resources = r.Contents()
+	default:
+		// resource hints supplied, return exactly those
+		resources = r.Query(names)
+	}
+
+	any := make([]*any.Any, 0, len(resources))
+	for _, r := range resources {
+		if transform != nil {
+			var err error
+			if r, err = transform(r); err != nil {
+				return nil, err
+			}
+		}
+
+		a, err := ptypes.MarshalAny(r)
+		if err != nil {
+			return nil, err
+		}
+
+		any = append(any, a)
+	}
+
+	return &envoy_api_v2.DiscoveryResponse{
+		VersionInfo: strconv.Itoa(version),
+		Resources:   any,
+		TypeUrl:     r.TypeURL(),
+		Nonce:       strconv.Itoa(version),
+	}, nil
+}
+
+func (s *contourServer) StreamAggregatedResources(srv discovery.AggregatedDiscoveryService_StreamAggregatedResourcesServer) error {
+	return s.streamAggregated(srv)
+}
+
 func (s *contourServer) StreamClusters(srv envoy_api_v2.ClusterDiscoveryService_StreamClustersServer) error {
 	return s.stream(srv)
 }
@@ -195,3 +251,27 @@ func (s *contourServer) StreamRoutes(srv envoy_api_v2.RouteDiscoveryService_Stre
 func (s *contourServer) StreamSecrets(srv discovery.SecretDiscoveryService_StreamSecretsServer) error {
 	return s.stream(srv)
 }
+
+func (s *contourServer) DeltaAggregatedResources(srv discovery.AggregatedDiscoveryService_DeltaAggregatedResourcesServer) error {
+	return s.streamDelta(srv, useADS)
+}
+
+func (s *contourServer) DeltaClusters(srv envoy_api_v2.ClusterDiscoveryService_DeltaClustersServer) error {
+	return s.streamDelta(srv, useDelta)
+}
+
+func (s *contourServer) DeltaEndpoints(srv envoy_api_v2.EndpointDiscoveryService_DeltaEndpointsServer) error {
+	return s.streamDelta(srv, useDelta)
+}
+
+func (s *contourServer) DeltaListeners(srv envoy_api_v2.ListenerDiscoveryService_DeltaListenersServer) error {
+	return s.streamDelta(srv, useDelta)
+}
+
+func (s *contourServer) DeltaRoutes(srv envoy_api_v2.RouteDiscoveryService_DeltaRoutesServer) error {
+	return s.streamDelta(srv, useDelta)
+}
+
+func (s *contourServer) DeltaSecrets(srv discovery.SecretDiscoveryService_DeltaSecretsServer) error {
+	return s.streamDelta(srv, useDelta)
+}
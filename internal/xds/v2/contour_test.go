@@ -21,10 +21,12 @@ import (
 	"testing"
 
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	"github.com/golang/protobuf/proto"
 	"github.com/projectcontour/contour/internal/xds"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/status"
 )
 
 func TestXDSHandlerStream(t *testing.T) {
@@ -150,6 +152,97 @@ func TestXDSHandlerStream(t *testing.T) {
 	}
 }
 
+func TestXDSHandlerStreamNacks(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	nack := xds.Nack{
+		NodeID:  "envoy-1",
+		TypeURL: "io.projectcontour.potato",
+		Nonce:   "1",
+		Message: "potatoes are not allowed",
+	}
+
+	requests := []*envoy_api_v2.DiscoveryRequest{{
+		Node:    &envoy_api_v2_core.Node{Id: "envoy-1"},
+		TypeUrl: "io.projectcontour.potato",
+	}, {
+		// Rejects the first response.
+		Node:          &envoy_api_v2_core.Node{Id: "envoy-1"},
+		TypeUrl:       "io.projectcontour.potato",
+		ResponseNonce: "1",
+		ErrorDetail:   &status.Status{Code: 3, Message: nack.Message},
+	}, {
+		// Rejects the second response with the same error.
+		Node:          &envoy_api_v2_core.Node{Id: "envoy-1"},
+		TypeUrl:       "io.projectcontour.potato",
+		ResponseNonce: "1",
+		ErrorDetail:   &status.Status{Code: 3, Message: nack.Message},
+	}, {
+		// Accepts the third response.
+		Node:          &envoy_api_v2_core.Node{Id: "envoy-1"},
+		TypeUrl:       "io.projectcontour.potato",
+		VersionInfo:   "1",
+		ResponseNonce: "1",
+	}, {
+		// Rejects the fourth response.
+		Node:          &envoy_api_v2_core.Node{Id: "envoy-1"},
+		TypeUrl:       "io.projectcontour.potato",
+		ResponseNonce: "1",
+		ErrorDetail:   &status.Status{Code: 3, Message: nack.Message},
+	}}
+
+	nacks := &xds.NackRecorder{}
+
+	var changes [][]xds.Nack
+	nacks.OnChange = func() {
+		changes = append(changes, nacks.Nacks())
+	}
+
+	xh := contourServer{
+		FieldLogger: log,
+		nacks:       nacks,
+		resources: map[string]xds.Resource{
+			"io.projectcontour.potato": &mockResource{
+				register: func(ch chan int, i int) {
+					ch <- 1
+				},
+				contents: func() []proto.Message {
+					return nil
+				},
+				typeurl: func() string { return "io.projectcontour.potato" },
+			},
+		},
+	}
+
+	stream := &mockStream{
+		context: context.Background,
+		recv: func() (*envoy_api_v2.DiscoveryRequest, error) {
+			if len(requests) == 0 {
+				return nil, io.EOF
+			}
+
+			req := requests[0]
+			requests = requests[1:]
+			return req, nil
+		},
+		send: func(resp *envoy_api_v2.DiscoveryResponse) error {
+			return nil
+		},
+	}
+
+	assert.Equal(t, io.EOF, xh.stream(stream))
+
+	// The repeated rejection doesn't change anything, and the
+	// final rejection is forgotten when the stream terminates.
+	assert.Equal(t, [][]xds.Nack{
+		{nack},
+		{},
+		{nack},
+		{},
+	}, changes)
+}
+
 type mockStream struct {
 	context func() context.Context
 	send    func(*envoy_api_v2.DiscoveryResponse) error
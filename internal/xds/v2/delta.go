@@ -0,0 +1,392 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	cache_v2 "github.com/envoyproxy/go-control-plane/pkg/cache/v2"
+	cache_v3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/projectcontour/contour/internal/xds"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type deltaStream interface {
+	Context() context.Context
+	Send(*envoy_api_v2.DeltaDiscoveryResponse) error
+	Recv() (*envoy_api_v2.DeltaDiscoveryRequest, error)
+}
+
+// placeholderVersion is the version of the blank entries that some
+// resources return for names that aren't in the cache. The versions
+// of cache entries start at 1, so it never matches a real entry.
+const placeholderVersion = "0"
+
+// deltaWatch tracks the state of a single resource type on an
+// incremental xDS stream.
+type deltaWatch struct {
+	typeURL  string
+	resource xds.DeltaResource
+
+	// ch is registered with the resource to receive its version
+	// when it changes.
+	ch chan int
+
+	// version is the latest version of the resource.
+	version int
+
+	// wildcard is true if Envoy has subscribed to every entry of
+	// the resource. Otherwise, subscribed holds the names of the
+	// entries that Envoy has subscribed to.
+	wildcard   bool
+	subscribed map[string]bool
+
+	// sent holds the version of each entry that Envoy has.
+	sent map[string]string
+
+	// pending is true if the entries that Envoy has may be out
+	// of date, and responded is true once the first response of
+	// this type has been sent.
+	pending, responded bool
+}
+
+// register registers for changes to the resource.
+func (w *deltaWatch) register() {
+	w.resource.Register(w.ch, w.version)
+}
+
+// notified records that the resource changed to the given version.
+func (w *deltaWatch) notified(version int) {
+	if version > w.version {
+		w.version = version
+	}
+
+	w.pending = true
+
+	// Registrations only fire once, so renew it.
+	w.register()
+}
+
+// subscribe updates the entries that Envoy has subscribed to.
+func (w *deltaWatch) subscribe(req *envoy_api_v2.DeltaDiscoveryRequest) {
+	for _, name := range req.ResourceNamesSubscribe {
+		if name == "*" {
+			w.wildcard = true
+			w.pending = true
+			continue
+		}
+
+		if !w.subscribed[name] {
+			w.subscribed[name] = true
+			w.pending = true
+		}
+	}
+
+	for _, name := range req.ResourceNamesUnsubscribe {
+		// Envoy forgets about the entries that it
+		// unsubscribes from, so they don't need to be
+		// removed.
+		delete(w.subscribed, name)
+		delete(w.sent, name)
+	}
+}
+
+// response returns a response that brings Envoy's copy of the
+// resource up to date, or nil if it is already up to date. The
+// response holds each entry whose version differs from the version
+// Envoy has, and the names of the entries Envoy has that have gone.
+// If transform is not nil, it is applied to each of the entries
+// before they are marshaled.
+func (w *deltaWatch) response(nonce int, transform func(proto.Message) (proto.Message, error)) (*envoy_api_v2.DeltaDiscoveryResponse, error) {
+	w.pending = false
+
+	versions := w.resource.Versions()
+
+	var names []string
+	for name := range w.subscribed {
+		names = append(names, name)
+	}
+	if w.wildcard {
+		for name := range versions {
+			if !w.subscribed[name] {
+				names = append(names, name)
+			}
+		}
+	}
+
+	resp := &envoy_api_v2.DeltaDiscoveryResponse{
+		SystemVersionInfo: strconv.Itoa(w.version),
+		TypeUrl:           w.typeURL,
+		Nonce:             strconv.Itoa(nonce),
+	}
+
+	current := make(map[string]bool, len(names))
+	for _, msg := range w.resource.Query(names) {
+		name := resourceName(msg)
+		current[name] = true
+
+		version, ok := versions[name]
+		if !ok {
+			version = placeholderVersion
+		}
+
+		if w.sent[name] == version {
+			continue
+		}
+
+		if transform != nil {
+			var err error
+			if msg, err = transform(msg); err != nil {
+				return nil, err
+			}
+		}
+
+		a, err := ptypes.MarshalAny(msg)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Resources = append(resp.Resources, &envoy_api_v2.Resource{
+			Name:     name,
+			Version:  version,
+			Resource: a,
+		})
+		w.sent[name] = version
+	}
+
+	for name := range w.sent {
+		if !current[name] {
+			resp.RemovedResources = append(resp.RemovedResources, name)
+			delete(w.sent, name)
+		}
+	}
+	sort.Strings(resp.RemovedResources)
+
+	// Always answer the first request, so that Envoy isn't left
+	// waiting for a resource type that has no entries.
+	if len(resp.Resources) == 0 && len(resp.RemovedResources) == 0 && w.responded {
+		return nil, nil
+	}
+
+	w.responded = true
+	return resp, nil
+}
+
+// streamDelta processes a stream of incremental DiscoveryRequests.
+// Each time a resource changes, Envoy is sent only the entries it has
+// subscribed to that were added, changed or removed. If the stream
+// carries more than one resource type, the updates are sent in the
+// order given by adsOrder.
+//
+// If transform is not nil, it is applied to each of the entries before
+// they are sent.
+func (s *contourServer) streamDelta(st deltaStream, transform func(proto.Message) (proto.Message, error)) error {
+	// Bump connection counter and set it as a field on the logger.
+	log := s.WithField("connection", connections.next()).WithField("delta", true)
+
+	ctx, cancel := context.WithCancel(st.Context())
+	defer cancel()
+
+	var nodeID string
+
+	// watches holds the resource types Envoy has subscribed
+	// to, in the order their updates must be sent.
+	var watches []*deltaWatch
+
+	// nonce identifies each response on this stream.
+	var nonce int
+
+	// Notify whether the stream terminated on error.
+	done := func(log *logrus.Entry, err error) error {
+		for _, w := range watches {
+			s.nacks.Clear(nodeID, w.typeURL)
+		}
+
+		if err != nil {
+			log.WithError(err).Error("stream terminated")
+		} else {
+			log.Info("stream terminated")
+		}
+
+		return err
+	}
+
+	// Receive requests in the background, so that we can wait for
+	// requests and resource changes at the same time.
+	requests, errs := receive(ctx, func() (interface{}, error) {
+		return st.Recv()
+	})
+
+	for {
+		// Wait for a request, or a change to any of the
+		// resources that Envoy has subscribed to.
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(requests)},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(errs)},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		}
+		for _, w := range watches {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(w.ch)})
+		}
+
+		chosen, recv, _ := reflect.Select(cases)
+		switch chosen {
+		case 0:
+			req := recv.Interface().(*envoy_api_v2.DeltaDiscoveryRequest)
+
+			// note: redeclare log in this scope so the next time around the loop all is forgotten.
+			log := log.WithField("response_nonce", req.ResponseNonce)
+			if req.Node != nil {
+				nodeID = req.Node.Id
+				log = log.WithField("node_id", req.Node.Id)
+			}
+
+			r, ok := s.resources[req.TypeUrl]
+			if !ok {
+				return done(log, fmt.Errorf("no resource registered for typeURL %q", req.TypeUrl))
+			}
+
+			dr, ok := r.(xds.DeltaResource)
+			if !ok {
+				return done(log, fmt.Errorf("resource registered for typeURL %q does not support incremental xDS", req.TypeUrl))
+			}
+
+			// Incremental requests only name the changes to the
+			// subscription, so don't record them against a rejection.
+			s.recordResult(log, nodeID, req.TypeUrl, nil, req.ResponseNonce, req.ErrorDetail)
+
+			log.WithField("subscribe", req.ResourceNamesSubscribe).
+				WithField("unsubscribe", req.ResourceNamesUnsubscribe).
+				WithField("type_url", req.TypeUrl).Info("stream_wait")
+
+			w := findDeltaWatch(watches, req.TypeUrl)
+			if w == nil {
+				w = &deltaWatch{
+					typeURL:    req.TypeUrl,
+					resource:   dr,
+					ch:         make(chan int, 1),
+					version:    -1,
+					wildcard:   len(req.ResourceNamesSubscribe) == 0,
+					subscribed: map[string]bool{},
+					sent:       map[string]string{},
+					pending:    true,
+				}
+
+				// Envoy tells us which entries it already
+				// has when it reconnects, so that they don't
+				// need to be sent again.
+				for name, version := range req.InitialResourceVersions {
+					w.sent[name] = version
+				}
+
+				watches = append(watches, w)
+				sort.SliceStable(watches, func(i, j int) bool {
+					return adsRank(watches[i].typeURL) < adsRank(watches[j].typeURL)
+				})
+
+				w.register()
+			}
+
+			w.subscribe(req)
+
+		case 1:
+			return done(log, recv.Interface().(error))
+
+		case 2:
+			return done(log, ctx.Err())
+
+		default:
+			watches[chosen-3].notified(int(recv.Int()))
+		}
+
+		// Collect any other changes that have happened, so
+		// that we don't send a later type before an earlier
+		// one that changed at the same time.
+		for _, w := range watches {
+			select {
+			case version := <-w.ch:
+				w.notified(version)
+			default:
+			}
+		}
+
+		for _, w := range watches {
+			if !w.pending {
+				continue
+			}
+
+			resp, err := w.response(nonce+1, transform)
+			if err != nil {
+				return done(log, err)
+			}
+
+			if resp == nil {
+				continue
+			}
+
+			if err := st.Send(resp); err != nil {
+				return done(log, err)
+			}
+
+			nonce++
+		}
+	}
+}
+
+// findDeltaWatch returns the watch for typeURL, or nil if there isn't one.
+func findDeltaWatch(watches []*deltaWatch, typeURL string) *deltaWatch {
+	for _, w := range watches {
+		if w.typeURL == typeURL {
+			return w
+		}
+	}
+
+	return nil
+}
+
+// resourceName returns the name of a v2 or v3 xDS resource.
+func resourceName(msg proto.Message) string {
+	if name := cache_v2.GetResourceName(msg); name != "" {
+		return name
+	}
+
+	return cache_v3.GetResourceName(msg)
+}
+
+// useDelta returns a copy of msg with each of its gRPC configuration
+// sources changed to use the incremental xDS protocol, so that Envoy
+// fetches the resources that msg refers to in the same way as msg.
+func useDelta(msg proto.Message) (proto.Message, error) {
+	return rewriteConfigSources(msg, func(source protoreflect.Message) {
+		api := source.Descriptor().Fields().ByName("api_config_source")
+		if !source.Has(api) {
+			return
+		}
+
+		apiSource := source.Mutable(api).Message()
+		apiType := apiSource.Descriptor().Fields().ByName("api_type")
+		if apiSource.Get(apiType).Enum() == envoy_api_v2_core.ApiConfigSource_GRPC.Number() {
+			apiSource.Set(apiType, protoreflect.ValueOfEnum(envoy_api_v2_core.ApiConfigSource_DELTA_GRPC.Number()))
+		}
+	})
+}
@@ -0,0 +1,265 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v2"
+	"github.com/golang/protobuf/proto"
+	"github.com/projectcontour/contour/internal/protobuf"
+	"github.com/projectcontour/contour/internal/xds"
+	xdscache_v2 "github.com/projectcontour/contour/internal/xdscache/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUseDelta(t *testing.T) {
+	source := func(apiType envoy_api_v2_core.ApiConfigSource_ApiType) *envoy_api_v2_core.ConfigSource {
+		return &envoy_api_v2_core.ConfigSource{
+			ConfigSourceSpecifier: &envoy_api_v2_core.ConfigSource_ApiConfigSource{
+				ApiConfigSource: &envoy_api_v2_core.ApiConfigSource{
+					ApiType: apiType,
+					GrpcServices: []*envoy_api_v2_core.GrpcService{{
+						TargetSpecifier: &envoy_api_v2_core.GrpcService_EnvoyGrpc_{
+							EnvoyGrpc: &envoy_api_v2_core.GrpcService_EnvoyGrpc{
+								ClusterName: "contour",
+							},
+						},
+					}},
+				},
+			},
+		}
+	}
+
+	cluster := func(source *envoy_api_v2_core.ConfigSource) *envoy_api_v2.Cluster {
+		return &envoy_api_v2.Cluster{
+			Name: "default/kuard/80",
+			EdsClusterConfig: &envoy_api_v2.Cluster_EdsClusterConfig{
+				EdsConfig:   source,
+				ServiceName: "default/kuard",
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		msg  proto.Message
+		want proto.Message
+	}{
+		"grpc config source": {
+			msg:  cluster(source(envoy_api_v2_core.ApiConfigSource_GRPC)),
+			want: cluster(source(envoy_api_v2_core.ApiConfigSource_DELTA_GRPC)),
+		},
+		"rest config source": {
+			msg:  cluster(source(envoy_api_v2_core.ApiConfigSource_REST)),
+			want: cluster(source(envoy_api_v2_core.ApiConfigSource_REST)),
+		},
+		"no config source": {
+			msg:  cluster(nil),
+			want: cluster(nil),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			orig := proto.Clone(tc.msg)
+
+			got, err := useDelta(tc.msg)
+			require.NoError(t, err)
+			protobuf.ExpectEqual(t, tc.want, got)
+
+			// The original message must not be modified.
+			protobuf.ExpectEqual(t, orig, tc.msg)
+		})
+	}
+}
+
+func TestStreamDelta(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	clusters := &xdscache_v2.ClusterCache{}
+	routes := &xdscache_v2.RouteCache{}
+
+	cluster := func(name string, alt string) *envoy_api_v2.Cluster {
+		return &envoy_api_v2.Cluster{Name: name, AltStatName: alt}
+	}
+
+	clusters.Update(map[string]*envoy_api_v2.Cluster{
+		"a": cluster("a", "a"),
+		"b": cluster("b", "b"),
+	})
+
+	srv := NewContourServer(log, nil, clusters, routes).(*contourServer)
+
+	open := func() (*deltaTestStream, chan error) {
+		st := &deltaTestStream{
+			ctx:       context.Background(),
+			requests:  make(chan *envoy_api_v2.DeltaDiscoveryRequest),
+			responses: make(chan *envoy_api_v2.DeltaDiscoveryResponse, 10),
+		}
+
+		done := make(chan error)
+		go func() {
+			done <- srv.streamDelta(st, nil)
+		}()
+
+		return st, done
+	}
+
+	st, done := open()
+
+	expect := func(typeURL string, resources map[string]string, removed ...string) {
+		t.Helper()
+
+		select {
+		case resp := <-st.responses:
+			assert.Equal(t, typeURL, resp.TypeUrl)
+
+			got := map[string]string{}
+			for _, r := range resp.Resources {
+				got[r.Name] = r.Version
+			}
+			assert.Equal(t, resources, got)
+			assert.Equal(t, removed, resp.RemovedResources)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s response", typeURL)
+		}
+	}
+
+	expectNothing := func() {
+		t.Helper()
+
+		select {
+		case resp := <-st.responses:
+			t.Fatalf("unexpected %s response", resp.TypeUrl)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	// Subscribing to every cluster sends them all.
+	st.requests <- &envoy_api_v2.DeltaDiscoveryRequest{
+		TypeUrl: resource.ClusterType,
+	}
+	expect(resource.ClusterType, map[string]string{"a": "1", "b": "1"})
+
+	// Only the clusters that changed are sent.
+	clusters.Update(map[string]*envoy_api_v2.Cluster{
+		"a": cluster("a", "a"),
+		"b": cluster("b", "bb"),
+		"c": cluster("c", "c"),
+	})
+	expect(resource.ClusterType, map[string]string{"b": "2", "c": "2"})
+
+	clusters.Update(map[string]*envoy_api_v2.Cluster{
+		"a": cluster("a", "a"),
+		"b": cluster("b", "bb"),
+	})
+	expect(resource.ClusterType, map[string]string{}, "c")
+
+	// Nothing is sent if nothing changed.
+	clusters.Update(map[string]*envoy_api_v2.Cluster{
+		"a": cluster("a", "a"),
+		"b": cluster("b", "bb"),
+	})
+	expectNothing()
+
+	// Routes that don't exist yet are sent as placeholders.
+	st.requests <- &envoy_api_v2.DeltaDiscoveryRequest{
+		TypeUrl:                resource.RouteType,
+		ResourceNamesSubscribe: []string{"ingress_http"},
+	}
+	expect(resource.RouteType, map[string]string{"ingress_http": placeholderVersion})
+
+	routes.Update(map[string]*envoy_api_v2.RouteConfiguration{
+		"ingress_http":  {Name: "ingress_http"},
+		"ingress_https": {Name: "ingress_https"},
+	})
+	expect(resource.RouteType, map[string]string{"ingress_http": "1"})
+
+	// Routes that Envoy hasn't subscribed to aren't sent.
+	st.requests <- &envoy_api_v2.DeltaDiscoveryRequest{
+		TypeUrl:                  resource.RouteType,
+		ResourceNamesUnsubscribe: []string{"ingress_http"},
+	}
+	expectNothing()
+
+	routes.Update(map[string]*envoy_api_v2.RouteConfiguration{
+		"ingress_http":  {Name: "ingress_http", ValidateClusters: protobuf.Bool(true)},
+		"ingress_https": {Name: "ingress_https"},
+	})
+	expectNothing()
+
+	close(st.requests)
+	assert.Equal(t, io.EOF, <-done)
+
+	// A reconnecting Envoy is only sent the clusters that it
+	// doesn't already have.
+	st, done = open()
+	st.requests <- &envoy_api_v2.DeltaDiscoveryRequest{
+		TypeUrl: resource.ClusterType,
+		InitialResourceVersions: map[string]string{
+			"a": "1",
+			"b": "1",
+			"c": "2",
+		},
+	}
+	expect(resource.ClusterType, map[string]string{"b": "2"}, "c")
+
+	close(st.requests)
+	assert.Equal(t, io.EOF, <-done)
+}
+
+func TestNoDeltaServer(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	srv := NewNoDeltaServer(log, NewContourServer(log, nil))
+
+	assert.Equal(t, xds.ErrDeltaUnsupported, srv.DeltaAggregatedResources(nil))
+	assert.Equal(t, xds.ErrDeltaUnsupported, srv.DeltaClusters(nil))
+	assert.Equal(t, xds.ErrDeltaUnsupported, srv.DeltaEndpoints(nil))
+	assert.Equal(t, xds.ErrDeltaUnsupported, srv.DeltaListeners(nil))
+	assert.Equal(t, xds.ErrDeltaUnsupported, srv.DeltaRoutes(nil))
+	assert.Equal(t, xds.ErrDeltaUnsupported, srv.DeltaSecrets(nil))
+}
+
+type deltaTestStream struct {
+	ctx       context.Context
+	requests  chan *envoy_api_v2.DeltaDiscoveryRequest
+	responses chan *envoy_api_v2.DeltaDiscoveryResponse
+}
+
+func (s *deltaTestStream) Context() context.Context { return s.ctx }
+
+func (s *deltaTestStream) Send(resp *envoy_api_v2.DeltaDiscoveryResponse) error {
+	s.responses <- resp
+	return nil
+}
+
+func (s *deltaTestStream) Recv() (*envoy_api_v2.DeltaDiscoveryRequest, error) {
+	req, ok := <-s.requests
+	if !ok {
+		return nil, io.EOF
+	}
+
+	return req, nil
+}
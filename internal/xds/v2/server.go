@@ -17,7 +17,9 @@ import (
 	api "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
+	"github.com/projectcontour/contour/internal/xds"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 )
 
@@ -64,3 +66,49 @@ func RegisterServer(srv Server, registry *prometheus.Registry, opts ...grpc.Serv
 
 	return g
 }
+
+// NewNoDeltaServer returns a Server that serves the state of the world
+// xDS protocol with srv, and rejects incremental xDS streams with
+// xds.ErrDeltaUnsupported. It wraps servers that don't implement the
+// incremental protocol, so that an Envoy bootstrapped with --use-delta
+// gets an error that says why it can't be served.
+func NewNoDeltaServer(log logrus.FieldLogger, srv Server) Server {
+	return &noDeltaServer{
+		Server: srv,
+		log:    log,
+	}
+}
+
+type noDeltaServer struct {
+	Server
+	log logrus.FieldLogger
+}
+
+func (s *noDeltaServer) reject() error {
+	s.log.WithError(xds.ErrDeltaUnsupported).Error("rejected incremental xDS stream")
+	return xds.ErrDeltaUnsupported
+}
+
+func (s *noDeltaServer) DeltaAggregatedResources(discovery.AggregatedDiscoveryService_DeltaAggregatedResourcesServer) error {
+	return s.reject()
+}
+
+func (s *noDeltaServer) DeltaClusters(api.ClusterDiscoveryService_DeltaClustersServer) error {
+	return s.reject()
+}
+
+func (s *noDeltaServer) DeltaEndpoints(api.EndpointDiscoveryService_DeltaEndpointsServer) error {
+	return s.reject()
+}
+
+func (s *noDeltaServer) DeltaListeners(api.ListenerDiscoveryService_DeltaListenersServer) error {
+	return s.reject()
+}
+
+func (s *noDeltaServer) DeltaRoutes(api.RouteDiscoveryService_DeltaRoutesServer) error {
+	return s.reject()
+}
+
+func (s *noDeltaServer) DeltaSecrets(discovery.SecretDiscoveryService_DeltaSecretsServer) error {
+	return s.reject()
+}
@@ -0,0 +1,184 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	clusterservice "github.com/envoyproxy/go-control-plane/envoy/service/cluster/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	endpointservice "github.com/envoyproxy/go-control-plane/envoy/service/endpoint/v3"
+	listenerservice "github.com/envoyproxy/go-control-plane/envoy/service/listener/v3"
+	routeservice "github.com/envoyproxy/go-control-plane/envoy/service/route/v3"
+	secretservice "github.com/envoyproxy/go-control-plane/envoy/service/secret/v3"
+	"github.com/golang/protobuf/proto"
+	contour_xds_v2 "github.com/projectcontour/contour/internal/xds/v2"
+	"google.golang.org/grpc"
+)
+
+// NewContourServer returns a Server that answers v3 discovery
+// requests with the given v2 contour Server.
+//
+// The contour server streams whichever resources are registered
+// for the type URL that Envoy requests, so registering v3 resources
+// with it is enough to serve them. The only difference between the
+// v2 and v3 discovery protocols is the type of the request and
+// response messages, which are translated here.
+func NewContourServer(srv contour_xds_v2.Server) Server {
+	return &contourServer{
+		srv: srv,
+	}
+}
+
+type contourServer struct {
+	// Since the v2 server only implements the streaming
+	// protocols, embed the default null implementations to
+	// handle the unimplemented gRPC fetch endpoints.
+	discovery.UnimplementedAggregatedDiscoveryServiceServer
+	secretservice.UnimplementedSecretDiscoveryServiceServer
+	routeservice.UnimplementedRouteDiscoveryServiceServer
+	endpointservice.UnimplementedEndpointDiscoveryServiceServer
+	clusterservice.UnimplementedClusterDiscoveryServiceServer
+	listenerservice.UnimplementedListenerDiscoveryServiceServer
+
+	srv contour_xds_v2.Server
+}
+
+func (s *contourServer) StreamAggregatedResources(srv discovery.AggregatedDiscoveryService_StreamAggregatedResourcesServer) error {
+	return s.srv.StreamAggregatedResources(&stream{srv})
+}
+
+func (s *contourServer) StreamClusters(srv clusterservice.ClusterDiscoveryService_StreamClustersServer) error {
+	return s.srv.StreamClusters(&stream{srv})
+}
+
+func (s *contourServer) StreamEndpoints(srv endpointservice.EndpointDiscoveryService_StreamEndpointsServer) error {
+	return s.srv.StreamEndpoints(&stream{srv})
+}
+
+func (s *contourServer) StreamListeners(srv listenerservice.ListenerDiscoveryService_StreamListenersServer) error {
+	return s.srv.StreamListeners(&stream{srv})
+}
+
+func (s *contourServer) StreamRoutes(srv routeservice.RouteDiscoveryService_StreamRoutesServer) error {
+	return s.srv.StreamRoutes(&stream{srv})
+}
+
+func (s *contourServer) StreamSecrets(srv secretservice.SecretDiscoveryService_StreamSecretsServer) error {
+	return s.srv.StreamSecrets(&stream{srv})
+}
+
+func (s *contourServer) DeltaAggregatedResources(srv discovery.AggregatedDiscoveryService_DeltaAggregatedResourcesServer) error {
+	return s.srv.DeltaAggregatedResources(&deltaStream{srv})
+}
+
+func (s *contourServer) DeltaClusters(srv clusterservice.ClusterDiscoveryService_DeltaClustersServer) error {
+	return s.srv.DeltaClusters(&deltaStream{srv})
+}
+
+func (s *contourServer) DeltaEndpoints(srv endpointservice.EndpointDiscoveryService_DeltaEndpointsServer) error {
+	return s.srv.DeltaEndpoints(&deltaStream{srv})
+}
+
+func (s *contourServer) DeltaListeners(srv listenerservice.ListenerDiscoveryService_DeltaListenersServer) error {
+	return s.srv.DeltaListeners(&deltaStream{srv})
+}
+
+func (s *contourServer) DeltaRoutes(srv routeservice.RouteDiscoveryService_DeltaRoutesServer) error {
+	return s.srv.DeltaRoutes(&deltaStream{srv})
+}
+
+func (s *contourServer) DeltaSecrets(srv secretservice.SecretDiscoveryService_DeltaSecretsServer) error {
+	return s.srv.DeltaSecrets(&deltaStream{srv})
+}
+
+type grpcStream interface {
+	grpc.ServerStream
+	Send(*discovery.DiscoveryResponse) error
+	Recv() (*discovery.DiscoveryRequest, error)
+}
+
+// stream adapts a v3 discovery stream to a v2 discovery stream. The
+// v2 and v3 discovery messages have the same wire format, so each
+// message is translated by encoding it as one version and decoding
+// it as the other.
+type stream struct {
+	grpcStream
+}
+
+func (s *stream) Send(resp *envoy_api_v2.DiscoveryResponse) error {
+	var out discovery.DiscoveryResponse
+	if err := translate(resp, &out); err != nil {
+		return err
+	}
+
+	return s.grpcStream.Send(&out)
+}
+
+func (s *stream) Recv() (*envoy_api_v2.DiscoveryRequest, error) {
+	req, err := s.grpcStream.Recv()
+	if err != nil {
+		return nil, err
+	}
+
+	var out envoy_api_v2.DiscoveryRequest
+	if err := translate(req, &out); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+type grpcDeltaStream interface {
+	grpc.ServerStream
+	Send(*discovery.DeltaDiscoveryResponse) error
+	Recv() (*discovery.DeltaDiscoveryRequest, error)
+}
+
+// deltaStream adapts a v3 incremental discovery stream to a v2
+// incremental discovery stream.
+type deltaStream struct {
+	grpcDeltaStream
+}
+
+func (s *deltaStream) Send(resp *envoy_api_v2.DeltaDiscoveryResponse) error {
+	var out discovery.DeltaDiscoveryResponse
+	if err := translate(resp, &out); err != nil {
+		return err
+	}
+
+	return s.grpcDeltaStream.Send(&out)
+}
+
+func (s *deltaStream) Recv() (*envoy_api_v2.DeltaDiscoveryRequest, error) {
+	req, err := s.grpcDeltaStream.Recv()
+	if err != nil {
+		return nil, err
+	}
+
+	var out envoy_api_v2.DeltaDiscoveryRequest
+	if err := translate(req, &out); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+func translate(from proto.Message, to proto.Message) error {
+	buf, err := proto.Marshal(from)
+	if err != nil {
+		return err
+	}
+
+	return proto.Unmarshal(buf, to)
+}
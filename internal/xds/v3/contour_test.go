@@ -0,0 +1,98 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	envoy_config_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/golang/protobuf/proto"
+	"github.com/projectcontour/contour/internal/protobuf"
+	contour_xds_v2 "github.com/projectcontour/contour/internal/xds/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+func TestContourServerStream(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	cluster := &envoy_config_cluster_v3.Cluster{Name: "default/kuard/8080"}
+
+	srv := NewContourServer(contour_xds_v2.NewContourServer(log, nil, &mockResource{
+		register: func(ch chan int, last int) {
+			ch <- last + 1
+		},
+		contents: func() []proto.Message {
+			return []proto.Message{cluster}
+		},
+		typeurl: func() string { return resource.ClusterType },
+	}))
+
+	var got []*discovery.DiscoveryResponse
+	requests := []*discovery.DiscoveryRequest{{
+		TypeUrl: resource.ClusterType,
+	}}
+
+	err := srv.StreamClusters(&mockStream{
+		context: context.Background,
+		recv: func() (*discovery.DiscoveryRequest, error) {
+			if len(requests) == 0 {
+				return nil, io.EOF
+			}
+			req := requests[0]
+			requests = requests[1:]
+			return req, nil
+		},
+		send: func(resp *discovery.DiscoveryResponse) error {
+			got = append(got, resp)
+			return nil
+		},
+	})
+
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, 1, len(got))
+	assert.Equal(t, resource.ClusterType, got[0].TypeUrl)
+	assert.Equal(t, "0", got[0].VersionInfo)
+	protobuf.ExpectEqual(t, protobuf.MustMarshalAny(cluster), got[0].Resources[0])
+}
+
+type mockStream struct {
+	grpc.ServerStream
+
+	context func() context.Context
+	send    func(*discovery.DiscoveryResponse) error
+	recv    func() (*discovery.DiscoveryRequest, error)
+}
+
+func (m *mockStream) Context() context.Context                     { return m.context() }
+func (m *mockStream) Send(resp *discovery.DiscoveryResponse) error { return m.send(resp) }
+func (m *mockStream) Recv() (*discovery.DiscoveryRequest, error)   { return m.recv() }
+
+type mockResource struct {
+	contents func() []proto.Message
+	register func(chan int, int)
+	typeurl  func() string
+}
+
+func (m *mockResource) Contents() []proto.Message                       { return m.contents() }
+func (m *mockResource) Query(names []string) []proto.Message            { return nil }
+func (m *mockResource) Register(ch chan int, last int, hints ...string) { m.register(ch, last) }
+func (m *mockResource) TypeURL() string                                 { return m.typeurl() }
@@ -0,0 +1,92 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	clusterservice "github.com/envoyproxy/go-control-plane/envoy/service/cluster/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	endpointservice "github.com/envoyproxy/go-control-plane/envoy/service/endpoint/v3"
+	listenerservice "github.com/envoyproxy/go-control-plane/envoy/service/listener/v3"
+	routeservice "github.com/envoyproxy/go-control-plane/envoy/service/route/v3"
+	secretservice "github.com/envoyproxy/go-control-plane/envoy/service/secret/v3"
+	"github.com/projectcontour/contour/internal/xds"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// Server is a collection of handlers for streaming v3 discovery requests.
+type Server interface {
+	clusterservice.ClusterDiscoveryServiceServer
+	endpointservice.EndpointDiscoveryServiceServer
+	listenerservice.ListenerDiscoveryServiceServer
+	routeservice.RouteDiscoveryServiceServer
+	discovery.AggregatedDiscoveryServiceServer
+	secretservice.SecretDiscoveryServiceServer
+}
+
+// RegisterServer registers the given v3 xDS protocol Server with the
+// gRPC server g. This allows the v3 services to be served alongside
+// the v2 services registered by the v2 RegisterServer.
+func RegisterServer(g *grpc.Server, srv Server) {
+	discovery.RegisterAggregatedDiscoveryServiceServer(g, srv)
+	secretservice.RegisterSecretDiscoveryServiceServer(g, srv)
+	clusterservice.RegisterClusterDiscoveryServiceServer(g, srv)
+	endpointservice.RegisterEndpointDiscoveryServiceServer(g, srv)
+	listenerservice.RegisterListenerDiscoveryServiceServer(g, srv)
+	routeservice.RegisterRouteDiscoveryServiceServer(g, srv)
+}
+
+// NewNoDeltaServer returns a Server that serves the state of the world
+// xDS protocol with srv, and rejects incremental xDS streams with
+// xds.ErrDeltaUnsupported.
+func NewNoDeltaServer(log logrus.FieldLogger, srv Server) Server {
+	return &noDeltaServer{
+		Server: srv,
+		log:    log,
+	}
+}
+
+type noDeltaServer struct {
+	Server
+	log logrus.FieldLogger
+}
+
+func (s *noDeltaServer) reject() error {
+	s.log.WithError(xds.ErrDeltaUnsupported).Error("rejected incremental xDS stream")
+	return xds.ErrDeltaUnsupported
+}
+
+func (s *noDeltaServer) DeltaAggregatedResources(discovery.AggregatedDiscoveryService_DeltaAggregatedResourcesServer) error {
+	return s.reject()
+}
+
+func (s *noDeltaServer) DeltaClusters(clusterservice.ClusterDiscoveryService_DeltaClustersServer) error {
+	return s.reject()
+}
+
+func (s *noDeltaServer) DeltaEndpoints(endpointservice.EndpointDiscoveryService_DeltaEndpointsServer) error {
+	return s.reject()
+}
+
+func (s *noDeltaServer) DeltaListeners(listenerservice.ListenerDiscoveryService_DeltaListenersServer) error {
+	return s.reject()
+}
+
+func (s *noDeltaServer) DeltaRoutes(routeservice.RouteDiscoveryService_DeltaRoutesServer) error {
+	return s.reject()
+}
+
+func (s *noDeltaServer) DeltaSecrets(secretservice.SecretDiscoveryService_DeltaSecretsServer) error {
+	return s.reject()
+}
@@ -0,0 +1,90 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// VersionTracker tracks the version of each entry in a resource
+// cache. An entry is given a new version when it is added to the
+// cache or its contents change, so the version of an entry only
+// changes when Envoy needs to be sent the new contents.
+//
+// The zero value is ready to use.
+type VersionTracker struct {
+	mu      sync.Mutex
+	last    int
+	entries map[string]versionedEntry
+}
+
+type versionedEntry struct {
+	msg     proto.Message
+	version int
+}
+
+// Update records the full contents of a resource cache. Entries that
+// are not in contents are forgotten.
+func (t *VersionTracker) Update(contents map[string]proto.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entries := make(map[string]versionedEntry, len(contents))
+	t.record(entries, contents)
+	t.entries = entries
+}
+
+// Merge records changes to some of the entries of a resource cache.
+// Entries that are not in contents are left alone.
+func (t *VersionTracker) Merge(contents map[string]proto.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.entries == nil {
+		t.entries = make(map[string]versionedEntry, len(contents))
+	}
+
+	t.record(t.entries, contents)
+}
+
+// record stores contents in entries, keeping the existing version of
+// each entry whose contents haven't changed.
+func (t *VersionTracker) record(entries map[string]versionedEntry, contents map[string]proto.Message) {
+	t.last++
+
+	for name, msg := range contents {
+		if old, ok := t.entries[name]; ok && proto.Equal(old.msg, msg) {
+			entries[name] = old
+			continue
+		}
+
+		entries[name] = versionedEntry{msg: msg, version: t.last}
+	}
+}
+
+// Versions returns the version of each entry, keyed by name.
+func (t *VersionTracker) Versions() map[string]string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	versions := make(map[string]string, len(t.entries))
+	for name, e := range t.entries {
+		versions[name] = strconv.Itoa(e.version)
+	}
+
+	return versions
+}
@@ -0,0 +1,65 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"testing"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionTracker(t *testing.T) {
+	cluster := func(name string, alt string) proto.Message {
+		return &envoy_api_v2.Cluster{Name: name, AltStatName: alt}
+	}
+
+	var vt VersionTracker
+	assert.Empty(t, vt.Versions())
+
+	vt.Update(map[string]proto.Message{
+		"a": cluster("a", "a"),
+		"b": cluster("b", "b"),
+	})
+	assert.Equal(t, map[string]string{"a": "1", "b": "1"}, vt.Versions())
+
+	// Entries keep their version until their contents change.
+	vt.Update(map[string]proto.Message{
+		"a": cluster("a", "a"),
+		"b": cluster("b", "bb"),
+		"c": cluster("c", "c"),
+	})
+	assert.Equal(t, map[string]string{"a": "1", "b": "2", "c": "2"}, vt.Versions())
+
+	// Merging leaves the other entries alone.
+	vt.Merge(map[string]proto.Message{
+		"c": cluster("c", "cc"),
+		"d": cluster("d", "d"),
+	})
+	assert.Equal(t, map[string]string{"a": "1", "b": "2", "c": "3", "d": "3"}, vt.Versions())
+
+	// Updating forgets the entries that are gone.
+	vt.Update(map[string]proto.Message{
+		"a": cluster("a", "a"),
+	})
+	assert.Equal(t, map[string]string{"a": "1"}, vt.Versions())
+
+	// Entries that come back get a new version.
+	vt.Update(map[string]proto.Message{
+		"a": cluster("a", "a"),
+		"b": cluster("b", "bb"),
+	})
+	assert.Equal(t, map[string]string{"a": "1", "b": "5"}, vt.Versions())
+}
@@ -17,10 +17,13 @@ import (
 	"math"
 	"reflect"
 	"strconv"
+	"sync"
 
 	envoy_xds "github.com/envoyproxy/go-control-plane/pkg/cache/types"
 	"github.com/envoyproxy/go-control-plane/pkg/cache/v2"
+	cache_v3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
 	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v2"
+	resource_v3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/xds"
 	"github.com/sirupsen/logrus"
@@ -31,26 +34,62 @@ import (
 // snapshot to be created.
 type SnapshotHandler struct {
 
-	// resources holds the cache of xDS contents.
-	resources map[envoy_xds.ResponseType]ResourceCache
+	// resources holds the cache of v2 xDS contents.
+	resources map[envoy_xds.ResponseType]xds.Resource
+
+	// resourcesV3 holds the cache of v3 xDS contents.
+	resourcesV3 map[envoy_xds.ResponseType]xds.Resource
 
 	// snapshotCache is a snapshot-based cache that maintains a single versioned
-	// snapshot of responses for xDS resources that Contour manages.
+	// snapshot of responses for v2 xDS resources that Contour manages.
 	snapshotCache cache.SnapshotCache
 
+	// snapshotCacheV3 is the v3 equivalent of snapshotCache.
+	snapshotCacheV3 cache_v3.SnapshotCache
+
+	// mu protects the snapshot state below, since new snapshots
+	// are generated by both DAG rebuilds and endpoint changes.
+	mu sync.Mutex
+
 	// snapshotVersion holds the current version of the snapshot.
 	snapshotVersion int64
 
+	// snapshot and snapshotV3 hold the last snapshots that were
+	// set. Each resource type in a snapshot keeps the version of
+	// the snapshot in which it last changed, so Envoy is only sent
+	// the resource types that have changed.
+	snapshot   cache.Snapshot
+	snapshotV3 cache_v3.Snapshot
+
+	// versions holds the versions of the entries of each resource
+	// at the time of the last snapshot.
+	versions map[xds.Resource]map[string]string
+
 	logrus.FieldLogger
 }
 
-// NewSnapshotHandler returns an instance of SnapshotHandler.
-func NewSnapshotHandler(c cache.SnapshotCache, resources []ResourceCache, logger logrus.FieldLogger) *SnapshotHandler {
+// responseTypes are the resource types that make up a snapshot.
+var responseTypes = []envoy_xds.ResponseType{
+	envoy_xds.Endpoint,
+	envoy_xds.Cluster,
+	envoy_xds.Route,
+	envoy_xds.Listener,
+	envoy_xds.Secret,
+}
+
+// NewSnapshotHandler returns an instance of SnapshotHandler. The v2 and v3
+// resources are distinguished by their type URLs and published to the
+// corresponding snapshot cache.
+func NewSnapshotHandler(c cache.SnapshotCache, c3 cache_v3.SnapshotCache, resources []xds.Resource, logger logrus.FieldLogger) *SnapshotHandler {
+	v2, v3 := parseResources(resources)
 
 	sh := &SnapshotHandler{
-		snapshotCache: c,
-		resources:     parseResources(resources),
-		FieldLogger:   logger,
+		snapshotCache:   c,
+		snapshotCacheV3: c3,
+		resources:       v2,
+		resourcesV3:     v3,
+		versions:        map[xds.Resource]map[string]string{},
+		FieldLogger:     logger,
 	}
 
 	return sh
@@ -68,23 +107,55 @@ func (s *SnapshotHandler) OnChange(root *dag.DAG) {
 }
 
 // generateNewSnapshot creates a new snapshot against
-// the Contour XDS caches.
+// the Contour XDS caches. Only the resource types that
+// have changed since the last snapshot are given the new
+// snapshot version.
 func (s *SnapshotHandler) generateNewSnapshot() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	// Generate new snapshot version.
 	snapshotVersion := s.newSnapshotVersion()
 
-	// Create an snapshot with all xDS resources.
-	snapshot := cache.NewSnapshot(snapshotVersion,
-		asResources(s.resources[envoy_xds.Endpoint].Contents()),
-		asResources(s.resources[envoy_xds.Cluster].Contents()),
-		asResources(s.resources[envoy_xds.Route].Contents()),
-		asResources(s.resources[envoy_xds.Listener].Contents()),
-		nil,
-		asResources(s.resources[envoy_xds.Secret].Contents()))
+	for _, typ := range responseTypes {
+		if r := s.resources[typ]; s.changed(r) {
+			s.snapshot.Resources[typ] = cache.NewResources(snapshotVersion, asResources(r.Contents()))
+		}
+	}
 
-	if err := s.snapshotCache.SetSnapshot(xds.DefaultHash.String(), snapshot); err != nil {
+	if err := s.snapshotCache.SetSnapshot(xds.DefaultHash.String(), s.snapshot); err != nil {
 		s.Errorf("OnChange: Error setting snapshot: %q", err)
 	}
+
+	if s.snapshotCacheV3 == nil {
+		return
+	}
+
+	for _, typ := range responseTypes {
+		if r := s.resourcesV3[typ]; s.changed(r) {
+			s.snapshotV3.Resources[typ] = cache_v3.NewResources(snapshotVersion, asResources(r.Contents()))
+		}
+	}
+
+	if err := s.snapshotCacheV3.SetSnapshot(xds.DefaultHash.String(), s.snapshotV3); err != nil {
+		s.Errorf("OnChange: Error setting v3 snapshot: %q", err)
+	}
+}
+
+// changed returns true if the entries of r have changed since the
+// last snapshot. Resources that don't track the versions of their
+// entries are always considered to have changed.
+func (s *SnapshotHandler) changed(r xds.Resource) bool {
+	d, ok := r.(xds.DeltaResource)
+	if !ok {
+		return true
+	}
+
+	versions := d.Versions()
+	last, ok := s.versions[r]
+	s.versions[r] = versions
+
+	return !ok || !reflect.DeepEqual(last, versions)
 }
 
 // newSnapshotVersion increments the current snapshotVersion
@@ -119,11 +190,13 @@ func asResources(messages interface{}) []envoy_xds.Resource {
 	return protos
 }
 
-// parseResources converts an []ResourceCache to a map[envoy_xds.ResponseType]ResourceCache
-// for faster indexing when creating new snapshots.
-func parseResources(resources []ResourceCache) map[envoy_xds.ResponseType]ResourceCache {
+// parseResources converts an []xds.Resource to a pair of v2 and v3
+// map[envoy_xds.ResponseType]xds.Resource for faster indexing when
+// creating new snapshots.
+func parseResources(resources []xds.Resource) (map[envoy_xds.ResponseType]xds.Resource, map[envoy_xds.ResponseType]xds.Resource) {
 
-	resourceMap := make(map[envoy_xds.ResponseType]ResourceCache, len(resources))
+	resourceMap := make(map[envoy_xds.ResponseType]xds.Resource, len(resources))
+	resourceMapV3 := make(map[envoy_xds.ResponseType]xds.Resource, len(resources))
 
 	for _, r := range resources {
 		switch r.TypeURL() {
@@ -137,7 +210,17 @@ func parseResources(resources []ResourceCache) map[envoy_xds.ResponseType]Resour
 			resourceMap[envoy_xds.Secret] = r
 		case resource.EndpointType:
 			resourceMap[envoy_xds.Endpoint] = r
+		case resource_v3.ClusterType:
+			resourceMapV3[envoy_xds.Cluster] = r
+		case resource_v3.RouteType:
+			resourceMapV3[envoy_xds.Route] = r
+		case resource_v3.ListenerType:
+			resourceMapV3[envoy_xds.Listener] = r
+		case resource_v3.SecretType:
+			resourceMapV3[envoy_xds.Secret] = r
+		case resource_v3.EndpointType:
+			resourceMapV3[envoy_xds.Endpoint] = r
 		}
 	}
-	return resourceMap
+	return resourceMap, resourceMapV3
 }
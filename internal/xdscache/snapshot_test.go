@@ -17,7 +17,15 @@ import (
 	"math"
 	"testing"
 
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_xds "github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	"github.com/envoyproxy/go-control-plane/pkg/cache/v2"
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/projectcontour/contour/internal/xds"
+	xdscache_v2 "github.com/projectcontour/contour/internal/xdscache/v2"
+	xdscache_v3 "github.com/projectcontour/contour/internal/xdscache/v3"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetNewSnapshotVersion(t *testing.T) {
@@ -55,3 +63,74 @@ func TestGetNewSnapshotVersion(t *testing.T) {
 		want:            "1",
 	})
 }
+
+func TestParseResources(t *testing.T) {
+	clusters := &xdscache_v2.ClusterCache{}
+	secrets := &xdscache_v2.SecretCache{}
+
+	resources := ResourcesOf([]ResourceCache{clusters, secrets})
+	resources = append(resources, xdscache_v3.NewResourceCaches(fixture.NewTestLogger(t), nil, resources...)...)
+
+	v2, v3 := parseResources(resources)
+
+	assert.Equal(t, 2, len(v2))
+	assert.Equal(t, clusters, v2[envoy_xds.Cluster])
+	assert.Equal(t, secrets, v2[envoy_xds.Secret])
+
+	assert.Equal(t, 2, len(v3))
+	assert.Equal(t, resources[2], v3[envoy_xds.Cluster])
+	assert.Equal(t, resources[3], v3[envoy_xds.Secret])
+}
+
+func TestSnapshotHandlerVersions(t *testing.T) {
+	clusters := &xdscache_v2.ClusterCache{}
+	resources := ResourcesOf([]ResourceCache{
+		clusters,
+		xdscache_v2.NewEndpointsTranslator(fixture.NewTestLogger(t)),
+		&xdscache_v2.SecretCache{},
+		xdscache_v2.NewListenerCache(xdscache_v2.ListenerConfig{}, "0.0.0.0", 8002),
+		&xdscache_v2.RouteCache{},
+	})
+
+	snapshotCache := cache.NewSnapshotCache(false, xds.DefaultHash, fixture.NewTestLogger(t))
+	sh := NewSnapshotHandler(snapshotCache, nil, resources, fixture.NewTestLogger(t))
+
+	versions := func() map[envoy_xds.ResponseType]string {
+		snapshot, err := snapshotCache.GetSnapshot(xds.DefaultHash.String())
+		require.NoError(t, err)
+
+		versions := map[envoy_xds.ResponseType]string{}
+		for _, typ := range responseTypes {
+			versions[typ] = snapshot.Resources[typ].Version
+		}
+		return versions
+	}
+
+	sh.Refresh()
+	assert.Equal(t, map[envoy_xds.ResponseType]string{
+		envoy_xds.Endpoint: "1",
+		envoy_xds.Cluster:  "1",
+		envoy_xds.Route:    "1",
+		envoy_xds.Listener: "1",
+		envoy_xds.Secret:   "1",
+	}, versions())
+
+	// Only the resource types that changed get a new version.
+	clusters.Update(map[string]*envoy_api_v2.Cluster{
+		"default/kuard/80": {Name: "default/kuard/80"},
+	})
+	sh.Refresh()
+	assert.Equal(t, map[envoy_xds.ResponseType]string{
+		envoy_xds.Endpoint: "1",
+		envoy_xds.Cluster:  "2",
+		envoy_xds.Route:    "1",
+		envoy_xds.Listener: "1",
+		envoy_xds.Secret:   "1",
+	}, versions())
+
+	clusters.Update(map[string]*envoy_api_v2.Cluster{
+		"default/kuard/80": {Name: "default/kuard/80"},
+	})
+	sh.Refresh()
+	assert.Equal(t, "2", versions()[envoy_xds.Cluster])
+}
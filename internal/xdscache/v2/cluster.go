@@ -26,6 +26,7 @@ import (
 	envoy_v2 "github.com/projectcontour/contour/internal/envoy/v2"
 	"github.com/projectcontour/contour/internal/protobuf"
 	"github.com/projectcontour/contour/internal/sorter"
+	"github.com/projectcontour/contour/internal/xds"
 )
 
 // ClusterCache manages the contents of the gRPC CDS cache.
@@ -33,6 +34,8 @@ type ClusterCache struct {
 	mu     sync.Mutex
 	values map[string]*envoy_api_v2.Cluster
 	contour.Cond
+
+	versions xds.VersionTracker
 }
 
 // Update replaces the contents of the cache with the supplied map.
@@ -41,9 +44,15 @@ func (c *ClusterCache) Update(v map[string]*envoy_api_v2.Cluster) {
 	defer c.mu.Unlock()
 
 	c.values = v
+	c.versions.Update(protobuf.AsMessageMap(v))
 	c.Cond.Notify()
 }
 
+// Versions returns the version of each entry in the cache.
+func (c *ClusterCache) Versions() map[string]string {
+	return c.versions.Versions()
+}
+
 // Contents returns a copy of the cache's contents.
 func (c *ClusterCache) Contents() []proto.Message {
 	c.mu.Lock()
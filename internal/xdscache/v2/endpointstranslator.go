@@ -28,6 +28,7 @@ import (
 	"github.com/projectcontour/contour/internal/k8s"
 	"github.com/projectcontour/contour/internal/protobuf"
 	"github.com/projectcontour/contour/internal/sorter"
+	"github.com/projectcontour/contour/internal/xds"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -126,6 +127,14 @@ func (c *EndpointsCache) Recalculate() map[string]*envoy_api_v2.ClusterLoadAssig
 		for _, w := range cluster.Services {
 			n := types.NamespacedName{Namespace: w.ServiceNamespace, Name: w.ServiceName}
 			if lb := RecalculateEndpoints(w.ServicePort, c.endpoints[n]); lb != nil {
+				if cluster.HealthCheckPort > 0 {
+					for _, e := range lb {
+						e.GetEndpoint().HealthCheckConfig = &envoy_api_v2_endpoint.Endpoint_HealthCheckConfig{
+							PortValue: cluster.HealthCheckPort,
+						}
+					}
+				}
+
 				// Append the new set of endpoints. Users are allowed to set the load
 				// balancing weight to 0, which we reflect to Envoy as nil in order to
 				// assign no load to that locality.
@@ -247,6 +256,8 @@ type EndpointsTranslator struct {
 
 	mu      sync.Mutex // Protects entries.
 	entries map[string]*envoy_api_v2.ClusterLoadAssignment
+
+	versions xds.VersionTracker
 }
 
 // Merge combines the given entries with the existing entries in the
@@ -259,6 +270,8 @@ func (e *EndpointsTranslator) Merge(entries map[string]*envoy_api_v2.ClusterLoad
 	for k, v := range entries {
 		e.entries[k] = v
 	}
+
+	e.versions.Merge(protobuf.AsMessageMap(entries))
 }
 
 // OnChange observes DAG rebuild events.
@@ -300,6 +313,7 @@ func (e *EndpointsTranslator) OnChange(d *dag.DAG) {
 
 	e.mu.Lock()
 	e.entries = entries
+	e.versions.Update(protobuf.AsMessageMap(entries))
 	e.mu.Unlock()
 
 	e.Notify()
@@ -403,3 +417,8 @@ func (e *EndpointsTranslator) Query(names []string) []proto.Message {
 }
 
 func (*EndpointsTranslator) TypeURL() string { return resource2.EndpointType }
+
+// Versions returns the version of each entry in the cache.
+func (e *EndpointsTranslator) Versions() map[string]string {
+	return e.versions.Versions()
+}
@@ -173,6 +173,32 @@ func TestEndpointsTranslatorAddEndpoints(t *testing.T) {
 				},
 			},
 		},
+		"dual-stack addresses": {
+			ep: endpoints("default", "simple", v1.EndpointSubset{
+				Addresses: addresses(
+					"fd00::2",
+					"10.0.0.1",
+					"fd00::1",
+					"::ffff:10.0.0.2",
+				),
+				Ports: ports(
+					port("", 8080),
+				),
+			}),
+			want: []proto.Message{
+				&envoy_api_v2.ClusterLoadAssignment{ClusterName: "default/httpbin-org/a"},
+				&envoy_api_v2.ClusterLoadAssignment{ClusterName: "default/httpbin-org/b"},
+				&envoy_api_v2.ClusterLoadAssignment{
+					ClusterName: "default/simple",
+					Endpoints: envoy_v2.WeightedEndpoints(1,
+						envoy_v2.SocketAddress("10.0.0.1", 8080),
+						envoy_v2.SocketAddress("10.0.0.2", 8080), // IPv4-mapped addresses are written as IPv4
+						envoy_v2.SocketAddress("fd00::1", 8080),
+						envoy_v2.SocketAddress("fd00::2", 8080),
+					),
+				},
+			},
+		},
 		"multiple addresses": {
 			ep: endpoints("default", "simple", v1.EndpointSubset{
 				Addresses: addresses(
@@ -739,3 +765,59 @@ func clusterloadassignments(clas ...*envoy_api_v2.ClusterLoadAssignment) map[str
 	}
 	return m
 }
+
+// Test that a cluster with a health check port sets the
+// health check port on each of its endpoints.
+func TestEndpointsTranslatorHealthCheckPort(t *testing.T) {
+	et := NewEndpointsTranslator(fixture.NewTestLogger(t))
+	clusters := []*dag.ServiceCluster{
+		{
+			ClusterName: "default/simple",
+			Services: []dag.WeightedService{
+				{
+					Weight:           1,
+					ServiceName:      "simple",
+					ServiceNamespace: "default",
+					ServicePort:      v1.ServicePort{},
+				},
+			},
+		},
+		{
+			ClusterName: "default/simple/healthcheck/9000",
+			Services: []dag.WeightedService{
+				{
+					Weight:           1,
+					ServiceName:      "simple",
+					ServiceNamespace: "default",
+					ServicePort:      v1.ServicePort{},
+				},
+			},
+			HealthCheckPort: 9000,
+		},
+	}
+
+	require.NoError(t, et.cache.SetClusters(clusters))
+
+	et.OnAdd(endpoints("default", "simple", v1.EndpointSubset{
+		Addresses: addresses("192.168.183.24"),
+		Ports:     ports(port("", 8080)),
+	}))
+
+	hc := envoy_v2.WeightedEndpoints(1, envoy_v2.SocketAddress("192.168.183.24", 8080))
+	hc[0].LbEndpoints[0].GetEndpoint().HealthCheckConfig = &envoy_api_v2_endpoint.Endpoint_HealthCheckConfig{
+		PortValue: 9000,
+	}
+
+	want := []proto.Message{
+		&envoy_api_v2.ClusterLoadAssignment{
+			ClusterName: "default/simple",
+			Endpoints:   envoy_v2.WeightedEndpoints(1, envoy_v2.SocketAddress("192.168.183.24", 8080)),
+		},
+		&envoy_api_v2.ClusterLoadAssignment{
+			ClusterName: "default/simple/healthcheck/9000",
+			Endpoints:   hc,
+		},
+	}
+
+	protobuf.ExpectEqual(t, want, et.Contents())
+}
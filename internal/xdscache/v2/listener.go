@@ -32,6 +32,7 @@ import (
 	"github.com/projectcontour/contour/internal/protobuf"
 	"github.com/projectcontour/contour/internal/sorter"
 	"github.com/projectcontour/contour/internal/timeout"
+	"github.com/projectcontour/contour/internal/xds"
 )
 
 // nolint:golint
@@ -94,8 +95,14 @@ type ListenerConfig struct {
 	// If not set, defaults to 'envoy'
 	AccessLogType string
 
+	// AccessLogFormatString sets the Envoy format of access logs
+	// when AccessLogType is 'envoy'. If not set, Envoy's default
+	// format is used.
+	AccessLogFormatString string
+
 	// AccessLogFields sets the fields that should be shown in JSON logs.
-	// Valid entries are the keys from internal/envoy/accesslog.go:jsonheaders
+	// Valid entries are the keys from internal/envoy/accesslog.go:jsonheaders,
+	// or custom fields of the form "name=template".
 	// Defaults to a particular set of fields.
 	AccessLogFields []string
 
@@ -115,6 +122,37 @@ type ListenerConfig struct {
 
 	// ConnectionShutdownGracePeriod configures the drain_timeout for all Connection Managers.
 	ConnectionShutdownGracePeriod timeout.Setting
+
+	// Listeners holds the additional named listeners that
+	// virtual hosts can be attached to.
+	Listeners []NamedListener
+}
+
+// NamedListener holds configuration parameters for an additional
+// Envoy listener that virtual hosts can be attached to by name.
+type NamedListener struct {
+	// Name is the name of the listener. It is also used as the
+	// name of the route configuration for an HTTP listener.
+	Name string
+
+	// Address is the listener address.
+	// If not set, defaults to DEFAULT_HTTP_LISTENER_ADDRESS.
+	Address string
+
+	// Port is the listener port.
+	Port int
+
+	// Protocol is either dag.ListenerProtocolHTTP or
+	// dag.ListenerProtocolHTTPS.
+	Protocol string
+
+	// UseProxyProto configures the listener to expect a
+	// PROXY V1 or V2 preamble.
+	UseProxyProto bool
+
+	// AccessLog is the access log path.
+	// If not set, defaults to DEFAULT_HTTP_ACCESS_LOG.
+	AccessLog string
 }
 
 // httpAddress returns the port for the HTTP (non TLS)
@@ -190,21 +228,44 @@ func (lvc *ListenerConfig) accesslogFields() []string {
 }
 
 func (lvc *ListenerConfig) newInsecureAccessLog() []*envoy_api_v2_accesslog.AccessLog {
-	switch lvc.accesslogType() {
-	case "json":
-		return envoy_v2.FileAccessLogJSON(lvc.httpAccessLog(), lvc.accesslogFields())
-	default:
-		return envoy_v2.FileAccessLogEnvoy(lvc.httpAccessLog())
-	}
+	return lvc.newFileAccessLog(lvc.httpAccessLog())
 }
 
 func (lvc *ListenerConfig) newSecureAccessLog() []*envoy_api_v2_accesslog.AccessLog {
-	switch lvc.accesslogType() {
-	case "json":
-		return envoy_v2.FileAccessLogJSON(lvc.httpsAccessLog(), lvc.accesslogFields())
+	return lvc.newFileAccessLog(lvc.httpsAccessLog())
+}
+
+// newFileAccessLog returns the access log filter that writes
+// access logs to the supplied path in the configured format.
+func (lvc *ListenerConfig) newFileAccessLog(path string) []*envoy_api_v2_accesslog.AccessLog {
+	switch {
+	case lvc.accesslogType() == "json":
+		return envoy_v2.FileAccessLogJSON(path, lvc.accesslogFields())
+	case lvc.AccessLogFormatString != "":
+		return envoy_v2.FileAccessLogFormat(path, lvc.AccessLogFormatString)
 	default:
-		return envoy_v2.FileAccessLogEnvoy(lvc.httpsAccessLog())
+		return envoy_v2.FileAccessLogEnvoy(path)
+	}
+}
+
+// namedListenerConfig returns a copy of the listener configuration in
+// which the HTTP and HTTPS settings are replaced by those of the named
+// listener, or nil if no listener with that name is configured.
+func (lvc *ListenerConfig) namedListenerConfig(name string) *ListenerConfig {
+	for _, nl := range lvc.Listeners {
+		if nl.Name != name {
+			continue
+		}
+
+		config := *lvc
+		config.HTTPAddress, config.HTTPSAddress = nl.Address, nl.Address
+		config.HTTPPort, config.HTTPSPort = nl.Port, nl.Port
+		config.HTTPAccessLog, config.HTTPSAccessLog = nl.AccessLog, nl.AccessLog
+		config.UseProxyProto = nl.UseProxyProto
+		config.Listeners = nil
+		return &config
 	}
+	return nil
 }
 
 // minTLSVersion returns the requested minimum TLS protocol
@@ -224,17 +285,22 @@ type ListenerCache struct {
 
 	Config ListenerConfig
 	contour.Cond
+
+	versions xds.VersionTracker
 }
 
 // NewListenerCache returns an instance of a ListenerCache
 func NewListenerCache(config ListenerConfig, address string, port int) *ListenerCache {
 	stats := envoy_v2.StatsListener(address, port)
-	return &ListenerCache{
+	c := &ListenerCache{
 		Config: config,
 		staticValues: map[string]*envoy_api_v2.Listener{
 			stats.Name: stats,
 		},
 	}
+
+	c.versions.Update(protobuf.AsMessageMap(c.staticValues))
+	return c
 }
 
 // Update replaces the contents of the cache with the supplied map.
@@ -243,9 +309,21 @@ func (c *ListenerCache) Update(v map[string]*envoy_api_v2.Listener) {
 	defer c.mu.Unlock()
 
 	c.values = v
+
+	contents := protobuf.AsMessageMap(v)
+	for name, l := range c.staticValues {
+		contents[name] = l
+	}
+	c.versions.Update(contents)
+
 	c.Cond.Notify()
 }
 
+// Versions returns the version of each entry in the cache.
+func (c *ListenerCache) Versions() map[string]string {
+	return c.versions.Versions()
+}
+
 // Contents returns a copy of the cache's contents.
 func (c *ListenerCache) Contents() []proto.Message {
 	c.mu.Lock()
@@ -296,61 +374,137 @@ func (c *ListenerCache) OnChange(root *dag.DAG) {
 type listenerVisitor struct {
 	*ListenerConfig
 
+	// tracing is the tracing configuration of the HTTP
+	// connection managers, or nil if tracing is disabled.
+	tracing *http.HttpConnectionManager_Tracing
+
+	// accessLogService is the gRPC access log service that
+	// access logs are streamed to, or nil if they are only
+	// written to files.
+	accessLogService *dag.AccessLogService
+
+	// httpName and httpsName are the names of the Envoy listeners
+	// that virtual hosts and secure virtual hosts are added to.
+	// fallbackName is the name of the route configuration for
+	// secure virtual hosts that use the fallback certificate.
+	httpName, httpsName, fallbackName string
+
 	listeners map[string]*envoy_api_v2.Listener
 	http      bool // at least one dag.VirtualHost encountered
+
+	// rateLimitServices holds the rate limit services of
+	// the dag.VirtualHosts, keyed by their stage.
+	rateLimitServices map[uint32]*dag.RateLimitService
 }
 
 func visitListeners(root dag.Vertex, lvc *ListenerConfig) map[string]*envoy_api_v2.Listener {
-	lv := listenerVisitor{
+	lv := newListenerVisitor(lvc, ENVOY_HTTP_LISTENER, ENVOY_HTTPS_LISTENER, ENVOY_FALLBACK_ROUTECONFIG)
+	lv.visit(root)
+	return lv.build()
+}
+
+// visitNamedListener returns the Envoy listener for the virtual hosts
+// attached to the named dag.Listener, or nil if it is not configured.
+func visitNamedListener(l *dag.Listener, lvc *ListenerConfig) map[string]*envoy_api_v2.Listener {
+	config := lvc.namedListenerConfig(l.Name)
+	if config == nil {
+		return nil
+	}
+
+	lv := newListenerVisitor(config, l.Name, l.Name, fallbackRouteConfigName(l.Name))
+	lv.tracing = envoy_v2.Tracing(l.Tracing)
+	lv.accessLogService = l.AccessLogService
+	l.Visit(lv.visit)
+	return lv.build()
+}
+
+// fallbackRouteConfigName returns the name of the route configuration
+// holding the fallback certificate routes of the named listener.
+func fallbackRouteConfigName(listener string) string {
+	return path.Join(ENVOY_FALLBACK_ROUTECONFIG, listener)
+}
+
+func newListenerVisitor(lvc *ListenerConfig, httpName, httpsName, fallbackName string) *listenerVisitor {
+	return &listenerVisitor{
 		ListenerConfig: lvc,
+		httpName:       httpName,
+		httpsName:      httpsName,
+		fallbackName:   fallbackName,
 		listeners: map[string]*envoy_api_v2.Listener{
-			ENVOY_HTTPS_LISTENER: envoy_v2.Listener(
-				ENVOY_HTTPS_LISTENER,
+			httpsName: envoy_v2.Listener(
+				httpsName,
 				lvc.httpsAddress(),
 				lvc.httpsPort(),
 				secureProxyProtocol(lvc.UseProxyProto),
 			),
 		},
 	}
+}
 
-	lv.visit(root)
+// build finishes the listeners collected by the visitor.
+func (lv *listenerVisitor) build() map[string]*envoy_api_v2.Listener {
+	// Remove the https listener if there are no vhosts bound to it.
+	if len(lv.listeners[lv.httpsName].FilterChains) == 0 {
+		delete(lv.listeners, lv.httpsName)
+	} else {
+		// there's some https listeners, we need to sort the filter chains
+		// to ensure that the LDS entries are identical.
+		sort.Stable(sorter.For(lv.listeners[lv.httpsName].FilterChains))
+	}
 
 	if lv.http {
 		// Add a listener if there are vhosts bound to http.
 		cm := envoy_v2.HTTPConnectionManagerBuilder().
 			Codec(envoy_v2.CodecForVersions(lv.DefaultHTTPVersions...)).
 			DefaultFilters().
-			RouteConfigName(ENVOY_HTTP_LISTENER).
-			MetricsPrefix(ENVOY_HTTP_LISTENER).
-			AccessLoggers(lvc.newInsecureAccessLog()).
-			RequestTimeout(lvc.RequestTimeout).
-			ConnectionIdleTimeout(lvc.ConnectionIdleTimeout).
-			StreamIdleTimeout(lvc.StreamIdleTimeout).
-			MaxConnectionDuration(lvc.MaxConnectionDuration).
-			ConnectionShutdownGracePeriod(lvc.ConnectionShutdownGracePeriod).
-			Get()
-
-		lv.listeners[ENVOY_HTTP_LISTENER] = envoy_v2.Listener(
-			ENVOY_HTTP_LISTENER,
-			lvc.httpAddress(),
-			lvc.httpPort(),
-			proxyProtocol(lvc.UseProxyProto),
-			cm,
-		)
-	}
+			RouteConfigName(lv.httpName).
+			MetricsPrefix(lv.httpName).
+			AccessLoggers(lv.httpAccessLoggers(lv.newInsecureAccessLog())).
+			RequestTimeout(lv.RequestTimeout).
+			ConnectionIdleTimeout(lv.ConnectionIdleTimeout).
+			StreamIdleTimeout(lv.StreamIdleTimeout).
+			MaxConnectionDuration(lv.MaxConnectionDuration).
+			ConnectionShutdownGracePeriod(lv.ConnectionShutdownGracePeriod).
+			Tracing(lv.tracing)
+
+		// Add a rate limit filter for each rate limit service,
+		// in stage order so that the listener is stable.
+		stages := make([]uint32, 0, len(lv.rateLimitServices))
+		for stage := range lv.rateLimitServices {
+			stages = append(stages, stage)
+		}
+		sort.Slice(stages, func(i, j int) bool { return stages[i] < stages[j] })
 
-	// Remove the https listener if there are no vhosts bound to it.
-	if len(lv.listeners[ENVOY_HTTPS_LISTENER].FilterChains) == 0 {
-		delete(lv.listeners, ENVOY_HTTPS_LISTENER)
-	} else {
-		// there's some https listeners, we need to sort the filter chains
-		// to ensure that the LDS entries are identical.
-		sort.Stable(sorter.For(lv.listeners[ENVOY_HTTPS_LISTENER].FilterChains))
+		for _, stage := range stages {
+			cm.AddFilter(envoy_v2.FilterRateLimit(lv.rateLimitServices[stage]))
+		}
+
+		lv.listeners[lv.httpName] = envoy_v2.Listener(
+			lv.httpName,
+			lv.httpAddress(),
+			lv.httpPort(),
+			proxyProtocol(lv.UseProxyProto),
+			cm.Get(),
+		)
 	}
 
 	return lv.listeners
 }
 
+// httpAccessLoggers returns the supplied file access loggers,
+// followed by the gRPC access logger for HTTP connection
+// managers if an access log service is configured.
+func (lv *listenerVisitor) httpAccessLoggers(file []*envoy_api_v2_accesslog.AccessLog) []*envoy_api_v2_accesslog.AccessLog {
+	return append(file, envoy_v2.HTTPGRPCAccessLog(lv.accessLogService)...)
+}
+
+// tcpAccessLoggers returns the supplied file access loggers,
+// followed by the gRPC access logger for TCP proxies if an
+// access log service is configured.
+func (lv *listenerVisitor) tcpAccessLoggers(file []*envoy_api_v2_accesslog.AccessLog) []*envoy_api_v2_accesslog.AccessLog {
+	return append(file, envoy_v2.TCPGRPCAccessLog(lv.accessLogService)...)
+}
+
 func proxyProtocol(useProxy bool) []*envoy_api_v2_listener.ListenerFilter {
 	if useProxy {
 		return envoy_v2.ListenerFilters(
@@ -373,11 +527,31 @@ func (v *listenerVisitor) visit(vertex dag.Vertex) {
 	}
 
 	switch vh := vertex.(type) {
+	case *dag.Listener:
+		if vh.Name == "" {
+			v.tracing = envoy_v2.Tracing(vh.Tracing)
+			v.accessLogService = vh.AccessLogService
+			vh.Visit(v.visit)
+			break
+		}
+
+		// Virtual hosts attached to an additional listener
+		// are kept apart from those on the default listeners.
+		for name, l := range visitNamedListener(vh, v.ListenerConfig) {
+			v.listeners[name] = l
+		}
 	case *dag.VirtualHost:
 		// we only create on http listener so record the fact
 		// that we need to then double back at the end and add
 		// the listener properly.
 		v.http = true
+
+		if vh.RateLimitService != nil {
+			if v.rateLimitServices == nil {
+				v.rateLimitServices = map[uint32]*dag.RateLimitService{}
+			}
+			v.rateLimitServices[vh.RateLimitService.Stage] = vh.RateLimitService
+		}
 	case *dag.SecureVirtualHost:
 		var alpnProtos []string
 		var filters []*envoy_api_v2_listener.Filter
@@ -390,9 +564,16 @@ func (v *listenerVisitor) visit(vertex dag.Vertex) {
 					vh.AuthorizationService.Name,
 					vh.AuthorizationFailOpen,
 					vh.AuthorizationResponseTimeout,
+					vh.AuthorizationService.ProtocolVersion,
 				)
 			}
 
+			var rateLimitFilter *http.HttpFilter
+
+			if vh.RateLimitService != nil {
+				rateLimitFilter = envoy_v2.FilterRateLimit(vh.RateLimitService)
+			}
+
 			// Create a uniquely named HTTP connection manager for
 			// this vhost, so that the SNI name the client requests
 			// only grants access to that host. See RFC 6066 for
@@ -406,23 +587,25 @@ func (v *listenerVisitor) visit(vertex dag.Vertex) {
 					AddFilter(envoy_v2.FilterMisdirectedRequests(vh.VirtualHost.Name)).
 					DefaultFilters().
 					AddFilter(authFilter).
+					AddFilter(rateLimitFilter).
 					RouteConfigName(path.Join("https", vh.VirtualHost.Name)).
-					MetricsPrefix(ENVOY_HTTPS_LISTENER).
-					AccessLoggers(v.ListenerConfig.newSecureAccessLog()).
+					MetricsPrefix(v.httpsName).
+					AccessLoggers(v.httpAccessLoggers(v.ListenerConfig.newSecureAccessLog())).
 					RequestTimeout(v.ListenerConfig.RequestTimeout).
 					ConnectionIdleTimeout(v.ListenerConfig.ConnectionIdleTimeout).
 					StreamIdleTimeout(v.ListenerConfig.StreamIdleTimeout).
 					MaxConnectionDuration(v.ListenerConfig.MaxConnectionDuration).
 					ConnectionShutdownGracePeriod(v.ListenerConfig.ConnectionShutdownGracePeriod).
+					Tracing(v.tracing).
 					Get(),
 			)
 
 			alpnProtos = envoy_v2.ProtoNamesForVersions(v.DefaultHTTPVersions...)
 		} else {
 			filters = envoy_v2.Filters(
-				envoy_v2.TCPProxy(ENVOY_HTTPS_LISTENER,
+				envoy_v2.TCPProxy(v.httpsName,
 					vh.TCPProxy,
-					v.ListenerConfig.newSecureAccessLog()),
+					v.tcpAccessLoggers(v.ListenerConfig.newSecureAccessLog())),
 			)
 
 			// Do not offer ALPN for TCP proxying, since
@@ -444,15 +627,15 @@ func (v *listenerVisitor) visit(vertex dag.Vertex) {
 				alpnProtos...)
 		}
 
-		v.listeners[ENVOY_HTTPS_LISTENER].FilterChains = append(v.listeners[ENVOY_HTTPS_LISTENER].FilterChains,
+		v.listeners[v.httpsName].FilterChains = append(v.listeners[v.httpsName].FilterChains,
 			envoy_v2.FilterChainTLS(vh.VirtualHost.Name, downstreamTLS, filters))
 
 		// If this VirtualHost has enabled the fallback certificate then set a default
 		// FilterChain which will allow routes with this vhost to accept non-SNI TLS requests.
 		// Note that we don't add the misdirected requests filter on this chain because at this
 		// point we don't actually know the full set of server names that will be bound to the
-		// filter chain through the ENVOY_FALLBACK_ROUTECONFIG route configuration.
-		if vh.FallbackCertificate != nil && !envoy_v2.ContainsFallbackFilterChain(v.listeners[ENVOY_HTTPS_LISTENER].FilterChains) {
+		// filter chain through the fallback route configuration.
+		if vh.FallbackCertificate != nil && !envoy_v2.ContainsFallbackFilterChain(v.listeners[v.httpsName].FilterChains) {
 			// Construct the downstreamTLSContext passing the configured fallbackCertificate. The TLS minProtocolVersion will use
 			// the value defined in the Contour Configuration file if defined.
 			downstreamTLS = envoy_v2.DownstreamTLSContext(
@@ -465,18 +648,19 @@ func (v *listenerVisitor) visit(vertex dag.Vertex) {
 			filters = envoy_v2.Filters(
 				envoy_v2.HTTPConnectionManagerBuilder().
 					DefaultFilters().
-					RouteConfigName(ENVOY_FALLBACK_ROUTECONFIG).
-					MetricsPrefix(ENVOY_HTTPS_LISTENER).
-					AccessLoggers(v.ListenerConfig.newSecureAccessLog()).
+					RouteConfigName(v.fallbackName).
+					MetricsPrefix(v.httpsName).
+					AccessLoggers(v.httpAccessLoggers(v.ListenerConfig.newSecureAccessLog())).
 					RequestTimeout(v.ListenerConfig.RequestTimeout).
 					ConnectionIdleTimeout(v.ListenerConfig.ConnectionIdleTimeout).
 					StreamIdleTimeout(v.ListenerConfig.StreamIdleTimeout).
 					MaxConnectionDuration(v.ListenerConfig.MaxConnectionDuration).
 					ConnectionShutdownGracePeriod(v.ListenerConfig.ConnectionShutdownGracePeriod).
+					Tracing(v.tracing).
 					Get(),
 			)
 
-			v.listeners[ENVOY_HTTPS_LISTENER].FilterChains = append(v.listeners[ENVOY_HTTPS_LISTENER].FilterChains,
+			v.listeners[v.httpsName].FilterChains = append(v.listeners[v.httpsName].FilterChains,
 				envoy_v2.FilterChainTLSFallback(downstreamTLS, filters))
 		}
 
@@ -193,6 +193,42 @@ func TestListenerVisit(t *testing.T) {
 				SocketOptions: envoy_v2.TCPKeepaliveSocketOptions(),
 			}),
 		},
+		"one http only ingress with a custom access log format": {
+			ListenerConfig: ListenerConfig{
+				AccessLogFormatString: "%START_TIME% %REQ(X-TENANT-ID)% %RESPONSE_CODE%",
+			},
+			objs: []interface{}{
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: v1beta1.IngressSpec{
+						Backend: backend("kuard", 8080),
+					},
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Name:     "http",
+							Protocol: "TCP",
+							Port:     8080,
+						}},
+					},
+				},
+			},
+			want: listenermap(&envoy_api_v2.Listener{
+				Name:    ENVOY_HTTP_LISTENER,
+				Address: envoy_v2.SocketAddress("0.0.0.0", 8080),
+				FilterChains: envoy_v2.FilterChains(envoy_v2.HTTPConnectionManager(ENVOY_HTTP_LISTENER,
+					envoy_v2.FileAccessLogFormat(DEFAULT_HTTP_ACCESS_LOG, "%START_TIME% %REQ(X-TENANT-ID)% %RESPONSE_CODE%"), 0)),
+				SocketOptions: envoy_v2.TCPKeepaliveSocketOptions(),
+			}),
+		},
 		"one http only httpproxy": {
 			objs: []interface{}{
 				&contour_api_v1.HTTPProxy{
@@ -28,6 +28,7 @@ import (
 	envoy_v2 "github.com/projectcontour/contour/internal/envoy/v2"
 	"github.com/projectcontour/contour/internal/protobuf"
 	"github.com/projectcontour/contour/internal/sorter"
+	"github.com/projectcontour/contour/internal/xds"
 )
 
 // RouteCache manages the contents of the gRPC RDS cache.
@@ -35,6 +36,8 @@ type RouteCache struct {
 	mu     sync.Mutex
 	values map[string]*envoy_api_v2.RouteConfiguration
 	contour.Cond
+
+	versions xds.VersionTracker
 }
 
 // Update replaces the contents of the cache with the supplied map.
@@ -43,9 +46,15 @@ func (c *RouteCache) Update(v map[string]*envoy_api_v2.RouteConfiguration) {
 	defer c.mu.Unlock()
 
 	c.values = v
+	c.versions.Update(protobuf.AsMessageMap(v))
 	c.Cond.Notify()
 }
 
+// Versions returns the version of each entry in the cache.
+func (c *RouteCache) Versions() map[string]string {
+	return c.versions.Versions()
+}
+
 // Contents returns a copy of the cache's contents.
 func (c *RouteCache) Contents() []proto.Message {
 	c.mu.Lock()
@@ -103,7 +112,9 @@ func visitRoutes(root dag.Vertex) map[string]*envoy_api_v2.RouteConfiguration {
 	// find. For HTTP hosts, the routes will all be collected on the
 	// well-known ENVOY_HTTP_LISTENER, but for HTTPS hosts, we will
 	// generate a per-vhost collection. This lets us keep different
-	// SNI names disjoint when we later configure the listener.
+	// SNI names disjoint when we later configure the listener. HTTP
+	// hosts attached to an additional listener are collected on a
+	// route configuration named after that listener.
 	rv := routeVisitor{
 		routes: map[string]*envoy_api_v2.RouteConfiguration{
 			ENVOY_HTTP_LISTENER: envoy_v2.RouteConfiguration(ENVOY_HTTP_LISTENER),
@@ -119,7 +130,7 @@ func visitRoutes(root dag.Vertex) map[string]*envoy_api_v2.RouteConfiguration {
 	return rv.routes
 }
 
-func (v *routeVisitor) onVirtualHost(vh *dag.VirtualHost) {
+func (v *routeVisitor) onVirtualHost(vh *dag.VirtualHost, name string) {
 	var routes []*envoy_api_v2_route.Route
 
 	vh.Visit(func(v dag.Vertex) {
@@ -137,10 +148,7 @@ func (v *routeVisitor) onVirtualHost(vh *dag.VirtualHost) {
 				Action: envoy_v2.UpgradeHTTPS(),
 			})
 		} else {
-			rt := &envoy_api_v2_route.Route{
-				Match:  envoy_v2.RouteMatch(route),
-				Action: envoy_v2.RouteRoute(route),
-			}
+			rt := envoyRoute(route)
 			if route.RequestHeadersPolicy != nil {
 				rt.RequestHeadersToAdd = envoy_v2.HeaderValueList(route.RequestHeadersPolicy.Set, false)
 				rt.RequestHeadersToRemove = route.RequestHeadersPolicy.Remove
@@ -149,6 +157,9 @@ func (v *routeVisitor) onVirtualHost(vh *dag.VirtualHost) {
 				rt.ResponseHeadersToAdd = envoy_v2.HeaderValueList(route.ResponseHeadersPolicy.Set, false)
 				rt.ResponseHeadersToRemove = route.ResponseHeadersPolicy.Remove
 			}
+			routeRateLimits(rt, vh.RateLimitService, route.RateLimitPolicy)
+			routeFaultInjection(rt, route.FaultInjectionPolicy)
+			rt.Tracing = envoy_v2.RouteTracing(vh.TracingPolicy)
 			routes = append(routes, rt)
 		}
 	})
@@ -162,12 +173,17 @@ func (v *routeVisitor) onVirtualHost(vh *dag.VirtualHost) {
 		} else {
 			evh = envoy_v2.VirtualHost(vh.Name, routes...)
 		}
+		evh.RateLimits = virtualHostRateLimits(vh)
 
-		v.routes[ENVOY_HTTP_LISTENER].VirtualHosts = append(v.routes[ENVOY_HTTP_LISTENER].VirtualHosts, evh)
+		if _, ok := v.routes[name]; !ok {
+			v.routes[name] = envoy_v2.RouteConfiguration(name)
+		}
+
+		v.routes[name].VirtualHosts = append(v.routes[name].VirtualHosts, evh)
 	}
 }
 
-func (v *routeVisitor) onSecureVirtualHost(svh *dag.SecureVirtualHost) {
+func (v *routeVisitor) onSecureVirtualHost(svh *dag.SecureVirtualHost, fallbackName string) {
 	var routes []*envoy_api_v2_route.Route
 
 	svh.Visit(func(v dag.Vertex) {
@@ -176,10 +192,7 @@ func (v *routeVisitor) onSecureVirtualHost(svh *dag.SecureVirtualHost) {
 			return
 		}
 
-		rt := &envoy_api_v2_route.Route{
-			Match:  envoy_v2.RouteMatch(route),
-			Action: envoy_v2.RouteRoute(route),
-		}
+		rt := envoyRoute(route)
 		if route.RequestHeadersPolicy != nil {
 			rt.RequestHeadersToAdd = envoy_v2.HeaderValueList(route.RequestHeadersPolicy.Set, false)
 			rt.RequestHeadersToRemove = route.RequestHeadersPolicy.Remove
@@ -205,6 +218,10 @@ func (v *routeVisitor) onSecureVirtualHost(svh *dag.SecureVirtualHost) {
 			}
 		}
 
+		routeRateLimits(rt, svh.RateLimitService, route.RateLimitPolicy)
+		routeFaultInjection(rt, route.FaultInjectionPolicy)
+		rt.Tracing = envoy_v2.RouteTracing(svh.TracingPolicy)
+
 		routes = append(routes, rt)
 	})
 
@@ -223,6 +240,7 @@ func (v *routeVisitor) onSecureVirtualHost(svh *dag.SecureVirtualHost) {
 		} else {
 			evh = envoy_v2.VirtualHost(svh.VirtualHost.Name, routes...)
 		}
+		evh.RateLimits = virtualHostRateLimits(&svh.VirtualHost)
 
 		v.routes[name].VirtualHosts = append(v.routes[name].VirtualHosts, evh)
 
@@ -231,8 +249,8 @@ func (v *routeVisitor) onSecureVirtualHost(svh *dag.SecureVirtualHost) {
 		// and this routing table in RDS defines where the request proxies next.
 		if svh.FallbackCertificate != nil {
 			// Add fallback route if not already
-			if _, ok := v.routes[ENVOY_FALLBACK_ROUTECONFIG]; !ok {
-				v.routes[ENVOY_FALLBACK_ROUTECONFIG] = envoy_v2.RouteConfiguration(ENVOY_FALLBACK_ROUTECONFIG)
+			if _, ok := v.routes[fallbackName]; !ok {
+				v.routes[fallbackName] = envoy_v2.RouteConfiguration(fallbackName)
 			}
 
 			var fvh *envoy_api_v2_route.VirtualHost
@@ -242,7 +260,7 @@ func (v *routeVisitor) onSecureVirtualHost(svh *dag.SecureVirtualHost) {
 				fvh = envoy_v2.VirtualHost(svh.Name, routes...)
 			}
 
-			v.routes[ENVOY_FALLBACK_ROUTECONFIG].VirtualHosts = append(v.routes[ENVOY_FALLBACK_ROUTECONFIG].VirtualHosts, fvh)
+			v.routes[fallbackName].VirtualHosts = append(v.routes[fallbackName].VirtualHosts, fvh)
 		}
 	}
 }
@@ -250,12 +268,17 @@ func (v *routeVisitor) onSecureVirtualHost(svh *dag.SecureVirtualHost) {
 func (v *routeVisitor) visit(vertex dag.Vertex) {
 	switch l := vertex.(type) {
 	case *dag.Listener:
+		name := ENVOY_HTTP_LISTENER
+		if l.Name != "" {
+			name = l.Name
+		}
+
 		l.Visit(func(vertex dag.Vertex) {
 			switch vh := vertex.(type) {
 			case *dag.VirtualHost:
-				v.onVirtualHost(vh)
+				v.onVirtualHost(vh, name)
 			case *dag.SecureVirtualHost:
-				v.onSecureVirtualHost(vh)
+				v.onSecureVirtualHost(vh, fallbackRouteConfigName(l.Name))
 			default:
 				// recurse
 				vertex.Visit(v.visit)
@@ -267,13 +290,79 @@ func (v *routeVisitor) visit(vertex dag.Vertex) {
 	}
 }
 
+// envoyRoute returns the Envoy route for the supplied route. The
+// route either forwards requests to its clusters, or responds with
+// a redirect or a direct response.
+func envoyRoute(route *dag.Route) *envoy_api_v2_route.Route {
+	rt := &envoy_api_v2_route.Route{
+		Match: envoy_v2.RouteMatch(route),
+	}
+
+	switch {
+	case route.Redirect != nil:
+		rt.Action = envoy_v2.RouteRedirect(route.Redirect)
+	case route.DirectResponse != nil:
+		rt.Action = envoy_v2.RouteDirectResponse(route.DirectResponse)
+	default:
+		rt.Action = envoy_v2.RouteRoute(route)
+	}
+
+	return rt
+}
+
+// virtualHostRateLimits returns the rate limit actions for the
+// virtual host's rate limit descriptors, or nil if rate limiting is
+// not enabled for the virtual host.
+func virtualHostRateLimits(vh *dag.VirtualHost) []*envoy_api_v2_route.RateLimit {
+	if vh.RateLimitService == nil {
+		return nil
+	}
+
+	return envoy_v2.RateLimits(vh.RateLimitService.Stage, vh.RateLimitPolicy)
+}
+
+// routeRateLimits adds the rate limit actions for the supplied route
+// rate limit descriptors to rt. The route keeps the rate limits of
+// its virtual host, since Envoy otherwise ignores them for routes that
+// have their own rate limits.
+func routeRateLimits(rt *envoy_api_v2_route.Route, rls *dag.RateLimitService, policy *dag.RateLimitPolicy) {
+	if rls == nil || policy == nil {
+		return
+	}
+
+	action, ok := rt.Action.(*envoy_api_v2_route.Route_Route)
+	if !ok {
+		return
+	}
+
+	action.Route.RateLimits = envoy_v2.RateLimits(rls.Stage, policy)
+	action.Route.IncludeVhRateLimits = protobuf.Bool(true)
+}
+
+// routeFaultInjection adds the per-route fault filter config for the
+// supplied policy to rt. Routes without a policy are left unchanged,
+// so the fault filter injects no faults for them.
+func routeFaultInjection(rt *envoy_api_v2_route.Route, policy *dag.FaultInjectionPolicy) {
+	if policy == nil {
+		return
+	}
+
+	if rt.TypedPerFilterConfig == nil {
+		rt.TypedPerFilterConfig = map[string]*any.Any{}
+	}
+
+	rt.TypedPerFilterConfig["envoy.filters.http.fault"] = envoy_v2.RouteFault(policy)
+}
+
 // sortRoutes sorts the given Route slice in place. Routes are ordered
 // first by longest prefix (or regex), then by the length of the
-// HeaderMatch slice (if any). The HeaderMatch slice is also ordered
-// by the matching header name.
+// HeaderMatch slice (if any), then by the length of the
+// QueryParameterMatcher slice (if any). The HeaderMatch and
+// QueryParameterMatcher slices are also ordered by name.
 func sortRoutes(routes []*envoy_api_v2_route.Route) {
 	for _, r := range routes {
 		sort.Stable(sorter.For(r.Match.Headers))
+		sort.Stable(sorter.For(r.Match.QueryParameters))
 	}
 
 	sort.Stable(sorter.For(routes))
@@ -206,7 +206,7 @@ func TestGRPC(t *testing.T) {
 				FieldLogger: log,
 			}
 
-			srv := contour_xds_v2.RegisterServer(contour_xds_v2.NewContourServer(log, xdscache.ResourcesOf(resources)...), nil)
+			srv := contour_xds_v2.RegisterServer(contour_xds_v2.NewContourServer(log, nil, xdscache.ResourcesOf(resources)...), nil)
 			l, err := net.Listen("tcp", "127.0.0.1:0")
 			require.NoError(t, err)
 			done := make(chan error, 1)
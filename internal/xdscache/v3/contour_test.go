@@ -0,0 +1,425 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"testing"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	contour_api_v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/projectcontour/contour/internal/xdscache"
+	xdscache_v2 "github.com/projectcontour/contour/internal/xdscache/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	io_prometheus_client "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// TestContourResourcesTranslate builds the v2 resources for a
+// configuration that uses as many Contour features as possible,
+// and checks that every one of them is translated to v3.
+func TestContourResourcesTranslate(t *testing.T) {
+	h2 := "h2"
+	extension := types.NamespacedName{Namespace: "projectcontour", Name: "extension"}
+	admin := []dag.NamedListener{{
+		Name:     "admin",
+		Address:  "127.0.0.1",
+		Port:     9001,
+		Protocol: "http",
+	}}
+
+	objs := []interface{}{
+		fixture.SecretRootsCert,
+		&v1.Secret{
+			ObjectMeta: fixture.ObjectMeta("roots/ca"),
+			Data: map[string][]byte{
+				dag.CACertificateKey: []byte(fixture.CERTIFICATE),
+			},
+		},
+		fixture.NewService("roots/kuard").WithPorts(
+			v1.ServicePort{Name: "http", Port: 8080, TargetPort: intstr.FromInt(8080)},
+			v1.ServicePort{Name: "https", Port: 8443, TargetPort: intstr.FromInt(8443)},
+		),
+		fixture.NewService("roots/tcp").WithPorts(
+			v1.ServicePort{Name: "tcp", Port: 9000, TargetPort: intstr.FromInt(9000)},
+		),
+		fixture.NewService("projectcontour/extension").WithPorts(
+			v1.ServicePort{Name: "grpc", Port: 9091, TargetPort: intstr.FromInt(9091)},
+		),
+		&contour_api_v1alpha1.ExtensionService{
+			ObjectMeta: fixture.ObjectMeta("projectcontour/extension"),
+			Spec: contour_api_v1alpha1.ExtensionServiceSpec{
+				Services: []contour_api_v1alpha1.ExtensionServiceTarget{{
+					Name: "extension",
+					Port: 9091,
+				}},
+			},
+		},
+		&v1beta1.Ingress{
+			ObjectMeta: fixture.ObjectMeta("roots/ingress"),
+			Spec: v1beta1.IngressSpec{
+				TLS: []v1beta1.IngressTLS{{
+					Hosts:      []string{"ingress.example.com"},
+					SecretName: "ssl-cert",
+				}},
+				Rules: []v1beta1.IngressRule{{
+					Host: "ingress.example.com",
+					IngressRuleValue: v1beta1.IngressRuleValue{
+						HTTP: &v1beta1.HTTPIngressRuleValue{
+							Paths: []v1beta1.HTTPIngressPath{{
+								Path: "/",
+								Backend: v1beta1.IngressBackend{
+									ServiceName: "kuard",
+									ServicePort: intstr.FromInt(8080),
+								},
+							}},
+						},
+					},
+				}},
+			},
+		},
+		fixture.NewProxy("roots/http").WithSpec(contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "http.example.com",
+				CORSPolicy: &contour_api_v1.CORSPolicy{
+					AllowCredentials: true,
+					AllowOrigin:      []string{"*"},
+					AllowMethods:     []contour_api_v1.CORSHeaderValue{"GET", "POST"},
+					AllowHeaders:     []contour_api_v1.CORSHeaderValue{"authorization"},
+					ExposeHeaders:    []contour_api_v1.CORSHeaderValue{"x-custom"},
+					MaxAge:           "10m",
+				},
+				RateLimitPolicy: &contour_api_v1.VirtualHostRateLimitPolicy{
+					ExtensionServiceRef: contour_api_v1.ExtensionServiceReference{
+						Namespace: "projectcontour",
+						Name:      "extension",
+					},
+					Domain:          "contour",
+					ResponseTimeout: "1s",
+					Descriptors: []contour_api_v1.RateLimitDescriptor{{
+						Entries: []contour_api_v1.RateLimitDescriptorEntry{{
+							RemoteAddress: &contour_api_v1.RemoteAddressDescriptor{},
+						}},
+					}},
+				},
+				TracingPolicy: &contour_api_v1.TracingPolicy{
+					SamplingRate: "50",
+				},
+			},
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/api",
+				}, {
+					Header: &contour_api_v1.HeaderMatchCondition{
+						Name:     "x-header",
+						Contains: "abc",
+					},
+				}, {
+					QueryParameter: &contour_api_v1.QueryParameterMatchCondition{
+						Name:  "debug",
+						Exact: "1",
+					},
+				}},
+				EnableWebsockets: true,
+				TimeoutPolicy: &contour_api_v1.TimeoutPolicy{
+					Response: "10s",
+					Idle:     "1m",
+				},
+				RetryPolicy: &contour_api_v1.RetryPolicy{
+					NumRetries:           3,
+					PerTryTimeout:        "1s",
+					RetryOn:              []contour_api_v1.RetryOn{"retriable-status-codes"},
+					RetriableStatusCodes: []uint32{503},
+				},
+				HealthCheckPolicy: &contour_api_v1.HTTPHealthCheckPolicy{
+					Path: "/healthz",
+				},
+				LoadBalancerPolicy: &contour_api_v1.LoadBalancerPolicy{
+					Strategy: "RingHash",
+					RingHash: &contour_api_v1.RingHashConfig{
+						MinimumRingSize: 1024,
+					},
+					RequestHashPolicies: []contour_api_v1.RequestHashPolicy{{
+						HeaderHashOptions: &contour_api_v1.HeaderHashOptions{HeaderName: "x-user"},
+					}, {
+						CookieHashOptions: &contour_api_v1.CookieHashOptions{CookieName: "session", TTL: "1h"},
+					}, {
+						HashSourceIP: true,
+					}},
+				},
+				PathRewritePolicy: &contour_api_v1.PathRewritePolicy{
+					ReplacePrefix: []contour_api_v1.ReplacePrefix{{Replacement: "/v1"}},
+				},
+				RequestHeadersPolicy: &contour_api_v1.HeadersPolicy{
+					Set:    []contour_api_v1.HeaderValue{{Name: "x-request", Value: "1"}},
+					Remove: []string{"x-remove"},
+				},
+				ResponseHeadersPolicy: &contour_api_v1.HeadersPolicy{
+					Set: []contour_api_v1.HeaderValue{{Name: "x-response", Value: "1"}},
+				},
+				RateLimitPolicy: &contour_api_v1.RateLimitPolicy{
+					Descriptors: []contour_api_v1.RateLimitDescriptor{{
+						Entries: []contour_api_v1.RateLimitDescriptorEntry{{
+							GenericKey: &contour_api_v1.GenericKeyDescriptor{Value: "api"},
+						}, {
+							RequestHeader: &contour_api_v1.RequestHeaderDescriptor{
+								HeaderName:    "x-user",
+								DescriptorKey: "user",
+							},
+						}},
+					}},
+				},
+				FaultInjectionPolicy: &contour_api_v1.FaultInjectionPolicy{
+					Abort: &contour_api_v1.FaultAbort{StatusCode: 503, Percentage: 10},
+				},
+				Services: []contour_api_v1.Service{{
+					Name:   "kuard",
+					Port:   8080,
+					Weight: 90,
+					OutlierDetection: &contour_api_v1.OutlierDetection{
+						ConsecutiveServerErrors: 5,
+					},
+					CircuitBreakerPolicy: &contour_api_v1.CircuitBreakerPolicy{
+						MaxConnections: 100,
+					},
+				}, {
+					Name:   "kuard",
+					Port:   8443,
+					Weight: 10,
+					UpstreamValidation: &contour_api_v1.UpstreamValidation{
+						CACertificate: "ca",
+						SubjectName:   "kuard",
+					},
+					ResponseHeadersPolicy: &contour_api_v1.HeadersPolicy{
+						Remove: []string{"server"},
+					},
+				}, {
+					Name:   "kuard",
+					Port:   8080,
+					Mirror: true,
+				}},
+			}, {
+				Conditions: []contour_api_v1.MatchCondition{{
+					Regex: "/regex/[0-9]+",
+				}},
+				PathRewritePolicy: &contour_api_v1.PathRewritePolicy{
+					RegexRewrite: &contour_api_v1.RegexRewrite{
+						Pattern:      "^/regex/([0-9]+)$",
+						Substitution: "/\\1",
+					},
+				},
+				Services: []contour_api_v1.Service{{
+					Name:     "kuard",
+					Port:     8080,
+					Protocol: &h2,
+				}},
+			}, {
+				Conditions: []contour_api_v1.MatchCondition{{
+					Exact: "/redirect",
+				}},
+				RequestRedirectPolicy: &contour_api_v1.HTTPRequestRedirectPolicy{
+					Scheme:     "https",
+					Hostname:   "example.com",
+					StatusCode: 301,
+				},
+			}, {
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/direct",
+				}},
+				DirectResponsePolicy: &contour_api_v1.HTTPDirectResponsePolicy{
+					StatusCode: 200,
+					Body:       "ok",
+				},
+			}},
+		}),
+		fixture.NewProxy("roots/https").WithSpec(contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "https.example.com",
+				TLS: &contour_api_v1.TLS{
+					SecretName:             "ssl-cert",
+					MinimumProtocolVersion: "1.3",
+					ClientValidation: &contour_api_v1.DownstreamValidation{
+						CACertificate: "ca",
+					},
+				},
+				Authorization: &contour_api_v1.AuthorizationServer{
+					ExtensionServiceRef: contour_api_v1.ExtensionServiceReference{
+						Namespace: "projectcontour",
+						Name:      "extension",
+					},
+					ResponseTimeout: "1s",
+					AuthPolicy: &contour_api_v1.AuthorizationPolicy{
+						Context: map[string]string{"key": "value"},
+					},
+				},
+			},
+			Routes: []contour_api_v1.Route{{
+				PermitInsecure: true,
+				LoadBalancerPolicy: &contour_api_v1.LoadBalancerPolicy{
+					Strategy: "Cookie",
+				},
+				Services: []contour_api_v1.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}, {
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/public",
+				}},
+				AuthPolicy: &contour_api_v1.AuthorizationPolicy{
+					Disabled: true,
+				},
+				Services: []contour_api_v1.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		}),
+		fixture.NewProxy("roots/admin").WithSpec(contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn:     "admin.example.com",
+				Listener: "admin",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		}),
+		fixture.NewProxy("roots/tcp").WithSpec(contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "tcp.example.com",
+				TLS: &contour_api_v1.TLS{
+					Passthrough: true,
+				},
+			},
+			TCPProxy: &contour_api_v1.TCPProxy{
+				HealthCheckPolicy: &contour_api_v1.TCPHealthCheckPolicy{
+					IntervalSeconds: 5,
+					TimeoutSeconds:  2,
+				},
+				Services: []contour_api_v1.Service{{
+					Name: "tcp",
+					Port: 9000,
+				}},
+			},
+		}),
+	}
+
+	builder := dag.Builder{
+		Source: dag.KubernetesCache{
+			FieldLogger: fixture.NewTestLogger(t),
+		},
+		Processors: []dag.Processor{
+			&dag.IngressProcessor{
+				FieldLogger: fixture.NewTestLogger(t),
+			},
+			&dag.ExtensionServiceProcessor{
+				FieldLogger: fixture.NewTestLogger(t),
+			},
+			&dag.HTTPProxyProcessor{
+				Listeners: admin,
+			},
+			&dag.ListenerProcessor{
+				Listeners: admin,
+			},
+			&dag.TracingProcessor{
+				FieldLogger:      fixture.NewTestLogger(t),
+				Provider:         dag.TracingProviderOpenCensus,
+				ExtensionService: &extension,
+				SamplingRate:     100,
+				CustomTags: []dag.TracingCustomTag{{
+					TagName: "cluster",
+					Literal: "test",
+				}},
+			},
+			&dag.AccessLogServiceProcessor{
+				FieldLogger:      fixture.NewTestLogger(t),
+				ExtensionService: &extension,
+				LogName:          "contour",
+			},
+		},
+	}
+	for _, o := range objs {
+		builder.Source.Insert(o)
+	}
+	root := builder.Build()
+
+	// Every object must be valid, or the features it uses
+	// would not be covered.
+	for name, pu := range root.StatusCache.GetProxyUpdates() {
+		for _, cond := range pu.Conditions {
+			assert.Equal(t, contour_api_v1.ConditionTrue, cond.Status, "%s: %v", name, cond.Errors)
+		}
+	}
+
+	endpoints := xdscache_v2.NewEndpointsTranslator(fixture.NewTestLogger(t))
+	resources := []xdscache.ResourceCache{
+		&xdscache_v2.ClusterCache{},
+		endpoints,
+		&xdscache_v2.SecretCache{},
+		xdscache_v2.NewListenerCache(xdscache_v2.ListenerConfig{
+			AccessLogType:   "json",
+			AccessLogFields: []string{"@timestamp", "method", "path"},
+			Listeners: []xdscache_v2.NamedListener{{
+				Name:     "admin",
+				Address:  "127.0.0.1",
+				Port:     9001,
+				Protocol: "http",
+			}},
+		}, "0.0.0.0", 8002),
+		&xdscache_v2.RouteCache{},
+	}
+	for _, name := range []string{"roots/kuard", "roots/tcp", "projectcontour/extension"} {
+		endpoints.OnAdd(&v1.Endpoints{
+			ObjectMeta: fixture.ObjectMeta(name),
+			Subsets: []v1.EndpointSubset{{
+				Addresses: []v1.EndpointAddress{{IP: "192.168.0.1"}},
+				Ports: []v1.EndpointPort{
+					{Name: "http", Port: 8080},
+					{Name: "https", Port: 8443},
+					{Name: "tcp", Port: 9000},
+					{Name: "grpc", Port: 9091},
+				},
+			}},
+		})
+	}
+	for _, r := range resources {
+		r.OnChange(root)
+	}
+
+	untranslated := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "untranslated"}, []string{"type_url"})
+
+	v2 := xdscache.ResourcesOf(resources)
+	v3 := NewResourceCaches(fixture.NewTestLogger(t), untranslated, v2...)
+
+	for i, r := range v3 {
+		t.Run(r.TypeURL(), func(t *testing.T) {
+			want := v2[i].Contents()
+			require.NotEmpty(t, want)
+			assert.Equal(t, len(want), len(r.Contents()))
+
+			m := &io_prometheus_client.Metric{}
+			require.NoError(t, untranslated.WithLabelValues(r.TypeURL()).Write(m))
+			assert.Equal(t, float64(0), m.GetGauge().GetValue())
+		})
+	}
+}
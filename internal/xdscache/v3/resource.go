@@ -0,0 +1,197 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package v3 provides the v3 xDS resource caches.
+package v3
+
+import (
+	"sync"
+
+	cache_v2 "github.com/envoyproxy/go-control-plane/pkg/cache/v2"
+	resource_v2 "github.com/envoyproxy/go-control-plane/pkg/resource/v2"
+	resource_v3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/golang/protobuf/proto"
+	envoy_v3 "github.com/projectcontour/contour/internal/envoy/v3"
+	"github.com/projectcontour/contour/internal/xds"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// typeURLs maps each v2 resource type URL to its v3 equivalent.
+var typeURLs = map[string]string{
+	resource_v2.ClusterType:  resource_v3.ClusterType,
+	resource_v2.EndpointType: resource_v3.EndpointType,
+	resource_v2.ListenerType: resource_v3.ListenerType,
+	resource_v2.RouteType:    resource_v3.RouteType,
+	resource_v2.SecretType:   resource_v3.SecretType,
+}
+
+// ResourceCache serves the contents of a v2 resource cache as v3
+// resources. The v2 cache remains responsible for observing the DAG
+// and notifying registered watchers; its contents are translated to
+// v3 when they are requested.
+//
+// Translated entries are kept until the version of the v2 entry
+// changes, so an entry is only translated again when its contents
+// change. Entries that can't be translated are left out, so that
+// they are not served to v3 clients. Each such entry is logged once
+// per version, and counted by the untranslated gauge until it
+// changes or is removed.
+type ResourceCache struct {
+	logrus.FieldLogger
+
+	v2 xds.Resource
+
+	// untranslated is set to the number of entries that
+	// can't be translated to v3. It may be nil.
+	untranslated prometheus.Gauge
+
+	mu      sync.Mutex
+	entries map[string]upgradedEntry
+}
+
+// upgradedEntry is the v3 translation of a version of a v2 entry,
+// or the error that prevented it from being translated.
+type upgradedEntry struct {
+	version string
+	msg     proto.Message
+	err     error
+}
+
+var _ xds.DeltaResource = &ResourceCache{}
+
+// NewResourceCaches returns a v3 ResourceCache for each of the
+// given v2 resources. If untranslated is not nil, it records the
+// number of entries of each cache that can't be translated to v3,
+// labelled by the v3 type URL.
+func NewResourceCaches(log logrus.FieldLogger, untranslated *prometheus.GaugeVec, resources ...xds.Resource) []xds.Resource {
+	caches := make([]xds.Resource, 0, len(resources))
+	for _, r := range resources {
+		typeURL := typeURLs[r.TypeURL()]
+
+		c := &ResourceCache{
+			FieldLogger: log.WithField("type_url", typeURL),
+			v2:          r,
+			entries:     map[string]upgradedEntry{},
+		}
+		if untranslated != nil {
+			c.untranslated = untranslated.WithLabelValues(typeURL)
+		}
+
+		caches = append(caches, c)
+	}
+
+	return caches
+}
+
+// Contents returns the v3 translation of the v2 cache contents.
+func (c *ResourceCache) Contents() []proto.Message {
+	// Read the versions before the contents, so that an entry
+	// which changes in between is cached under its old version
+	// and translated again on the next request.
+	versions := c.versions()
+	upgraded := c.upgrade(versions, c.v2.Contents())
+
+	// Forget the entries that have been removed from the v2 cache.
+	c.mu.Lock()
+	for name := range c.entries {
+		if _, ok := versions[name]; !ok {
+			delete(c.entries, name)
+		}
+	}
+	c.setUntranslated()
+	c.mu.Unlock()
+
+	return upgraded
+}
+
+// Query returns the v3 translation of the named v2 cache entries.
+func (c *ResourceCache) Query(names []string) []proto.Message {
+	return c.upgrade(c.versions(), c.v2.Query(names))
+}
+
+// Register registers ch to receive a value when the v2 cache changes.
+func (c *ResourceCache) Register(ch chan int, last int, hints ...string) {
+	c.v2.Register(ch, last, hints...)
+}
+
+// Versions returns the versions of the v2 cache entries. The v2 and
+// v3 translations of an entry change together, so they share a version.
+func (c *ResourceCache) Versions() map[string]string {
+	return c.v2.(xds.DeltaResource).Versions()
+}
+
+// TypeURL returns the v3 type URL of the cache contents.
+func (c *ResourceCache) TypeURL() string {
+	return typeURLs[c.v2.TypeURL()]
+}
+
+// versions returns the versions of the v2 cache entries, or
+// nil if the v2 cache doesn't track them. Without versions,
+// entries are translated each time they are requested.
+func (c *ResourceCache) versions() map[string]string {
+	if d, ok := c.v2.(xds.DeltaResource); ok {
+		return d.Versions()
+	}
+	return nil
+}
+
+// upgrade translates messages to v3, reusing the translation
+// of each entry whose version hasn't changed.
+func (c *ResourceCache) upgrade(versions map[string]string, messages []proto.Message) []proto.Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	upgraded := make([]proto.Message, 0, len(messages))
+	for _, m := range messages {
+		name := cache_v2.GetResourceName(m)
+		version, versioned := versions[name]
+
+		e, ok := c.entries[name]
+		if !ok || !versioned || e.version != version {
+			e = upgradedEntry{version: version}
+			e.msg, e.err = envoy_v3.Upgrade(m)
+			if e.err != nil {
+				c.WithError(e.err).WithField("name", name).
+					Error("failed to translate resource to v3, it is not served to v3 clients")
+			}
+			if versioned {
+				c.entries[name] = e
+			}
+		}
+
+		if e.err == nil {
+			upgraded = append(upgraded, e.msg)
+		}
+	}
+
+	c.setUntranslated()
+	return upgraded
+}
+
+// setUntranslated sets the untranslated gauge to the number of
+// cached entries that failed to translate. It must be called with
+// the lock held.
+func (c *ResourceCache) setUntranslated() {
+	if c.untranslated == nil {
+		return
+	}
+
+	var n int
+	for _, e := range c.entries {
+		if e.err != nil {
+			n++
+		}
+	}
+	c.untranslated.Set(float64(n))
+}
@@ -0,0 +1,182 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"testing"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	envoy_api_v2_endpoint "github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
+	envoy_config_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_config_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	cache_v2 "github.com/envoyproxy/go-control-plane/pkg/cache/v2"
+	resource_v2 "github.com/envoyproxy/go-control-plane/pkg/resource/v2"
+	resource_v3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/golang/protobuf/proto"
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/projectcontour/contour/internal/protobuf"
+	"github.com/prometheus/client_golang/prometheus"
+	io_prometheus_client "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceCache(t *testing.T) {
+	v2 := &mockResource{
+		typeURL: resource_v2.EndpointType,
+		contents: []proto.Message{
+			&envoy_api_v2.ClusterLoadAssignment{ClusterName: "default/httpbin"},
+			&envoy_api_v2.ClusterLoadAssignment{ClusterName: "default/kuard"},
+		},
+	}
+
+	caches := NewResourceCaches(fixture.NewTestLogger(t), nil, v2)
+	assert.Equal(t, 1, len(caches))
+
+	c := caches[0]
+	assert.Equal(t, resource_v3.EndpointType, c.TypeURL())
+
+	protobuf.ExpectEqual(t, []proto.Message{
+		&envoy_config_endpoint_v3.ClusterLoadAssignment{ClusterName: "default/httpbin"},
+		&envoy_config_endpoint_v3.ClusterLoadAssignment{ClusterName: "default/kuard"},
+	}, c.Contents())
+
+	protobuf.ExpectEqual(t, []proto.Message{
+		&envoy_config_endpoint_v3.ClusterLoadAssignment{ClusterName: "default/kuard"},
+	}, c.Query([]string{"default/kuard"}))
+
+	ch := make(chan int, 1)
+	c.Register(ch, 7)
+	assert.Equal(t, 8, <-ch)
+}
+
+func TestResourceCacheVersions(t *testing.T) {
+	v2 := &mockDeltaResource{
+		mockResource: mockResource{
+			typeURL: resource_v2.EndpointType,
+			contents: []proto.Message{
+				&envoy_api_v2.ClusterLoadAssignment{ClusterName: "default/httpbin"},
+				&envoy_api_v2.ClusterLoadAssignment{ClusterName: "default/kuard"},
+			},
+		},
+		versions: map[string]string{
+			"default/httpbin": "1",
+			"default/kuard":   "1",
+		},
+	}
+
+	c := NewResourceCaches(fixture.NewTestLogger(t), nil, v2)[0]
+
+	first := c.Contents()
+	assert.Equal(t, 2, len(first))
+
+	// Entries whose version hasn't changed are not translated again.
+	second := c.Contents()
+	assert.Same(t, first[0], second[0])
+	assert.Same(t, first[1], second[1])
+
+	// Entries whose version has changed are translated again.
+	v2.contents[1] = &envoy_api_v2.ClusterLoadAssignment{
+		ClusterName: "default/kuard",
+		Endpoints:   []*envoy_api_v2_endpoint.LocalityLbEndpoints{{}},
+	}
+	v2.versions["default/kuard"] = "2"
+
+	third := c.Contents()
+	assert.Same(t, first[0], third[0])
+	protobuf.ExpectEqual(t, &envoy_config_endpoint_v3.ClusterLoadAssignment{
+		ClusterName: "default/kuard",
+		Endpoints:   []*envoy_config_endpoint_v3.LocalityLbEndpoints{{}},
+	}, third[1])
+}
+
+func TestResourceCacheUpgradeError(t *testing.T) {
+	v2 := &mockDeltaResource{
+		mockResource: mockResource{
+			typeURL: resource_v2.ClusterType,
+			contents: []proto.Message{
+				&envoy_api_v2.Cluster{Name: "default/kuard"},
+				// Hosts was deprecated in v2 and can't be
+				// translated to v3.
+				&envoy_api_v2.Cluster{
+					Name:  "default/httpbin",
+					Hosts: []*envoy_api_v2_core.Address{{}},
+				},
+			},
+		},
+		versions: map[string]string{
+			"default/kuard":   "1",
+			"default/httpbin": "1",
+		},
+	}
+
+	untranslated := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "untranslated"}, []string{"type_url"})
+	gauge := func() float64 {
+		m := &io_prometheus_client.Metric{}
+		require.NoError(t, untranslated.WithLabelValues(resource_v3.ClusterType).Write(m))
+		return m.GetGauge().GetValue()
+	}
+
+	c := NewResourceCaches(fixture.NewTestLogger(t), untranslated, v2)[0]
+
+	// The entry that can't be translated is left out, and counted.
+	protobuf.ExpectEqual(t, []proto.Message{
+		&envoy_config_cluster_v3.Cluster{Name: "default/kuard"},
+	}, c.Contents())
+	assert.Equal(t, float64(1), gauge())
+
+	assert.Empty(t, c.Query([]string{"default/httpbin"}))
+	assert.Equal(t, float64(1), gauge())
+
+	// Once the entry changes so that it can be translated,
+	// it is served and no longer counted.
+	v2.contents[1] = &envoy_api_v2.Cluster{Name: "default/httpbin"}
+	v2.versions["default/httpbin"] = "2"
+
+	protobuf.ExpectEqual(t, []proto.Message{
+		&envoy_config_cluster_v3.Cluster{Name: "default/kuard"},
+		&envoy_config_cluster_v3.Cluster{Name: "default/httpbin"},
+	}, c.Contents())
+	assert.Equal(t, float64(0), gauge())
+}
+
+type mockDeltaResource struct {
+	mockResource
+	versions map[string]string
+}
+
+func (m *mockDeltaResource) Versions() map[string]string { return m.versions }
+
+type mockResource struct {
+	typeURL  string
+	contents []proto.Message
+}
+
+func (m *mockResource) Contents() []proto.Message { return m.contents }
+
+func (m *mockResource) Query(names []string) []proto.Message {
+	var values []proto.Message
+	for _, n := range names {
+		for _, c := range m.contents {
+			if cache_v2.GetResourceName(c) == n {
+				values = append(values, c)
+			}
+		}
+	}
+	return values
+}
+
+func (m *mockResource) Register(ch chan int, last int, hints ...string) { ch <- last + 1 }
+func (m *mockResource) TypeURL() string                                 { return m.typeURL }